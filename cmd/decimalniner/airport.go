@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	d9 "github.com/curbz/decimal-niner/internal"
+	"github.com/curbz/decimal-niner/internal/atc"
+	"github.com/curbz/decimal-niner/internal/logger"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+const airportUsage = `usage: decimalniner airport [flags] <ICAO>
+
+  parses the airport from the X-Plane data files and prints its runways,
+  SIDs and STARs, parking, runway access points, hub weights, controllers,
+  holds and the data file records that were skipped or defaulted. With
+  -geojson the airport is written as GeoJSON instead, which can be loaded
+  as an overlay on the web radar.
+
+flags:`
+
+// runAirport runs the airport subcommand and returns the exit code
+func runAirport(args []string) int {
+	fs := flag.NewFlagSet("airport", flag.ContinueOnError)
+	configFlag := fs.String("config", "config.yaml", "Path to the config file")
+	geojson := fs.Bool("geojson", false, "write the airport as GeoJSON to stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), airportUsage)
+		fs.PrintDefaults()
+	}
+
+	// flags may be given either side of the ICAO code
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	icao := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	// the log file of the sim session is left alone and only errors are written to the console
+	logger.Log.SetLevel(logrus.ErrorLevel)
+
+	cfg, err := util.LoadConfig[d9config](*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading configuration file: %v\n", err)
+		return 1
+	}
+	d9.Resources = cfg.D9.Resources
+
+	ai, err := atc.InspectAirport(*configFlag, icao)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error inspecting airport: %v\n", err)
+		return 1
+	}
+
+	if *geojson {
+		if err := ai.WriteGeoJSON(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing GeoJSON: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	ai.Write(os.Stdout)
+	return 0
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	d9 "github.com/curbz/decimal-niner/internal"
+	"github.com/curbz/decimal-niner/internal/atc"
+	"github.com/curbz/decimal-niner/internal/logger"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+const cacheUsage = `usage: decimalniner cache build|clear [flags]
+
+  build    parse the X-Plane data files for the airports of the flight plans
+           and write the navdata cache used at startup
+  clear    remove the navdata cache, so that the data files are parsed again
+
+flags:`
+
+// runCache runs the cache subcommand and returns the exit code
+func runCache(args []string) int {
+	fs := flag.NewFlagSet("cache", flag.ContinueOnError)
+	configFlag := fs.String("config", "config.yaml", "Path to the config file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), cacheUsage)
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		return 2
+	}
+	command := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	// the log file of the sim session is left alone and only errors are written to the console
+	logger.Log.SetLevel(logrus.ErrorLevel)
+
+	cfg, err := util.LoadConfig[d9config](*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading configuration file: %v\n", err)
+		return 1
+	}
+	d9.Resources = cfg.D9.Resources
+
+	switch command {
+	case "build":
+		start := time.Now()
+		// the cache is keyed by the airports of the flight plans, as at startup
+		te, err := newTrafficEngine(cfg.D9.TrafficEngine, *configFlag)
+		if te == nil && err == nil {
+			err = fmt.Errorf("unsupported traffic engine %s", cfg.D9.TrafficEngine)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error initialising traffic engine: %v\n", err)
+			return 1
+		}
+		_, airports := te.LoadFlightPlans(te.GetFlightPlanPath())
+
+		info, err := atc.BuildNavdataCache(*configFlag, airports)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error building navdata cache: %v\n", err)
+			return 1
+		}
+		fmt.Printf("navdata cache written to %s in %v: %d airports, %d controllers, %d holds, %d fixes\n",
+			info.Path, time.Since(start).Round(time.Millisecond), info.Airports, info.Controllers, info.Holds, info.Fixes)
+		if info.Skipped > 0 || info.Defaulted > 0 {
+			fmt.Printf("%d data file records skipped and %d defaulted, decimalniner airport <ICAO> lists them\n",
+				info.Skipped, info.Defaulted)
+		}
+	case "clear":
+		n, err := atc.ClearNavdataCache(*configFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error clearing navdata cache: %v\n", err)
+			return 1
+		}
+		fmt.Printf("%d navdata cache files removed\n", n)
+	default:
+		fs.Usage()
+		return 2
+	}
+	return 0
+}
@@ -27,6 +27,20 @@ type d9config struct {
 
 func main() {
 
+	// subcommands for working on resources without running the sim connection
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "phrases":
+			os.Exit(runPhrases(os.Args[2:]))
+		case "pronunciation":
+			os.Exit(runPronunciation(os.Args[2:]))
+		case "cache":
+			os.Exit(runCache(os.Args[2:]))
+		case "airport":
+			os.Exit(runAirport(os.Args[2:]))
+		}
+	}
+
 	configFlag := flag.String("config", "", "Path to the config file (optional)")
 
 	// mock server to emulate X-Plane REST+WebSocket
@@ -88,14 +102,8 @@ func main() {
 		time.Sleep(250 * time.Millisecond)
 	}
 
-	var te atc.TrafficEngine
-	var teErr error
-	switch cfg.D9.TrafficEngine {
-	case "trafficglobal":
-		te, teErr = trafficglobal.New(cfgPath)
-	case "d9traffic":
-		te, teErr = d9traffic.New(cfgPath)
-	default:
+	te, teErr := newTrafficEngine(cfg.D9.TrafficEngine, cfgPath)
+	if te == nil && teErr == nil {
 		logger.Log.Fatalf("unsupported traffic engine specified in decimal-niner configuration: %s", cfg.D9.TrafficEngine)
 		return
 	}
@@ -142,3 +150,14 @@ func main() {
 	logger.Log.Info("Received interrupt, shutting down...")
 	xpc.Stop()
 }
+
+// newTrafficEngine creates the named traffic engine, returning nil if the engine is not supported
+func newTrafficEngine(name, cfgPath string) (atc.TrafficEngine, error) {
+	switch name {
+	case "trafficglobal":
+		return trafficglobal.New(cfgPath)
+	case "d9traffic":
+		return d9traffic.New(cfgPath)
+	}
+	return nil, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	d9 "github.com/curbz/decimal-niner/internal"
+	"github.com/curbz/decimal-niner/internal/atc"
+	"github.com/curbz/decimal-niner/internal/logger"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+const phrasesUsage = `usage: decimalniner phrases lint|render [flags]
+
+  lint     check the phrase files for unknown variables and macros, logic errors,
+           missing phase keys and readbacks that will be mangled
+  render   print every exchange as spoken to synthetic aircraft
+
+flags:`
+
+// runPhrases runs the phrases subcommand and returns the exit code
+func runPhrases(args []string) int {
+	fs := flag.NewFlagSet("phrases", flag.ContinueOnError)
+	configFlag := fs.String("config", "config.yaml", "Path to the config file")
+	phase := fs.String("phase", "", "render: only render exchanges for the phase key, e.g. taxi_out")
+	unicom := fs.Bool("unicom", false, "render: render the Unicom phrases")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), phrasesUsage)
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		return 2
+	}
+	command := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	// the log file of the sim session is left alone and only errors are written to the console
+	logger.Log.SetLevel(logrus.ErrorLevel)
+
+	cfg, err := util.LoadConfig[d9config](*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading configuration file: %v\n", err)
+		return 1
+	}
+	d9.Resources = cfg.D9.Resources
+
+	switch command {
+	case "lint":
+		issues, err := atc.LintPhrases()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error linting phrases: %v\n", err)
+			return 1
+		}
+		errors := 0
+		for _, issue := range issues {
+			fmt.Println(issue)
+			if !issue.Warning {
+				errors++
+			}
+		}
+		fmt.Printf("%d errors, %d warnings\n", errors, len(issues)-errors)
+		if errors > 0 {
+			return 1
+		}
+	case "render":
+		opts := atc.PhraseRenderOptions{PhaseKey: *phase, Unicom: *unicom}
+		if err := atc.RenderPhrases(*configFlag, os.Stdout, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "error rendering phrases: %v\n", err)
+			return 1
+		}
+	default:
+		fs.Usage()
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	d9 "github.com/curbz/decimal-niner/internal"
+	"github.com/curbz/decimal-niner/internal/atc"
+	"github.com/curbz/decimal-niner/internal/logger"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+const pronunciationUsage = `usage: decimalniner pronunciation [flags]
+
+  reports the words of fix, SID, STAR, airport and airline callsign names that
+  the pronunciation dictionaries do not cover, flagging those likely to be
+  mispronounced, and optionally writes them as a dictionary stub with empty
+  respellings to fill in
+
+flags:`
+
+// runPronunciation runs the pronunciation subcommand and returns the exit code
+func runPronunciation(args []string) int {
+	fs := flag.NewFlagSet("pronunciation", flag.ContinueOnError)
+	configFlag := fs.String("config", "config.yaml", "Path to the config file")
+	region := fs.String("region", "", "ICAO region or airport prefix to check, e.g. EG or K, all regions if empty")
+	language := fs.String("language", "en", "language of the dictionaries to check against")
+	stub := fs.String("stub", "", "write the flagged words as a dictionary stub to this file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), pronunciationUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// the log file of the sim session is left alone and only errors are written to the console
+	logger.Log.SetLevel(logrus.ErrorLevel)
+
+	cfg, err := util.LoadConfig[d9config](*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading configuration file: %v\n", err)
+		return 1
+	}
+	d9.Resources = cfg.D9.Resources
+
+	report, err := atc.PronunciationCoverage(*configFlag, *region, *language)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error checking pronunciation: %v\n", err)
+		return 1
+	}
+	report.Write(os.Stdout)
+
+	if *stub != "" {
+		f, err := os.Create(*stub)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error creating dictionary stub: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		if err := report.WriteStub(f); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing dictionary stub: %v\n", err)
+			return 1
+		}
+		fmt.Printf("\n%d words written to %s\n", len(report.Flagged()), *stub)
+	}
+	return 0
+}
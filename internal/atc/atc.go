@@ -2,12 +2,16 @@ package atc
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
+	d9 "github.com/curbz/decimal-niner/internal"
 	"github.com/curbz/decimal-niner/internal/flightphase"
 	"github.com/curbz/decimal-niner/internal/flightplan"
 	"github.com/curbz/decimal-niner/internal/logger"
@@ -24,10 +28,21 @@ type Service struct {
 	AirlineByICAO         map[string]*AirlineInfo
 	AirlineByName         map[string]*AirlineInfo // Keyed by Name "British Airways"
 	AirlineCodesByCountry map[string][]string     // Keyed by CountryCode (e.g., "GB" -> ["BAW", "EZY"])
+	airlineMu             sync.RWMutex            // guards the airline maps, which are replaced when the airlines file changes
+	spatialMu             sync.Mutex              // guards spatialIdx
+	spatialIdx            *spatialIndex           // positions of the airports, controllers and holds, built on first use
 	Airports              map[string]*Airport
+	airportMu             sync.RWMutex    // guards Airports, which is replaced as airports are loaded and evicted
+	flightAirports        map[string]bool // origins and destinations of the active flights, guarded by airportMu
+	fixes                 map[string]*Fix // fixes of the procedures of airports loaded after startup
+	airportIndex          airportIndex    // sections of the airports data files, built by the first airport load
+	closures              []Closure       // closures of the NOTAM file, guarded by notamMu
+	notamMu               sync.RWMutex    // guards closures, which are replaced when the NOTAM file changes
 	AirportService        AirportProvider
 	FlightSchedules       map[string][]flightplan.ScheduledFlight
 	Weather               *Weather
+	METARs                map[string]*METAR // per airport weather overrides loaded from the METAR file
+	TransitionRules       *TransitionRules
 	DataProvider          simdata.SimDataProvider
 	SimInitTime           time.Time // the date/time within the sim
 	SessionInitTime       time.Time // the real-world date/time when the SimInitTime was synced, used to calculate current sim time and elapsed time in sim
@@ -93,10 +108,19 @@ type config struct {
 		AirportCIFPDir             string       `yaml:"airports_cifp_dir"`
 		AirportsDataFile           string       `yaml:"airports_data_file"`
 		AirlinesFile               string       `yaml:"airlines_file"`
+		MetarFile                  string       `yaml:"metar_file"`
 		AirlineCountryCodeFallback string       `yaml:"airline_country_code_fallback"`
 		Voices                     VoicesConfig `yaml:"voices"`
 		ListenAllFreqs             bool         `yaml:"listen_all_frequencies"`
 		StrictFlightPlanMatch      bool         `yaml:"strict_flightplan_matching"`
+		ResourceReloadInterval     int          `yaml:"resource_reload_interval"` // seconds between checks for changed resource files, 0 disables
+		NavdataCacheDir            string       `yaml:"navdata_cache_dir"`        // directory of the parsed navdata cache, empty disables
+		XPlaneRoot                 string       `yaml:"xplane_root"`              // X-Plane installation whose custom scenery airports replace those of the airports data file
+		StrictNavdata              bool         `yaml:"strict_navdata"`           // fail to start if any record of the data files is skipped
+		AirportLoadRadiusNM        float64      `yaml:"airport_load_radius_nm"`   // airports beyond this distance of the user are stubs unless used by an active flight, 0 loads all
+		OverridesFile              string       `yaml:"overrides_file"`           // local corrections to the controllers and airports of the data files, empty for none
+		DefaultControllerHours     bool         `yaml:"default_controller_hours"` // close the towers of small and medium airports at night unless the overrides file sets their hours
+		NotamFile                  string       `yaml:"notam_file"`               // runway, taxiway and stand closures in sim zulu, empty for none
 	} `yaml:"atc"`
 }
 
@@ -123,77 +147,77 @@ func New(cfgPath string, fScheds map[string][]flightplan.ScheduledFlight, requir
 
 	start := time.Now()
 
-	// load hold data
-	logger.Log.Info("Loading X-Plane Holds data")
-	allHolds, airportHolds, allFixes, err := loadHolds(cfg.ATC.AtcNavDataFile, cfg.ATC.AtcHoldsFile, cfg.ATC.AtcFixesFile)
+	// load hold, airport and controller data, from the navdata cache when the data files are unchanged
+	nav, err := loadNavdata(cfg, requiredAirports)
 	if err != nil {
-		logger.Log.Errorf("Error loading hold data: %v", err)
+		logger.Log.Error(err)
 		return nil, err
 	}
-	logger.Log.Infof("Holds data loaded: seeded %d holds\n", len(allHolds))
+	airports := nav.Airports
 
-	// load airports and controller data
-	arptControllers, airports, err := parseApt(cfg.ATC.AirportsDataFile, requiredAirports)
+	overrides, err := loadOverrides(cfg.ATC.OverridesFile)
 	if err != nil {
-		logger.Log.Errorf("Error parsing airports data file: %v", err)
+		logger.Log.Error(err)
 		return nil, err
 	}
-	atcControllers, err := parseATCdatFiles(cfg.ATC.AtcDataFile, false, requiredAirports)
+	controllers, err := overrides.apply(nav.Controllers, airports)
 	if err != nil {
-		logger.Log.Errorf("Error parsing ATC data file: %v", err)
+		logger.Log.Error(err)
 		return nil, err
 	}
-	db := append(atcControllers, arptControllers...)
-	regionControllers, err := parseATCdatFiles(cfg.ATC.AtcRegionsFile, true, requiredAirports)
-	if err != nil {
-		logger.Log.Errorf("Error parsing ATC regions file: %v", err)
-		return nil, err
+	if cfg.ATC.OverridesFile != "" {
+		logger.Log.Infof("Overrides loaded: %d controller and %d airport entries", len(overrides.Controllers), len(overrides.Airports))
 	}
-	db = append(db, regionControllers...)
 
-	// enrich airport data
-	logger.Log.Info("Loading X-Plane airport files")
+	// after the overrides, which set the hours of airports and of their controllers
+	if cfg.ATC.DefaultControllerHours {
+		n := setDefaultControllerHours(controllers, airports)
+		logger.Log.Infof("Default opening hours set for %d airport controllers", n)
+	}
 
-	err = loadAirports(cfg.ATC.AirportCIFPDir, airports, requiredAirports, airportHolds, allHolds, allFixes)
+	closures, err := loadNOTAMs(cfg.ATC.NotamFile)
 	if err != nil {
-		logger.Log.Errorf("Error loading airport data from CIFP files: %v", err)
+		logger.Log.Error(err)
 		return nil, err
 	}
-	logger.Log.Info("Airport data loaded: seeded ", len(airports), " airports")
-
-	logger.Log.Infof("ATC controller database generated: seeded %d controllers\n", len(db))
+	if cfg.ATC.NotamFile != "" {
+		logger.Log.Infof("NOTAMs loaded: %d closures", len(closures))
+	}
 
-	logger.Log.Infof("ATC data loaded in %v\n", time.Since(start))
+	var fixes map[string]*Fix // only kept to parse the CIFP files of the airports loaded as the user flies
+	if cfg.ATC.AirportLoadRadiusNM > 0 {
+		fixes = nav.Fixes
+		logger.Log.Infof("Airports within %.0fnm of the user and those of active flights will be loaded as the user flies",
+			cfg.ATC.AirportLoadRadiusNM)
+	}
 
-	// load airlines from JSON file
-	airlinesFile, err := os.Open(cfg.ATC.AirlinesFile)
+	metars, err := loadMETARs(cfg.ATC.MetarFile, airports)
 	if err != nil {
-		logger.Log.Errorf("Could not open airlines.json (%s): %v", cfg.ATC.AirlinesFile, err)
+		logger.Log.Errorf("Error loading METAR file (%s): %v", cfg.ATC.MetarFile, err)
 		return nil, err
 	}
-	defer airlinesFile.Close()
+	if len(metars) > 0 {
+		logger.Log.Infof("METARs loaded: overriding sim weather at %d airports", len(metars))
+	}
 
-	airlinesBytes, err := io.ReadAll(airlinesFile)
+	transitionFile := filepath.Join(d9.Resources, "transition_levels.json")
+	transitionRules, err := loadTransitionRules(transitionFile)
 	if err != nil {
-		logger.Log.Errorf("Could not read airlines.json (%s): %v", cfg.ATC.AirlinesFile, err)
+		logger.Log.Errorf("Error loading transition level rules (%s): %v", transitionFile, err)
 		return nil, err
 	}
+	logger.Log.Infof("Transition level rules loaded (%d)", len(transitionRules.Rules))
 
-	var airlinesData map[string]*AirlineInfo
-	// Unmarshal the JSON into the map
-	err = json.Unmarshal(airlinesBytes, &airlinesData)
+	logger.Log.Infof("ATC controller database generated: seeded %d controllers\n", len(controllers))
+
+	logger.Log.Infof("ATC data loaded in %v\n", time.Since(start))
+
+	// load airlines from JSON file
+	airlinesData, airlineByName, airlineCodesByCountry, err := loadAirlines(cfg.ATC.AirlinesFile)
 	if err != nil {
-		logger.Log.Errorf("Error unmarshaling JSON for airlines.json (%s): %v", cfg.ATC.AirlinesFile, err)
+		logger.Log.Error(err)
 		return nil, err
 	}
-
-	airlineByName := make(map[string]*AirlineInfo)
-	airlineCodesByCountry := make(map[string][]string)
-	for icao, info := range airlinesData {
-		info.ICAO = icao
-		airlineByName[info.AirlineName] = info
-		airlineCodesByCountry[info.CountryCode] = append(airlineCodesByCountry[info.CountryCode], icao)
-	}
 	logger.Log.Infof("Airlines loaded successfully (%d)", len(airlinesData))
 
 	if runtime.GOOS == "windows" {
@@ -213,20 +237,68 @@ func New(cfgPath string, fScheds map[string][]flightplan.ScheduledFlight, requir
 	return &Service{
 		Config:                cfg,
 		Broadcast:             make(chan *Aircraft, cfg.ATC.MessageBufferSize),
-		Controllers:           db,
-		Holds:                 allHolds,
+		Controllers:           controllers,
+		Holds:                 nav.Holds,
 		AirlineByICAO:         airlinesData,
 		AirlineByName:         airlineByName,
 		AirlineCodesByCountry: airlineCodesByCountry,
 		Airports:              airports,
 		FlightSchedules:       fScheds,
 		Weather:               &Weather{Wind: &Wind{}, Baro: &Baro{Sealevel: 101325, Flight: 101325}},
+		METARs:                metars,
+		TransitionRules:       transitionRules,
 		VoiceManager:          vm,
+		fixes:                 fixes,
+		closures:              closures,
 	}, nil
 }
 
+// loadAirlines loads the airlines file and returns the airlines keyed by ICAO code and by name, and the ICAO
+// codes of the airlines of each country
+func loadAirlines(path string) (map[string]*AirlineInfo, map[string]*AirlineInfo, map[string][]string, error) {
+	airlinesFile, err := os.Open(path)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not open airlines.json (%s): %v", path, err)
+	}
+	defer airlinesFile.Close()
+
+	airlinesBytes, err := io.ReadAll(airlinesFile)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not read airlines.json (%s): %v", path, err)
+	}
+
+	var airlinesData map[string]*AirlineInfo
+	// Unmarshal the JSON into the map
+	err = json.Unmarshal(airlinesBytes, &airlinesData)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error unmarshaling JSON for airlines.json (%s): %v", path, err)
+	}
+	if len(airlinesData) == 0 {
+		return nil, nil, nil, fmt.Errorf("no airlines found in airlines.json (%s)", path)
+	}
+
+	airlineByName := make(map[string]*AirlineInfo)
+	airlineCodesByCountry := make(map[string][]string)
+	for icao, info := range airlinesData {
+		if info == nil || info.AirlineName == "" || info.Callsign == "" {
+			return nil, nil, nil, fmt.Errorf("airline %s in airlines.json (%s) has no airline_name or callsign", icao, path)
+		}
+		info.ICAO = icao
+		airlineByName[info.AirlineName] = info
+		airlineCodesByCountry[info.CountryCode] = append(airlineCodesByCountry[info.CountryCode], icao)
+	}
+	return airlinesData, airlineByName, airlineCodesByCountry, nil
+}
+
 func (s *Service) Run() {
 	s.startComms()
+	if s.Config.ATC.ResourceReloadInterval > 0 {
+		interval := time.Duration(s.Config.ATC.ResourceReloadInterval) * time.Second
+		util.GoSafe(func() { s.watchResources(interval) })
+	}
+	if s.Config.ATC.AirportLoadRadiusNM > 0 {
+		util.GoSafe(func() { s.watchAirports(airportLoadInterval) })
+	}
 	util.GoSafe(func() {
 		s.VoiceManager.startCleaner(30*time.Second, func() (float64, float64) {
 			us := s.GetUserState()
@@ -273,6 +345,12 @@ func (s *Service) Transmit(userState UserState, ac *Aircraft) {
 			continue
 		}
 
+		// a tuned facility which has closed since the user last tuned or moved stays silent
+		if !s.isControllerOpen(userFac) && !s.Config.ATC.ListenAllFreqs {
+			util.LogWithLabel(ac.Registration, "user tuned to closed facility %s - audio will not be generated", userFac.Name)
+			continue
+		}
+
 		// match when user and aircraft ICAO are the same and the roles are the same (e.g. both are Tower)
 		match := (userFac.ICAO == aiFac.ICAO && userFac.RoleID == aiFac.RoleID)
 
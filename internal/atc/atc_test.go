@@ -1,9 +1,11 @@
 package atc
 
 import (
+	"math"
 	"os"
 	"testing"
 
+	"github.com/curbz/decimal-niner/internal/constants"
 	"github.com/curbz/decimal-niner/internal/flightphase"
 	"github.com/curbz/decimal-niner/internal/logger"
 )
@@ -112,3 +114,49 @@ func TestIsAirborne(t *testing.T) {
 		}
 	}
 }
+
+func TestWindComponents(t *testing.T) {
+	tests := []struct {
+		name          string
+		wind          Wind
+		turbulence    float64
+		rwyHeading    float64
+		wantHeadwind  float64
+		wantCrosswind float64
+	}{
+		{"headwind", Wind{Direction: 270, Speed: 10 / constants.MpsToKnots}, 0, 270, 10, 0},
+		{"tailwind", Wind{Direction: 90, Speed: 10 / constants.MpsToKnots}, 0, 270, -10, 0},
+		{"crosswind", Wind{Direction: 360, Speed: 10 / constants.MpsToKnots}, 0, 270, 0, 10},
+		{"gusting crosswind", Wind{Direction: 360, Speed: 10 / constants.MpsToKnots}, 0.4, 270, 0, 20},
+	}
+
+	for _, tc := range tests {
+		w := &Weather{Wind: &tc.wind, Turbulence: tc.turbulence}
+		head, cross := w.WindComponents(tc.rwyHeading)
+		if math.Abs(head-tc.wantHeadwind) > 0.01 || math.Abs(cross-tc.wantCrosswind) > 0.01 {
+			t.Errorf("%s: WindComponents(%v) = %0.2f, %0.2f; want %0.2f, %0.2f",
+				tc.name, tc.rwyHeading, head, cross, tc.wantHeadwind, tc.wantCrosswind)
+		}
+	}
+}
+
+func TestGetApproachMinima(t *testing.T) {
+	ilsVis, ilsCeiling := GetApproachMinima("ILS")
+	ndbVis, ndbCeiling := GetApproachMinima("NDB")
+	visualVis, visualCeiling := GetApproachMinima("")
+	unknownVis, unknownCeiling := GetApproachMinima("GLS")
+
+	catIIIVis, catIIICeiling := GetApproachMinima(ApproachCatIII)
+	if catIIIVis >= ilsVis || catIIICeiling >= ilsCeiling {
+		t.Errorf("CAT III minima %v/%v should be lower than ILS minima %v/%v", catIIIVis, catIIICeiling, ilsVis, ilsCeiling)
+	}
+	if ilsVis >= ndbVis || ilsCeiling >= ndbCeiling {
+		t.Errorf("ILS minima %v/%v should be lower than NDB minima %v/%v", ilsVis, ilsCeiling, ndbVis, ndbCeiling)
+	}
+	if ndbVis >= visualVis || ndbCeiling >= visualCeiling {
+		t.Errorf("NDB minima %v/%v should be lower than visual minima %v/%v", ndbVis, ndbCeiling, visualVis, visualCeiling)
+	}
+	if unknownVis != visualVis || unknownCeiling != visualCeiling {
+		t.Errorf("unknown approach minima = %v/%v; want visual minima %v/%v", unknownVis, unknownCeiling, visualVis, visualCeiling)
+	}
+}
@@ -3,10 +3,8 @@ package atc
 import (
 	"fmt"
 	"math/rand"
-	"strings"
 	"time"
 
-	"github.com/curbz/decimal-niner/internal/constants"
 	"github.com/curbz/decimal-niner/internal/flightclass"
 	"github.com/curbz/decimal-niner/internal/flightphase"
 	"github.com/curbz/decimal-niner/internal/flightplan"
@@ -41,6 +39,7 @@ type Flight struct {
 	AssignedRunway      *Runway
 	AssignedSID         *Procedure
 	AssignedSTAR        *Procedure
+	STARFromStub        bool // the STAR was assigned while the destination was a stub without procedures
 	Vectoring           bool
 	FinalIntercepted    bool
 	Squawk              string
@@ -54,6 +53,10 @@ type Flight struct {
 	Holding             *Holding
 	GroundSpeed         float64
 	ActiveManeuver      *ManeuverState
+	Emergency           *Emergency
+	Diversion           *Diversion
+	WeatherReason       string // reason the aircraft is unable to land due to weather, e.g. "windshear", empty if none
+	WeatherGoArounds    int    // number of go-arounds flown due to weather at the current destination
 	TargetAltitude	   float64
 	TargetHeading	   float64
 	TargetDistance	   float64
@@ -68,6 +71,9 @@ type Holding struct {
 	TargetApproachAlt float64
 	TargetHoldAlt     float64
 	AssignedHold      *Hold
+	Entry             HoldEntry // entry procedure chosen on reaching the hold fix
+	Leg               HoldLeg   // leg of the pattern or entry being flown once at the fix
+	LegSeconds        float64   // time flown on the current leg
 }
 
 // ManeuverDirection describes the direction of an avoidance turn.
@@ -225,6 +231,8 @@ func (s *Service) NotifyCruisePositionChange(ac *Aircraft) {
 }
 
 func (s *Service) GetAirlineByCode(code string) *AirlineInfo {
+	s.airlineMu.RLock()
+	defer s.airlineMu.RUnlock()
 	airlineInfo, exists := s.AirlineByICAO[code]
 	if !exists {
 		return nil
@@ -233,6 +241,8 @@ func (s *Service) GetAirlineByCode(code string) *AirlineInfo {
 }
 
 func (s *Service) GetAirlineByName(name string) *AirlineInfo {
+	s.airlineMu.RLock()
+	defer s.airlineMu.RUnlock()
 	// 1. Find the ICAO code from the name index
 	a, exists := s.AirlineByName[name]
 	if !exists {
@@ -243,6 +253,8 @@ func (s *Service) GetAirlineByName(name string) *AirlineInfo {
 }
 
 func (s *Service) GetRandomAirlineByCountry(countryCode string) string {
+	s.airlineMu.RLock()
+	defer s.airlineMu.RUnlock()
 	// 1. Get the list of ICAO codes for this country
 	airlines, exists := s.AirlineCodesByCountry[countryCode]
 	if !exists || len(airlines) == 0 {
@@ -454,7 +466,7 @@ func (s *Service) getTransistionAltitude(ac *Aircraft) (transitionAlt int) {
 
 	if ac.Flight.Comms.Controller != nil {
 		cIcao := ac.Flight.Comms.Controller.ICAO
-		if ap, ok := s.Airports[cIcao]; ok && ap.TransAlt > 0 {
+		if ap, ok := s.GetAirports()[cIcao]; ok && ap.TransAlt > 0 {
 			return ap.TransAlt
 		}
 	}
@@ -462,16 +474,11 @@ func (s *Service) getTransistionAltitude(ac *Aircraft) (transitionAlt int) {
 	// 2. FALLBACK: Look at the nearest airport under the plane
 	// This is crucial for Center controllers who don't have a TransAlt
 	nearICAO := s.AirportService.GetClosestAirport(ac.Flight.Position.Lat, ac.Flight.Position.Long, 30.0)
-	if nearAp, ok := s.Airports[nearICAO]; ok && nearAp.TransAlt > 0 {
+	if nearAp, ok := s.GetAirports()[nearICAO]; ok && nearAp.TransAlt > 0 {
 		transitionAlt = nearAp.TransAlt
 	} else {
-		// 3. FINAL FALLBACK: Continental Standards
-		// If ICAO starts with E or L (Europe), use 6000, otherwise 18000
-		if strings.HasPrefix(nearICAO, "E") || strings.HasPrefix(nearICAO, "L") {
-			transitionAlt = constants.TransitionAltRegionEUFt
-		} else {
-			transitionAlt = constants.TransitionAltRegionOtherFt
-		}
+		// 3. FINAL FALLBACK: the regional transition altitude
+		transitionAlt = s.TransitionRules.ruleFor(nearICAO).TransitionAlt
 	}
 
 	return transitionAlt
@@ -0,0 +1,339 @@
+package atc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/curbz/decimal-niner/internal/logger"
+	"github.com/curbz/decimal-niner/pkg/geometry"
+)
+
+// airportLoadInterval is how often the airports around the user are loaded and evicted
+const airportLoadInterval = 30 * time.Second
+
+// airportEvictFactor is the multiple of the load radius beyond which a loaded airport is evicted, so that an
+// airport on the edge of the radius is not loaded and evicted over and over
+const airportEvictFactor = 1.5
+
+// GetAirports returns the airports keyed by ICAO code. The map is replaced rather than modified as airports are
+// loaded and evicted, so it may be ranged over without a lock, but it must not be modified.
+func (s *Service) GetAirports() map[string]*Airport {
+	s.airportMu.RLock()
+	defer s.airportMu.RUnlock()
+	return s.Airports
+}
+
+// SetFlightAirports records the origins and destinations of the active flights, which are loaded in full and
+// never evicted whatever their distance from the user
+func (s *Service) SetFlightAirports(icaos map[string]bool) {
+	s.airportMu.Lock()
+	defer s.airportMu.Unlock()
+	s.flightAirports = icaos
+}
+
+// airportRetryMax is the longest wait before an airport that failed to load is tried again
+const airportRetryMax = 30 * time.Minute
+
+// loadFailure records the failed loads of an airport, which is tried again after a wait that doubles with
+// each failure
+type loadFailure struct {
+	attempts int
+	retryAt  time.Time
+}
+
+// fail records a failed load at now and sets the time of the next attempt
+func (f *loadFailure) fail(now time.Time, interval time.Duration) {
+	f.attempts++
+	f.retryAt = now.Add(min(interval<<min(f.attempts-1, 10), airportRetryMax))
+}
+
+// watchAirports loads the airports within the load radius of the user and those of the active flights, and
+// evicts the airports that are no longer needed back to stubs. An airport that fails to load remains a stub
+// and is tried again later.
+func (s *Service) watchAirports(interval time.Duration) {
+	failed := make(map[string]*loadFailure)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		pos := s.GetUserState().Position
+		if pos.Lat == 0 && pos.Long == 0 {
+			continue // no position from the sim yet
+		}
+		now := time.Now()
+		waiting := make(map[string]bool)
+		for icao, f := range failed {
+			if now.Before(f.retryAt) {
+				waiting[icao] = true
+			}
+		}
+		load, evict := s.airportChanges(pos.Lat, pos.Long, waiting)
+		if len(load) == 0 && len(evict) == 0 {
+			continue
+		}
+
+		// each airport is loaded on its own so that one with bad data does not hold back the others
+		loaded := make(map[string]*Airport)
+		for _, icao := range sortedKeys(load) {
+			airports, err := s.loadFullAirports(map[string]bool{icao: true})
+			if err != nil {
+				if failed[icao] == nil {
+					failed[icao] = &loadFailure{}
+				}
+				failed[icao].fail(now, interval)
+				logger.Log.Errorf("unable to load airport %s, it remains a stub until %s: %v", icao,
+					failed[icao].retryAt.Format(time.TimeOnly), err)
+				continue
+			}
+			delete(failed, icao)
+			for icao, ap := range airports {
+				loaded[icao] = ap
+			}
+		}
+		s.replaceAirports(loaded, evict)
+		logger.Log.Infof("%d airports loaded and %d evicted in %v", len(loaded), len(evict), time.Since(now))
+	}
+}
+
+// airportChanges returns the stubs to load and the loaded airports to evict for the user position. Stubs in
+// waiting are not loaded.
+func (s *Service) airportChanges(lat, lon float64, waiting map[string]bool) (load, evict map[string]bool) {
+	s.airportMu.RLock()
+	airports, flights := s.Airports, s.flightAirports
+	s.airportMu.RUnlock()
+
+	radius := s.Config.ATC.AirportLoadRadiusNM
+	load, evict = make(map[string]bool), make(map[string]bool)
+	for icao, ap := range airports {
+		dist := geometry.DistNM(lat, lon, ap.Lat, ap.Lon)
+		if ap.Stub && !waiting[icao] && (flights[icao] || dist <= radius) {
+			load[icao] = true
+		} else if !ap.Stub && !flights[icao] && dist > radius*airportEvictFactor {
+			evict[icao] = true
+		}
+	}
+	return load, evict
+}
+
+// loadFullAirports parses the airports in full, with their CIFP data, parking and taxi network. With
+// strict_navdata set none of the airports are loaded if a record of their data was skipped.
+func (s *Service) loadFullAirports(icaos map[string]bool) (map[string]*Airport, error) {
+	if len(icaos) == 0 {
+		return nil, nil
+	}
+	if s.airportIndex == nil {
+		idx, err := indexAirports(s.Config)
+		if err != nil {
+			return nil, fmt.Errorf("error indexing airports data files: %w", err)
+		}
+		s.airportIndex = idx
+	}
+	diag := &ParseDiagnostics{}
+	airports, err := s.airportIndex.parse(icaos, diag)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing airports data file: %w", err)
+	}
+
+	airportHolds := make(map[string][]*Hold)
+	for _, h := range s.Holds {
+		if icaos[h.ICAO] {
+			airportHolds[h.ICAO] = append(airportHolds[h.ICAO], h)
+		}
+	}
+	for _, holds := range airportHolds {
+		sort.Slice(holds, func(i, j int) bool { return holds[i].Ident < holds[j].Ident })
+	}
+
+	// airports missing from the airports data file are not created as they would replace nothing
+	required := make(map[string]bool)
+	for icao := range airports {
+		required[icao] = true
+	}
+	if err := loadAirports(s.Config.ATC.AirportCIFPDir, airports, required, airportHolds, s.Holds, s.fixes, diag); err != nil {
+		return nil, fmt.Errorf("error loading airport data from CIFP files: %w", err)
+	}
+	diag.LogSummary()
+	if err := checkNavdataDiagnostics(s.Config, diag); err != nil {
+		return nil, err
+	}
+	return airports, nil
+}
+
+// aptSection is the part of an airports data file holding the records of an airport
+type aptSection struct {
+	path       string
+	pack       string // scenery pack that supplies the airport, empty for the global airports
+	start, end int64  // byte offsets of the airport header and the record after the airport
+	line       int    // line number of the airport header
+}
+
+// airportIndex locates the airports of the airports data files, so that airports loaded as the user flies are
+// read from their sections rather than by scanning the files again
+type airportIndex map[string]aptSection
+
+// indexAirports indexes the airports data file of the config or, when the config has an X-Plane root, the
+// apt.dat files of the enabled scenery packs. Each airport is located in the highest priority pack that
+// defines it, as parseAirports takes it.
+func indexAirports(cfg *config) (airportIndex, error) {
+	if cfg.ATC.XPlaneRoot == "" {
+		idx := make(airportIndex)
+		return idx, idx.add(cfg.ATC.AirportsDataFile, "")
+	}
+	packs, err := readSceneryPacks(cfg.ATC.XPlaneRoot)
+	if err != nil {
+		return nil, err
+	}
+	idx := make(airportIndex)
+	for _, pack := range packs {
+		if pack.aptPath == "" {
+			if err := idx.add(cfg.ATC.AirportsDataFile, ""); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := idx.add(pack.aptPath, pack.name); err != nil {
+			logger.Log.Warnf("skipping scenery pack %s: %v", pack.name, err)
+		}
+	}
+	return idx, nil
+}
+
+// add indexes the airports of the file that are not already indexed from a higher priority pack
+func (idx airportIndex) add(path, pack string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open airports data file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	// a later definition of an airport in the same file replaces the earlier one, as in parseApt
+	sections := make(map[string]aptSection)
+	var cur *aptSection
+	var curICAO string
+	closeSection := func(end int64) {
+		if cur != nil {
+			cur.end = end
+			sections[curICAO] = *cur
+			cur = nil
+		}
+	}
+	r := bufio.NewReader(file)
+	var offset int64
+	lineNo := 0
+	for {
+		line, err := r.ReadString('\n')
+		if len(line) > 0 {
+			lineNo++
+			p := strings.Fields(line)
+			if len(p) > 0 && (p[0] == "1" || p[0] == "16" || p[0] == "17" || p[0] == "99") {
+				closeSection(offset)
+				if len(p) >= 5 && p[0] != "99" {
+					curICAO = p[4]
+					cur = &aptSection{path: path, pack: pack, start: offset, line: lineNo}
+				}
+			}
+			offset += int64(len(line))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading airports data file %s: %w", path, err)
+		}
+	}
+	closeSection(offset)
+
+	for icao, sec := range sections {
+		if _, indexed := idx[icao]; !indexed {
+			idx[icao] = sec
+		}
+	}
+	return nil
+}
+
+// parse parses the indexed airports in full. Airports that are not indexed are not returned.
+func (idx airportIndex) parse(icaos map[string]bool, diag *ParseDiagnostics) (map[string]*Airport, error) {
+	airports := make(map[string]*Airport)
+	for _, icao := range sortedKeys(icaos) {
+		sec, ok := idx[icao]
+		if !ok {
+			continue
+		}
+		file, err := os.Open(sec.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open airports data file %s: %w", sec.path, err)
+		}
+		_, parsed, err := parseAptRecords(io.NewSectionReader(file, sec.start, sec.end-sec.start), sec.path, sec.line-1,
+			map[string]bool{icao: true}, nil, diag)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		if ap := parsed[icao]; ap != nil {
+			ap.SceneryPack = sec.pack
+			airports[icao] = ap
+		}
+	}
+	return airports, nil
+}
+
+// replaceAirports replaces the stubs of the loaded airports and the evicted airports with stubs. The airports
+// keep the controllers of the stubs, which are those of the controller database, their LVP state and the
+// settings of the overrides file.
+func (s *Service) replaceAirports(loaded map[string]*Airport, evict map[string]bool) {
+	s.airportMu.Lock()
+	defer s.airportMu.Unlock()
+
+	next := make(map[string]*Airport, len(s.Airports))
+	for icao, ap := range s.Airports {
+		next[icao] = ap
+	}
+	for icao, full := range loaded {
+		cur, ok := next[icao]
+		if !ok {
+			continue
+		}
+		full.Controllers = cur.Controllers
+		full.LVP = cur.LVP
+		full.TransAlt, full.RunwayConfigs, full.Noise, full.Hours = cur.TransAlt, cur.RunwayConfigs, cur.Noise, cur.Hours
+		next[icao] = full
+	}
+	for icao := range evict {
+		if ap, ok := next[icao]; ok {
+			next[icao] = ap.stub()
+		}
+	}
+	s.Airports = next
+}
+
+// stub returns a copy of the airport without its procedures, holds, parking and taxi network
+func (ap *Airport) stub() *Airport {
+	runways := make(map[string]*Runway, len(ap.Runways))
+	for name, rwy := range ap.Runways {
+		runways[name] = &Runway{
+			Name: rwy.Name, Lat: rwy.Lat, Lon: rwy.Lon, EndLat: rwy.EndLat, EndLon: rwy.EndLon, Heading: rwy.Heading,
+			Length: rwy.Length, Width: rwy.Width, ThresholdElevation: rwy.ThresholdElevation, LowVisLighting: rwy.LowVisLighting,
+		}
+	}
+	return &Airport{
+		ICAO:          ap.ICAO,
+		Name:          ap.Name,
+		Lat:           ap.Lat,
+		Lon:           ap.Lon,
+		Elevation:     ap.Elevation,
+		TransAlt:      ap.TransAlt,
+		Region:        ap.Region,
+		Runways:       runways,
+		Controllers:   ap.Controllers,
+		LVP:           ap.LVP,
+		SceneryPack:   ap.SceneryPack,
+		Stub:          true,
+		RunwayConfigs: ap.RunwayConfigs,
+		Noise:         ap.Noise,
+		Hours:         ap.Hours,
+	}
+}
@@ -0,0 +1,165 @@
+package atc
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/curbz/decimal-niner/internal/flightplan"
+)
+
+func TestLoadAndEvictAirports(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config{}
+	cfg.ATC.AirportsDataFile = filepath.Join(dir, "apt.dat")
+	cfg.ATC.AirportCIFPDir = dir
+	cfg.ATC.AirportLoadRadiusNM = 50
+	writeResource(t, cfg.ATC.AirportsDataFile, `I
+1100 Version
+1      83 0 0 EGLL London Heathrow
+1302 datum_lat 51.4775
+1302 datum_lon -0.4614
+1300 51.4700 -0.4500 90.0 gate jets 101
+1301 E airline baw
+1      13 0 0 KJFK John F Kennedy Intl
+1302 datum_lat 40.6398
+1302 datum_lon -73.7789
+1300 40.6400 -73.7800 90.0 gate jets 7
+99
+`)
+
+	required := map[string]bool{"EGLL": true, "KJFK": true}
+	_, airports, err := parseAirports(cfg, required, map[string]bool{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for icao, ap := range airports {
+		if !ap.Stub || len(ap.Parking) != 0 || ap.Lat == 0 {
+			t.Fatalf("%s = %+v; want a stub with a position and no parking", icao, ap)
+		}
+	}
+	tower := &Controller{Name: "Heathrow Tower", ICAO: "EGLL"}
+	airports["EGLL"].Controllers = []*Controller{tower}
+	airports["EGLL"].LVP = true
+
+	s := &Service{Config: cfg, Airports: airports}
+
+	// the user near Heathrow
+	load, evict := s.airportChanges(51.5, -0.5, nil)
+	if len(load) != 1 || !load["EGLL"] || len(evict) != 0 {
+		t.Fatalf("changes near EGLL = load %v, evict %v; want load EGLL", load, evict)
+	}
+	loaded, err := s.loadFullAirports(load)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stub := s.GetAirports()["EGLL"]
+	s.replaceAirports(loaded, evict)
+
+	egll := s.GetAirportByICAO("EGLL")
+	if egll.Stub || egll.Parking["101"] == nil {
+		t.Fatalf("EGLL = %+v; want loaded with its parking", egll)
+	}
+	if len(egll.Controllers) != 1 || egll.Controllers[0] != tower || !egll.LVP {
+		t.Errorf("EGLL controllers %v and LVP %v not kept from the stub", egll.Controllers, egll.LVP)
+	}
+	if !stub.Stub || len(stub.Parking) != 0 {
+		t.Error("the stub was modified rather than replaced")
+	}
+	if !s.GetAirportByICAO("KJFK").Stub {
+		t.Error("KJFK loaded beyond the load radius")
+	}
+
+	// the user near Kennedy with a flight to Heathrow keeps Heathrow loaded
+	s.SetFlightAirports(map[string]bool{"EGLL": true})
+	load, evict = s.airportChanges(40.6, -73.8, nil)
+	if !load["KJFK"] || len(evict) != 0 {
+		t.Fatalf("changes near KJFK = load %v, evict %v; want load KJFK only", load, evict)
+	}
+
+	// and evicts it once the flight has gone
+	s.SetFlightAirports(nil)
+	_, evict = s.airportChanges(40.6, -73.8, nil)
+	if !evict["EGLL"] {
+		t.Fatalf("evict = %v; want EGLL", evict)
+	}
+	s.replaceAirports(nil, evict)
+	egll = s.GetAirportByICAO("EGLL")
+	if !egll.Stub || len(egll.Parking) != 0 || egll.Controllers[0] != tower || !egll.LVP {
+		t.Errorf("evicted EGLL = %+v; want a stub with its controllers and LVP", egll)
+	}
+
+	// an airport waiting to retry a failed load is not loaded
+	if load, _ := s.airportChanges(40.6, -73.8, map[string]bool{"KJFK": true}); load["KJFK"] {
+		t.Error("failed airport KJFK retried")
+	}
+}
+
+func TestLoadFailureBackoff(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	f := &loadFailure{}
+	for _, want := range []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute} {
+		f.fail(now, 30*time.Second)
+		if got := f.retryAt.Sub(now); got != want {
+			t.Errorf("retry after %d failures in %v; want %v", f.attempts, got, want)
+		}
+	}
+	for range 20 {
+		f.fail(now, 30*time.Second)
+	}
+	if got := f.retryAt.Sub(now); got != airportRetryMax {
+		t.Errorf("retry after %d failures in %v; want %v", f.attempts, got, airportRetryMax)
+	}
+}
+
+func TestLoadFullAirportsStrict(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config{}
+	cfg.ATC.AirportsDataFile = filepath.Join(dir, "apt.dat")
+	cfg.ATC.AirportCIFPDir = dir
+	writeResource(t, cfg.ATC.AirportsDataFile, `I
+1100 Version
+1      83 0 0 EGLL London Heathrow
+1302 datum_lat 51.4775
+1302 datum_lon -0.4614
+1300 51.4700 -0.4500 90.0 gate
+1      13 0 0 KJFK John F Kennedy Intl
+1302 datum_lat 40.6398
+1302 datum_lon -73.7789
+1300 40.6400 -73.7800 90.0 gate
+99
+`)
+	s := &Service{Config: cfg}
+	load := map[string]bool{"EGLL": true}
+
+	if loaded, err := s.loadFullAirports(load); err != nil || loaded["EGLL"] == nil {
+		t.Fatalf("loadFullAirports = %v, %v; want EGLL loaded without its bad parking record", loaded, err)
+	}
+
+	cfg.ATC.StrictNavdata = true
+	if loaded, err := s.loadFullAirports(load); err == nil || !strings.Contains(err.Error(), "apt.dat:6") || loaded != nil {
+		t.Errorf("strict loadFullAirports = %v, %v; want the skipped parking record", loaded, err)
+	}
+
+	// records of an airport read from its section of the file are reported at their line in the file
+	if loaded, err := s.loadFullAirports(map[string]bool{"KJFK": true}); err == nil || !strings.Contains(err.Error(), "apt.dat:10") || loaded != nil {
+		t.Errorf("strict loadFullAirports = %v, %v; want the skipped parking record of KJFK", loaded, err)
+	}
+}
+
+func TestAssignSTARFromStub(t *testing.T) {
+	s := &Service{}
+	ac := &Aircraft{Registration: "G-TEST"}
+	ac.Flight.Schedule = &flightplan.ScheduledFlight{IcaoOrigin: "KJFK", IcaoDest: "EGLL"}
+	rwy := &Runway{Name: "27L"}
+
+	s.AssignSTAR(ac, &Airport{ICAO: "EGLL", Stub: true}, rwy)
+	if !ac.Flight.STARFromStub {
+		t.Error("STAR assigned from a stub not marked to be assigned again")
+	}
+	s.AssignSTAR(ac, &Airport{ICAO: "EGLL"}, rwy)
+	if ac.Flight.STARFromStub {
+		t.Error("STAR assigned from the loaded airport still marked")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"math"
 	"math/rand"
@@ -39,6 +40,12 @@ type Airport struct {
 	Parking         map[string]*ParkingSpot // keyed by ParkingSpot.Name
 	HubWeights      map[string]float64      // Airline ICAO -> Strength (0.0 to 1.0)
 	ClassCounts     map[string]int          // "E": 20, "C": 100 (Total gates by size)
+	LVP             bool                    // low visibility procedures in operation
+	SceneryPack     string                  // custom scenery pack that supplied the airport, empty for the global airports
+	Stub            bool                    // only the position, runways and controllers are loaded, see airport_load_radius_nm
+	RunwayConfigs   []RunwayConfig          // preferred runway configurations of the overrides file, in order
+	Noise           NoisePreference         // noise preferences of the overrides file
+	Hours           *OpeningHours           // opening hours of the overrides file, nil if always open
 }
 
 type Runway struct {
@@ -57,6 +64,7 @@ type Runway struct {
 	MAHeading                int     // initial MA course (degrees)
 	MAFix                    string
 	HighestPrecisionApproach string // highest precision approach type
+	LowVisLighting           bool   // approach, centreline and touchdown zone lighting supports CAT II/III operations
 	SIDs                     []*Procedure
 	STARs                    []*Procedure
 	DepartureAccess          map[string]*AccessPoint // Key: "A13", Value: AccessPoint{Coord, "Foxtrot"}
@@ -130,25 +138,42 @@ const (
 )
 
 func (s *Service) GetClosestAirport(lat, lon, withinRangeNm float64) string {
-	var closestICAO string
-	for icao, coords := range s.Airports {
-
-		dist := geometry.DistNM(lat, lon, coords.Lat, coords.Lon)
+	si := s.spatial()
+	if i, dist, ok := si.airports.Nearest(lat, lon, withinRangeNm); ok && dist < withinRangeNm {
+		return si.airportICAOs[i]
+	}
+	return ""
+}
 
-		if dist < withinRangeNm {
-			withinRangeNm = dist
-			closestICAO = icao
+// NearestAirport returns the airport closest to the position that is accepted, searching outwards from the
+// position, or nil if no airport is accepted. accept is only called for airports closer than the best so far.
+func (s *Service) NearestAirport(lat, lon float64, accept func(ap *Airport) bool) *Airport {
+	si := s.spatial()
+	airports := s.GetAirports()
+	for radius := spatialCellDeg * 60; ; radius *= 2 {
+		var nearest *Airport
+		nearestDist := radius
+		for _, i := range si.airports.Within(lat, lon, radius) {
+			ap := airports[si.airportICAOs[i]]
+			if ap == nil {
+				continue
+			}
+			if d := geometry.DistNM(lat, lon, ap.Lat, ap.Lon); d <= nearestDist && (nearest == nil || d < nearestDist) && accept(ap) {
+				nearest, nearestDist = ap, d
+			}
+		}
+		// every accepted airport closer than the radius has been seen
+		if nearest != nil || radius >= math.Pi*geometry.EarthRadiusNM {
+			return nearest
 		}
 	}
-
-	return closestICAO
 }
 
 // GetAirportRunwayByICAO returns the Runway instance for the given airport ICAO and runway name and nil if not found
 func (s *Service) GetAirportRunwayByICAO(icao, rwy string) *Runway {
 	var r *Runway
 	if icao != "" && rwy != "" {
-		ap, found := s.Airports[icao]
+		ap, found := s.GetAirports()[icao]
 		if found {
 			r, _ = ap.Runways[rwy]
 		}
@@ -166,7 +191,7 @@ func (s *Service) GetAirportRunway(airport *Airport, rwy string) *Runway {
 }
 
 func (s *Service) GetAirportByICAO(icao string) *Airport {
-	ap, exists := s.Airports[icao]
+	ap, exists := s.GetAirports()[icao]
 	if !exists {
 		return nil
 	}
@@ -280,6 +305,9 @@ func (s *Service) AssignSTAR(ac *Aircraft, airport *Airport, arrRwy *Runway) {
 		return
 	}
 
+	// a stub has no procedures, the STAR is assigned again once the airport is loaded
+	ac.Flight.STARFromStub = airport != nil && airport.Stub
+
 	// STAR assignment probability check
 	if rand.Float32() < constants.STARProbabilityFactor {
 		origAirport := s.GetAirportByICAO(ac.Flight.Schedule.IcaoOrigin)
@@ -287,6 +315,7 @@ func (s *Service) AssignSTAR(ac *Aircraft, airport *Airport, arrRwy *Runway) {
 
 		if bestSTAR != nil {
 			ac.Flight.AssignedSTAR = bestSTAR
+			ac.Flight.Vectoring = false
 			util.LogWithLabel(ac.Registration, "assigned STAR %s", bestSTAR.Name)
 			return
 		}
@@ -325,12 +354,18 @@ func (s *Service) AssignRunwayAccessPoint(ac *Aircraft, ap *Airport, arrOrDep in
 		accessMap = rwy.DepartureAccess
 	}
 
-	for _, access := range accessMap {
-		// Which of these qualified entries is closest to our parking position?
-		dist := geometry.DistNM(spot.Lat, spot.Lon, access.Coord.Lat, access.Coord.Lon)
-		if dist < minDistToGate {
-			minDistToGate = dist
-			selected = access
+	// access points on closed taxiways are only used when every access point of the runway is closed
+	for pass := 0; pass < 2 && selected == nil; pass++ {
+		for _, access := range accessMap {
+			if pass == 0 && s.IsTaxiwayClosed(ap.ICAO, access.TaxiwayName) {
+				continue
+			}
+			// Which of these qualified entries is closest to our parking position?
+			dist := geometry.DistNM(spot.Lat, spot.Lon, access.Coord.Lat, access.Coord.Lon)
+			if dist < minDistToGate {
+				minDistToGate = dist
+				selected = access
+			}
 		}
 	}
 
@@ -343,7 +378,7 @@ func (s *Service) AssignRunwayAccessPoint(ac *Aircraft, ap *Airport, arrOrDep in
 }
 
 func loadAirports(dir string, airports map[string]*Airport, requiredAirports map[string]bool,
-	airportHolds map[string][]*Hold, allHolds map[string]*Hold, allFixes map[string]*Fix) error {
+	airportHolds map[string][]*Hold, allHolds map[string]*Hold, allFixes map[string]*Fix, diag *ParseDiagnostics) error {
 
 	for icao := range requiredAirports {
 
@@ -361,14 +396,17 @@ func loadAirports(dir string, airports map[string]*Airport, requiredAirports map
 
 		// Parse airport CIFP data for runway, approach and fixes data
 		path := filepath.Join(dir, icao+".dat")
-		err := parseCIFP(path, allFixes, ap)
+		err := parseCIFP(path, allFixes, ap, diag)
 		var pathErr *fs.PathError
 		if err != nil {
+			pos := recordPos{file: path, record: "CIFP"}
 			if errors.As(err, &pathErr) {
 				// if error is io/fs.PathError then prefix log message with WARN: otherwise report as error
 				logger.Log.Warn("CIFP file not found for airport ", icao, ": ", err)
+				diag.defaulted(pos, "no CIFP file, %s has no procedures", icao)
 			} else {
 				logger.Log.Error("error parsing CIFP file for airport ", icao, ": ", err)
+				diag.skip(pos, "%v", err)
 			}
 			continue
 		}
@@ -407,8 +445,22 @@ func loadAirports(dir string, airports map[string]*Airport, requiredAirports map
 	return nil
 }
 
-// parseApt processes the X-Plane apt.dat file with deep fallback logic for missing coordinates.
-func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map[string]*Airport, error) {
+// parseApt processes the X-Plane apt.dat file with deep fallback logic for missing coordinates. Required airports
+// that are not in fullAirports are parsed as stubs without parking or taxiways; if fullAirports is nil every
+// required airport is parsed in full.
+func parseApt(path string, requiredAirports, fullAirports map[string]bool, diag *ParseDiagnostics) ([]*Controller, map[string]*Airport, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open airports data file %s: %w", path, err)
+	}
+	defer file.Close()
+	return parseAptRecords(file, path, 0, requiredAirports, fullAirports, diag)
+}
+
+// parseAptRecords parses the records of an airports data file read from r, which may be a section of the file
+// starting after line firstLine, as parseApt does
+func parseAptRecords(r io.Reader, path string, firstLine int, requiredAirports, fullAirports map[string]bool,
+	diag *ParseDiagnostics) ([]*Controller, map[string]*Airport, error) {
 
 	var allcontrollers, apcontrollers []*Controller
 	airports := make(map[string]*Airport)
@@ -418,24 +470,21 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 		edgeBuffer = []RawEdge{}              // List of all segments for the current airport
 	)
 
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to open airports data file %s: %w", path, err)
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
+	var err error
+	scanner := bufio.NewScanner(r)
 	var curAirport *Airport
 	var curICAO, curName, region string
 	var curLat, curLon, curElev float64
 	var transAlt int
 	var isRequiredAirport bool
+	var isFullAirport bool
 	var isRequiredController bool
 	var batchStartIdx int
 	var airportPoints []aptPoint
 	var curParking *ParkingSpot // Temporary pointer to the spot being built
 	var curTaxiNames []string
 	canClearTaxiNames := false
+	lineNo := firstLine
 
 	roleMap := map[string]int{
 		"1050": 7, // Information (Weather)
@@ -448,12 +497,14 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 	}
 
 	for scanner.Scan() {
+		lineNo++
 		line := strings.TrimSpace(scanner.Text())
 		p := strings.Fields(line)
 		if len(p) < 2 {
 			continue
 		}
 		code := p[0]
+		pos := recordPos{file: path, line: lineNo, record: code}
 
 		// 1. HEADER RECORD (New Airport Start)
 		if code == "1" || code == "16" || code == "17" {
@@ -478,7 +529,7 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 				region = ""
 				curElev, err = strconv.ParseFloat(p[1], 0)
 				if err != nil {
-					logger.Log.Warnf("unable to get airport elevation for %s: %v", curICAO, err)
+					diag.defaulted(pos, "elevation %q of %s is not a number", p[1], curICAO)
 				}
 				airportPoints = []aptPoint{}
 
@@ -487,6 +538,8 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 					isRequiredAirport = false
 				}
 
+				isFullAirport = fullAirports == nil || fullAirports[curICAO]
+
 				if isRequiredAirport {
 					// start building new airport - clear all buffers and temp data
 					nodeBuffer = make(map[int]Coordinate) // Reset
@@ -497,38 +550,53 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 						Name:        curName,
 						Controllers: []*Controller{},
 						Runways:     make(map[string]*Runway),
+						Stub:        !isFullAirport,
 					}
 					airports[curICAO] = curAirport
 				} else {
 					curAirport = nil
 				}
+			} else {
+				diag.skip(pos, "%d fields, want at least 5", len(p))
 			}
 			continue
 		}
 
+		// records of the airports that are not required are not reported
+		var airportDiag *ParseDiagnostics
+		if curAirport != nil {
+			airportDiag = diag
+		}
+
 		// 1300: PARKING LOCATION
 		// 1300 51.469151 -0.446896 -92.6 gate heavy|jets 218L
-		if curAirport != nil && code == "1300" {
-			if len(p) >= 7 {
-				lat, _ := strconv.ParseFloat(p[1], 64)
-				lon, _ := strconv.ParseFloat(p[2], 64)
-				hdg, _ := strconv.ParseFloat(p[3], 64)
-
-				curParking = &ParkingSpot{
-					Lat:     lat,
-					Lon:     lon,
-					Heading: hdg,
-					Type:    p[4],
-					Name:    strings.Join(p[6:], " "),
-				}
-				// We don't add it to curAirport.Parking yet; we wait for metadata (1301)
+		if curAirport != nil && isFullAirport && code == "1300" {
+			curParking = nil
+			if len(p) < 7 {
+				diag.skip(pos, "%d fields, want at least 7", len(p))
+				continue
+			}
+			lat, latErr := strconv.ParseFloat(p[1], 64)
+			lon, lonErr := strconv.ParseFloat(p[2], 64)
+			if latErr != nil || lonErr != nil {
+				diag.skip(pos, "invalid position %q %q", p[1], p[2])
+				continue
+			}
+
+			curParking = &ParkingSpot{
+				Lat:     lat,
+				Lon:     lon,
+				Heading: diag.floatField(pos, "heading", p[3]),
+				Type:    p[4],
+				Name:    strings.Join(p[6:], " "),
 			}
+			// We don't add it to curAirport.Parking yet; we wait for metadata (1301)
 			continue
 		}
 
 		// 1301: PARKING METADATA (Follows a 1300)
 		// 1301 C airline baw afr klm dlh vir sas aza ibe sva ber ryr vlg ezy
-		if curAirport != nil && code == "1301" && curParking != nil {
+		if curAirport != nil && isFullAirport && code == "1301" && curParking != nil {
 			// Initialize Parking map if not yet done
 			if curAirport.Parking == nil {
 				curAirport.Parking = make(map[string]*ParkingSpot)
@@ -545,6 +613,8 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 				if len(p) >= 4 {
 					airlineCodes = strings.Join(p[3:], " ")
 				}
+			} else {
+				diag.defaulted(pos, "no width class or operation type for parking %s", curParking.Name)
 			}
 			curParking.AirlineCodes = airlineCodes
 			curAirport.Parking[curParking.Name] = curParking
@@ -556,11 +626,11 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 		if code == "1302" && len(p) == 3 {
 			switch p[1] {
 			case "datum_lat":
-				curLat, _ = strconv.ParseFloat(p[2], 64)
+				curLat = airportDiag.floatField(pos, "datum_lat", p[2])
 			case "datum_lon":
-				curLon, _ = strconv.ParseFloat(p[2], 64)
+				curLon = airportDiag.floatField(pos, "datum_lon", p[2])
 			case "transition_alt":
-				transAlt, _ = strconv.Atoi(p[2])
+				transAlt = airportDiag.intField(pos, "transition_alt", p[2])
 			case "region_code":
 				region = p[2]
 			}
@@ -580,12 +650,14 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 				if hLat != 0 && hLon != 0 {
 					airportPoints = append(airportPoints, aptPoint{hLat, hLon})
 				}
-			} else if (code == "100" || code == "101") && len(fields) >= 20 {
-				width, _ := strconv.ParseFloat(fields[1], 64)
-				lat1, _ := strconv.ParseFloat(fields[9], 64)
-				lon1, _ := strconv.ParseFloat(fields[10], 64)
-				lat2, _ := strconv.ParseFloat(fields[18], 64)
-				lon2, _ := strconv.ParseFloat(fields[19], 64)
+			} else if (code == "100" || code == "101") && len(fields) < 20 {
+				airportDiag.skip(pos, "%d fields, want at least 20", len(fields))
+			} else if code == "100" || code == "101" {
+				width := airportDiag.floatField(pos, "width", fields[1])
+				lat1 := airportDiag.floatField(pos, "latitude", fields[9])
+				lon1 := airportDiag.floatField(pos, "longitude", fields[10])
+				lat2 := airportDiag.floatField(pos, "end latitude", fields[18])
+				lon2 := airportDiag.floatField(pos, "end longitude", fields[19])
 
 				// DYNAMIC LENGTH MATH: Calculate physical length from coordinates
 				calculatedLength := 0.0
@@ -602,6 +674,7 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 					rwy1.EndLon = lon2
 					rwy1.Width = width
 					rwy1.Length = calculatedLength
+					rwy1.LowVisLighting = hasLowVisLighting(fields[5], fields[14], fields[15])
 
 					id2 := fields[17]
 					if id2 != "" && id2 != "xxx" && id2 != "nil" {
@@ -612,6 +685,9 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 						rwy2.EndLon = lon1
 						rwy2.Width = width
 						rwy2.Length = calculatedLength
+						if len(fields) >= 25 {
+							rwy2.LowVisLighting = hasLowVisLighting(fields[5], fields[23], fields[24])
+						}
 					}
 				}
 				if lat1 != 0 && lat2 != 0 {
@@ -638,7 +714,11 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 				}
 
 				if isRequiredAirport || isEnroute {
-					fRaw, _ := strconv.Atoi(p[1])
+					fRaw, err := strconv.Atoi(p[1])
+					if err != nil {
+						diag.skip(pos, "frequency %q of %s is not an integer", p[1], curICAO)
+						continue
+					}
 					fNorm := normaliseFreq(fRaw)
 					batchStartIdx = len(apcontrollers)
 
@@ -688,9 +768,11 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 		}
 
 		// 4. TRANSMITTER OVERRIDE (1100)
-		if code == "1100" && len(apcontrollers) > 0 {
-			la, _ := strconv.ParseFloat(p[1], 64)
-			lo, _ := strconv.ParseFloat(p[2], 64)
+		if code == "1100" && len(apcontrollers) > 0 && len(p) < 3 {
+			diag.skip(pos, "%d fields, want at least 3", len(p))
+		} else if code == "1100" && len(apcontrollers) > 0 {
+			la := diag.floatField(pos, "latitude", p[1])
+			lo := diag.floatField(pos, "longitude", p[2])
 			if math.Abs(la) > 0.1 {
 				for i := batchStartIdx; i < len(apcontrollers); i++ {
 					apcontrollers[i].Lat, apcontrollers[i].Lon = la, lo
@@ -699,17 +781,23 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 		}
 
 		// 5. TAXIWAY EXTRACTION
-		if curAirport != nil {
+		if curAirport != nil && isFullAirport {
 			if code == "1201" { // Taxiway Node
-				// Format: 1201 <lat> <lon> <node_id>
+				// Format: 1201 <lat> <lon> <usage> <node_id> <name>
 				fields := strings.Fields(line)
-				if len(fields) >= 4 {
-					lat, _ := strconv.ParseFloat(fields[1], 64)
-					lon, _ := strconv.ParseFloat(fields[2], 64)
-					nodeID, _ := strconv.Atoi(fields[4])
-
-					nodeBuffer[nodeID] = Coordinate{Lat: lat, Lon: lon}
+				if len(fields) < 5 {
+					diag.skip(pos, "%d fields, want at least 5", len(fields))
+					continue
+				}
+				lat, latErr := strconv.ParseFloat(fields[1], 64)
+				lon, lonErr := strconv.ParseFloat(fields[2], 64)
+				nodeID, idErr := strconv.Atoi(fields[4])
+				if latErr != nil || lonErr != nil || idErr != nil {
+					diag.skip(pos, "invalid node %q at %q %q", fields[4], fields[1], fields[2])
+					continue
 				}
+
+				nodeBuffer[nodeID] = Coordinate{Lat: lat, Lon: lon}
 				continue
 			}
 
@@ -718,8 +806,11 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 					curTaxiNames = []string{}
 					canClearTaxiNames = false
 				}
+				// Format: 1202 <node_id> <node_id> <direction> <type> [name], unnamed edges are not used
 				fields := strings.Fields(line)
-				if len(fields) >= 6 {
+				if len(fields) < 5 {
+					diag.skip(pos, "%d fields, want at least 5", len(fields))
+				} else if len(fields) >= 6 {
 					name := fields[5]
 
 					// 1. REJECT if it's a runway crossing (contains "/")
@@ -729,8 +820,12 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 					}
 
 					// 2. PROCESS everything else (Alpha, Bravo, N11, LINK56, etc.)
-					id1, _ := strconv.Atoi(fields[1])
-					id2, _ := strconv.Atoi(fields[2])
+					id1, err1 := strconv.Atoi(fields[1])
+					id2, err2 := strconv.Atoi(fields[2])
+					if err1 != nil || err2 != nil {
+						diag.skip(pos, "invalid nodes %q %q of taxiway %s", fields[1], fields[2], name)
+						continue
+					}
 
 					// Add to buffer for proximity math
 					edgeBuffer = append(edgeBuffer, RawEdge{
@@ -750,7 +845,11 @@ func parseApt(path string, requiredAirports map[string]bool) ([]*Controller, map
 			if code == "1204" {
 				fields := strings.Fields(line)
 
-				if len(curTaxiNames) == 0 || len(fields) < 3 {
+				if len(fields) < 3 {
+					diag.skip(pos, "%d fields, want at least 3", len(fields))
+					continue
+				}
+				if len(curTaxiNames) == 0 {
 					continue
 				}
 
@@ -874,7 +973,7 @@ func finaliseAirport(ap *Airport, dLat, dLon float64, pts []aptPoint, apctrls []
 	}
 }
 
-func parseCIFP(cifpPath string, allFixes map[string]*Fix, ap *Airport) error {
+func parseCIFP(cifpPath string, allFixes map[string]*Fix, ap *Airport, diag *ParseDiagnostics) error {
 
 	f, err := os.Open(cifpPath)
 	if err != nil {
@@ -918,20 +1017,31 @@ func parseCIFP(cifpPath string, allFixes map[string]*Fix, ap *Airport) error {
 	pendingProcs := []pendingProc{}
 
 	lastSeq := 1
+	lineNo := 0
 
 	for scan.Scan() {
+		lineNo++
 		line := strings.TrimSpace(scan.Text())
+		pos := recordPos{file: cifpPath, line: lineNo}
+		if i := strings.Index(line, ":"); i > 0 {
+			pos.record = line[:i]
+		}
 
 		// --- SID/STAR LOGIC ---
 		if strings.HasPrefix(line, "SID:") || strings.HasPrefix(line, "STAR:") {
 			fields := strings.Split(line, ",")
-			if len(fields) < 12 {
+			if len(fields) < 26 {
+				diag.skip(pos, "%d fields, want at least 26", len(fields))
 				continue
 			}
 
 			// Extract Sequence (e.g., "010")
 			seqPart := fields[0][strings.Index(fields[0], ":")+1:]
-			seq, _ := strconv.Atoi(strings.TrimSpace(seqPart))
+			seq, err := strconv.Atoi(strings.TrimSpace(seqPart))
+			if err != nil {
+				diag.skip(pos, "sequence %q is not an integer", seqPart)
+				continue
+			}
 
 			procName := strings.TrimSpace(fields[2])
 			procRwy := strings.TrimSpace(fields[3])
@@ -998,6 +1108,9 @@ func parseCIFP(cifpPath string, allFixes map[string]*Fix, ap *Airport) error {
 				if fields[11] == "TF" || fields[11] == "DF" {
 					currentProc.Legs = append(currentProc.Legs, pFix)
 				}
+			} else if !strings.HasPrefix(fixID, "RW") {
+				// runway thresholds are not fixes
+				diag.skip(pos, "fix %s_%s of %s is not in the fix data", fixID, regionID, procName)
 			}
 			continue
 		}
@@ -1011,6 +1124,7 @@ func parseCIFP(cifpPath string, allFixes map[string]*Fix, ap *Airport) error {
 		if strings.HasPrefix(line, "RWY:") {
 			parts := strings.Split(line, ";") // The physical data is usually after the semicolon
 			if len(parts) < 2 {
+				diag.skip(pos, "no threshold position")
 				continue
 			}
 
@@ -1022,6 +1136,17 @@ func parseCIFP(cifpPath string, allFixes map[string]*Fix, ap *Airport) error {
 
 			rwyName := normaliseRunwayName(strings.TrimPrefix(metaFields[0], "RWY:"))
 
+			var lat, lon float64
+			if len(dataFields) >= 2 {
+				var latErr, lonErr error
+				lat, latErr = parseCIFPCoord(dataFields[0])
+				lon, lonErr = parseCIFPCoord(dataFields[1])
+				if err := errors.Join(latErr, lonErr); err != nil {
+					diag.skip(pos, "invalid threshold position: %v", err)
+					continue
+				}
+			}
+
 			// Create or get the existing runway
 			rwEntry := ap.Runways[rwyName]
 			if rwEntry == nil {
@@ -1030,32 +1155,34 @@ func parseCIFP(cifpPath string, allFixes map[string]*Fix, ap *Airport) error {
 			}
 			rwEntry.Name = rwyName
 
+			// blank fields are left unset, fields that are not numbers are reported
 			if len(metaFields) >= 3 {
-				length, _ := strconv.ParseFloat(strings.TrimSpace(metaFields[1]), 64)
-				width, _ := strconv.ParseFloat(strings.TrimSpace(metaFields[2]), 64)
-				rwEntry.Length = length
-				rwEntry.Width = width
+				if length := strings.TrimSpace(metaFields[1]); length != "" {
+					rwEntry.Length = diag.floatField(pos, "length", length)
+				}
+				if width := strings.TrimSpace(metaFields[2]); width != "" {
+					rwEntry.Width = diag.floatField(pos, "width", width)
+				}
 				if len(metaFields) >= 4 {
 					// 1. Parse Heading (Token 3 in metaFields)
-					if h, err := strconv.Atoi(strings.TrimSpace(metaFields[3])); err == nil {
-						rwEntry.Heading = float64(h) // Already in degrees (e.g., 00079)
+					if h := strings.TrimSpace(metaFields[3]); h != "" {
+						rwEntry.Heading = float64(diag.intField(pos, "heading", h)) // Already in degrees (e.g., 00079)
 					}
 				}
 			}
 
-			// 2. Parse Coordinates from dataFields
+			// 2. Coordinates from dataFields
 			if len(dataFields) >= 2 {
-				rwEntry.Lat = parseCIFPCoord(dataFields[0])
-				rwEntry.Lon = parseCIFPCoord(dataFields[1])
+				rwEntry.Lat = lat
+				rwEntry.Lon = lon
 			}
 
 			// 3. Parse Threshold Elevation (Token 2 in dataFields)
 			if len(dataFields) >= 3 {
 				// Remove trailing semicolon if present and parse
-				elevStr := strings.TrimRight(dataFields[2], ";")
-				if e, err := strconv.Atoi(elevStr); err == nil {
+				if elevStr := strings.TrimSpace(strings.TrimRight(dataFields[2], ";")); elevStr != "" {
 					// Note: CIFP elevation is often 1014 meaning 101.4, check your data source!
-					rwEntry.ThresholdElevation = float64(e) / 10.0
+					rwEntry.ThresholdElevation = float64(diag.intField(pos, "threshold elevation", elevStr)) / 10.0
 				}
 			}
 			continue
@@ -1067,6 +1194,7 @@ func parseCIFP(cifpPath string, allFixes map[string]*Fix, ap *Airport) error {
 
 		fields := strings.Split(line, ",")
 		if len(fields) < 26 {
+			diag.skip(pos, "%d fields, want at least 26", len(fields))
 			continue
 		}
 
@@ -1387,6 +1515,17 @@ func getUsage(ap *Airport, taxiName string, rwyName string) string {
 	return "both"
 }
 
+// hasLowVisLighting returns true if the apt.dat runway centreline, approach and touchdown zone lighting codes
+// support CAT II/III operations: centreline lights, ALSF-II or Calvert CAT II/III approach lights and TDZ lights
+func hasLowVisLighting(centreline, approach, tdz string) bool {
+	return centreline == "1" && (approach == "2" || approach == "4") && tdz == "1"
+}
+
+// IsCatIIorIII returns true if the runway has an ILS and the lighting required for CAT II/III approaches
+func (r *Runway) IsCatIIorIII() bool {
+	return r.HighestPrecisionApproach == "ILS" && r.LowVisLighting
+}
+
 func finaliseParking(ap *Airport, namedNodes []NamedNode) {
 	for _, park := range ap.Parking {
 		// We pass the Coordinate, not a NodeID
@@ -1586,11 +1725,12 @@ func cleanAirportName(n string) string {
 	return strings.TrimSpace(n)
 }
 
-func parseCIFPCoord(coord string) float64 {
+// parseCIFPCoord converts a CIFP coordinate, e.g. N51283900 or W000290597, to decimal degrees
+func parseCIFPCoord(coord string) (float64, error) {
 	coord = strings.TrimSpace(coord)
 	// CIFP coords are typically N51283900 (9 chars) or W000290597 (10 chars)
 	if len(coord) < 7 {
-		return 0
+		return 0, fmt.Errorf("coordinate %q is too short", coord)
 	}
 
 	dir := coord[0]
@@ -1598,19 +1738,26 @@ func parseCIFPCoord(coord string) float64 {
 	// Latitude (N/S) uses 2 digits for degrees: [1:3]
 	// Longitude (E/W) uses 3 digits for degrees: [1:4]
 	degLen := 2
-	if dir == 'E' || dir == 'W' {
+	switch dir {
+	case 'E', 'W':
 		degLen = 3
+	case 'N', 'S':
+	default:
+		return 0, fmt.Errorf("coordinate %q has no hemisphere", coord)
 	}
 
 	// 1. Extract Degrees
-	deg, _ := strconv.ParseFloat(coord[1:1+degLen], 64)
+	deg, degErr := strconv.ParseFloat(coord[1:1+degLen], 64)
 
 	// 2. Extract Minutes (always 2 digits following degrees)
-	min, _ := strconv.ParseFloat(coord[1+degLen:3+degLen], 64)
+	min, minErr := strconv.ParseFloat(coord[1+degLen:3+degLen], 64)
 
 	// 3. Extract Seconds (everything remaining)
 	secStr := coord[3+degLen:]
-	rawSec, _ := strconv.ParseFloat(secStr, 64)
+	rawSec, secErr := strconv.ParseFloat(secStr, 64)
+	if degErr != nil || minErr != nil || secErr != nil {
+		return 0, fmt.Errorf("coordinate %q is not a number", coord)
+	}
 
 	// Determine the power of 10 for the seconds denominator.
 	// If input is "SSss" (len 4), we need to divide rawSec by 100 to get SS.ss.
@@ -1632,7 +1779,7 @@ func parseCIFPCoord(coord string) float64 {
 		decimal = -decimal
 	}
 
-	return decimal
+	return decimal, nil
 }
 
 func getReciprocalName(name string) string {
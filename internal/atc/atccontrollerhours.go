@@ -0,0 +1,96 @@
+package atc
+
+import (
+	"math"
+)
+
+// defaultControllerHours are the local opening hours of the delivery, ground and tower controllers by airport
+// size, used when default_controller_hours is set. The controllers of large airports are always open.
+var defaultControllerHours = map[string]OpeningHours{
+	"medium": {Open: 6 * 60, Close: 23 * 60},
+	"small":  {Open: 8 * 60, Close: 20 * 60},
+}
+
+// airportSize returns "large", "medium" or "small" from the length and number of the airport's runways
+func airportSize(ap *Airport) string {
+	longest := 0.0
+	for _, rwy := range ap.Runways {
+		longest = math.Max(longest, rwy.Length)
+	}
+	switch {
+	case longest >= 2800 || len(ap.Runways) >= 6: // runways are keyed by each end
+		return "large"
+	case longest >= 1800:
+		return "medium"
+	default:
+		return "small"
+	}
+}
+
+// isAirportController reports whether the controller is the delivery, ground or tower of an airport
+func isAirportController(c *Controller) bool {
+	return c.IsPoint && c.RoleID >= controllerRoles["del"] && c.RoleID <= controllerRoles["twr"]
+}
+
+// setDefaultControllerHours sets the default opening hours of the airport controllers without hours, converting
+// the local hours to zulu by the airport's longitude. Airports with opening hours of their own keep them for
+// their controllers. It returns the number of controllers given hours.
+func setDefaultControllerHours(controllers []*Controller, airports map[string]*Airport) int {
+	n := 0
+	for _, c := range controllers {
+		if !isAirportController(c) || c.Hours != nil {
+			continue
+		}
+		ap, ok := airports[c.ICAO]
+		if !ok || ap.Hours != nil {
+			continue
+		}
+		local, ok := defaultControllerHours[airportSize(ap)]
+		if !ok {
+			continue
+		}
+		offset := int(math.Round(ap.Lon/15.0)) * 60
+		c.Hours = &OpeningHours{
+			Open:  (local.Open - offset + 1440) % 1440,
+			Close: (local.Close - offset + 1440) % 1440,
+		}
+		n++
+	}
+	return n
+}
+
+// controllerHours returns the opening hours of the controller, or those of its airport when it has none of its
+// own, nil if always open
+func (s *Service) controllerHours(c *Controller) *OpeningHours {
+	if c.Hours != nil || !isAirportController(c) {
+		return c.Hours
+	}
+	if ap := s.GetAirportByICAO(c.ICAO); ap != nil {
+		return ap.Hours
+	}
+	return nil
+}
+
+// isControllerOpen reports whether the controller is open at the sim time. Controllers are open until the sim
+// time is known.
+func (s *Service) isControllerOpen(c *Controller) bool {
+	hours := s.controllerHours(c)
+	if hours == nil || s.SimInitTime.IsZero() {
+		return true
+	}
+	return hours.IsOpen(s.GetCurrentZuluTime())
+}
+
+// closedController returns a controller of the airport with the role that is closed at the sim time, or nil
+func (s *Service) closedController(icao string, role int) *Controller {
+	ap := s.GetAirportByICAO(icao)
+	if ap == nil {
+		return nil
+	}
+	for _, c := range ap.Controllers {
+		if c.RoleID == role && !s.isControllerOpen(c) {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package atc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetDefaultControllerHours(t *testing.T) {
+	short := map[string]*Runway{"08": {Name: "08", Length: 1500}, "26": {Name: "26", Length: 1500}}
+	long := map[string]*Runway{"09L": {Name: "09L", Length: 3900}, "27R": {Name: "27R", Length: 3900}}
+	airports := map[string]*Airport{
+		"EGLC": {ICAO: "EGLC", Lon: 0.05, Runways: short},
+		"LCLK": {ICAO: "LCLK", Lon: 33.6, Runways: short},
+		"EGLL": {ICAO: "EGLL", Lon: -0.46, Runways: long},
+	}
+	set := &OpeningHours{Open: 0, Close: 60}
+	cityTower := &Controller{ICAO: "EGLC", RoleID: 3, IsPoint: true}
+	larnacaTower := &Controller{ICAO: "LCLK", RoleID: 3, IsPoint: true}
+	heathrowTower := &Controller{ICAO: "EGLL", RoleID: 3, IsPoint: true}
+	cityApproach := &Controller{ICAO: "EGLC", RoleID: 4, IsPoint: true}
+	cityGround := &Controller{ICAO: "EGLC", RoleID: 2, IsPoint: true, Hours: set}
+
+	n := setDefaultControllerHours([]*Controller{cityTower, larnacaTower, heathrowTower, cityApproach, cityGround}, airports)
+	if n != 2 {
+		t.Errorf("hours set for %d controllers; want 2", n)
+	}
+	if cityTower.Hours == nil || *cityTower.Hours != (OpeningHours{Open: 8 * 60, Close: 20 * 60}) {
+		t.Errorf("EGLC tower hours = %v; want 0800-2000", cityTower.Hours)
+	}
+	// two hours east of Greenwich the local hours are two hours earlier in zulu
+	if larnacaTower.Hours == nil || *larnacaTower.Hours != (OpeningHours{Open: 6 * 60, Close: 18 * 60}) {
+		t.Errorf("LCLK tower hours = %v; want 0600-1800", larnacaTower.Hours)
+	}
+	if heathrowTower.Hours != nil || cityApproach.Hours != nil || cityGround.Hours != set {
+		t.Error("hours set for a large airport, an approach unit or a controller with hours")
+	}
+}
+
+func TestAirportHoursCloseControllers(t *testing.T) {
+	hours := &OpeningHours{Open: 6*60 + 30, Close: 21*60 + 30}
+	tower := &Controller{Name: "City Tower", ICAO: "EGLC", RoleID: 3, IsPoint: true}
+	ground := &Controller{Name: "City Ground", ICAO: "EGLC", RoleID: 2, IsPoint: true, Hours: &OpeningHours{Open: 0, Close: 23 * 60}}
+	unicom := &Controller{Name: "City Unicom", ICAO: "EGLC", RoleID: 0, IsPoint: true}
+	approach := &Controller{Name: "Thames Radar", ICAO: "EGLC", RoleID: 4, IsPoint: true}
+	ap := &Airport{ICAO: "EGLC", Lon: 0.055, Hours: hours, Controllers: []*Controller{tower, ground, unicom, approach},
+		Runways: map[string]*Runway{"09": {Name: "09", Length: 1500}, "27": {Name: "27", Length: 1500}}}
+	s := &Service{Airports: map[string]*Airport{"EGLC": ap}}
+
+	// the airport hours are kept over the default hours of its size
+	if n := setDefaultControllerHours(ap.Controllers, s.Airports); n != 0 || tower.Hours != nil {
+		t.Errorf("default hours set for %d controllers, tower hours %v; want the airport hours kept", n, tower.Hours)
+	}
+
+	s.SyncSimTime(time.Date(2026, 1, 1, 22, 0, 0, 0, time.UTC), time.Now())
+	if s.isControllerOpen(tower) {
+		t.Error("tower without hours open outside the airport hours")
+	}
+	if !s.isControllerOpen(ground) || !s.isControllerOpen(unicom) || !s.isControllerOpen(approach) {
+		t.Error("controller with its own hours, Unicom or approach closed by the airport hours")
+	}
+	if c := s.closedController("EGLC", 3); c != tower {
+		t.Errorf("closedController = %v; want the tower", c)
+	}
+
+	s.SyncSimTime(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), time.Now())
+	if !s.isControllerOpen(tower) {
+		t.Error("tower closed within the airport hours")
+	}
+}
+
+func TestClosedControllerSkipped(t *testing.T) {
+	tower := &Controller{Name: "City Tower", ICAO: "EGLC", RoleID: 3, Freqs: []int{118400}, Lat: 51.505, Lon: 0.055,
+		IsPoint: true, Hours: &OpeningHours{Open: 8 * 60, Close: 20 * 60}}
+	unicom := &Controller{Name: "City Unicom", ICAO: "EGLC", RoleID: 0, Freqs: []int{122800}, Lat: 51.505, Lon: 0.055,
+		IsPoint: true}
+	s := &Service{
+		Controllers:    []*Controller{tower, unicom},
+		AirportService: &MockAirportProvider{MockReturn: "EGLC"},
+		Airports: map[string]*Airport{
+			"EGLC": {ICAO: "EGLC", Lat: 51.505, Lon: 0.055, Controllers: []*Controller{tower, unicom}},
+		},
+	}
+
+	// open until the sim time is known
+	if c := s.locateController("TEST", 0, 3, 51.505, 0.055, 0, "EGLC"); c != tower {
+		t.Fatalf("locateController without a sim time = %v; want the tower", c)
+	}
+
+	s.SyncSimTime(time.Date(2026, 1, 1, 22, 0, 0, 0, time.UTC), time.Now())
+	if c := s.locateController("TEST", 0, 3, 51.505, 0.055, 0, "EGLC"); c != nil {
+		t.Errorf("locateController at night = %v; want the closed tower skipped", c)
+	}
+	if c := s.locateController("TEST", 0, RoleNone, 51.505, 0.055, 0, "EGLC"); c != unicom {
+		t.Errorf("locateController of any role at night = %v; want Unicom", c)
+	}
+	if c := s.closedController("EGLC", 3); c != tower {
+		t.Errorf("closedController = %v; want the tower", c)
+	}
+
+	// the user tuned to the closed tower hears nothing
+	s.UserState.ActiveFacilities = map[int]*Controller{1: tower}
+	s.NotifyUserStateChange(Position{Lat: 51.505, Long: 0.055}, map[int]int{1: 11840}, map[int]int{1: 3}, true)
+	if c, ok := s.UserState.ActiveFacilities[1]; ok {
+		t.Errorf("COM1 facility = %v; want none on the closed frequency", c)
+	}
+
+	// a facility tuned before it closed stays silent without a retune
+	s.Config = &config{}
+	s.Broadcast = make(chan *Aircraft, 1)
+	ac := &Aircraft{Registration: "G-TEST"}
+	ac.Flight.Comms.Controller = tower
+	tuned := UserState{ActiveFacilities: map[int]*Controller{1: tower}}
+	s.Transmit(tuned, ac)
+	if len(s.Broadcast) != 0 {
+		t.Error("transmission heard on the closed tower frequency")
+	}
+	s.SyncSimTime(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), time.Now())
+	s.Transmit(tuned, ac)
+	if len(s.Broadcast) != 1 {
+		t.Error("transmission not heard on the open tower frequency")
+	}
+}
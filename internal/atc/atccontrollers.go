@@ -23,6 +23,7 @@ type Controller struct {
 	IsPoint    bool
 	IsRegion   bool
 	Airspaces  []Airspace
+	Hours      *OpeningHours // opening hours, nil if always open
 }
 
 type Airspace struct {
@@ -34,6 +35,79 @@ type Airspace struct {
 	MinLon, MaxLon float64
 }
 
+// setBounds calculates the area and bounding box of the airspace polygon
+func (a *Airspace) setBounds() {
+	a.Area = geometry.CalculateRoughArea(a.Points)
+
+	// 1. Initialize bounds
+	minLa, maxLa := 90.0, -90.0
+	minLo, maxLo := 180.0, -180.0
+
+	// 2. Standard Lat bounds
+	for _, p := range a.Points {
+		if p[0] < minLa {
+			minLa = p[0]
+		}
+		if p[0] > maxLa {
+			maxLa = p[0]
+		}
+	}
+
+	// 3. Smart Longitude bounds (detect wrap-around)
+	// Find the "gap" in longitude to determine if we cross the dateline
+	actualMinLo, actualMaxLo := 180.0, -180.0
+	hasEast := false
+	hasWest := false
+
+	for _, p := range a.Points {
+		lon := p[1]
+		if lon > 0 {
+			hasEast = true
+		}
+		if lon < 0 {
+			hasWest = true
+		}
+		if lon < actualMinLo {
+			actualMinLo = lon
+		}
+		if lon > actualMaxLo {
+			actualMaxLo = lon
+		}
+	}
+
+	// If a polygon has points in both East and West AND spans a huge distance,
+	// it's a dateline crosser (like Anchorage)
+	if hasEast && hasWest && (actualMaxLo-actualMinLo > 180) {
+		// Anchorage Case: Min is the smallest positive, Max is the largest negative
+		// effectively "wrapping around" the back of the map.
+		minLo, maxLo = 180.0, -180.0
+		for _, p := range a.Points {
+			if p[1] > 0 && p[1] < minLo {
+				minLo = p[1]
+			} // Smallest East (e.g. 165)
+			if p[1] < 0 && p[1] > maxLo {
+				maxLo = p[1]
+			} // Largest West (e.g. -140)
+		}
+	} else {
+		// Standard case
+		minLo, maxLo = actualMinLo, actualMaxLo
+	}
+
+	a.MinLat, a.MaxLat = minLa, maxLa
+	a.MinLon, a.MaxLon = minLo, maxLo
+}
+
+// controllerRoles maps the roles of the atc.dat and overrides files to role IDs
+var controllerRoles = map[string]int{
+	"unicom": 0,
+	"del":    1,
+	"gnd":    2,
+	"twr":    3,
+	"tracon": 4, // Approach/Departure
+	"ctr":    6,
+}
+
 type PhaseFacility struct {
 	atcPhase string
 	roleId   int
@@ -45,6 +119,7 @@ type Comms struct {
 	NextController *Controller
 	CruiseHandoff  int // flag to indicate to phrase generation that this is a handoff scenario and not just a routine position update
 	CountryCode    string
+	Language       string // language of the current exchange when native language transmissions are used, empty for English
 }
 
 type Handoff int
@@ -57,35 +132,39 @@ const (
 
 const RoleNone = -1
 
-func parseATCdatFiles(path string, isRegion bool, requiredICAOs map[string]bool) ([]*Controller, error) {
+func parseATCdatFiles(path string, isRegion bool, requiredICAOs map[string]bool, diag *ParseDiagnostics) ([]*Controller, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open ATC data file %s: %w", path, err)
 	}
 	defer file.Close()
 
-	roleMap := map[string]int{
-		"del":    1,
-		"gnd":    2,
-		"twr":    3,
-		"tracon": 4, // Approach/Departure
-		"ctr":    6,
-	}
-
 	scanner := bufio.NewScanner(file)
 	var list []*Controller
 	var cur *Controller
 	var curPoly *Airspace
 	var isRequired bool // Track if the current controller block should be kept
+	lineNo := 0
 
 	for scanner.Scan() {
+		lineNo++
 		line := strings.TrimSpace(scanner.Text())
-		if line == "" || line[0] == '#' {
+		if line == "" || line[0] == '#' || isDataFileHeader(line) {
 			continue
 		}
 		p := strings.Fields(line)
+		record := strings.ToUpper(p[0])
+		pos := recordPos{file: path, line: lineNo, record: record}
 
-		switch strings.ToUpper(p[0]) {
+		switch record {
+		case "NAME", "FACILITY_ID", "ICAO", "ROLE", "FREQ", "CHAN", "POINT":
+			if len(p) < 2 {
+				diag.skip(pos, "no value")
+				continue
+			}
+		}
+
+		switch record {
 		case "CONTROLLER":
 			cur = &Controller{IsRegion: isRegion, IsPoint: false}
 			isRequired = true // Default to true until we check the ICAO/Role
@@ -100,7 +179,11 @@ func parseATCdatFiles(path string, isRegion bool, requiredICAOs map[string]bool)
 		case "ROLE":
 			if cur != nil {
 				roleStr := strings.ToLower(p[1])
-				cur.RoleID = roleMap[roleStr]
+				role, ok := controllerRoles[roleStr]
+				if !ok {
+					diag.defaulted(pos, "unknown role %q, using Unicom", p[1])
+				}
+				cur.RoleID = role
 
 				// FILTER LOGIC:
 				// If it's a local airport role (DEL, GND, TWR), check requiredICAOs.
@@ -113,7 +196,11 @@ func parseATCdatFiles(path string, isRegion bool, requiredICAOs map[string]bool)
 			}
 		case "FREQ", "CHAN":
 			if cur != nil && isRequired {
-				fRaw, _ := strconv.Atoi(p[1])
+				fRaw, err := strconv.Atoi(p[1])
+				if err != nil {
+					diag.skip(pos, "frequency %q is not an integer", p[1])
+					continue
+				}
 				cur.Freqs = append(cur.Freqs, normaliseFreq(fRaw))
 			}
 		case "AIRSPACE_POLYGON_BEGIN":
@@ -122,16 +209,26 @@ func parseATCdatFiles(path string, isRegion bool, requiredICAOs map[string]bool)
 			}
 			f, c := -99999.0, 99999.0
 			if len(p) >= 3 {
-				f, _ = strconv.ParseFloat(p[1], 64)
-				c, _ = strconv.ParseFloat(p[2], 64)
+				f = diag.floatField(pos, "floor", p[1])
+				c = diag.floatField(pos, "ceiling", p[2])
+			} else {
+				diag.defaulted(pos, "no floor and ceiling, using unlimited")
 			}
 			curPoly = &Airspace{Floor: f, Ceiling: c}
 		case "POINT":
 			if !isRequired {
 				continue
 			}
-			la, _ := strconv.ParseFloat(p[1], 64)
-			lo, _ := strconv.ParseFloat(p[2], 64)
+			if len(p) < 3 {
+				diag.skip(pos, "%d fields, want 3", len(p))
+				continue
+			}
+			la, laErr := strconv.ParseFloat(p[1], 64)
+			lo, loErr := strconv.ParseFloat(p[2], 64)
+			if laErr != nil || loErr != nil {
+				diag.skip(pos, "invalid position %q %q", p[1], p[2])
+				continue
+			}
 			if curPoly != nil {
 				curPoly.Points = append(curPoly.Points, [2]float64{la, lo})
 			}
@@ -140,66 +237,7 @@ func parseATCdatFiles(path string, isRegion bool, requiredICAOs map[string]bool)
 			}
 		case "AIRSPACE_POLYGON_END":
 			if cur != nil && curPoly != nil {
-				curPoly.Area = geometry.CalculateRoughArea(curPoly.Points)
-
-				// 1. Initialize bounds
-				minLa, maxLa := 90.0, -90.0
-				minLo, maxLo := 180.0, -180.0
-
-				// 2. Standard Lat bounds
-				for _, p := range curPoly.Points {
-					if p[0] < minLa {
-						minLa = p[0]
-					}
-					if p[0] > maxLa {
-						maxLa = p[0]
-					}
-				}
-
-				// 3. Smart Longitude bounds (detect wrap-around)
-				// Find the "gap" in longitude to determine if we cross the dateline
-				actualMinLo, actualMaxLo := 180.0, -180.0
-				hasEast := false
-				hasWest := false
-
-				for _, p := range curPoly.Points {
-					lon := p[1]
-					if lon > 0 {
-						hasEast = true
-					}
-					if lon < 0 {
-						hasWest = true
-					}
-					if lon < actualMinLo {
-						actualMinLo = lon
-					}
-					if lon > actualMaxLo {
-						actualMaxLo = lon
-					}
-				}
-
-				// If a polygon has points in both East and West AND spans a huge distance,
-				// it's a dateline crosser (like Anchorage)
-				if hasEast && hasWest && (actualMaxLo-actualMinLo > 180) {
-					// Anchorage Case: Min is the smallest positive, Max is the largest negative
-					// effectively "wrapping around" the back of the map.
-					minLo, maxLo = 180.0, -180.0
-					for _, p := range curPoly.Points {
-						if p[1] > 0 && p[1] < minLo {
-							minLo = p[1]
-						} // Smallest East (e.g. 165)
-						if p[1] < 0 && p[1] > maxLo {
-							maxLo = p[1]
-						} // Largest West (e.g. -140)
-					}
-				} else {
-					// Standard case
-					minLo, maxLo = actualMinLo, actualMaxLo
-				}
-
-				curPoly.MinLat, curPoly.MaxLat = minLa, maxLa
-				curPoly.MinLon, curPoly.MaxLon = minLo, maxLo
-
+				curPoly.setBounds()
 				cur.Airspaces = append(cur.Airspaces, *curPoly)
 			}
 			curPoly = nil
@@ -242,6 +280,12 @@ func (s *Service) AssignController(ac *Aircraft) *Controller {
 			ac.Flight.Position.Lat, ac.Flight.Position.Long, ac.Flight.Position.Altitude, searchICAO)
 	}
 
+	// A closed airport facility without Unicom hands over to the approach unit
+	if aiFac == nil && s.closedController(searchICAO, phaseFacility.roleId) != nil {
+		aiFac = s.locateController(ac.Registration+"_Closed", 0, controllerRoles["tracon"],
+			ac.Flight.Position.Lat, ac.Flight.Position.Long, ac.Flight.Position.Altitude, searchICAO)
+	}
+
 	// Final Global Fallback (Unicom anywhere nearby)
 	// we check searchICAO isn't empty as we may have already performed the same search if the phase-based search returned no ICAO
 	if aiFac == nil && searchICAO != "" {
@@ -277,7 +321,7 @@ func (s *Service) locateController(label string, tFreq, tRole int, uLa, uLo, uAl
 
 	// --- TIER 0: THE TARGET ICAO SHORTCUT ---
 	if targetICAO != "" {
-		ap, exists := s.Airports[targetICAO]
+		ap, exists := s.GetAirports()[targetICAO]
 		if !exists {
 			// airport not found, resort to proximity search
 			util.LogWithLabel(label, "no airport found for target ICAO of %s - fallback to proximity search", targetICAO)
@@ -287,7 +331,7 @@ func (s *Service) locateController(label string, tFreq, tRole int, uLa, uLo, uAl
 			if distToTarget < constants.ControllerTargetICAOCloseNM {
 				var backupMatch *Controller
 				for _, c := range ap.Controllers {
-					if c.ICAO == targetICAO && c.IsPoint {
+					if c.ICAO == targetICAO && c.IsPoint && s.isControllerOpen(c) {
 						if tRole != RoleNone && c.RoleID == tRole {
 							return c
 						}
@@ -308,10 +352,13 @@ func (s *Service) locateController(label string, tFreq, tRole int, uLa, uLo, uAl
 		}
 	}
 
+	si := s.spatial()
+
 	// --- TIER 1: SCAN POINTS (Proximity + Frequency) ---
-	for _, c := range s.Controllers {
+	for _, i := range si.points.Within(uLa, uLo, searchLimit) {
+		c := s.Controllers[i]
 
-		if !c.IsPoint || c.RoleID >= 7 {
+		if !c.IsPoint || c.RoleID >= 7 || !s.isControllerOpen(c) {
 			continue
 		}
 
@@ -378,9 +425,9 @@ func (s *Service) locateController(label string, tFreq, tRole int, uLa, uLo, uAl
 	}
 
 	// --- TIER 2: SCAN POLYGONS (Center/Oceanic) ---
-	for i := range s.Controllers {
+	for _, i := range si.airspaces.Containing(uLa, uLo) {
 		c := s.Controllers[i]
-		if len(c.Airspaces) == 0 {
+		if len(c.Airspaces) == 0 || !s.isControllerOpen(c) {
 			continue
 		}
 
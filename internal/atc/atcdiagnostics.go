@@ -0,0 +1,157 @@
+package atc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/curbz/decimal-niner/internal/logger"
+)
+
+// ParseDiagnostic is a record of an X-Plane data file that was skipped or read with a defaulted value
+type ParseDiagnostic struct {
+	File    string
+	Line    int    // zero if the diagnostic is for the whole file
+	Record  string // record type, e.g. "1300", "SID" or "FREQ"
+	Skipped bool   // the record was skipped, otherwise one of its values was defaulted
+	Reason  string
+}
+
+func (d ParseDiagnostic) String() string {
+	action := "defaulted"
+	if d.Skipped {
+		action = "skipped"
+	}
+	return fmt.Sprintf("%s:%d: %s %s: %s", d.File, d.Line, d.Record, action, d.Reason)
+}
+
+// ParseDiagnostics collects the diagnostics of the data file parsers so that bad data can be told apart from
+// parser bugs. A nil collector discards them. It is not safe for concurrent use.
+type ParseDiagnostics struct {
+	Entries []ParseDiagnostic
+}
+
+// recordPos identifies the record being parsed
+type recordPos struct {
+	file   string
+	line   int
+	record string
+}
+
+func (d *ParseDiagnostics) add(pos recordPos, skipped bool, format string, args ...any) {
+	if d == nil {
+		return
+	}
+	d.Entries = append(d.Entries, ParseDiagnostic{
+		File: pos.file, Line: pos.line, Record: pos.record, Skipped: skipped, Reason: fmt.Sprintf(format, args...),
+	})
+}
+
+// skip records that the record was not used
+func (d *ParseDiagnostics) skip(pos recordPos, format string, args ...any) {
+	d.add(pos, true, format, args...)
+}
+
+// defaulted records that the record was used with a default in place of a missing or invalid value
+func (d *ParseDiagnostics) defaulted(pos recordPos, format string, args ...any) {
+	d.add(pos, false, format, args...)
+}
+
+// floatField parses a numeric field of the record, defaulting it to zero if it is not a number
+func (d *ParseDiagnostics) floatField(pos recordPos, name, s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		d.defaulted(pos, "%s %q is not a number", name, s)
+		return 0
+	}
+	return f
+}
+
+// intField parses an integer field of the record, defaulting it to zero if it is not an integer
+func (d *ParseDiagnostics) intField(pos recordPos, name, s string) int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		d.defaulted(pos, "%s %q is not an integer", name, s)
+		return 0
+	}
+	return i
+}
+
+// Counts returns the number of skipped and defaulted records
+func (d *ParseDiagnostics) Counts() (skipped, defaulted int) {
+	if d == nil {
+		return 0, 0
+	}
+	for _, e := range d.Entries {
+		if e.Skipped {
+			skipped++
+		} else {
+			defaulted++
+		}
+	}
+	return skipped, defaulted
+}
+
+// Err returns an error describing the skipped records, or nil if no record was skipped
+func (d *ParseDiagnostics) Err() error {
+	skipped, _ := d.Counts()
+	if skipped == 0 {
+		return nil
+	}
+	for _, e := range d.Entries {
+		if e.Skipped {
+			return fmt.Errorf("%d data file records skipped, the first at %s", skipped, e)
+		}
+	}
+	return nil
+}
+
+// LogSummary logs the number of skipped and defaulted records of each file and record type along with the
+// first reason of each. Every diagnostic is logged at debug level.
+func (d *ParseDiagnostics) LogSummary() {
+	skipped, defaulted := d.Counts()
+	if skipped == 0 && defaulted == 0 {
+		logger.Log.Info("Navdata parse diagnostics: no records skipped or defaulted")
+		return
+	}
+	logger.Log.Warnf("Navdata parse diagnostics: %d records skipped, %d defaulted", skipped, defaulted)
+
+	type group struct {
+		file, record       string
+		skipped, defaulted int
+		first              ParseDiagnostic
+	}
+	groups := make(map[string]*group)
+	for _, e := range d.Entries {
+		logger.Log.Debug(e.String())
+		key := e.File + "\x00" + e.Record
+		g, ok := groups[key]
+		if !ok {
+			g = &group{file: e.File, record: e.Record, first: e}
+			groups[key] = g
+		}
+		if e.Skipped {
+			g.skipped++
+		} else {
+			g.defaulted++
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		g := groups[key]
+		logger.Log.Warnf("  %s %s: %d skipped, %d defaulted, e.g. line %d: %s",
+			g.file, g.record, g.skipped, g.defaulted, g.first.Line, g.first.Reason)
+	}
+}
+
+// isDataFileHeader reports whether the line is the origin, version or end of file line that starts and ends the
+// X-Plane data files
+func isDataFileHeader(line string) bool {
+	return line == "I" || line == "A" || line == "99" || strings.Contains(line, " Version")
+}
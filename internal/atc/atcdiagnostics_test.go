@@ -0,0 +1,209 @@
+package atc
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// findDiagnostic returns the diagnostic of the file line, or nil
+func findDiagnostic(diag *ParseDiagnostics, file string, line int) *ParseDiagnostic {
+	for i, e := range diag.Entries {
+		if e.File == file && e.Line == line {
+			return &diag.Entries[i]
+		}
+	}
+	return nil
+}
+
+func TestParseDiagnosticsHoldData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "earth_hold.dat")
+	writeResource(t, path, `I
+1140 Version
+LAM EG EGLL 3 263.0 1.0 0.0 L 7000 15000 220
+BNN EG ENRT 3
+OCK EG ENRT 3 x 1.0 0.0 R 7000 0 0
+99
+`)
+	diag := &ParseDiagnostics{}
+	holds, _, err := parseHoldData(path, diag)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(holds) != 2 || holds["BNN_EG"] != nil {
+		t.Errorf("holds = %v; want LAM and OCK", holds)
+	}
+	if skipped, defaulted := diag.Counts(); skipped != 1 || defaulted != 1 {
+		t.Fatalf("counts = %d skipped, %d defaulted; want 1, 1: %v", skipped, defaulted, diag.Entries)
+	}
+	if d := findDiagnostic(diag, path, 4); d == nil || !d.Skipped || d.Record != "HOLD" {
+		t.Errorf("line 4 diagnostic = %+v; want HOLD skipped", d)
+	}
+	if d := findDiagnostic(diag, path, 5); d == nil || d.Skipped || !strings.Contains(d.Reason, "inbound course") {
+		t.Errorf("line 5 diagnostic = %+v; want inbound course defaulted", d)
+	}
+}
+
+func TestParseDiagnosticsApt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apt.dat")
+	writeResource(t, path, `I
+1100 Version
+1      83 0 0 EGLL London Heathrow
+1302 datum_lat 51.4775
+1302 datum_lon west
+1054 1185OO HEATHROW TOWER
+1300 51.4700 -0.4500 90.0 gate
+1300 51.4710 -0.4510 90.0 gate jets 521
+1301 E airline baw
+1201 51.4700 -0.4500 both
+1      13 0 0 KJFK John F Kennedy Intl
+1300 40.6 -73.7 90.0 gate
+99
+`)
+	diag := &ParseDiagnostics{}
+	controllers, airports, err := parseApt(path, map[string]bool{"EGLL": true}, nil, diag)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ap := airports["EGLL"]
+	if ap == nil || len(ap.Parking) != 1 || ap.Parking["521"] == nil {
+		t.Fatalf("EGLL parking = %v; want 521 only", ap)
+	}
+	if len(controllers) != 0 {
+		t.Errorf("controllers = %v; want the tower with the bad frequency skipped", controllers)
+	}
+
+	for _, want := range []struct {
+		line    int
+		record  string
+		skipped bool
+	}{
+		{5, "1302", false},
+		{6, "1054", true},
+		{7, "1300", true},
+		{10, "1201", true},
+	} {
+		if d := findDiagnostic(diag, path, want.line); d == nil || d.Record != want.record || d.Skipped != want.skipped {
+			t.Errorf("line %d diagnostic = %+v; want %s skipped %v", want.line, d, want.record, want.skipped)
+		}
+	}
+	// records of airports that are not required are not reported
+	if d := findDiagnostic(diag, path, 12); d != nil {
+		t.Errorf("unexpected diagnostic for KJFK: %v", d)
+	}
+	if len(diag.Entries) != 4 {
+		t.Errorf("diagnostics = %v; want 4", diag.Entries)
+	}
+}
+
+func TestParseDiagnosticsCIFPRunway(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "EGLL.dat")
+	writeResource(t, path, `RWY:RW09L,12799,164,00089, ,IAA ,3,;N51283900,W000290597,0079;
+RWY:RW27R,12799,164,00269, ,IAA ,3,;N5128X900,E000265600,0077;
+RWY:RW09R,long,164,00089, , , , ;N51275900,W000263800,0075;
+RWY:RW27L, , ,00269, , , , ;N51275900,W000263800,;
+`)
+	ap := &Airport{ICAO: "EGLL", Runways: map[string]*Runway{}}
+	diag := &ParseDiagnostics{}
+	if err := parseCIFP(path, nil, ap, diag); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rwy := ap.Runways["09L"]; rwy == nil || rwy.Length != 12799 || rwy.Lat < 51.4 || rwy.Lon > 0 {
+		t.Errorf("09L = %+v; want the parsed runway", rwy)
+	}
+	if rwy := ap.Runways["27R"]; rwy != nil {
+		t.Errorf("27R = %+v; want the runway with the bad threshold skipped", rwy)
+	}
+	if d := findDiagnostic(diag, path, 2); d == nil || !d.Skipped || d.Record != "RWY" || !strings.Contains(d.Reason, "N5128X900") {
+		t.Errorf("line 2 diagnostic = %+v; want RWY skipped", d)
+	}
+	if d := findDiagnostic(diag, path, 3); d == nil || d.Skipped || !strings.Contains(d.Reason, "length") {
+		t.Errorf("line 3 diagnostic = %+v; want length defaulted", d)
+	}
+	// blank fields are common in CIFP and are not reported
+	if d := findDiagnostic(diag, path, 4); d != nil {
+		t.Errorf("unexpected diagnostic for blank fields: %v", d)
+	}
+	if diag.Err() == nil {
+		t.Error("Err = nil; want the skipped runway to fail strict mode")
+	}
+}
+
+func TestParseDiagnosticsATCdat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "atc.dat")
+	writeResource(t, path, `A
+1000 Version
+CONTROLLER
+NAME London Control
+ROLE ctr
+FREQ 127.1
+FREQ 132600
+AIRSPACE_POLYGON_BEGIN 0 66000
+POINT 50.0 -2.0
+POINT 53.0
+POINT 53.0 1.0
+POINT 50.0 1.0
+AIRSPACE_POLYGON_END
+CONTROLLER_END
+`)
+	diag := &ParseDiagnostics{}
+	controllers, err := parseATCdatFiles(path, true, nil, diag)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(controllers) != 1 || len(controllers[0].Freqs) != 1 || len(controllers[0].Airspaces[0].Points) != 3 {
+		t.Fatalf("controllers = %+v", controllers)
+	}
+	if d := findDiagnostic(diag, path, 6); d == nil || !d.Skipped || d.Record != "FREQ" {
+		t.Errorf("line 6 diagnostic = %+v; want FREQ skipped", d)
+	}
+	if d := findDiagnostic(diag, path, 10); d == nil || !d.Skipped || d.Record != "POINT" {
+		t.Errorf("line 10 diagnostic = %+v; want POINT skipped", d)
+	}
+}
+
+func TestParseDiagnosticsErr(t *testing.T) {
+	var discard *ParseDiagnostics
+	discard.skip(recordPos{file: "apt.dat", line: 1, record: "1300"}, "ignored")
+	if err := discard.Err(); err != nil {
+		t.Errorf("nil collector Err = %v", err)
+	}
+
+	diag := &ParseDiagnostics{}
+	diag.defaulted(recordPos{file: "apt.dat", line: 3, record: "1302"}, "datum_lat %q is not a number", "x")
+	if err := diag.Err(); err != nil {
+		t.Errorf("Err with only defaulted records = %v; want nil", err)
+	}
+	diag.skip(recordPos{file: "apt.dat", line: 7, record: "1300"}, "%d fields, want at least 7", 5)
+	err := diag.Err()
+	if err == nil || !strings.Contains(err.Error(), "apt.dat:7: 1300 skipped: 5 fields, want at least 7") {
+		t.Errorf("Err = %v", err)
+	}
+}
+
+func TestLoadNavdataStrict(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config{}
+	cfg.ATC.NavdataCacheDir = filepath.Join(dir, "cache")
+	cfg.ATC.AirportsDataFile = filepath.Join(dir, "apt.dat")
+	required := map[string]bool{"EGLL": true}
+
+	nd := testNavdata()
+	nd.Diagnostics.skip(recordPos{file: "EGLL.dat", line: 12, record: "SID"}, "fix XXX_EG of CPT3G is not in the fix data")
+	if err := writeNavdataCache(cfg.ATC.NavdataCacheDir, navdataCacheKey(cfg, required), nd); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadNavdata(cfg, required)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded.Diagnostics.Entries) != 1 {
+		t.Errorf("diagnostics not restored from cache: %v", loaded.Diagnostics.Entries)
+	}
+
+	cfg.ATC.StrictNavdata = true
+	if _, err := loadNavdata(cfg, required); err == nil || !strings.Contains(err.Error(), "EGLL.dat:12") {
+		t.Errorf("strict loadNavdata error = %v; want the skipped SID record", err)
+	}
+}
@@ -0,0 +1,162 @@
+package atc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/curbz/decimal-niner/internal/constants"
+	"github.com/curbz/decimal-niner/pkg/util"
+	"github.com/mohae/deepcopy"
+)
+
+type EmergencyType int
+
+const (
+	EmergencyNone EmergencyType = iota
+	EmergencyMedical
+	EmergencyEngineFailure
+	EmergencyPressurization
+	EmergencyRadioFailure
+)
+
+var emergencyTypeNames = map[EmergencyType]string{
+	EmergencyNone:           "none",
+	EmergencyMedical:        "medical",
+	EmergencyEngineFailure:  "engine_failure",
+	EmergencyPressurization: "pressurization",
+	EmergencyRadioFailure:   "radio_failure",
+}
+
+// emergencyNatureMap holds the spoken description of each emergency type
+var emergencyNatureMap = map[EmergencyType]string{
+	EmergencyMedical:        "medical emergency on board",
+	EmergencyEngineFailure:  "engine failure",
+	EmergencyPressurization: "loss of cabin pressure, emergency descent",
+	EmergencyRadioFailure:   "radio failure",
+}
+
+func (t EmergencyType) String() string {
+	if name, ok := emergencyTypeNames[t]; ok {
+		return name
+	}
+	return emergencyTypeNames[EmergencyNone]
+}
+
+// ParseEmergencyType converts a configured emergency name (e.g. "engine_failure") to its type
+func ParseEmergencyType(name string) (EmergencyType, error) {
+	for t, n := range emergencyTypeNames {
+		if n == name && t != EmergencyNone {
+			return t, nil
+		}
+	}
+	return EmergencyNone, fmt.Errorf("unknown emergency type: %s", name)
+}
+
+type Emergency struct {
+	Type       EmergencyType
+	DeclaredAt time.Time
+	DivertICAO string
+	Announced  bool // set once the declaration has been sent for phrase generation
+}
+
+// IsDistress returns true when the emergency warrants a MAYDAY call. Other emergencies are
+// urgency (PAN PAN) calls.
+func (em *Emergency) IsDistress() bool {
+	return em.Type == EmergencyEngineFailure || em.Type == EmergencyPressurization
+}
+
+// Squawk returns the transponder code for the emergency
+func (em *Emergency) Squawk() string {
+	if em.Type == EmergencyRadioFailure {
+		return fmt.Sprintf("%04d", constants.SquawkRadioFailure)
+	}
+	return fmt.Sprintf("%04d", constants.SquawkEmergency)
+}
+
+// DeclareEmergency places the aircraft in an emergency state, sets the emergency squawk and generates
+// the declaration exchange with the controller. divertICAO is the airport the aircraft will divert to
+// and may be empty if the aircraft continues to its destination.
+func (s *Service) DeclareEmergency(ac *Aircraft, t EmergencyType, divertICAO string) {
+
+	ac.Flight.Emergency = &Emergency{
+		Type:       t,
+		DeclaredAt: s.GetCurrentZuluTime(),
+		DivertICAO: divertICAO,
+	}
+	ac.Flight.Squawk = ac.Flight.Emergency.Squawk()
+
+	util.LogWithLabel(ac.Registration, "emergency declared: %s, squawking %s, diverting to %s", t, ac.Flight.Squawk, divertICAO)
+
+	if s.transmitSnapshot(ac, "emergency") {
+		// the declaration is only ever announced once
+		ac.Flight.Emergency.Announced = true
+	}
+}
+
+// transmitSnapshot generates phrases for a snapshot of the aircraft in the background, so that subsequent
+// updates to the aircraft do not affect the transmission. Returns false if the snapshot could not be taken.
+func (s *Service) transmitSnapshot(ac *Aircraft, context string) bool {
+	v := deepcopy.Copy(ac)
+	acSnap, ok := v.(*Aircraft)
+	if !ok {
+		util.LogWarnWithLabel(ac.Registration, "failed to deepcopy aircraft snapshot for %s; skipping phrase generation", context)
+		return false
+	}
+
+	util.GoSafe(func() {
+		acSnap.Flight.Comms.Controller = s.AssignController(acSnap)
+		if acSnap.Flight.Comms.Controller != nil {
+			s.Transmit(s.UserState, acSnap)
+		}
+	})
+	return true
+}
+
+// emergencyPhraseKey returns the phrase category used to announce an emergency
+func emergencyPhraseKey(em *Emergency) string {
+	if em.Type == EmergencyRadioFailure {
+		return "emergency_radio_failure"
+	}
+	return "emergency"
+}
+
+// formatDistress returns the distress or urgency prefix. When ack is true the single form used by a
+// controller acknowledging the call is returned.
+func formatDistress(em *Emergency, ack bool) string {
+	if em == nil {
+		return ""
+	}
+	switch {
+	case em.IsDistress() && ack:
+		return "mayday"
+	case em.IsDistress():
+		return "mayday mayday mayday"
+	case ack:
+		return "pan pan"
+	default:
+		return "pan pan, pan pan, pan pan"
+	}
+}
+
+// formatEmergencyNature returns the spoken description of the emergency
+func formatEmergencyNature(em *Emergency) string {
+	if em == nil {
+		return ""
+	}
+	return emergencyNatureMap[em.Type]
+}
+
+// formatDivert returns the name of the airport the aircraft is diverting to, falling back to
+// the destination when no emergency or weather diversion has been set
+func formatDivert(ac *Aircraft, airportNameLookup map[string]*Airport) string {
+	icao := ac.Flight.Destination
+	if ac.Flight.Emergency != nil && ac.Flight.Emergency.DivertICAO != "" {
+		icao = ac.Flight.Emergency.DivertICAO
+	} else if ac.Flight.Diversion != nil && ac.Flight.Diversion.ICAO != "" {
+		icao = ac.Flight.Diversion.ICAO
+	}
+	if icao == "" {
+		return "nearest suitable airport"
+	}
+	return formatAirportName(icao, airportNameLookup)
+}
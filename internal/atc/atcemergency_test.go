@@ -0,0 +1,86 @@
+package atc
+
+import "testing"
+
+func TestParseEmergencyType(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    EmergencyType
+		wantErr bool
+	}{
+		{"medical", EmergencyMedical, false},
+		{"engine_failure", EmergencyEngineFailure, false},
+		{"pressurization", EmergencyPressurization, false},
+		{"radio_failure", EmergencyRadioFailure, false},
+		{"none", EmergencyNone, true},
+		{"fire", EmergencyNone, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseEmergencyType(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseEmergencyType(%q) = %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+		if !tt.wantErr && got.String() != tt.name {
+			t.Errorf("%v.String() = %q; want %q", got, got.String(), tt.name)
+		}
+	}
+}
+
+func TestEmergencyPhraseology(t *testing.T) {
+	tests := []struct {
+		emType     EmergencyType
+		wantSquawk string
+		wantCall   string
+		wantAck    string
+		wantKey    string
+	}{
+		{EmergencyMedical, "7700", "pan pan, pan pan, pan pan", "pan pan", "emergency"},
+		{EmergencyEngineFailure, "7700", "mayday mayday mayday", "mayday", "emergency"},
+		{EmergencyPressurization, "7700", "mayday mayday mayday", "mayday", "emergency"},
+		{EmergencyRadioFailure, "7600", "pan pan, pan pan, pan pan", "pan pan", "emergency_radio_failure"},
+	}
+
+	for _, tt := range tests {
+		em := &Emergency{Type: tt.emType}
+		if got := em.Squawk(); got != tt.wantSquawk {
+			t.Errorf("%s: Squawk() = %q; want %q", tt.emType, got, tt.wantSquawk)
+		}
+		if got := formatDistress(em, false); got != tt.wantCall {
+			t.Errorf("%s: formatDistress() = %q; want %q", tt.emType, got, tt.wantCall)
+		}
+		if got := formatDistress(em, true); got != tt.wantAck {
+			t.Errorf("%s: formatDistress(ack) = %q; want %q", tt.emType, got, tt.wantAck)
+		}
+		if got := emergencyPhraseKey(em); got != tt.wantKey {
+			t.Errorf("%s: emergencyPhraseKey() = %q; want %q", tt.emType, got, tt.wantKey)
+		}
+	}
+
+	if got := formatDistress(nil, false); got != "" {
+		t.Errorf("formatDistress(nil) = %q; want empty", got)
+	}
+}
+
+func TestFormatDivert(t *testing.T) {
+	airports := map[string]*Airport{
+		"EGKK": {ICAO: "EGKK", Name: "Gatwick"},
+		"EGLL": {ICAO: "EGLL", Name: "Heathrow"},
+	}
+
+	tests := []struct {
+		name string
+		ac   *Aircraft
+		want string
+	}{
+		{"diversion airport", &Aircraft{Flight: Flight{Destination: "EGLL", Emergency: &Emergency{DivertICAO: "EGKK"}}}, "Gatwick"},
+		{"destination fallback", &Aircraft{Flight: Flight{Destination: "EGLL", Emergency: &Emergency{}}}, "Heathrow"},
+		{"no destination", &Aircraft{}, "nearest suitable airport"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDivert(tt.ac, airports); got != tt.want {
+			t.Errorf("%s: formatDivert() = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package atc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/curbz/decimal-niner/pkg/geometry"
+)
+
+// HoldEntry is the procedure used to join a holding pattern, chosen from the aircraft's heading on reaching the fix
+type HoldEntry int
+
+const (
+	HoldEntryDirect HoldEntry = iota
+	HoldEntryParallel
+	HoldEntryTeardrop
+)
+
+func (e HoldEntry) String() string {
+	switch e {
+	case HoldEntryParallel:
+		return "parallel"
+	case HoldEntryTeardrop:
+		return "teardrop"
+	default:
+		return "direct"
+	}
+}
+
+// HoldLeg is the part of the holding pattern, or of its entry, that an aircraft is flying
+type HoldLeg int
+
+const (
+	HoldLegInbound       HoldLeg = iota // tracking the inbound course to the fix
+	HoldLegOutboundTurn                 // turning from the fix onto the outbound leg
+	HoldLegOutbound                     // flying away from the fix parallel to the inbound course
+	HoldLegInboundTurn                  // turning back onto the inbound course
+	HoldLegEntryOutbound                // parallel or teardrop entry leg flown away from the fix
+	HoldLegEntryTurn                    // parallel entry turn back towards the fix on the holding side
+)
+
+const (
+	holdLegMinutesLow  = 1.0     // ICAO leg time at or below 14,000ft
+	holdLegMinutesHigh = 1.5     // ICAO leg time above 14,000ft
+	holdLegHighAltFt   = 14000.0 // altitude above which the longer legs are flown
+	holdTurnRateDegSec = 3.0     // rate one turn
+	holdTeardropOffset = 30.0    // angle of the teardrop entry leg from the outbound course
+)
+
+// HoldPattern is the racetrack flown at a hold, with courses in degrees true
+type HoldPattern struct {
+	InboundTrack float64 // true track of the inbound leg to the fix
+	LeftTurns    bool
+	LegMinutes   float64 // time of the inbound leg when the legs are timed
+	LegNM        float64 // length of the legs when they are defined by distance, otherwise zero
+}
+
+// HasPattern returns true if the hold's racetrack is published
+func (h *Hold) HasPattern() bool {
+	return h.Turn == "L" || h.Turn == "R"
+}
+
+// Pattern returns the racetrack of the hold for the magnetic variation (degrees, east positive) and the altitude
+// (feet) it is flown at. Unpublished leg times are the ICAO defaults for the altitude.
+func (h *Hold) Pattern(magVar, altitudeFt float64) HoldPattern {
+	p := HoldPattern{
+		InboundTrack: geometry.NormalizeHeading(h.InboundCourse + magVar),
+		LeftTurns:    h.Turn == "L",
+		LegMinutes:   h.LegTime,
+		LegNM:        h.LegDist,
+	}
+	if p.LegNM > 0 {
+		p.LegMinutes = 0
+	} else if p.LegMinutes <= 0 {
+		p.LegMinutes = DefaultHoldLegMinutes(altitudeFt)
+	}
+	return p
+}
+
+// DefaultHoldLegMinutes returns the ICAO inbound leg time for a hold flown at the altitude (feet)
+func DefaultHoldLegMinutes(altitudeFt float64) float64 {
+	if altitudeFt > holdLegHighAltFt {
+		return holdLegMinutesHigh
+	}
+	return holdLegMinutesLow
+}
+
+// OutboundTrack returns the true track of the outbound leg
+func (p HoldPattern) OutboundTrack() float64 {
+	return geometry.NormalizeHeading(p.InboundTrack + 180.0)
+}
+
+// TurnSign returns 1 for right turns and -1 for left turns, for adding to headings in the direction of the turns
+func (p HoldPattern) TurnSign() float64 {
+	if p.LeftTurns {
+		return -1
+	}
+	return 1
+}
+
+// Entry returns the entry procedure for an aircraft reaching the fix on the heading (degrees true). The sectors
+// are divided by the inbound course and a line through the fix at 70 degrees to the outbound course on the
+// holding side.
+func (p HoldPattern) Entry(heading float64) HoldEntry {
+	// heading relative to the inbound track, mirrored so that left turns share the right hand sectors
+	rel := geometry.NormalizeHeading((heading - p.InboundTrack) * p.TurnSign())
+	switch {
+	case rel >= 110 && rel < 180:
+		return HoldEntryTeardrop
+	case rel >= 180 && rel < 290:
+		return HoldEntryParallel
+	default:
+		return HoldEntryDirect
+	}
+}
+
+// TeardropTrack returns the true track of the teardrop entry leg, offset from the outbound course towards the
+// holding side
+func (p HoldPattern) TeardropTrack() float64 {
+	return geometry.NormalizeHeading(p.OutboundTrack() - holdTeardropOffset*p.TurnSign())
+}
+
+// LegLengthNM returns the length of the straight legs at the ground speed (knots)
+func (p HoldPattern) LegLengthNM(speedKts float64) float64 {
+	if p.LegNM > 0 {
+		return p.LegNM
+	}
+	return speedKts * p.LegMinutes / 60.0
+}
+
+// TurnRadiusNM returns the radius of a rate one turn at the speed (knots)
+func TurnRadiusNM(speedKts float64) float64 {
+	// half a circle is flown in 180 / rate seconds
+	halfCircleNM := speedKts / 3600.0 * (180.0 / holdTurnRateDegSec)
+	return halfCircleNM / math.Pi
+}
+
+// Outline returns the racetrack as lat/lon points for a hold at the fix flown at the speed (knots), starting and
+// ending at the fix
+func (p HoldPattern) Outline(fixLat, fixLon, speedKts float64) [][2]float64 {
+	const arcSteps = 12
+
+	legNM := p.LegLengthNM(speedKts)
+	r := TurnRadiusNM(speedKts)
+
+	// unit vectors east/north along the inbound track and towards the holding side
+	rad := geometry.DegToRad(p.InboundTrack)
+	ue, un := math.Sin(rad), math.Cos(rad)
+	ve, vn := un*p.TurnSign(), -ue*p.TurnSign()
+
+	cosLat := math.Cos(geometry.DegToRad(fixLat))
+	toLatLon := func(e, n float64) [2]float64 {
+		return [2]float64{fixLat + n/60.0, fixLon + e/(60.0*cosLat)}
+	}
+
+	points := make([][2]float64, 0, 2*arcSteps+3)
+	// turn onto the outbound leg beyond the fix
+	for i := 0; i <= arcSteps; i++ {
+		phi := math.Pi * float64(i) / arcSteps
+		c, s := r*(1-math.Cos(phi)), r*math.Sin(phi)
+		points = append(points, toLatLon(c*ve+s*ue, c*vn+s*un))
+	}
+	// turn onto the inbound leg at the end of the outbound leg
+	for i := 0; i <= arcSteps; i++ {
+		phi := math.Pi * float64(i) / arcSteps
+		c, s := r*(1+math.Cos(phi)), -legNM-r*math.Sin(phi)
+		points = append(points, toLatLon(c*ve+s*ue, c*vn+s*un))
+	}
+	// inbound leg back to the fix
+	return append(points, toLatLon(0, 0))
+}
+
+// formatHoldPattern returns the holding instructions for the hold flown at the altitude (feet), e.g. "inbound
+// track 263, left turns, one minute legs", or "as published" if the pattern of the hold is not known
+func formatHoldPattern(h *Hold, altitudeFt float64) string {
+	if h == nil || !h.HasPattern() {
+		return "as published"
+	}
+
+	turns := "right turns"
+	if h.Turn == "L" {
+		turns = "left turns"
+	}
+
+	var legs string
+	if h.LegDist > 0 {
+		legs = fmt.Sprintf("%s mile legs", formatHoldNumber(h.LegDist))
+	} else {
+		minutes := h.LegTime
+		if minutes <= 0 {
+			minutes = DefaultHoldLegMinutes(altitudeFt)
+		}
+		legs = fmt.Sprintf("%s minute legs", formatHoldNumber(minutes))
+	}
+
+	course := int(math.Round(h.InboundCourse)) % 360
+	if course == 0 {
+		course = 360
+	}
+	return fmt.Sprintf("inbound track %03d, %s, %s", course, turns, legs)
+}
+
+// holdNumberWords holds the spoken leg times and distances that are said in words
+var holdNumberWords = map[float64]string{
+	1:   "one",
+	1.5: "one and a half",
+	2:   "two",
+	2.5: "two and a half",
+	3:   "three",
+}
+
+func formatHoldNumber(n float64) string {
+	if w, ok := holdNumberWords[n]; ok {
+		return w
+	}
+	return fmt.Sprintf("%g", n)
+}
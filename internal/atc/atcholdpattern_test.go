@@ -0,0 +1,146 @@
+package atc
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/curbz/decimal-niner/pkg/geometry"
+)
+
+func TestParseHoldDataPattern(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "earth_hold.dat")
+	writeResource(t, path, `I
+1140 Version
+LAM EG EGLL 3 263.0 1.0 0.0 L 7000 15000 220
+BNN EG ENRT 3 296.0 0.0 5.0 R 7000 0 0
+XXX EG ENRT 11 90.0 0.0 0.0 X 3000 0 0
+`)
+	holds, airportHolds, err := parseHoldData(path, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lam := holds["LAM_EG"]
+	if lam == nil || lam.InboundCourse != 263 || lam.LegTime != 1 || lam.LegDist != 0 || lam.Turn != "L" ||
+		lam.MinAlt != 7000 || lam.MaxAlt != 15000 || lam.SpeedKts != 220 {
+		t.Errorf("LAM = %+v", lam)
+	}
+	if len(airportHolds["EGLL"]) != 1 {
+		t.Errorf("EGLL holds = %v", airportHolds["EGLL"])
+	}
+	if bnn := holds["BNN_EG"]; bnn == nil || bnn.LegDist != 5 || bnn.Turn != "R" || !bnn.HasPattern() {
+		t.Errorf("BNN = %+v", bnn)
+	}
+	if xxx := holds["XXX_EG"]; xxx == nil || xxx.HasPattern() {
+		t.Errorf("XXX = %+v; want no pattern", xxx)
+	}
+}
+
+func TestHoldPatternEntry(t *testing.T) {
+	right := HoldPattern{InboundTrack: 360, LegMinutes: 1}
+	left := HoldPattern{InboundTrack: 360, LeftTurns: true, LegMinutes: 1}
+
+	tests := []struct {
+		pattern HoldPattern
+		heading float64
+		want    HoldEntry
+	}{
+		{right, 360, HoldEntryDirect},
+		{right, 270, HoldEntryParallel},
+		{right, 60, HoldEntryDirect},
+		{right, 90, HoldEntryDirect},
+		{right, 120, HoldEntryTeardrop},
+		{right, 150, HoldEntryTeardrop},
+		{right, 180, HoldEntryParallel},
+		{right, 210, HoldEntryParallel},
+		{right, 260, HoldEntryParallel},
+		{right, 109, HoldEntryDirect},
+		{right, 110, HoldEntryTeardrop},
+		{right, 179, HoldEntryTeardrop},
+		{right, 289, HoldEntryParallel},
+		{right, 290, HoldEntryDirect},
+		{left, 90, HoldEntryParallel},
+		{left, 240, HoldEntryTeardrop},
+		{left, 150, HoldEntryParallel},
+		{left, 250, HoldEntryTeardrop},
+		{left, 180, HoldEntryParallel},
+		{left, 70, HoldEntryDirect},
+		{left, 300, HoldEntryDirect},
+	}
+	for _, tt := range tests {
+		if got := tt.pattern.Entry(tt.heading); got != tt.want {
+			t.Errorf("Entry(left %v, heading %v) = %v; want %v", tt.pattern.LeftTurns, tt.heading, got, tt.want)
+		}
+	}
+
+	if got := right.TeardropTrack(); got != 150 {
+		t.Errorf("right TeardropTrack = %v; want 150", got)
+	}
+	if got := left.TeardropTrack(); got != 210 {
+		t.Errorf("left TeardropTrack = %v; want 210", got)
+	}
+}
+
+func TestHoldPattern(t *testing.T) {
+	h := &Hold{InboundCourse: 263, Turn: "L"}
+	p := h.Pattern(-2, 9000)
+	if p.InboundTrack != 261 || !p.LeftTurns || p.LegMinutes != 1 || p.LegNM != 0 {
+		t.Errorf("Pattern = %+v", p)
+	}
+	if p := h.Pattern(0, 20000); p.LegMinutes != 1.5 {
+		t.Errorf("LegMinutes above 14,000ft = %v; want 1.5", p.LegMinutes)
+	}
+	h.LegDist = 4
+	if p := h.Pattern(0, 20000); p.LegNM != 4 || p.LegMinutes != 0 || p.LegLengthNM(240) != 4 {
+		t.Errorf("distance Pattern = %+v", p)
+	}
+}
+
+func TestHoldPatternOutline(t *testing.T) {
+	const fixLat, fixLon, speed = 51.646, 0.152, 210.0
+	p := HoldPattern{InboundTrack: 263, LeftTurns: true, LegMinutes: 1}
+	points := p.Outline(fixLat, fixLon, speed)
+
+	first, last := points[0], points[len(points)-1]
+	if geometry.DistNM(first[0], first[1], fixLat, fixLon) > 0.01 || geometry.DistNM(last[0], last[1], fixLat, fixLon) > 0.01 {
+		t.Fatalf("outline does not start and end at the fix: %v .. %v", first, last)
+	}
+
+	// the far end of the racetrack is a leg plus a turn radius behind the fix, on the left of the inbound track
+	// i.e. clockwise from the outbound track of 083
+	legNM, r := p.LegLengthNM(speed), TurnRadiusNM(speed)
+	var farthest [2]float64
+	for _, pt := range points {
+		if geometry.DistNM(pt[0], pt[1], fixLat, fixLon) > geometry.DistNM(farthest[0], farthest[1], fixLat, fixLon) || farthest[0] == 0 {
+			farthest = pt
+		}
+	}
+	wantNM := math.Hypot(legNM+r, r)
+	if d := geometry.DistNM(farthest[0], farthest[1], fixLat, fixLon); math.Abs(d-wantNM) > 0.1 {
+		t.Errorf("farthest point %.2fnm from the fix; want %.2fnm", d, wantNM)
+	}
+	bearing := geometry.CalculateBearing(fixLat, fixLon, farthest[0], farthest[1])
+	if diff := geometry.NormalizeDiffDegrees(bearing, 83); diff < 0 || diff > 30 {
+		t.Errorf("farthest point bearing %.0f; want behind the fix on the holding side", bearing)
+	}
+}
+
+func TestFormatHoldPattern(t *testing.T) {
+	tests := []struct {
+		hold *Hold
+		alt  float64
+		want string
+	}{
+		{&Hold{InboundCourse: 263, LegTime: 1, Turn: "L"}, 9000, "inbound track 263, left turns, one minute legs"},
+		{&Hold{InboundCourse: 5, Turn: "R"}, 20000, "inbound track 005, right turns, one and a half minute legs"},
+		{&Hold{InboundCourse: 359.8, LegDist: 4, Turn: "R"}, 9000, "inbound track 360, right turns, 4 mile legs"},
+		{&Hold{InboundCourse: 90}, 9000, "as published"},
+		{nil, 9000, "as published"},
+	}
+	for _, tt := range tests {
+		if got := formatHoldPattern(tt.hold, tt.alt); got != tt.want {
+			t.Errorf("formatHoldPattern(%+v) = %q; want %q", tt.hold, got, tt.want)
+		}
+	}
+}
@@ -16,15 +16,20 @@ import (
 )
 
 type Hold struct {
-	Ident    string `json:"ident"`
-	Region   string
-	FullName string `json:"fullname"`
-	ICAO     string // airport ICAO or 'ENRT'
-	MinAlt   int
-	MaxAlt   int
-	Lat      float64 `json:"lat"`
-	Lon      float64 `json:"lon"`
-	X, Y, Z  float64
+	Ident         string `json:"ident"`
+	Region        string
+	FullName      string  `json:"fullname"`
+	ICAO          string  // airport ICAO or 'ENRT'
+	InboundCourse float64 `json:"inbound_course"` // magnetic inbound course to the fix
+	LegTime       float64 `json:"leg_time"`       // minutes, zero if the legs are defined by distance
+	LegDist       float64 `json:"leg_dist"`       // nautical miles, zero if the legs are timed
+	Turn          string  `json:"turn"`           // 'L' or 'R', empty if the pattern is not published
+	MinAlt        int
+	MaxAlt        int
+	SpeedKts      int     `json:"speed"` // maximum holding speed, zero if none published
+	Lat           float64 `json:"lat"`
+	Lon           float64 `json:"lon"`
+	X, Y, Z       float64
 }
 
 type Fix struct {
@@ -42,15 +47,15 @@ type ProcedureFix struct {
 	ConstraintType int // 0 = at, 1 = at or above, 2 = at or below
 }
 
-func loadHolds(navDataFile, holdsDataFile, fixesFile string) (map[string]*Hold, map[string][]*Hold, map[string]*Fix, error) {
+func loadHolds(navDataFile, holdsDataFile, fixesFile string, diag *ParseDiagnostics) (map[string]*Hold, map[string][]*Hold, map[string]*Fix, error) {
 
-	allFixes, err := parseFixData(fixesFile)
+	allFixes, err := parseFixData(fixesFile, diag)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 	logger.Log.Infof("%d fixes read from fix data", len(allFixes))
 
-	namedFixes, err := parseNavData(navDataFile)
+	namedFixes, err := parseNavData(navDataFile, diag)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -65,7 +70,7 @@ func loadHolds(navDataFile, holdsDataFile, fixesFile string) (map[string]*Hold,
 	allFixes = namedFixes
 	logger.Log.Infof("consolidated fix count: %d", len(allFixes))
 
-	allHolds, airportHolds, err := parseHoldData(holdsDataFile)
+	allHolds, airportHolds, err := parseHoldData(holdsDataFile, diag)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -92,16 +97,6 @@ func (h *Hold) InitUnitVector() {
 	h.X, h.Y, h.Z = toUnit(radLat, radLon)
 }
 
-func parseFloat(s string) float64 {
-	f, _ := strconv.ParseFloat(s, 64)
-	return f
-}
-
-func parseInt(s string) int {
-	i, _ := strconv.Atoi(s)
-	return i
-}
-
 // enrich holds with lat/lon from fixes, and precompute unit vectors for nearest-hold search
 func resolveHoldCoordinates(allHolds map[string]*Hold, allFixes map[string]*Fix) {
 
@@ -253,17 +248,7 @@ func (s *Service) AssignHold(ac *Aircraft, icao string, fallbackToGlobalHolds bo
 
 	if fallbackToGlobalHolds {
 		// GLOBAL FALLBACK: Find nearest regional en-route fix structure hold
-		var bestGlobalHold *Hold
-		bestDotGlobal := -2.0
-		for _, h := range s.Holds {
-			dot := ux*h.X + uy*h.Y + uz*h.Z
-			if dot > bestDotGlobal {
-				bestDotGlobal = dot
-				bestGlobalHold = h
-			}
-		}
-
-		holding.AssignedHold = bestGlobalHold
+		holding.AssignedHold = s.spatial().nearestHold(lat, lng)
 		holding.PatternEntryTime = s.GetCurrentZuluTime() // Set once here!
 		holding.ArrivedAtHoldFix = false
 		holding.ExitingHold = false
@@ -277,7 +262,7 @@ func (s *Service) AssignHold(ac *Aircraft, icao string, fallbackToGlobalHolds bo
 }
 
 // extract all holds from hold data file. returns two maps or an error
-func parseHoldData(path string) (map[string]*Hold, map[string][]*Hold, error) {
+func parseHoldData(path string, diag *ParseDiagnostics) (map[string]*Hold, map[string][]*Hold, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error opening file %s: %w", path, err)
@@ -288,24 +273,36 @@ func parseHoldData(path string) (map[string]*Hold, map[string][]*Hold, error) {
 	airportHolds := make(map[string][]*Hold)
 
 	scan := bufio.NewScanner(f)
+	lineNo := 0
 
 	for scan.Scan() {
+		lineNo++
 		line := strings.TrimSpace(scan.Text())
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "1140 Version") {
+		if line == "" || strings.HasPrefix(line, "#") || isDataFileHeader(line) {
 			continue
 		}
 
+		pos := recordPos{file: path, line: lineNo, record: "HOLD"}
 		fields := strings.Fields(line)
 		if len(fields) < 11 {
+			diag.skip(pos, "%d fields, want 11", len(fields))
 			continue
 		}
 
+		// ident, region, airport, fix type, inbound course, leg time, leg distance, turn, min alt, max alt, speed
 		h := &Hold{
-			Ident:  fields[0],
-			Region: fields[1],
-			ICAO:   fields[2], // airport ICAO or 'ENRT'
-			MinAlt: parseInt(fields[8]),
-			MaxAlt: parseInt(fields[9]),
+			Ident:         fields[0],
+			Region:        fields[1],
+			ICAO:          fields[2], // airport ICAO or 'ENRT'
+			InboundCourse: diag.floatField(pos, "inbound course", fields[4]),
+			LegTime:       diag.floatField(pos, "leg time", fields[5]),
+			LegDist:       diag.floatField(pos, "leg distance", fields[6]),
+			MinAlt:        diag.intField(pos, "minimum altitude", fields[8]),
+			MaxAlt:        diag.intField(pos, "maximum altitude", fields[9]),
+			SpeedKts:      diag.intField(pos, "speed", fields[10]),
+		}
+		if turn := strings.ToUpper(fields[7]); turn == "L" || turn == "R" {
+			h.Turn = turn
 		}
 
 		key := h.Ident + "_" + h.Region
@@ -324,7 +321,7 @@ func parseHoldData(path string) (map[string]*Hold, map[string][]*Hold, error) {
 	return allHolds, airportHolds, scan.Err()
 }
 
-func parseNavData(path string) (map[string]*Fix, error) {
+func parseNavData(path string, diag *ParseDiagnostics) (map[string]*Fix, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("error opening file %s: %w", path, err)
@@ -333,25 +330,33 @@ func parseNavData(path string) (map[string]*Fix, error) {
 
 	fixes := make(map[string]*Fix)
 	scan := bufio.NewScanner(f)
+	lineNo := 0
 
 	for scan.Scan() {
+		lineNo++
 		line := strings.TrimSpace(scan.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
+		// only NDBs, VORs and DMEs are named fixes
 		fields := strings.Fields(line)
-		if len(fields) < 10 {
-			continue
-		}
-
 		rowType := fields[0]
 		if rowType != "2" && rowType != "3" && rowType != "12" {
 			continue
 		}
 
-		lat := parseFloat(fields[1])
-		lon := parseFloat(fields[2])
+		pos := recordPos{file: path, line: lineNo, record: rowType}
+		if len(fields) < 10 {
+			diag.skip(pos, "%d fields, want at least 10", len(fields))
+			continue
+		}
+		lat, latErr := strconv.ParseFloat(fields[1], 64)
+		lon, lonErr := strconv.ParseFloat(fields[2], 64)
+		if latErr != nil || lonErr != nil {
+			diag.skip(pos, "invalid position %q %q", fields[1], fields[2])
+			continue
+		}
 		ident := fields[7]
 		region := fields[9]
 		fullName := strings.Join(fields[10:], " ")
@@ -396,7 +401,7 @@ func cleanFixName(name string) string {
 
 }
 
-func parseFixData(path string) (map[string]*Fix, error) {
+func parseFixData(path string, diag *ParseDiagnostics) (map[string]*Fix, error) {
 
 	fixes := make(map[string]*Fix)
 
@@ -407,18 +412,26 @@ func parseFixData(path string) (map[string]*Fix, error) {
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
+	lineNo := 0
 
 	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Fields(line)
-		if len(parts) < 4 {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || isDataFileHeader(line) {
 			continue
 		}
 
-		lat, _ := strconv.ParseFloat(parts[0], 64)
-		lon, _ := strconv.ParseFloat(parts[1], 64)
+		pos := recordPos{file: path, line: lineNo, record: "FIX"}
+		parts := strings.Fields(line)
+		if len(parts) < 5 {
+			diag.skip(pos, "%d fields, want at least 5", len(parts))
+			continue
+		}
 
-		if lat < -90 || lat > 90 {
+		lat, latErr := strconv.ParseFloat(parts[0], 64)
+		lon, lonErr := strconv.ParseFloat(parts[1], 64)
+		if latErr != nil || lonErr != nil || lat < -90 || lat > 90 {
+			diag.skip(pos, "invalid position %q %q", parts[0], parts[1])
 			continue
 		}
 
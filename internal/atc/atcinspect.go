@@ -0,0 +1,357 @@
+package atc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/curbz/decimal-niner/pkg/geometry"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+// inspectHoldSpeedKts is the speed the racetracks of the airport's holds are drawn for
+const inspectHoldSpeedKts = 210.0
+
+// AirportInspection is the parsed data of one airport, for checking the output of the apt.dat and CIFP parsers
+type AirportInspection struct {
+	Airport     *Airport
+	Controllers []*Controller     // controllers of the airport from apt.dat and atc.dat
+	Diagnostics []ParseDiagnostic // records of the data files skipped or defaulted while parsing
+}
+
+// InspectAirport parses the airport from the data files of the config. The navdata cache is not read or
+// written so that the parsers always run.
+func InspectAirport(cfgPath, icao string) (*AirportInspection, error) {
+	cfg, err := util.LoadConfig[config](cfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading configuration file: %v", err)
+	}
+
+	// the airport is loaded in full whatever the airport load radius
+	cfg.ATC.AirportLoadRadiusNM = 0
+	icao = strings.ToUpper(icao)
+	nd, err := parseNavdata(cfg, map[string]bool{icao: true})
+	if err != nil {
+		return nil, err
+	}
+	ap := nd.Airports[icao]
+	if ap == nil {
+		return nil, fmt.Errorf("airport %s not found in %s", icao, cfg.ATC.AirportsDataFile)
+	}
+
+	ai := &AirportInspection{Airport: ap, Diagnostics: nd.Diagnostics.Entries}
+	seen := make(map[*Controller]bool)
+	for _, c := range append(append([]*Controller{}, ap.Controllers...), nd.Controllers...) {
+		if c.ICAO == icao && !seen[c] {
+			seen[c] = true
+			ai.Controllers = append(ai.Controllers, c)
+		}
+	}
+	sort.SliceStable(ai.Controllers, func(i, j int) bool {
+		return ai.Controllers[i].RoleID < ai.Controllers[j].RoleID
+	})
+	return ai, nil
+}
+
+// Write prints the airport's runways, procedures, parking, hub weights, controllers, holds and parse diagnostics
+func (ai *AirportInspection) Write(w io.Writer) {
+	ap := ai.Airport
+	fmt.Fprintf(w, "%s %s\n", ap.ICAO, ap.Name)
+	fmt.Fprintf(w, "  position %.5f %.5f, elevation %.0fft, region %s, transition altitude %dft\n",
+		ap.Lat, ap.Lon, ap.Elevation, ap.Region, ap.TransAlt)
+	if ap.SceneryPack != "" {
+		fmt.Fprintf(w, "  scenery pack %s\n", ap.SceneryPack)
+	}
+
+	fmt.Fprintf(w, "\nrunways (%d):\n", len(ap.Runways))
+	for _, rwy := range sortedRunways(ap) {
+		fmt.Fprintf(w, "  %-4s threshold %.5f %.5f, end %.5f %.5f, heading %03.0f, %.0fm x %.0fm, elevation %.0fft\n",
+			rwy.Name, rwy.Lat, rwy.Lon, rwy.EndLat, rwy.EndLon, rwy.Heading, rwy.Length, rwy.Width, rwy.ThresholdElevation)
+		approach := rwy.HighestPrecisionApproach
+		if approach == "" {
+			approach = "none"
+		}
+		fmt.Fprintf(w, "       approach %s, CAT II/III lighting %v, FAF %dft at %.1fnm\n",
+			approach, rwy.LowVisLighting, rwy.FAFalt, rwy.FAFdistNM)
+		fmt.Fprintf(w, "       missed approach %dft, heading %03d, fix %s\n", rwy.MAalt, rwy.MAHeading, orNone(rwy.MAFix))
+		writeProcedures(w, "SID", rwy.SIDs)
+		writeProcedures(w, "STAR", rwy.STARs)
+		writeAccessPoints(w, "departure access", rwy.DepartureAccess)
+		writeAccessPoints(w, "arrival access", rwy.ArrivalAccess)
+	}
+
+	fmt.Fprintf(w, "\nparking (%d):\n", len(ap.Parking))
+	for _, p := range sortedParking(ap) {
+		fmt.Fprintf(w, "  %-12s %-9s class %-1s %-16s %.5f %.5f heading %03.0f, taxiway %s, airlines %s\n",
+			p.Name, p.Type, orNone(p.WidthClass), p.SizeType, p.Lat, p.Lon, p.Heading, orNone(p.TaxiwayName), orNone(p.AirlineCodes))
+	}
+
+	if len(ap.ClassCounts) > 0 {
+		classes := make([]string, 0, len(ap.ClassCounts))
+		for class := range ap.ClassCounts {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		fmt.Fprintf(w, "\nparking by width class:")
+		for _, class := range classes {
+			fmt.Fprintf(w, " %s %d", class, ap.ClassCounts[class])
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "\nhub weights (%d):\n", len(ap.HubWeights))
+	airlines := make([]string, 0, len(ap.HubWeights))
+	for code := range ap.HubWeights {
+		airlines = append(airlines, code)
+	}
+	sort.Slice(airlines, func(i, j int) bool {
+		wi, wj := ap.HubWeights[airlines[i]], ap.HubWeights[airlines[j]]
+		return wi > wj || (wi == wj && airlines[i] < airlines[j])
+	})
+	for _, code := range airlines {
+		fmt.Fprintf(w, "  %-4s %.3f\n", code, ap.HubWeights[code])
+	}
+
+	fmt.Fprintf(w, "\ncontrollers (%d):\n", len(ai.Controllers))
+	for _, c := range ai.Controllers {
+		freqs := make([]string, len(c.Freqs))
+		for i, f := range c.Freqs {
+			freqs[i] = fmt.Sprintf("%.3f", float64(f)/1000.0)
+		}
+		fmt.Fprintf(w, "  %-9s %-30s %s", roleNameMap[c.RoleID], c.Name, strings.Join(freqs, " "))
+		if c.IsPoint {
+			fmt.Fprintf(w, " at %.5f %.5f", c.Lat, c.Lon)
+		}
+		if len(c.Airspaces) > 0 {
+			fmt.Fprintf(w, ", %d airspaces", len(c.Airspaces))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "\nholds (%d):\n", len(ap.Holds))
+	for _, h := range sortedHolds(ap) {
+		fmt.Fprintf(w, "  %-6s %.5f %.5f, %s, %d-%dft\n", h.Ident, h.Lat, h.Lon, formatHoldPattern(h, float64(h.MinAlt)), h.MinAlt, h.MaxAlt)
+	}
+
+	fmt.Fprintf(w, "\nparse diagnostics (%d):\n", len(ai.Diagnostics))
+	for _, d := range ai.Diagnostics {
+		fmt.Fprintf(w, "  %s\n", d)
+	}
+}
+
+func writeProcedures(w io.Writer, kind string, procs []*Procedure) {
+	sorted := append([]*Procedure{}, procs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, p := range sorted {
+		fmt.Fprintf(w, "       %-4s %-8s entry %s, exit %s\n", kind, p.Name, formatProcedureFix(p.Entry), formatProcedureFix(p.Exit))
+	}
+}
+
+func formatProcedureFix(pf *ProcedureFix) string {
+	if pf == nil || pf.Fix == nil {
+		return "none"
+	}
+	s := pf.Fix.Ident
+	if pf.ConstraintAlt > 0 {
+		s += fmt.Sprintf(" %s %dft", [...]string{"at", "at or above", "at or below"}[pf.ConstraintType%3], pf.ConstraintAlt)
+	}
+	return s
+}
+
+func writeAccessPoints(w io.Writer, kind string, access map[string]*AccessPoint) {
+	for _, name := range sortedKeys(access) {
+		a := access[name]
+		var flags []string
+		if a.IsHighSpeed {
+			flags = append(flags, "high speed")
+		}
+		if a.IsNearEnd {
+			flags = append(flags, "near end")
+		}
+		fmt.Fprintf(w, "       %s %-6s taxiway %s, %.2fnm, bearing %03.0f", kind, name, orNone(a.TaxiwayName), a.Dist, a.Bearing)
+		if len(flags) > 0 {
+			fmt.Fprintf(w, " (%s)", strings.Join(flags, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedRunways(ap *Airport) []*Runway {
+	runways := make([]*Runway, 0, len(ap.Runways))
+	for _, name := range sortedKeys(ap.Runways) {
+		runways = append(runways, ap.Runways[name])
+	}
+	return runways
+}
+
+func sortedParking(ap *Airport) []*ParkingSpot {
+	parking := make([]*ParkingSpot, 0, len(ap.Parking))
+	for _, name := range sortedKeys(ap.Parking) {
+		parking = append(parking, ap.Parking[name])
+	}
+	return parking
+}
+
+func sortedHolds(ap *Airport) []*Hold {
+	holds := append([]*Hold{}, ap.Holds...)
+	sort.Slice(holds, func(i, j int) bool { return holds[i].Ident < holds[j].Ident })
+	return holds
+}
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// geoJSONPosition returns a GeoJSON position, which is longitude first
+func geoJSONPosition(lat, lon float64) [2]float64 {
+	return [2]float64{lon, lat}
+}
+
+func (c *geoJSONCollection) point(kind string, lat, lon float64, props map[string]any) {
+	c.add(kind, geoJSONGeometry{Type: "Point", Coordinates: geoJSONPosition(lat, lon)}, props)
+}
+
+func (c *geoJSONCollection) line(kind string, points [][2]float64, props map[string]any) {
+	coords := make([][2]float64, len(points))
+	for i, p := range points {
+		coords[i] = geoJSONPosition(p[0], p[1])
+	}
+	c.add(kind, geoJSONGeometry{Type: "LineString", Coordinates: coords}, props)
+}
+
+func (c *geoJSONCollection) add(kind string, g geoJSONGeometry, props map[string]any) {
+	if props == nil {
+		props = make(map[string]any)
+	}
+	props["kind"] = kind
+	c.Features = append(c.Features, geoJSONFeature{Type: "Feature", Geometry: g, Properties: props})
+}
+
+// WriteGeoJSON writes the airport as a GeoJSON feature collection. Every feature has a "kind" property: airport,
+// runway, faf, sid, star, departure_access, arrival_access, parking, controller, airspace or hold.
+func (ai *AirportInspection) WriteGeoJSON(w io.Writer) error {
+	ap := ai.Airport
+	fc := &geoJSONCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+
+	fc.point("airport", ap.Lat, ap.Lon, map[string]any{
+		"name": ap.ICAO + " " + ap.Name, "elevation": ap.Elevation, "hub_weights": ap.HubWeights,
+		"class_counts": ap.ClassCounts, "scenery_pack": ap.SceneryPack,
+	})
+
+	for _, rwy := range sortedRunways(ap) {
+		fc.line("runway", [][2]float64{{rwy.Lat, rwy.Lon}, {rwy.EndLat, rwy.EndLon}}, map[string]any{
+			"name": rwy.Name, "heading": rwy.Heading, "length": rwy.Length, "width": rwy.Width,
+			"approach": rwy.HighestPrecisionApproach, "faf_alt": rwy.FAFalt, "faf_dist_nm": rwy.FAFdistNM,
+			"ma_alt": rwy.MAalt, "ma_heading": rwy.MAHeading, "ma_fix": rwy.MAFix,
+		})
+		if rwy.FAFdistNM > 0 {
+			lat, lon := geometry.Project(rwy.Lat, rwy.Lon, math.Mod(rwy.Heading+180.0, 360.0), rwy.FAFdistNM)
+			fc.point("faf", lat, lon, map[string]any{"name": "FAF " + rwy.Name, "runway": rwy.Name, "alt": rwy.FAFalt})
+		}
+		for _, procs := range []struct {
+			kind  string
+			procs []*Procedure
+		}{{"sid", rwy.SIDs}, {"star", rwy.STARs}} {
+			for _, p := range procs.procs {
+				for _, pf := range []struct {
+					role string
+					fix  *ProcedureFix
+				}{{"entry", p.Entry}, {"exit", p.Exit}} {
+					if pf.fix == nil || pf.fix.Fix == nil {
+						continue
+					}
+					fc.point(procs.kind, pf.fix.Fix.Lat, pf.fix.Fix.Lon, map[string]any{
+						"name": pf.fix.Fix.Ident, "procedure": p.Name, "runway": rwy.Name, "fix": pf.role, "alt": pf.fix.ConstraintAlt,
+					})
+				}
+			}
+		}
+		for _, access := range []struct {
+			kind   string
+			points map[string]*AccessPoint
+		}{{"departure_access", rwy.DepartureAccess}, {"arrival_access", rwy.ArrivalAccess}} {
+			for _, name := range sortedKeys(access.points) {
+				a := access.points[name]
+				fc.point(access.kind, a.Coord.Lat, a.Coord.Lon, map[string]any{
+					"name": name, "runway": rwy.Name, "taxiway": a.TaxiwayName, "high_speed": a.IsHighSpeed, "near_end": a.IsNearEnd,
+				})
+			}
+		}
+	}
+
+	for _, p := range sortedParking(ap) {
+		fc.point("parking", p.Lat, p.Lon, map[string]any{
+			"name": p.Name, "type": p.Type, "width_class": p.WidthClass, "size_type": p.SizeType,
+			"airlines": p.AirlineCodes, "heading": p.Heading, "taxiway": p.TaxiwayName,
+		})
+	}
+
+	for _, c := range ai.Controllers {
+		props := func() map[string]any {
+			return map[string]any{"name": c.Name, "role": roleNameMap[c.RoleID], "freqs": c.Freqs}
+		}
+		if c.IsPoint {
+			fc.point("controller", c.Lat, c.Lon, props())
+		}
+		for _, a := range c.Airspaces {
+			ring := make([][2]float64, 0, len(a.Points)+1)
+			for _, p := range a.Points {
+				ring = append(ring, geoJSONPosition(p[0], p[1]))
+			}
+			if len(ring) > 0 {
+				ring = append(ring, ring[0])
+			}
+			airspace := props()
+			airspace["floor"], airspace["ceiling"] = a.Floor, a.Ceiling
+			fc.add("airspace", geoJSONGeometry{Type: "Polygon", Coordinates: [][][2]float64{ring}}, airspace)
+		}
+	}
+
+	for _, h := range sortedHolds(ap) {
+		props := map[string]any{"name": h.Ident, "pattern": formatHoldPattern(h, float64(h.MinAlt)), "min_alt": h.MinAlt, "max_alt": h.MaxAlt}
+		if h.Lat == 0 && h.Lon == 0 {
+			continue // not resolved to a fix
+		}
+		if h.HasPattern() {
+			// the magnetic variation is not known without the sim so the racetrack is drawn on the magnetic course
+			fc.line("hold", h.Pattern(0, float64(h.MinAlt)).Outline(h.Lat, h.Lon, inspectHoldSpeedKts), props)
+		} else {
+			fc.point("hold", h.Lat, h.Lon, props)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(fc)
+}
@@ -0,0 +1,104 @@
+package atc
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testAirportInspection() *AirportInspection {
+	nd := testNavdata()
+	ap := nd.Airports["EGLL"]
+	rwy := ap.Runways["27R"]
+	rwy.Lat, rwy.Lon, rwy.EndLat, rwy.EndLon = 51.4650, -0.4340, 51.4648, -0.4822
+	rwy.FAFalt, rwy.FAFdistNM = 2500, 7.5
+	rwy.DepartureAccess["A10"].Coord.Lat, rwy.DepartureAccess["A10"].Coord.Lon = 51.4649, -0.4350
+	ap.Parking["521"].Lat, ap.Parking["521"].Lon = 51.4700, -0.4600
+	ap.HubWeights = map[string]float64{"BAW": 0.6, "VIR": 0.1}
+	ap.Holds[0].InboundCourse, ap.Holds[0].Turn = 118, "R"
+	return &AirportInspection{Airport: ap, Controllers: ap.Controllers}
+}
+
+func TestAirportInspectionWrite(t *testing.T) {
+	var buf bytes.Buffer
+	testAirportInspection().Write(&buf)
+	out := buf.String()
+
+	for _, want := range []string{
+		"EGLL london heathrow",
+		"runways (1):",
+		"27R ",
+		"SID  CPT3G",
+		"STAR BNN1B",
+		"A10",
+		"taxiway Alpha",
+		"521",
+		"class E",
+		"BAW  0.600",
+		"Heathrow",
+		"BNN",
+		"inbound track 118, right turns",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "BAW") > strings.Index(out, "VIR") {
+		t.Error("hub weights are not sorted by weight")
+	}
+}
+
+func TestAirportInspectionWriteGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testAirportInspection().WriteGeoJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var fc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if fc.Type != "FeatureCollection" {
+		t.Errorf("type = %q", fc.Type)
+	}
+
+	kinds := make(map[string]int)
+	for _, f := range fc.Features {
+		kind, _ := f.Properties["kind"].(string)
+		kinds[kind]++
+
+		switch kind {
+		case "airport", "parking":
+			var pos [2]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &pos); err != nil {
+				t.Fatalf("%s coordinates: %v", kind, err)
+			}
+			if pos[0] > 0 || pos[1] < 51 {
+				t.Errorf("%s position %v is not longitude first", kind, pos)
+			}
+		case "runway", "hold":
+			if f.Geometry.Type != "LineString" {
+				t.Errorf("%s geometry = %s; want LineString", kind, f.Geometry.Type)
+			}
+		case "airspace":
+			if f.Geometry.Type != "Polygon" {
+				t.Errorf("airspace geometry = %s; want Polygon", f.Geometry.Type)
+			}
+		}
+	}
+	for _, kind := range []string{"airport", "runway", "faf", "sid", "star", "departure_access", "parking", "controller", "hold"} {
+		if kinds[kind] == 0 {
+			t.Errorf("no %s feature in %v", kind, kinds)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package atc
+
+import (
+	"strings"
+)
+
+// getExchangeLanguage returns the language in which the aircraft's controller speaks to it, or empty for
+// English. Ground and tower controllers use the local language with airlines of their own country when native
+// language transmissions are enabled and both phrases and a voice exist for the language.
+func (s *Service) getExchangeLanguage(ac *Aircraft) string {
+	if s.Config == nil || !s.Config.ATC.Voices.NativeLanguage || s.VoiceManager == nil {
+		return ""
+	}
+	controller := ac.Flight.Comms.Controller
+	if controller == nil || controller.RoleID < 1 || controller.RoleID > 3 {
+		// only delivery, ground and tower
+		return ""
+	}
+
+	controllerISO, err := convertIcaoToIso(controller.ICAO)
+	if err != nil {
+		return ""
+	}
+	airlineISO, err := convertIcaoToIso(ac.Flight.Comms.CountryCode)
+	if err != nil || airlineISO != controllerISO {
+		return ""
+	}
+
+	lang, ok := countryLanguages[controllerISO]
+	if !ok || len(s.VoiceManager.phrases().languages[lang]) == 0 || !s.VoiceManager.hasLanguageVoice(lang) {
+		return ""
+	}
+	return lang
+}
+
+// getLanguageExchanges returns the native language exchanges for the phase key, or nil if the language does
+// not define the phase key
+func (pc *PhraseClasses) getLanguageExchanges(phraseKey, language string) []Exchange {
+	if language == "" {
+		return nil
+	}
+	return pc.languages[language][phraseKey]
+}
+
+// hasLanguageVoice returns true if there is at least one voice for the language
+func (vm *VoiceManager) hasLanguageVoice(language string) bool {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+	return len(vm.languageVoicePools[language]) > 0
+}
+
+// translateRunwayLanguage converts the left, right and centre designators of the runway to words in the
+// language, using the English words if the language has none
+func translateRunwayLanguage(runway, language string) string {
+	suffixes, ok := localeRunwaySuffixes[language]
+	if !ok {
+		return translateRunway(runway)
+	}
+	for i, designator := range []string{"L", "R", "C"} {
+		if strings.HasSuffix(runway, designator) {
+			return strings.TrimSuffix(runway, designator) + " " + suffixes[i]
+		}
+	}
+	return runway
+}
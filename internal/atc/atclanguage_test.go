@@ -0,0 +1,104 @@
+package atc
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGetExchangeLanguage(t *testing.T) {
+	cfg := &config{}
+	cfg.ATC.Voices.NativeLanguage = true
+	s := &Service{
+		Config: cfg,
+		VoiceManager: &VoiceManager{
+			PhraseClasses: PhraseClasses{languages: map[string]map[string][]Exchange{
+				"fr": {"takeoff": {{ID: "fr_takeoff"}}},
+				"de": {"takeoff": {{ID: "de_takeoff"}}},
+			}},
+			languageVoicePools: map[string][]string{"fr": {"fr_FR-siwis-medium#0"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		icao        string
+		roleID      int
+		countryCode string
+		want        string
+	}{
+		{"domestic airline at french tower", "LFPG", 3, "LF", "fr"},
+		{"domestic airline at french ground", "LFPG", 2, "LF", "fr"},
+		{"foreign airline at french tower", "LFPG", 3, "EG", ""},
+		{"domestic airline at french approach", "LFPG", 4, "LF", ""},
+		{"no voice for language", "EDDF", 3, "ED", ""},
+		{"no language for country", "EGLL", 3, "EG", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ac := &Aircraft{Flight: Flight{Comms: Comms{
+				Controller:  &Controller{ICAO: tt.icao, RoleID: tt.roleID},
+				CountryCode: tt.countryCode,
+			}}}
+			if got := s.getExchangeLanguage(ac); got != tt.want {
+				t.Errorf("getExchangeLanguage() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+
+	cfg.ATC.Voices.NativeLanguage = false
+	ac := &Aircraft{Flight: Flight{Comms: Comms{Controller: &Controller{ICAO: "LFPG", RoleID: 3}, CountryCode: "LF"}}}
+	if got := s.getExchangeLanguage(ac); got != "" {
+		t.Errorf("expected English when native language is disabled, got %q", got)
+	}
+}
+
+func TestTranslateNumericsLanguage(t *testing.T) {
+	tests := []struct{ in, language, want string }{
+		{"27", "fr", " deux sept "},
+		{"AF123", "fr", "AF un deux trois "},
+		{"09", "de", " null neun "},
+		{"123", "xx", " one two three "},
+	}
+	for _, tt := range tests {
+		if got := translateNumerics(tt.in, tt.language); got != tt.want {
+			t.Errorf("translateNumerics(%q, %s) = %q; want %q", tt.in, tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateRunwayLanguage(t *testing.T) {
+	tests := []struct{ in, language, want string }{
+		{"27L", "fr", "27 gauche"},
+		{"09C", "de", "09 mitte"},
+		{"26", "fr", "26"},
+		{"27R", "", "27right"},
+	}
+	for _, tt := range tests {
+		if got := translateRunwayLanguage(tt.in, tt.language); got != tt.want {
+			t.Errorf("translateRunwayLanguage(%q, %s) = %q; want %q", tt.in, tt.language, got, tt.want)
+		}
+	}
+}
+
+func TestLanguagePhraseFiles(t *testing.T) {
+	base := loadPhraseFileForTest(t, "resources/phrases.json")
+	for _, lang := range countryLanguages {
+		path := "resources/phrases_" + lang + ".json"
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		for key, exchanges := range loadPhraseFileForTest(t, path) {
+			if _, ok := base[key]; !ok {
+				t.Errorf("%s: phase key %s is not a base phase key", path, key)
+			}
+			for i, ex := range exchanges {
+				if _, err := compilePhrase(ex.Pilot); err != nil {
+					t.Errorf("%s [%s] exchange %d (Pilot): %v", path, key, i+1, err)
+				}
+				if _, err := compilePhrase(ex.ATC); err != nil {
+					t.Errorf("%s [%s] exchange %d (ATC): %v", path, key, i+1, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,455 @@
+package atc
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/curbz/decimal-niner/internal/constants"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+// METAR is an observation loaded from a METAR file which overrides the sim weather at its airport
+type METAR struct {
+	ICAO     string
+	Observed time.Time // from the NOAA cycle file date line, zero if not known
+	Raw      string
+	Weather  *Weather
+}
+
+var (
+	metarWindRegex     = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	metarVisSMRegex    = regexp.MustCompile(`^[PM]?(\d+)?(?:/(\d+))?SM$`)
+	metarWxRegex       = regexp.MustCompile(`^(\+|-|VC)?(MI|BC|PR|DR|BL|SH|TS|FZ)?((?:DZ|RA|SN|SG|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)+)$`)
+	metarCloudRegex    = regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV)(\d{3})(?:CB|TCU|///)?$`)
+	metarTempRegex     = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+	metarCycleDateLine = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}$`)
+)
+
+// cloud cover fraction reported for each METAR cloud amount
+var metarCloudCover = map[string]float64{"FEW": 0.25, "SCT": 0.5, "BKN": 0.75, "OVC": 1.0, "VV": 1.0}
+
+// precipitation intensity reported for each METAR intensity qualifier
+var metarPrecipIntensity = map[string]float64{"-": 0.25, "": 0.5, "+": 0.9}
+
+// LoadMETARFile reads METARs from a text file in the NOAA cycle format, where each report is preceded by a
+// "YYYY/MM/DD hh:mm" line, or with one report per line. The latest report for each airport is returned,
+// keyed by ICAO code.
+func LoadMETARFile(path string) (map[string]*METAR, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	metars := make(map[string]*METAR)
+	var observed time.Time
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if metarCycleDateLine.MatchString(line) {
+			observed, _ = time.Parse("2006/01/02 15:04", line)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if fields[0] == "METAR" || fields[0] == "SPECI" {
+			fields = fields[1:]
+		}
+		if len(fields) < 2 || len(fields[0]) != 4 {
+			continue
+		}
+		icao := fields[0]
+		if existing, ok := metars[icao]; ok && observed.Before(existing.Observed) {
+			continue
+		}
+		metars[icao] = &METAR{ICAO: icao, Observed: observed, Raw: strings.Join(fields, " ")}
+		observed = time.Time{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return metars, nil
+}
+
+// loadMETARs loads the METAR file and decodes the reports for the known airports. No overrides are returned
+// if path is empty.
+func loadMETARs(path string, airports map[string]*Airport) (map[string]*METAR, error) {
+	if path == "" {
+		return nil, nil
+	}
+	metars, err := LoadMETARFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]*METAR)
+	for icao, m := range metars {
+		ap, ok := airports[icao]
+		if !ok {
+			continue
+		}
+		w, err := ParseMETAR(m.Raw, ap.Elevation)
+		if err != nil {
+			util.LogWarnWithLabel(icao, "ignoring METAR %q: %v", m.Raw, err)
+			continue
+		}
+		m.Weather = w
+		overrides[icao] = m
+	}
+	return overrides, nil
+}
+
+// ParseMETAR decodes the surface weather from a METAR report for an airport of the given elevation (feet).
+// Remarks and trend forecasts are ignored.
+func ParseMETAR(raw string, elevationFt float64) (*Weather, error) {
+	fields := strings.Fields(raw)
+	if len(fields) > 0 && (fields[0] == "METAR" || fields[0] == "SPECI") {
+		fields = fields[1:]
+	}
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("report is too short")
+	}
+
+	w := &Weather{Wind: &Wind{}, Baro: &Baro{Sealevel: 101325, Flight: 101325}}
+	decodedWind := false
+	ceiling := 0.0
+
+	// skip the station and observation time
+	for i := 2; i < len(fields); i++ {
+		tok := fields[i]
+		switch tok {
+		case "RMK", "TEMPO", "BECMG", "NOSIG":
+			i = len(fields)
+			continue
+		case "CAVOK":
+			w.Vis = 10000
+			continue
+		case "AUTO", "COR", "NSC", "SKC", "CLR", "NCD", "NSW":
+			continue
+		}
+
+		if m := metarWindRegex.FindStringSubmatch(tok); m != nil {
+			toMps := 1 / constants.MpsToKnots
+			switch m[4] {
+			case "MPS":
+				toMps = 1
+			case "KMH":
+				toMps = 1 / 3.6
+			}
+			speed, _ := strconv.ParseFloat(m[2], 64)
+			if m[1] != "VRB" {
+				w.Wind.Direction, _ = strconv.ParseFloat(m[1], 64)
+			}
+			w.Wind.Speed = speed * toMps
+			if m[3] != "" {
+				// inverse of the gust heuristic used when reporting the wind
+				gust, _ := strconv.ParseFloat(m[3], 64)
+				w.Turbulence = (gust - speed) * toMps * constants.MpsToKnots / 25.0
+			}
+			decodedWind = true
+			continue
+		}
+
+		if len(tok) == 4 && isDigits(tok) && decodedWind && w.Vis == 0 {
+			vis, _ := strconv.ParseFloat(tok, 64)
+			if vis == 9999 {
+				vis = 10000
+			}
+			w.Vis = vis
+			continue
+		}
+
+		if m := metarVisSMRegex.FindStringSubmatch(tok); m != nil {
+			sm := 0.0
+			if m[2] != "" {
+				// fraction, with an optional whole number in the previous field
+				num, _ := strconv.ParseFloat(m[1], 64)
+				den, _ := strconv.ParseFloat(m[2], 64)
+				if den > 0 {
+					sm = num / den
+				}
+				if prev := fields[i-1]; isDigits(prev) && len(prev) == 1 {
+					whole, _ := strconv.ParseFloat(prev, 64)
+					sm += whole
+				}
+			} else {
+				sm, _ = strconv.ParseFloat(m[1], 64)
+			}
+			w.Vis = sm * constants.MetersPerStatuteMile
+			continue
+		}
+
+		if m := metarWxRegex.FindStringSubmatch(tok); m != nil {
+			if intensity, ok := metarPrecipIntensity[m[1]]; ok && isPrecipitation(m[3]) && intensity > w.Precip {
+				w.Precip = intensity
+			}
+			continue
+		}
+
+		if m := metarCloudRegex.FindStringSubmatch(tok); m != nil {
+			hundreds, _ := strconv.ParseFloat(m[2], 64)
+			layer := CloudLayer{BaseFt: elevationFt + hundreds*100, Coverage: metarCloudCover[m[1]]}
+			w.Clouds = append(w.Clouds, layer)
+			if layer.Coverage >= constants.CeilingMinCoverage && (ceiling == 0 || layer.BaseFt < ceiling) {
+				ceiling = layer.BaseFt
+			}
+			continue
+		}
+
+		if m := metarTempRegex.FindStringSubmatch(tok); m != nil {
+			w.Temp = parseMETARTemp(m[1])
+			w.Dewpoint = w.Temp
+			if m[2] != "" {
+				w.Dewpoint = parseMETARTemp(m[2])
+			}
+			w.Humidity = RelativeHumidity(w.Temp, w.Dewpoint)
+			continue
+		}
+
+		if len(tok) == 5 && (tok[0] == 'Q' || tok[0] == 'A') && isDigits(tok[1:]) {
+			v, _ := strconv.ParseFloat(tok[1:], 64)
+			if tok[0] == 'Q' {
+				w.Baro.Sealevel = v * 100
+			} else {
+				w.Baro.Sealevel = v / 100 * constants.PascalsPerInHg
+			}
+			w.Baro.Flight = w.Baro.Sealevel
+			continue
+		}
+	}
+
+	if !decodedWind {
+		return nil, fmt.Errorf("no wind group found")
+	}
+	w.Ceiling = ceiling
+	return w, nil
+}
+
+// FormatMETAR formats the weather as a METAR report for an airport of the given elevation (feet)
+func FormatMETAR(icao string, t time.Time, w *Weather, elevationFt float64) string {
+	parts := []string{icao, t.UTC().Format("021504Z")}
+	parts = append(parts, formatMETARConditions(w, elevationFt)...)
+	parts = append(parts, formatMETARTemp(w.Temp)+"/"+formatMETARTemp(w.Dewpoint))
+
+	if isNorthAmerica(icao) {
+		parts = append(parts, fmt.Sprintf("A%04d", int(math.Round(w.Baro.Sealevel/constants.PascalsPerInHg*100))))
+	} else {
+		// QNH is truncated to the whole hectopascal, as in the controller's report
+		parts = append(parts, fmt.Sprintf("Q%04d", int(w.Baro.Sealevel/100)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// FormatTAF formats a persistence forecast from the current weather, valid for 24 hours from the next hour
+func FormatTAF(icao string, t time.Time, w *Weather, elevationFt float64) string {
+	t = t.UTC()
+	from := t.Truncate(time.Hour).Add(time.Hour)
+	to := from.Add(24 * time.Hour)
+	validity := fmt.Sprintf("%02d%02d/%02d%02d", from.Day(), from.Hour(), to.Day(), to.Hour())
+	if to.Hour() == 0 {
+		// validity ending at midnight is reported as hour 24 of the previous day
+		prev := to.Add(-time.Hour)
+		validity = fmt.Sprintf("%02d%02d/%02d24", from.Day(), from.Hour(), prev.Day())
+	}
+
+	parts := []string{"TAF", icao, t.Format("021504Z"), validity}
+	parts = append(parts, formatMETARConditions(w, elevationFt)...)
+	return strings.Join(parts, " ")
+}
+
+// formatMETARConditions returns the wind, visibility, present weather and cloud groups
+func formatMETARConditions(w *Weather, elevationFt float64) []string {
+	parts := []string{formatMETARWind(w)}
+
+	wx := formatMETARPresentWeather(w)
+
+	var clouds []string
+	lowCloud := false
+	layers := append([]CloudLayer(nil), w.Clouds...)
+	sort.Slice(layers, func(i, j int) bool { return layers[i].BaseFt < layers[j].BaseFt })
+	for _, c := range layers {
+		amount := metarCloudAmount(c.Coverage)
+		if amount == "" {
+			continue
+		}
+		aglFt := math.Max(0, c.BaseFt-elevationFt)
+		if aglFt < 5000 {
+			lowCloud = true
+		}
+		clouds = append(clouds, fmt.Sprintf("%s%03d", amount, int(math.Min(999, aglFt/100))))
+	}
+
+	if (w.Vis == 0 || w.Vis >= 10000) && wx == "" && !lowCloud {
+		return append(parts, "CAVOK")
+	}
+
+	parts = append(parts, formatMETARVisibility(w.Vis))
+	if wx != "" {
+		parts = append(parts, wx)
+	}
+	if len(clouds) == 0 {
+		clouds = []string{"NSC"}
+	}
+	return append(parts, clouds...)
+}
+
+func formatMETARWind(w *Weather) string {
+	if w.Wind == nil {
+		return "00000KT"
+	}
+	speedKt := int(math.Round(w.Wind.Speed * constants.MpsToKnots))
+	if speedKt < 1 {
+		return "00000KT"
+	}
+	dir := int(math.Round(w.Wind.Direction/10)) * 10 % 360
+	if dir == 0 {
+		dir = 360
+	}
+	wind := fmt.Sprintf("%03d%02d", dir, speedKt)
+	if w.Turbulence > 0.2 {
+		// same gust heuristic as the controller's wind report
+		if gustKt := speedKt + int(math.Round(w.Turbulence*25.0)); gustKt >= speedKt+10 {
+			wind += fmt.Sprintf("G%02d", gustKt)
+		}
+	}
+	return wind + "KT"
+}
+
+// formatMETARVisibility rounds down to the reporting increments for the visibility range
+func formatMETARVisibility(visM float64) string {
+	switch {
+	case visM <= 0 || visM >= 10000:
+		return "9999"
+	case visM < 800:
+		return fmt.Sprintf("%04d", int(visM/50)*50)
+	case visM < 5000:
+		return fmt.Sprintf("%04d", int(visM/100)*100)
+	default:
+		return fmt.Sprintf("%04d", int(visM/1000)*1000)
+	}
+}
+
+func formatMETARPresentWeather(w *Weather) string {
+	if w.Precip >= 0.05 {
+		intensity := ""
+		switch {
+		case w.Precip < 0.33:
+			intensity = "-"
+		case w.Precip >= 0.66:
+			intensity = "+"
+		}
+		if w.Temp <= 0 {
+			return intensity + "SN"
+		}
+		return intensity + "RA"
+	}
+	switch {
+	case w.Vis > 0 && w.Vis < 1000:
+		return "FG"
+	case w.Vis > 0 && w.Vis < 5000:
+		return "BR"
+	}
+	return ""
+}
+
+// metarCloudAmount returns the METAR cloud amount for the cover fraction, or an empty string for clear sky
+func metarCloudAmount(coverage float64) string {
+	switch {
+	case coverage < 0.125:
+		return ""
+	case coverage <= 0.25:
+		return "FEW"
+	case coverage <= 0.5:
+		return "SCT"
+	case coverage < 1.0:
+		return "BKN"
+	default:
+		return "OVC"
+	}
+}
+
+func formatMETARTemp(c float64) string {
+	t := int(math.Round(c))
+	if t < 0 {
+		return fmt.Sprintf("M%02d", -t)
+	}
+	return fmt.Sprintf("%02d", t)
+}
+
+func parseMETARTemp(s string) float64 {
+	neg := strings.HasPrefix(s, "M")
+	v, _ := strconv.ParseFloat(strings.TrimPrefix(s, "M"), 64)
+	if neg {
+		return -v
+	}
+	return v
+}
+
+func isPrecipitation(phenomena string) bool {
+	for _, p := range []string{"DZ", "RA", "SN", "SG", "PL", "GR", "GS", "UP"} {
+		if strings.Contains(phenomena, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// GetAirportWeather returns the weather at the airport, which is the loaded METAR for the airport if there is
+// one, otherwise the sim region weather
+func (s *Service) GetAirportWeather(icao string) *Weather {
+	m, ok := s.METARs[icao]
+	if !ok || m.Weather == nil {
+		return s.Weather
+	}
+	w := *m.Weather
+	if s.Weather != nil {
+		w.MagVar = s.Weather.MagVar
+	}
+	return &w
+}
+
+// GetMETAR returns the METAR for the airport: the loaded report if there is one, otherwise a report generated
+// from the current sim weather. An empty string is returned for an unknown airport.
+func (s *Service) GetMETAR(icao string) string {
+	if m, ok := s.METARs[icao]; ok {
+		return m.Raw
+	}
+	ap := s.GetAirportByICAO(icao)
+	if ap == nil || s.Weather == nil {
+		return ""
+	}
+	return FormatMETAR(icao, s.GetCurrentZuluTime(), s.Weather, ap.Elevation)
+}
+
+// GetTAF returns a forecast for the airport generated from its current weather, or an empty string for an
+// unknown airport
+func (s *Service) GetTAF(icao string) string {
+	ap := s.GetAirportByICAO(icao)
+	w := s.GetAirportWeather(icao)
+	if ap == nil || w == nil {
+		return ""
+	}
+	return FormatTAF(icao, s.GetCurrentZuluTime(), w, ap.Elevation)
+}
@@ -0,0 +1,129 @@
+package atc
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/curbz/decimal-niner/internal/constants"
+)
+
+func TestParseMETAR(t *testing.T) {
+	w, err := ParseMETAR("METAR EGLL 181250Z 24015G28KT 9999 -RA FEW012 BKN025 OVC040 12/M02 Q0998 NOSIG", 83)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Wind.Direction != 240 || math.Abs(w.Wind.Speed*constants.MpsToKnots-15) > 0.01 {
+		t.Errorf("wind = %.0f/%.1fkt; want 240/15kt", w.Wind.Direction, w.Wind.Speed*constants.MpsToKnots)
+	}
+	if math.Abs(w.Turbulence-13.0/25.0) > 0.01 {
+		t.Errorf("turbulence = %.2f; want gust derived %.2f", w.Turbulence, 13.0/25.0)
+	}
+	if w.Vis != 10000 {
+		t.Errorf("vis = %.0f; want 10000", w.Vis)
+	}
+	if w.Precip != 0.25 {
+		t.Errorf("precip = %.2f; want 0.25 for light rain", w.Precip)
+	}
+	if len(w.Clouds) != 3 || w.Ceiling != 83+2500 {
+		t.Errorf("clouds = %d, ceiling = %.0f; want 3 layers and ceiling %d MSL", len(w.Clouds), w.Ceiling, 83+2500)
+	}
+	if w.Temp != 12 || w.Dewpoint != -2 {
+		t.Errorf("temp/dew = %.0f/%.0f; want 12/-2", w.Temp, w.Dewpoint)
+	}
+	if w.Baro.Sealevel != 99800 {
+		t.Errorf("baro = %.0f; want 99800", w.Baro.Sealevel)
+	}
+
+	w, err = ParseMETAR("KSFO 181256Z 28008KT 1 1/2SM BR OVC004 14/13 A2992", 13)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(w.Vis-1.5*constants.MetersPerStatuteMile) > 1 {
+		t.Errorf("vis = %.0f; want 1.5 statute miles", w.Vis)
+	}
+	if w.Ceiling != 13+400 {
+		t.Errorf("ceiling = %.0f; want %d", w.Ceiling, 13+400)
+	}
+	if math.Abs(w.Baro.Sealevel-101321) > 5 {
+		t.Errorf("baro = %.0f; want about 101321", w.Baro.Sealevel)
+	}
+
+	w, err = ParseMETAR("UUEE 181300Z 05005MPS CAVOK M05/M09 Q1031", 630)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if math.Abs(w.Wind.Speed-5) > 0.01 || w.Vis != 10000 || w.Ceiling != 0 || w.Temp != -5 {
+		t.Errorf("unexpected decode of metric CAVOK report: %+v", w)
+	}
+
+	if _, err := ParseMETAR("EGLL 181250Z", 83); err == nil {
+		t.Errorf("expected error for report without wind")
+	}
+}
+
+func TestFormatMETAR(t *testing.T) {
+	obs := time.Date(2026, 10, 18, 12, 50, 0, 0, time.UTC)
+
+	raw := "EGLL 181250Z 24015G28KT 3000 -RA BKN008 OVC020 12/10 Q0998"
+	w, err := ParseMETAR(raw, 83)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := FormatMETAR("EGLL", obs, w, 83); got != raw {
+		t.Errorf("FormatMETAR() = %q; want %q", got, raw)
+	}
+
+	clear := &Weather{Wind: &Wind{Direction: 90, Speed: 2}, Baro: &Baro{Sealevel: 101325}, Temp: 20, Dewpoint: 5}
+	if got, want := FormatMETAR("KJFK", obs, clear, 13), "KJFK 181250Z 09004KT CAVOK 20/05 A2992"; got != want {
+		t.Errorf("FormatMETAR() = %q; want %q", got, want)
+	}
+
+	if got, want := FormatTAF("EGLL", obs, w, 83), "TAF EGLL 181250Z 1813/1913 24015G28KT 3000 -RA BKN008 OVC020"; got != want {
+		t.Errorf("FormatTAF() = %q; want %q", got, want)
+	}
+}
+
+func TestLoadMETARFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metars.txt")
+	data := "2026/10/18 12:50\nEGLL 181250Z 24015KT 9999 FEW030 12/05 Q1012\n\n" +
+		"2026/10/18 11:50\nEGLL 181150Z 23012KT 9999 FEW030 11/05 Q1012\n\n" +
+		"2026/10/18 12:56\nKSFO 181256Z 28008KT 10SM CLR 14/08 A2992\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	metars, err := LoadMETARFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metars) != 2 {
+		t.Fatalf("loaded %d METARs; want 2", len(metars))
+	}
+	if got := metars["EGLL"].Raw; got != "EGLL 181250Z 24015KT 9999 FEW030 12/05 Q1012" {
+		t.Errorf("expected latest EGLL report to be kept, got %q", got)
+	}
+
+	s := &Service{
+		Weather:  &Weather{Wind: &Wind{}, Baro: &Baro{Sealevel: 101325}, MagVar: 2},
+		Airports: map[string]*Airport{"EGLL": {ICAO: "EGLL", Elevation: 83}},
+	}
+	s.METARs, err = loadMETARs(path, s.Airports)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := s.METARs["KSFO"]; ok {
+		t.Errorf("expected METAR for unknown airport to be ignored")
+	}
+	if w := s.GetAirportWeather("EGLL"); w.Wind.Direction != 240 || w.MagVar != 2 {
+		t.Errorf("expected EGLL weather from METAR with sim magnetic variation, got %+v", w)
+	}
+	if w := s.GetAirportWeather("EGKK"); w != s.Weather {
+		t.Errorf("expected sim weather for airport without METAR")
+	}
+	if got := s.GetMETAR("EGLL"); got != metars["EGLL"].Raw {
+		t.Errorf("GetMETAR() = %q; want loaded report", got)
+	}
+}
@@ -0,0 +1,103 @@
+package atc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/curbz/decimal-niner/internal/constants"
+	"github.com/curbz/decimal-niner/internal/flightphase"
+)
+
+// altitude units of a transition rule
+const (
+	AltitudeUnitsFeet   = "feet"
+	AltitudeUnitsMetric = "metric"
+)
+
+// metricFlightLevel is an entry of the metric flight level allocation table: the level in metres and the
+// flight level in feet flown by aircraft with altimeters in feet
+type metricFlightLevel struct {
+	Metres    int
+	Feet      int
+	Eastbound bool // allocated to tracks 000-179, otherwise 180-359
+}
+
+// metricFlightLevels is the metric RVSM level allocation table, in ascending order
+var metricFlightLevels = []metricFlightLevel{
+	{600, 2000, true}, {900, 3000, false}, {1200, 3900, true}, {1500, 4900, false},
+	{1800, 5900, true}, {2100, 6900, false}, {2400, 7900, true}, {2700, 8900, false},
+	{3000, 9800, true}, {3300, 10800, false}, {3600, 11800, true}, {3900, 12800, false},
+	{4200, 13800, true}, {4500, 14800, false}, {4800, 15700, true}, {5100, 16700, false},
+	{5400, 17700, true}, {5700, 18700, false}, {6000, 19700, true}, {6300, 20700, false},
+	{6600, 21700, true}, {6900, 22600, false}, {7200, 23600, true}, {7500, 24600, false},
+	{7800, 25600, true}, {8100, 26600, false}, {8400, 27600, true}, {8900, 29100, true},
+	{9200, 30100, false}, {9500, 31100, true}, {9800, 32100, false}, {10100, 33100, true},
+	{10400, 34100, false}, {10700, 35100, true}, {11000, 36100, false}, {11300, 37100, true},
+	{11600, 38100, false}, {11900, 39100, true}, {12200, 40100, false}, {12500, 41100, true},
+	{13100, 43000, false}, {13700, 44900, true}, {14300, 46900, false}, {14900, 48900, true},
+}
+
+// nearestMetricFlightLevel returns the level of the allocation table closest to the altitude (feet). Only
+// levels for the direction of flight are considered unless anyDirection is true.
+func nearestMetricFlightLevel(altFt float64, eastbound, anyDirection bool) metricFlightLevel {
+	best := metricFlightLevels[0]
+	bestDiff := math.Inf(1)
+	for _, l := range metricFlightLevels {
+		if !anyDirection && l.Eastbound != eastbound {
+			continue
+		}
+		if diff := math.Abs(float64(l.Feet) - altFt); diff < bestDiff {
+			best, bestDiff = l, diff
+		}
+	}
+	return best
+}
+
+// SnapToMetricFlightLevel returns the altitude (feet) of the metric flight level closest to altFt that is
+// allocated to the track (degrees)
+func SnapToMetricFlightLevel(altFt, track float64) float64 {
+	track = math.Mod(track+360, 360)
+	return float64(nearestMetricFlightLevel(altFt, track < 180, false).Feet)
+}
+
+// UsesMetricAltitudes returns true if altitudes are given in metres in the airspace of the airport or controller
+func (s *Service) UsesMetricAltitudes(icao string) bool {
+	return s.TransitionRules.ruleFor(icao).Units == AltitudeUnitsMetric
+}
+
+// usesMetricAltitudes returns true if the controller of the aircraft gives altitudes in metres
+func (s *Service) usesMetricAltitudes(ac *Aircraft) bool {
+	return s.UsesMetricAltitudes(s.getTransitionICAO(ac))
+}
+
+// scaleMetricAltitude rounds the altitude (feet) to metres. At or above the transition level the altitude is
+// given as the nearest metric flight level and the returned bool value is true.
+func scaleMetricAltitude(rawAlt float64, transitionLevel int, phase flightphase.Phase) (int, bool) {
+	if transitionLevel > 0 && rawAlt >= float64(transitionLevel*100)-50 {
+		return nearestMetricFlightLevel(rawAlt, false, true).Metres, true
+	}
+
+	metres := rawAlt / constants.MetersToFeet
+	switch phase.Current {
+	case flightphase.Final.Index(), flightphase.Approach.Index():
+		// Nearest 100m for precision during landing
+		return int(math.Round(metres/100) * 100), false
+	default:
+		// Nearest 300m, the vertical separation of metric levels
+		return int(math.Round(metres/300) * 300), false
+	}
+}
+
+// formatMetres returns the metric altitude in words, e.g. "8 thousand 9 hundred metres"
+func formatMetres(metres int) string {
+	thousands := metres / 1000
+	hundreds := (metres % 1000) / 100
+	switch {
+	case thousands == 0:
+		return fmt.Sprintf("%d hundred metres", hundreds)
+	case hundreds == 0:
+		return fmt.Sprintf("%d thousand metres", thousands)
+	default:
+		return fmt.Sprintf("%d thousand %d hundred metres", thousands, hundreds)
+	}
+}
@@ -0,0 +1,70 @@
+package atc
+
+import (
+	"testing"
+
+	"github.com/curbz/decimal-niner/internal/flightphase"
+)
+
+func TestSnapToMetricFlightLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		alt   float64
+		track float64
+		want  float64
+	}{
+		{"eastbound FL290 becomes 8900m", 29000, 90, 29100},
+		{"westbound FL300 becomes 9200m", 30000, 270, 30100},
+		{"westbound FL290 becomes 9200m", 29000, 270, 30100},
+		{"eastbound FL350 becomes 10700m", 35000, 45, 35100},
+		{"negative track is westbound", 33000, -10, 32100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SnapToMetricFlightLevel(tt.alt, tt.track); got != tt.want {
+				t.Errorf("SnapToMetricFlightLevel(%.0f, %.0f) = %.0f; want %.0f", tt.alt, tt.track, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatMetricAltitude(t *testing.T) {
+	tests := []struct {
+		name            string
+		rawAlt          float64
+		transitionLevel int
+		phaseCurrent    int
+		want            string
+	}{
+		{"metric flight level", 29100, 118, flightphase.Cruise.Index(), "flight level 8 thousand 9 hundred metres"},
+		{"metric altitude below transition", 3000, 118, flightphase.Arrival.Index(), "9 hundred metres"},
+		{"metric approach altitude to 100m", 4920, 118, flightphase.Approach.Index(), "1 thousand 5 hundred metres"},
+		{"metric altitude to nearest 300m", 6560, 118, flightphase.Departure.Index(), "2 thousand 1 hundred metres"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ph := flightphase.Phase{Current: tt.phaseCurrent}
+			if got := formatAltitude(tt.rawAlt, tt.transitionLevel, ph, true); got != tt.want {
+				t.Errorf("formatAltitude(%.0f) = %q; want %q", tt.rawAlt, got, tt.want)
+			}
+		})
+	}
+
+	ph := flightphase.Phase{Current: flightphase.Cruise.Index()}
+	if got, want := generateAltClearance(29100, 118, 27600, ph, true), "descend to flight level 8 thousand 4 hundred metres"; got != want {
+		t.Errorf("generateAltClearance() = %q; want %q", got, want)
+	}
+}
+
+func TestUsesMetricAltitudes(t *testing.T) {
+	rules, err := loadTransitionRules("resources/transition_levels.json")
+	if err != nil {
+		t.Fatalf("unexpected error loading transition rules: %v", err)
+	}
+	s := &Service{TransitionRules: rules}
+	for icao, want := range map[string]bool{"ZBAA": true, "UAAA": true, "VHHH": false, "EGLL": false, "UUEE": false} {
+		if got := s.UsesMetricAltitudes(icao); got != want {
+			t.Errorf("UsesMetricAltitudes(%s) = %v; want %v", icao, got, want)
+		}
+	}
+}
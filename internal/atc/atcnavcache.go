@@ -0,0 +1,350 @@
+package atc
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/curbz/decimal-niner/internal/logger"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+// navdataCacheVersion is incremented whenever a cached type changes so that caches written by older builds
+// are rebuilt rather than decoded into the wrong shape
+const navdataCacheVersion = 5
+
+// navdataCachePattern matches the cache files in the cache directory
+const navdataCachePattern = "navdata-*.gob"
+
+// navdata is the X-Plane airport, controller, hold and fix data once parsed and linked
+type navdata struct {
+	Controllers []*Controller
+	Airports    map[string]*Airport
+	Holds       map[string]*Hold
+	Fixes       map[string]*Fix
+	Diagnostics ParseDiagnostics // records skipped or defaulted by the parsers
+}
+
+// navdataCache is the on-disk form of navdata. Gob does not preserve shared pointers, so the controllers of
+// each airport are stored as indexes into Controllers and the holds and fixes are linked again by key on load.
+type navdataCache struct {
+	Version            int
+	Key                string
+	Data               navdata
+	AirportControllers map[string][]int
+}
+
+// NavdataCacheInfo describes a navdata cache file written by BuildNavdataCache
+type NavdataCacheInfo struct {
+	Path                                string
+	Airports, Controllers, Holds, Fixes int
+	Skipped, Defaulted                  int // records skipped or defaulted by the parsers
+}
+
+// loadNavdata returns the navdata for the required airports and logs a summary of its parse diagnostics. With
+// strict_navdata set it fails if any record of the data files was skipped.
+func loadNavdata(cfg *config, requiredAirports map[string]bool) (*navdata, error) {
+	nd, err := readOrParseNavdata(cfg, requiredAirports)
+	if err != nil {
+		return nil, err
+	}
+	nd.Diagnostics.LogSummary()
+	if err := checkNavdataDiagnostics(cfg, &nd.Diagnostics); err != nil {
+		return nil, err
+	}
+	return nd, nil
+}
+
+// checkNavdataDiagnostics returns an error for the skipped records of the data files when strict_navdata is set
+func checkNavdataDiagnostics(cfg *config, diag *ParseDiagnostics) error {
+	if !cfg.ATC.StrictNavdata {
+		return nil
+	}
+	if err := diag.Err(); err != nil {
+		return fmt.Errorf("strict_navdata is set and %w", err)
+	}
+	return nil
+}
+
+// readOrParseNavdata returns the navdata for the required airports from the cache when it is up to date,
+// otherwise the X-Plane data files are parsed and the cache rebuilt
+func readOrParseNavdata(cfg *config, requiredAirports map[string]bool) (*navdata, error) {
+	dir := cfg.ATC.NavdataCacheDir
+	if dir == "" {
+		return parseNavdata(cfg, requiredAirports)
+	}
+
+	start := time.Now()
+	key := navdataCacheKey(cfg, requiredAirports)
+	path := navdataCachePath(dir, key)
+	nd, err := readNavdataCache(path, key)
+	if err == nil {
+		logger.Log.Infof("Navdata loaded from cache %s in %v", path, time.Since(start))
+		return nd, nil
+	}
+	if !os.IsNotExist(err) {
+		logger.Log.Warnf("ignoring navdata cache %s: %v", path, err)
+	} else {
+		logger.Log.Info("Navdata cache is missing or out of date, parsing X-Plane data files")
+	}
+
+	nd, err = parseNavdata(cfg, requiredAirports)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeNavdataCache(dir, key, nd); err != nil {
+		logger.Log.Warnf("unable to write navdata cache: %v", err)
+	}
+	return nd, nil
+}
+
+// parseNavdata parses the X-Plane hold, fix, airport, ATC and CIFP data files. With an airport load radius the
+// required airports are parsed as stubs, without CIFP data, to be loaded in full as the user flies.
+func parseNavdata(cfg *config, requiredAirports map[string]bool) (*navdata, error) {
+	diag := &ParseDiagnostics{}
+
+	// load hold data
+	logger.Log.Info("Loading X-Plane Holds data")
+	allHolds, airportHolds, allFixes, err := loadHolds(cfg.ATC.AtcNavDataFile, cfg.ATC.AtcHoldsFile, cfg.ATC.AtcFixesFile, diag)
+	if err != nil {
+		return nil, fmt.Errorf("error loading hold data: %w", err)
+	}
+	logger.Log.Infof("Holds data loaded: seeded %d holds\n", len(allHolds))
+
+	// load airports and controller data
+	var fullAirports map[string]bool
+	if cfg.ATC.AirportLoadRadiusNM > 0 {
+		fullAirports = map[string]bool{}
+	}
+	arptControllers, airports, err := parseAirports(cfg, requiredAirports, fullAirports, diag)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing airports data file: %w", err)
+	}
+	atcControllers, err := parseATCdatFiles(cfg.ATC.AtcDataFile, false, requiredAirports, diag)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ATC data file: %w", err)
+	}
+	db := append(atcControllers, arptControllers...)
+	regionControllers, err := parseATCdatFiles(cfg.ATC.AtcRegionsFile, true, requiredAirports, diag)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ATC regions file: %w", err)
+	}
+	db = append(db, regionControllers...)
+
+	if fullAirports != nil {
+		logger.Log.Info("Airport stubs loaded: seeded ", len(airports), " airports")
+		return &navdata{Controllers: db, Airports: airports, Holds: allHolds, Fixes: allFixes, Diagnostics: *diag}, nil
+	}
+
+	// enrich airport data
+	logger.Log.Info("Loading X-Plane airport files")
+
+	err = loadAirports(cfg.ATC.AirportCIFPDir, airports, requiredAirports, airportHolds, allHolds, allFixes, diag)
+	if err != nil {
+		return nil, fmt.Errorf("error loading airport data from CIFP files: %w", err)
+	}
+	logger.Log.Info("Airport data loaded: seeded ", len(airports), " airports")
+
+	return &navdata{Controllers: db, Airports: airports, Holds: allHolds, Fixes: allFixes, Diagnostics: *diag}, nil
+}
+
+// navdataCacheKey identifies the data files and required airports that a cache was built from. A change to
+// the path, size or modification time of any of the files, or to the required airports, gives a new key.
+func navdataCacheKey(cfg *config, requiredAirports map[string]bool) string {
+	icaos := make([]string, 0, len(requiredAirports))
+	for icao := range requiredAirports {
+		icaos = append(icaos, icao)
+	}
+	sort.Strings(icaos)
+
+	files := []string{cfg.ATC.AirportsDataFile, cfg.ATC.AtcDataFile, cfg.ATC.AtcRegionsFile,
+		cfg.ATC.AtcHoldsFile, cfg.ATC.AtcNavDataFile, cfg.ATC.AtcFixesFile}
+	files = append(files, sceneryPackFiles(cfg.ATC.XPlaneRoot)...)
+	for _, icao := range icaos {
+		files = append(files, filepath.Join(cfg.ATC.AirportCIFPDir, icao+".dat"))
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "v%d\n", navdataCacheVersion)
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			fmt.Fprintf(h, "%s %d %d\n", f, info.Size(), info.ModTime().UnixNano())
+		} else {
+			fmt.Fprintf(h, "%s missing\n", f)
+		}
+	}
+	fmt.Fprintf(h, "required %v stubs %v\n", icaos, cfg.ATC.AirportLoadRadiusNM > 0)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func navdataCachePath(dir, key string) string {
+	return filepath.Join(dir, "navdata-"+key[:16]+".gob")
+}
+
+// readNavdataCache decodes the cache file, which must have been written for the key by this cache version
+func readNavdataCache(path, key string) (*navdata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var c navdataCache
+	if err := gob.NewDecoder(f).Decode(&c); err != nil {
+		return nil, fmt.Errorf("error decoding navdata cache: %w", err)
+	}
+	if c.Version != navdataCacheVersion || c.Key != key {
+		return nil, fmt.Errorf("navdata cache was written for other data files (version %d)", c.Version)
+	}
+
+	nd := &c.Data
+	nd.link(c.AirportControllers)
+	return nd, nil
+}
+
+// writeNavdataCache writes the navdata to the cache directory, replacing any caches of other data files.
+// The file is written under a temporary name and renamed so that a partly written cache is never read.
+func writeNavdataCache(dir, key string, nd *navdata) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	index := make(map[*Controller]int, len(nd.Controllers))
+	for i, c := range nd.Controllers {
+		index[c] = i
+	}
+	airportControllers := make(map[string][]int)
+	for icao, ap := range nd.Airports {
+		for _, c := range ap.Controllers {
+			if i, ok := index[c]; ok {
+				airportControllers[icao] = append(airportControllers[icao], i)
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, "navdata-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	c := navdataCache{Version: navdataCacheVersion, Key: key, Data: *nd, AirportControllers: airportControllers}
+	if err := gob.NewEncoder(tmp).Encode(&c); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error encoding navdata cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	path := navdataCachePath(dir, key)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	old, _ := filepath.Glob(filepath.Join(dir, navdataCachePattern))
+	for _, f := range old {
+		if f != path {
+			os.Remove(f)
+		}
+	}
+	logger.Log.Infof("Navdata cache written to %s", path)
+	return nil
+}
+
+// link restores the pointers shared between the airports, controllers, holds and fixes after decoding
+func (nd *navdata) link(airportControllers map[string][]int) {
+	for key, h := range nd.Holds {
+		if f, ok := nd.Fixes[key]; ok && f.Hold != nil {
+			f.Hold = h
+		}
+	}
+	linkFix := func(pf *ProcedureFix) {
+		if pf == nil || pf.Fix == nil {
+			return
+		}
+		if f, ok := nd.Fixes[pf.Fix.Ident+"_"+pf.Fix.Region]; ok {
+			pf.Fix = f
+		}
+	}
+
+	for icao, ap := range nd.Airports {
+		for i, h := range ap.Holds {
+			if shared, ok := nd.Holds[h.Ident+"_"+h.Region]; ok {
+				ap.Holds[i] = shared
+			}
+		}
+		for _, rwy := range ap.Runways {
+			for _, proc := range append(append([]*Procedure{}, rwy.SIDs...), rwy.STARs...) {
+				linkFix(proc.Entry)
+				linkFix(proc.Exit)
+			}
+		}
+		if indexes, ok := airportControllers[icao]; ok {
+			ap.Controllers = make([]*Controller, len(indexes))
+			for i, idx := range indexes {
+				ap.Controllers[i] = nd.Controllers[idx]
+			}
+		}
+	}
+}
+
+// BuildNavdataCache parses the X-Plane data files of the config for the required airports and writes the
+// navdata cache, replacing any existing cache
+func BuildNavdataCache(cfgPath string, requiredAirports map[string]bool) (*NavdataCacheInfo, error) {
+	cfg, err := util.LoadConfig[config](cfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading configuration file: %v", err)
+	}
+	if cfg.ATC.NavdataCacheDir == "" {
+		return nil, fmt.Errorf("navdata_cache_dir is not set in %s", cfgPath)
+	}
+
+	nd, err := parseNavdata(cfg, requiredAirports)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNavdataDiagnostics(cfg, &nd.Diagnostics); err != nil {
+		return nil, err
+	}
+	key := navdataCacheKey(cfg, requiredAirports)
+	if err := writeNavdataCache(cfg.ATC.NavdataCacheDir, key, nd); err != nil {
+		return nil, err
+	}
+	skipped, defaulted := nd.Diagnostics.Counts()
+	return &NavdataCacheInfo{
+		Path:        navdataCachePath(cfg.ATC.NavdataCacheDir, key),
+		Airports:    len(nd.Airports),
+		Controllers: len(nd.Controllers),
+		Holds:       len(nd.Holds),
+		Fixes:       len(nd.Fixes),
+		Skipped:     skipped,
+		Defaulted:   defaulted,
+	}, nil
+}
+
+// ClearNavdataCache removes the navdata cache files and returns the number removed
+func ClearNavdataCache(cfgPath string) (int, error) {
+	cfg, err := util.LoadConfig[config](cfgPath)
+	if err != nil {
+		return 0, fmt.Errorf("error reading configuration file: %v", err)
+	}
+	if cfg.ATC.NavdataCacheDir == "" {
+		return 0, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(cfg.ATC.NavdataCacheDir, navdataCachePattern))
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil {
+			return 0, err
+		}
+	}
+	return len(files), nil
+}
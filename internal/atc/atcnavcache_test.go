@@ -0,0 +1,150 @@
+package atc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testNavdata returns navdata whose airport shares its controller, hold and procedure fix with the top level
+// slices and maps, as the parsers produce
+func testNavdata() *navdata {
+	ctrl := &Controller{Name: "Heathrow", ICAO: "EGLL", RoleID: 3, Freqs: []int{118500}, IsPoint: true, Lat: 51.47, Lon: -0.45}
+	region := &Controller{Name: "London", RoleID: 6, IsRegion: true, Airspaces: []Airspace{{Floor: 0, Ceiling: 66000, Points: [][2]float64{{50, -2}, {53, -2}, {53, 1}}}}}
+	hold := &Hold{Ident: "BNN", Region: "EG", ICAO: "EGLL", FullName: "BOVINGDON", MinAlt: 7000, MaxAlt: 15000, Lat: 51.72, Lon: -0.55}
+	hold.InitUnitVector()
+	fix := &Fix{Ident: "BNN", Region: "EG", FullName: "BOVINGDON", Lat: 51.72, Lon: -0.55, Hold: hold}
+	other := &Fix{Ident: "CPT", Region: "EG", Lat: 51.49, Lon: -1.22}
+	sid := &Procedure{Name: "CPT3G", Type: PROC_TYPE_SID, Entry: &ProcedureFix{Fix: other, ConstraintAlt: 6000}, Exit: &ProcedureFix{Fix: other}}
+	star := &Procedure{Name: "BNN1B", Type: PROC_TYPE_STAR, Entry: &ProcedureFix{Fix: fix}, Exit: &ProcedureFix{Fix: fix}}
+
+	return &navdata{
+		Controllers: []*Controller{region, ctrl},
+		Airports: map[string]*Airport{
+			"EGLL": {
+				ICAO: "EGLL", Name: "london heathrow", Lat: 51.47, Lon: -0.45, TransAlt: 6000, Region: "EG",
+				Runways: map[string]*Runway{"27R": {Name: "27R", Heading: 270, SIDs: []*Procedure{sid}, STARs: []*Procedure{star},
+					DepartureAccess: map[string]*AccessPoint{"A10": {Name: "A10", TaxiwayName: "Alpha"}}}},
+				Holds:       []*Hold{hold},
+				Controllers: []*Controller{ctrl},
+				Parking:     map[string]*ParkingSpot{"521": {Name: "521", Type: "gate", WidthClass: "E"}},
+			},
+		},
+		Holds: map[string]*Hold{"BNN_EG": hold},
+		Fixes: map[string]*Fix{"BNN_EG": fix, "CPT_EG": other},
+	}
+}
+
+func TestNavdataCacheRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "navdata-0000000000000000.gob"), []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	key := "0123456789abcdef0123456789abcdef"
+	if err := writeNavdataCache(dir, key, testNavdata()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	if len(files) != 1 || files[0] != navdataCachePath(dir, key) {
+		t.Fatalf("cache directory holds %v; want only the new cache", files)
+	}
+
+	nd, err := readNavdataCache(navdataCachePath(dir, key), key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ap := nd.Airports["EGLL"]
+	if ap == nil || ap.Runways["27R"].DepartureAccess["A10"].TaxiwayName != "Alpha" || ap.Parking["521"].WidthClass != "E" {
+		t.Fatalf("airport not restored: %+v", ap)
+	}
+	if len(nd.Controllers) != 2 || len(nd.Controllers[0].Airspaces[0].Points) != 3 {
+		t.Fatalf("controllers not restored: %+v", nd.Controllers)
+	}
+
+	// shared pointers are linked again
+	hold := nd.Holds["BNN_EG"]
+	if ap.Controllers[0] != nd.Controllers[1] {
+		t.Error("airport controller is not the controller in the database")
+	}
+	if ap.Holds[0] != hold || nd.Fixes["BNN_EG"].Hold != hold || hold.X == 0 {
+		t.Error("airport hold and fix hold are not the hold in the holds map")
+	}
+	if ap.Runways["27R"].STARs[0].Exit.Fix != nd.Fixes["BNN_EG"] || ap.Runways["27R"].SIDs[0].Entry.Fix != nd.Fixes["CPT_EG"] {
+		t.Error("procedure fixes are not the fixes in the fixes map")
+	}
+
+	if _, err := readNavdataCache(navdataCachePath(dir, key), "another key"); err == nil {
+		t.Error("expected error for a cache of other data files")
+	}
+}
+
+func TestNavdataCacheKey(t *testing.T) {
+	dir := t.TempDir()
+	apt := filepath.Join(dir, "apt.dat")
+	if err := os.WriteFile(apt, []byte("I\n1100 Version\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config{}
+	cfg.ATC.AirportsDataFile = apt
+	cfg.ATC.AirportCIFPDir = dir
+	required := map[string]bool{"EGLL": true}
+
+	key := navdataCacheKey(cfg, required)
+	if key != navdataCacheKey(cfg, map[string]bool{"EGLL": true}) {
+		t.Error("key is not stable")
+	}
+	if key == navdataCacheKey(cfg, map[string]bool{"EGLL": true, "KJFK": true}) {
+		t.Error("key unchanged by required airports")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "EGLL.dat"), []byte("RWY:RW27R"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if key == navdataCacheKey(cfg, required) {
+		t.Error("key unchanged by new CIFP file")
+	}
+	key = navdataCacheKey(cfg, required)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(apt, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if key == navdataCacheKey(cfg, required) {
+		t.Error("key unchanged by modified apt.dat")
+	}
+}
+
+func TestLoadNavdataFromCache(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yaml")
+	cacheDir := filepath.Join(dir, "cache")
+	if err := os.WriteFile(cfgPath, []byte("atc:\n  navdata_cache_dir: \""+cacheDir+"\"\n  airports_data_file: \""+filepath.Join(dir, "apt.dat")+"\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config{}
+	cfg.ATC.NavdataCacheDir = cacheDir
+	cfg.ATC.AirportsDataFile = filepath.Join(dir, "apt.dat")
+	required := map[string]bool{"EGLL": true}
+
+	// the data files do not exist, so the airports can only come from the cache
+	if err := writeNavdataCache(cacheDir, navdataCacheKey(cfg, required), testNavdata()); err != nil {
+		t.Fatal(err)
+	}
+	nd, err := loadNavdata(cfg, required)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nd.Airports["EGLL"] == nil {
+		t.Error("airport not loaded from cache")
+	}
+	if _, err := loadNavdata(cfg, map[string]bool{"KJFK": true}); err == nil {
+		t.Error("expected error parsing missing data files when the cache is out of date")
+	}
+
+	n, err := ClearNavdataCache(cfgPath)
+	if err != nil || n != 1 {
+		t.Errorf("ClearNavdataCache = %d, %v; want 1 file removed", n, err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(cacheDir, navdataCachePattern)); len(files) != 0 {
+		t.Errorf("cache files left: %v", files)
+	}
+}
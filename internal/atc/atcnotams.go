@@ -0,0 +1,261 @@
+package atc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/curbz/decimal-niner/internal/flightclass"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+// Closure is a NOTAM closing a runway, taxiway or parking stand of an airport, optionally between two sim zulu
+// times. A runway closure closes both of its ends.
+type Closure struct {
+	Airport string    `yaml:"airport"`
+	Runway  string    `yaml:"runway"`
+	Taxiway string    `yaml:"taxiway"`
+	Stand   string    `yaml:"stand"`
+	From    time.Time `yaml:"from"`   // sim zulu, e.g. 2026-10-18T06:00:00Z; closed from the start if omitted
+	Until   time.Time `yaml:"until"`  // sim zulu; closed until further notice if omitted
+	Reason  string    `yaml:"reason"` // spoken as "closed for <reason>", e.g. "maintenance"
+}
+
+// notamFile is the layout of the NOTAM file
+type notamFile struct {
+	Closures []Closure `yaml:"closures"`
+}
+
+// Kind returns "runway", "taxiway" or "stand"
+func (c Closure) Kind() string {
+	switch {
+	case c.Runway != "":
+		return "runway"
+	case c.Taxiway != "":
+		return "taxiway"
+	default:
+		return "stand"
+	}
+}
+
+// Name returns the name of the closed runway, taxiway or stand
+func (c Closure) Name() string {
+	return c.Runway + c.Taxiway + c.Stand
+}
+
+// ActiveAt reports whether the closure is in force at the sim time
+func (c Closure) ActiveAt(t time.Time) bool {
+	if !c.From.IsZero() && t.Before(c.From) {
+		return false
+	}
+	return c.Until.IsZero() || t.Before(c.Until)
+}
+
+// closes reports whether the closure closes the runway, taxiway or stand of the airport
+func (c Closure) closes(icao, kind, name string) bool {
+	if c.Airport != icao || c.Kind() != kind {
+		return false
+	}
+	if kind == "runway" {
+		rwy := strings.ToUpper(c.Runway)
+		return strings.EqualFold(rwy, name) || strings.EqualFold(getReciprocalName(rwy), name)
+	}
+	return strings.EqualFold(c.Name(), name)
+}
+
+func (c Closure) validate() error {
+	if c.Airport == "" {
+		return fmt.Errorf("no airport")
+	}
+	n := 0
+	for _, name := range []string{c.Runway, c.Taxiway, c.Stand} {
+		if name != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("exactly one of runway, taxiway or stand is required")
+	}
+	if !c.From.IsZero() && !c.Until.IsZero() && !c.Until.After(c.From) {
+		return fmt.Errorf("until %s is not after from %s", c.Until.Format(time.RFC3339), c.From.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// loadNOTAMs reads and validates the closures of the NOTAM file, an empty path has no closures
+func loadNOTAMs(path string) ([]Closure, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := util.LoadConfig[notamFile](path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading NOTAM file %s: %w", path, err)
+	}
+	for i, c := range f.Closures {
+		if err := c.validate(); err != nil {
+			return nil, fmt.Errorf("invalid NOTAM file %s: closure %d (%s %s): %w", path, i+1, c.Airport, c.Name(), err)
+		}
+		// airports and runways are keyed in upper case, e.g. a closure of egll 09l closes EGLL 09L
+		f.Closures[i].Airport = strings.ToUpper(c.Airport)
+		f.Closures[i].Runway = strings.ToUpper(c.Runway)
+		f.Closures[i].Taxiway = strings.ToUpper(c.Taxiway)
+		f.Closures[i].Stand = strings.ToUpper(c.Stand)
+	}
+	return f.Closures, nil
+}
+
+// reloadNOTAMs loads the NOTAM file and replaces the closures in force
+func (s *Service) reloadNOTAMs() error {
+	closures, err := loadNOTAMs(s.Config.ATC.NotamFile)
+	if err != nil {
+		return err
+	}
+	s.SetClosures(closures)
+	return nil
+}
+
+// SetClosures replaces the closures in force
+func (s *Service) SetClosures(closures []Closure) {
+	s.notamMu.Lock()
+	defer s.notamMu.Unlock()
+	s.closures = closures
+}
+
+// ActiveClosures returns the closures of the airport in force at the sim time, or those of every airport if
+// icao is empty. Timed closures are not in force until the sim time is known.
+func (s *Service) ActiveClosures(icao string) []Closure {
+	s.notamMu.RLock()
+	defer s.notamMu.RUnlock()
+	now := s.GetCurrentZuluTime()
+	var active []Closure
+	for _, c := range s.closures {
+		if icao != "" && c.Airport != icao {
+			continue
+		}
+		timed := !c.From.IsZero() || !c.Until.IsZero()
+		if (timed && s.SimInitTime.IsZero()) || !c.ActiveAt(now) {
+			continue
+		}
+		active = append(active, c)
+	}
+	return active
+}
+
+// isClosed reports whether the runway, taxiway or stand of the airport is closed at the sim time
+func (s *Service) isClosed(icao, kind, name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range s.ActiveClosures(icao) {
+		if c.closes(icao, kind, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRunwayClosed reports whether either end of the runway is closed at the sim time
+func (s *Service) IsRunwayClosed(icao, rwy string) bool {
+	return s.isClosed(icao, "runway", rwy)
+}
+
+// IsTaxiwayClosed reports whether the taxiway is closed at the sim time
+func (s *Service) IsTaxiwayClosed(icao, twy string) bool {
+	return s.isClosed(icao, "taxiway", twy)
+}
+
+// IsStandClosed reports whether the parking stand is closed at the sim time
+func (s *Service) IsStandClosed(icao, stand string) bool {
+	return s.isClosed(icao, "stand", stand)
+}
+
+// Outline returns the lat/lon points of the closed surface at the airport: the thresholds of a runway, the
+// stand, or the runway access points and stands joining a taxiway. It is empty if the surface is not found.
+func (c Closure) Outline(ap *Airport) [][2]float64 {
+	var points [][2]float64
+	switch c.Kind() {
+	case "runway":
+		for _, name := range []string{c.Runway, getReciprocalName(c.Runway)} {
+			if rwy, ok := ap.Runways[name]; ok {
+				return [][2]float64{{rwy.Lat, rwy.Lon}, {rwy.EndLat, rwy.EndLon}}
+			}
+		}
+	case "taxiway":
+		for _, rwy := range ap.Runways {
+			for _, accessMap := range []map[string]*AccessPoint{rwy.DepartureAccess, rwy.ArrivalAccess} {
+				for _, access := range accessMap {
+					if strings.EqualFold(access.TaxiwayName, c.Taxiway) {
+						points = append(points, [2]float64{access.Coord.Lat, access.Coord.Lon})
+					}
+				}
+			}
+		}
+		for _, spot := range ap.Parking {
+			if strings.EqualFold(spot.TaxiwayName, c.Taxiway) {
+				points = append(points, [2]float64{spot.Lat, spot.Lon})
+			}
+		}
+	case "stand":
+		for _, spot := range ap.Parking {
+			if strings.EqualFold(spot.Name, c.Stand) {
+				return [][2]float64{{spot.Lat, spot.Lon}}
+			}
+		}
+	}
+	return points
+}
+
+// routeClosures returns the closures of the runway the aircraft is cleared to and of the taxiways of its taxi
+// route, as the route is spoken by @TAXIPATH
+func routeClosures(ac *Aircraft, closures []Closure) []Closure {
+	var taxiways []string
+	if spot := ac.Flight.AssignedParkingSpot; spot != nil {
+		taxiways = append(taxiways, spot.TaxiwayName)
+	}
+	access := ac.Flight.DepartureAccess
+	if ac.Flight.Phase.Class == flightclass.Arriving {
+		access = ac.Flight.ArrivalAccess
+	}
+	if access != nil {
+		taxiways = append(taxiways, access.TaxiwayName)
+	}
+
+	var relevant []Closure
+	for _, c := range closures {
+		switch c.Kind() {
+		case "runway":
+			if ac.Flight.AssignedRunwayName != "" && c.closes(c.Airport, "runway", ac.Flight.AssignedRunwayName) {
+				relevant = append(relevant, c)
+			}
+		case "taxiway":
+			for _, twy := range taxiways {
+				if twy != "" && c.closes(c.Airport, "taxiway", twy) {
+					relevant = append(relevant, c)
+					break
+				}
+			}
+		}
+	}
+	return relevant
+}
+
+// formatClosures returns the runway and taxiway closures spoken by a controller, e.g. ", taxiway Bravo closed,
+// runway 09right closed for maintenance", or an empty string when none are closed. Stand closures are not
+// broadcast.
+func formatClosures(closures []Closure, language string) string {
+	var sb strings.Builder
+	for _, c := range closures {
+		switch c.Kind() {
+		case "runway":
+			sb.WriteString(", runway " + translateRunwayLanguage(c.Runway, language) + " closed")
+		case "taxiway":
+			sb.WriteString(", taxiway " + phoneticiseAlphaFirst(strings.ToUpper(c.Taxiway), false) + " closed")
+		default:
+			continue
+		}
+		if c.Reason != "" {
+			sb.WriteString(" for " + c.Reason)
+		}
+	}
+	return sb.String()
+}
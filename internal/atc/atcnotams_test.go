@@ -0,0 +1,152 @@
+package atc
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/curbz/decimal-niner/internal/flightclass"
+)
+
+func TestLoadNOTAMsValidation(t *testing.T) {
+	for _, tc := range []struct {
+		name, yaml, want string
+	}{
+		{"airport", "closures:\n  - {runway: 09R}\n", "no airport"},
+		{"surface", "closures:\n  - {airport: EGLL}\n", "exactly one"},
+		{"two surfaces", "closures:\n  - {airport: EGLL, runway: 09R, taxiway: B}\n", "exactly one"},
+		{"times", "closures:\n  - {airport: EGLL, taxiway: B, from: 2026-10-18T12:00:00Z, until: 2026-10-18T06:00:00Z}\n", "not after"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "notams.yaml")
+			writeResource(t, path, tc.yaml)
+			if _, err := loadNOTAMs(path); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error = %v; want %q", err, tc.want)
+			}
+		})
+	}
+
+	if c, err := loadNOTAMs(""); err != nil || len(c) != 0 {
+		t.Errorf("no NOTAM file = %v, %v", c, err)
+	}
+	c, err := loadNOTAMs("resources/notams.example.yaml")
+	if err != nil || len(c) != 3 {
+		t.Fatalf("example NOTAM file = %v, %v", c, err)
+	}
+	if !c[0].From.Equal(time.Date(2026, 10, 18, 6, 0, 0, 0, time.UTC)) || c[0].Kind() != "runway" || c[2].Name() != "35" {
+		t.Errorf("example closures = %+v", c)
+	}
+
+	// names are matched whatever their case in the file
+	path := filepath.Join(t.TempDir(), "notams.yaml")
+	writeResource(t, path, "closures:\n  - {airport: egll, runway: 09l}\n  - {airport: egll, taxiway: b}\n")
+	c, err = loadNOTAMs(path)
+	if err != nil {
+		t.Fatalf("lower case NOTAM file: %v", err)
+	}
+	s := &Service{}
+	s.SetClosures(c)
+	if !s.IsRunwayClosed("EGLL", "27R") || !s.IsTaxiwayClosed("EGLL", "B") {
+		t.Errorf("lower case closures not applied: %+v", c)
+	}
+	ap := &Airport{ICAO: "EGLL", Runways: map[string]*Runway{"09L": {Name: "09L", Lat: 51.47, Lon: -0.48, EndLat: 51.47, EndLon: -0.43}}}
+	if got := c[0].Outline(ap); len(got) != 2 {
+		t.Errorf("lower case runway outline = %v", got)
+	}
+}
+
+func TestClosures(t *testing.T) {
+	s := &Service{}
+	s.SetClosures([]Closure{
+		{Airport: "EGLL", Runway: "09R", Reason: "maintenance",
+			From: time.Date(2026, 10, 18, 6, 0, 0, 0, time.UTC), Until: time.Date(2026, 10, 18, 18, 0, 0, 0, time.UTC)},
+		{Airport: "EGLL", Taxiway: "B"},
+		{Airport: "EGLL", Stand: "512"},
+	})
+
+	// timed closures are not in force until the sim time is known
+	if s.IsRunwayClosed("EGLL", "09R") || !s.IsTaxiwayClosed("EGLL", "b") || !s.IsStandClosed("EGLL", "512") {
+		t.Error("closures without a sim time")
+	}
+
+	s.SyncSimTime(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC), time.Now())
+	if !s.IsRunwayClosed("EGLL", "09R") || !s.IsRunwayClosed("EGLL", "27L") || s.IsRunwayClosed("EGLL", "09L") ||
+		!s.IsRunwayClosed("EGLL", "09r") {
+		t.Error("runway 09R/27L not closed alone")
+	}
+	if s.IsTaxiwayClosed("EGKK", "B") || s.IsTaxiwayClosed("EGLL", "") {
+		t.Error("taxiway closed at another airport or without a name")
+	}
+	want := ", runway 09right closed for maintenance, taxiway Bravo closed"
+	if got := formatClosures(s.ActiveClosures("EGLL"), ""); got != want {
+		t.Errorf("formatClosures = %q; want %q", got, want)
+	}
+
+	s.SyncSimTime(time.Date(2026, 10, 18, 18, 0, 0, 0, time.UTC), time.Now())
+	if s.IsRunwayClosed("EGLL", "09R") {
+		t.Error("runway closed after the NOTAM expired")
+	}
+	if got := formatClosures(s.ActiveClosures("EGKK"), ""); got != "" {
+		t.Errorf("formatClosures without closures = %q", got)
+	}
+}
+
+func TestAccessPointAvoidsClosedTaxiway(t *testing.T) {
+	bravo := &AccessPoint{Name: "B1", Coord: Coordinate{Lat: 51.470, Lon: -0.450}, TaxiwayName: "B"}
+	alpha := &AccessPoint{Name: "A1", Coord: Coordinate{Lat: 51.470, Lon: -0.480}, TaxiwayName: "A"}
+	rwy := &Runway{Name: "27R", DepartureAccess: map[string]*AccessPoint{"B1": bravo, "A1": alpha}}
+	ap := &Airport{ICAO: "EGLL", Runways: map[string]*Runway{"27R": rwy}}
+	ac := &Aircraft{Registration: "G-TEST"}
+	ac.Flight.AssignedRunway = rwy
+	ac.Flight.AssignedParkingSpot = &ParkingSpot{Name: "512", Lat: 51.471, Lon: -0.451}
+
+	s := &Service{}
+	s.AssignRunwayAccessPoint(ac, ap, DEPARTURE_CONTEXT)
+	if ac.Flight.DepartureAccess != bravo {
+		t.Fatalf("departure access = %v; want the nearest, B1", ac.Flight.DepartureAccess)
+	}
+
+	s.SetClosures([]Closure{{Airport: "EGLL", Taxiway: "B"}})
+	s.AssignRunwayAccessPoint(ac, ap, DEPARTURE_CONTEXT)
+	if ac.Flight.DepartureAccess != alpha {
+		t.Errorf("departure access = %v; want A1 with taxiway B closed", ac.Flight.DepartureAccess)
+	}
+
+	// with every access point closed the nearest is still used
+	s.SetClosures([]Closure{{Airport: "EGLL", Taxiway: "B"}, {Airport: "EGLL", Taxiway: "A"}})
+	s.AssignRunwayAccessPoint(ac, ap, DEPARTURE_CONTEXT)
+	if ac.Flight.DepartureAccess != bravo {
+		t.Errorf("departure access = %v; want B1 with every taxiway closed", ac.Flight.DepartureAccess)
+	}
+}
+
+func TestRouteClosures(t *testing.T) {
+	closures := []Closure{
+		{Airport: "EGLL", Runway: "09R"},
+		{Airport: "EGLL", Runway: "09L"},
+		{Airport: "EGLL", Taxiway: "B", Reason: "maintenance"},
+		{Airport: "EGLL", Taxiway: "K"},
+		{Airport: "EGLL", Stand: "512"},
+	}
+	ac := &Aircraft{}
+	ac.Flight.AssignedRunwayName = "27L"
+	ac.Flight.AssignedParkingSpot = &ParkingSpot{Name: "512", TaxiwayName: "A"}
+	ac.Flight.DepartureAccess = &AccessPoint{Name: "B1", TaxiwayName: "b"}
+
+	want := ", runway 09right closed, taxiway Bravo closed for maintenance"
+	if got := formatClosures(routeClosures(ac, closures), ""); got != want {
+		t.Errorf("departure route closures = %q; want %q", got, want)
+	}
+
+	ac.Flight.Phase.Class = flightclass.Arriving
+	ac.Flight.AssignedRunwayName = "23"
+	if got := formatClosures(routeClosures(ac, closures), ""); got != "" {
+		t.Errorf("arrival route closures = %q; want none", got)
+	}
+
+	// closures are spoken by the controller but not read back
+	if rb := autoReadback("{$CALLSIGN}, taxi to runway {@RUNWAY} via {@TAXIPATH}[{@CLOSURES}]."); strings.Contains(rb, "CLOSURES") {
+		t.Errorf("autoReadback = %q; want the closures left out", rb)
+	}
+}
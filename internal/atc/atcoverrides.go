@@ -0,0 +1,378 @@
+package atc
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/curbz/decimal-niner/internal/logger"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+// Overrides are local corrections to the controllers and airports of the X-Plane data files. They are applied
+// after the data files are parsed so that gaps can be fixed without editing the X-Plane files.
+type Overrides struct {
+	Controllers []ControllerOverride       `yaml:"controllers"`
+	Airports    map[string]AirportOverride `yaml:"airports"` // keyed by ICAO code
+}
+
+// ControllerOverride adds, replaces, updates or removes controllers. Replace, update and remove match the
+// controllers of the ICAO code and role, and of the name if one is given. Update sets the frequencies and
+// opening hours given and leaves the rest of the matched controllers unchanged.
+type ControllerOverride struct {
+	Action       string      `yaml:"action"` // add, replace, update or remove
+	Name         string      `yaml:"name"`
+	ICAO         string      `yaml:"icao"`
+	Role         string      `yaml:"role"`          // unicom, del, gnd, twr, tracon or ctr
+	Frequencies  []float64   `yaml:"frequencies"`   // MHz, e.g. 118.5
+	Position     []float64   `yaml:"position"`      // latitude and longitude of a point controller, the airport if omitted
+	Polygon      [][]float64 `yaml:"polygon"`       // latitude and longitude of the airspace points
+	Floor        float64     `yaml:"floor"`         // feet
+	Ceiling      float64     `yaml:"ceiling"`       // feet, unlimited if zero
+	OpeningHours string      `yaml:"opening_hours"` // zulu, e.g. "0600-2230", or "H24"; always open if omitted
+}
+
+// AirportOverride sets the operations of an airport. The opening hours also close the airport's delivery, ground
+// and tower controllers that have no hours of their own.
+type AirportOverride struct {
+	TransitionAltitude int             `yaml:"transition_altitude"` // feet
+	RunwayConfigs      []RunwayConfig  `yaml:"runway_configs"`      // in order of preference
+	Noise              NoisePreference `yaml:"noise"`
+	OpeningHours       string          `yaml:"opening_hours"` // zulu, e.g. "0600-2230", always open if empty or "H24"
+}
+
+// RunwayConfig is a preferred pair of arrival and departure runways
+type RunwayConfig struct {
+	Arrival   string `yaml:"arrival"`
+	Departure string `yaml:"departure"`
+}
+
+// NoisePreference sets how far the wind may favour other runways before the preferred runway configurations
+// are given up, and the runways avoided for noise abatement
+type NoisePreference struct {
+	MaxTailwindKt  float64  `yaml:"max_tailwind_kt"`  // tailwind component accepted on the preferred runways
+	MaxCrosswindKt float64  `yaml:"max_crosswind_kt"` // crosswind component accepted on the preferred runways, unlimited if zero
+	AvoidRunways   []string `yaml:"avoid_runways"`    // only used when the wind leaves no other runway
+}
+
+// Avoids reports whether the runway is avoided for noise abatement
+func (n NoisePreference) Avoids(rwy string) bool {
+	for _, name := range n.AvoidRunways {
+		if name == rwy {
+			return true
+		}
+	}
+	return false
+}
+
+// OpeningHours are the daily opening hours in minutes past midnight zulu. The hours wrap past midnight when
+// Close is before Open.
+type OpeningHours struct {
+	Open, Close int
+}
+
+// parseOpeningHours parses hours of the form "0600-2230", or "H24" which returns nil as always open
+func parseOpeningHours(s string) (*OpeningHours, error) {
+	if s == "H24" {
+		return nil, nil
+	}
+	var oh, om, ch, cm int
+	if n, _ := fmt.Sscanf(s, "%2d%2d-%2d%2d", &oh, &om, &ch, &cm); n != 4 || len(s) != 9 {
+		return nil, fmt.Errorf("opening hours %q are not of the form 0600-2230", s)
+	}
+	if om > 59 || cm > 59 || oh*60+om > 1440 || ch*60+cm > 1440 {
+		return nil, fmt.Errorf("opening hours %q are not valid times", s)
+	}
+	return &OpeningHours{Open: oh*60 + om, Close: ch*60 + cm}, nil
+}
+
+// IsOpen reports whether the time falls within the opening hours
+func (h *OpeningHours) IsOpen(t time.Time) bool {
+	if h == nil || h.Open == h.Close {
+		return true
+	}
+	t = t.UTC()
+	mins := t.Hour()*60 + t.Minute()
+	if h.Open < h.Close {
+		return mins >= h.Open && mins < h.Close
+	}
+	return mins >= h.Open || mins < h.Close
+}
+
+func (h *OpeningHours) String() string {
+	return fmt.Sprintf("%02d%02d-%02d%02d", h.Open/60, h.Open%60, h.Close/60, h.Close%60)
+}
+
+// IsOpen reports whether the airport is open at the time
+func (ap *Airport) IsOpen(t time.Time) bool {
+	return ap.Hours.IsOpen(t)
+}
+
+// loadOverrides reads and validates the overrides file, an empty path has no overrides
+func loadOverrides(path string) (*Overrides, error) {
+	if path == "" {
+		return &Overrides{}, nil
+	}
+	o, err := util.LoadConfig[Overrides](path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading overrides file %s: %w", path, err)
+	}
+	if err := o.validate(); err != nil {
+		return nil, fmt.Errorf("invalid overrides file %s: %w", path, err)
+	}
+	return o, nil
+}
+
+// validate checks the overrides without reference to the data files
+func (o *Overrides) validate() error {
+	for i, c := range o.Controllers {
+		if err := c.validate(); err != nil {
+			return fmt.Errorf("controller %d (%s %s %s): %w", i+1, c.Action, c.ICAO, c.Name, err)
+		}
+	}
+	for icao, a := range o.Airports {
+		if a.TransitionAltitude < 0 {
+			return fmt.Errorf("airport %s: negative transition altitude", icao)
+		}
+		if a.Noise.MaxTailwindKt < 0 || a.Noise.MaxCrosswindKt < 0 {
+			return fmt.Errorf("airport %s: negative noise wind limit", icao)
+		}
+		for _, rc := range a.RunwayConfigs {
+			if rc.Arrival == "" || rc.Departure == "" {
+				return fmt.Errorf("airport %s: runway config without arrival and departure runways", icao)
+			}
+		}
+		if a.OpeningHours != "" {
+			if _, err := parseOpeningHours(a.OpeningHours); err != nil {
+				return fmt.Errorf("airport %s: %w", icao, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c ControllerOverride) validate() error {
+	switch c.Action {
+	case "add", "replace", "update", "remove":
+	default:
+		return fmt.Errorf("action %q is not add, replace, update or remove", c.Action)
+	}
+	if _, ok := controllerRoles[strings.ToLower(c.Role)]; !ok {
+		return fmt.Errorf("role %q is not unicom, del, gnd, twr, tracon or ctr", c.Role)
+	}
+	if c.ICAO == "" && c.Name == "" {
+		return fmt.Errorf("no icao or name")
+	}
+	if c.OpeningHours != "" {
+		if _, err := parseOpeningHours(c.OpeningHours); err != nil {
+			return err
+		}
+	}
+	switch c.Action {
+	case "remove":
+		return nil
+	case "update":
+		if len(c.Frequencies) == 0 && c.OpeningHours == "" {
+			return fmt.Errorf("no frequencies or opening hours to update")
+		}
+	default:
+		if len(c.Frequencies) == 0 {
+			return fmt.Errorf("no frequencies")
+		}
+	}
+	for _, f := range c.Frequencies {
+		if f < 118 || f >= 137 {
+			return fmt.Errorf("frequency %.3f is not a VHF airband frequency in MHz", f)
+		}
+	}
+	if c.Position != nil {
+		if len(c.Position) != 2 || !validLatLon(c.Position[0], c.Position[1]) {
+			return fmt.Errorf("position %v is not a latitude and longitude", c.Position)
+		}
+	}
+	if c.Polygon != nil {
+		if len(c.Polygon) < 3 {
+			return fmt.Errorf("polygon of %d points, want at least 3", len(c.Polygon))
+		}
+		for _, p := range c.Polygon {
+			if len(p) != 2 || !validLatLon(p[0], p[1]) {
+				return fmt.Errorf("polygon point %v is not a latitude and longitude", p)
+			}
+		}
+		if c.Ceiling != 0 && c.Ceiling <= c.Floor {
+			return fmt.Errorf("ceiling %.0f is not above floor %.0f", c.Ceiling, c.Floor)
+		}
+	} else if c.Position == nil && c.ICAO == "" && c.Action != "update" {
+		return fmt.Errorf("no position, polygon or airport")
+	}
+	return nil
+}
+
+func validLatLon(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+// freqs returns the frequencies of the override in kHz
+func (c ControllerOverride) freqs() []int {
+	var freqs []int
+	for _, f := range c.Frequencies {
+		freqs = append(freqs, int(math.Round(f*1000)))
+	}
+	return freqs
+}
+
+// update sets the frequencies and opening hours of the override on the controller
+func (c ControllerOverride) update(ctrl *Controller) {
+	if len(c.Frequencies) > 0 {
+		ctrl.Freqs = c.freqs()
+	}
+	if c.OpeningHours != "" {
+		ctrl.Hours, _ = parseOpeningHours(c.OpeningHours) // validated on load
+	}
+}
+
+// matches reports whether the controller is one replaced, updated or removed by the override
+func (c ControllerOverride) matches(ctrl *Controller) bool {
+	if c.ICAO != "" && ctrl.ICAO != c.ICAO {
+		return false
+	}
+	if c.Name != "" && !strings.EqualFold(ctrl.Name, c.Name) {
+		return false
+	}
+	return ctrl.RoleID == controllerRoles[strings.ToLower(c.Role)]
+}
+
+// controller builds the controller added by the override, positioned at the airport if it has no position or
+// polygon
+func (c ControllerOverride) controller(airports map[string]*Airport) (*Controller, error) {
+	ctrl := &Controller{Name: c.Name, ICAO: c.ICAO, RoleID: controllerRoles[strings.ToLower(c.Role)], Freqs: c.freqs()}
+	ctrl.Hours, _ = parseOpeningHours(c.OpeningHours) // validated on load
+
+	switch {
+	case c.Polygon != nil:
+		ceiling := c.Ceiling
+		if ceiling == 0 {
+			ceiling = 99999
+		}
+		as := Airspace{Floor: c.Floor, Ceiling: ceiling}
+		for _, p := range c.Polygon {
+			as.Points = append(as.Points, [2]float64{p[0], p[1]})
+		}
+		as.setBounds()
+		ctrl.Airspaces = []Airspace{as}
+		ctrl.Lat, ctrl.Lon = c.Polygon[0][0], c.Polygon[0][1]
+		if c.Position != nil {
+			ctrl.Lat, ctrl.Lon = c.Position[0], c.Position[1]
+		}
+	case c.Position != nil:
+		ctrl.IsPoint = true
+		ctrl.Lat, ctrl.Lon = c.Position[0], c.Position[1]
+	default:
+		ap, ok := airports[c.ICAO]
+		if !ok {
+			return nil, fmt.Errorf("no position or polygon and airport %s is not loaded", c.ICAO)
+		}
+		ctrl.IsPoint = true
+		ctrl.Lat, ctrl.Lon = ap.Lat, ap.Lon
+	}
+	if ctrl.Name == "" {
+		ctrl.Name = ctrl.ICAO + " " + roleNameMap[ctrl.RoleID]
+	}
+	return ctrl, nil
+}
+
+// apply merges the overrides into the controllers and airports, returning the controllers. Point controllers
+// of an airport are also added to and removed from the airport's controllers. An airport override of an
+// airport that is not loaded, or a replace, update or remove that matches no controller, is logged and ignored.
+func (o *Overrides) apply(controllers []*Controller, airports map[string]*Airport) ([]*Controller, error) {
+	for i, c := range o.Controllers {
+		label := fmt.Sprintf("controller %d (%s %s %s %s)", i+1, c.Action, c.ICAO, c.Role, c.Name)
+
+		if c.Action == "update" {
+			n := 0
+			for _, ctrl := range controllers {
+				if c.matches(ctrl) {
+					c.update(ctrl)
+					n++
+				}
+			}
+			if n == 0 {
+				logger.Log.Warnf("Overrides: %s matches no controller", label)
+			}
+			continue
+		}
+
+		if c.Action != "add" {
+			n := len(controllers)
+			controllers = removeMatching(controllers, c)
+			for _, ap := range airports {
+				ap.Controllers = removeMatching(ap.Controllers, c)
+			}
+			if len(controllers) == n {
+				logger.Log.Warnf("Overrides: %s matches no controller", label)
+			}
+			if c.Action == "remove" {
+				continue
+			}
+		}
+
+		ctrl, err := c.controller(airports)
+		if err != nil {
+			return nil, fmt.Errorf("overrides %s: %w", label, err)
+		}
+		controllers = append(controllers, ctrl)
+		if ap, ok := airports[ctrl.ICAO]; ok && ctrl.IsPoint {
+			ap.Controllers = append(ap.Controllers, ctrl)
+		}
+	}
+
+	for icao, a := range o.Airports {
+		ap, ok := airports[icao]
+		if !ok {
+			logger.Log.Warnf("Overrides: airport %s is not loaded", icao)
+			continue
+		}
+		if err := a.apply(ap); err != nil {
+			return nil, fmt.Errorf("overrides airport %s: %w", icao, err)
+		}
+	}
+	return controllers, nil
+}
+
+// removeMatching returns the controllers without those matched by the override
+func removeMatching(controllers []*Controller, c ControllerOverride) []*Controller {
+	var kept []*Controller
+	for _, ctrl := range controllers {
+		if !c.matches(ctrl) {
+			kept = append(kept, ctrl)
+		}
+	}
+	return kept
+}
+
+// apply sets the airport operations, checking the runways against those of the airport
+func (a AirportOverride) apply(ap *Airport) error {
+	for _, rc := range a.RunwayConfigs {
+		for _, name := range []string{rc.Arrival, rc.Departure} {
+			if _, ok := ap.Runways[name]; !ok {
+				return fmt.Errorf("runway config %s/%s: no runway %s", rc.Arrival, rc.Departure, name)
+			}
+		}
+	}
+	for _, name := range a.Noise.AvoidRunways {
+		if _, ok := ap.Runways[name]; !ok {
+			return fmt.Errorf("avoided runway %s does not exist", name)
+		}
+	}
+
+	if a.TransitionAltitude > 0 {
+		ap.TransAlt = a.TransitionAltitude
+	}
+	ap.RunwayConfigs = a.RunwayConfigs
+	ap.Noise = a.Noise
+	if a.OpeningHours != "" {
+		ap.Hours, _ = parseOpeningHours(a.OpeningHours) // validated on load
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package atc
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadOverridesValidation(t *testing.T) {
+	for _, tc := range []struct {
+		name, yaml, want string
+	}{
+		{"action", "controllers:\n  - {action: move, icao: EGLL, role: twr}\n", "action \"move\""},
+		{"role", "controllers:\n  - {action: remove, icao: EGLL, role: radar}\n", "role \"radar\""},
+		{"frequency", "controllers:\n  - {action: add, icao: EGLL, role: twr, frequencies: [118500]}\n", "VHF airband"},
+		{"no frequency", "controllers:\n  - {action: add, icao: EGLL, role: twr}\n", "no frequencies"},
+		{"polygon", "controllers:\n  - {action: add, icao: EGLL, role: tracon, frequencies: [119.725], polygon: [[51, 0], [52, 0]]}\n", "at least 3"},
+		{"position", "controllers:\n  - {action: add, name: Solent Radar, role: tracon, frequencies: [120.225], position: [95, 0]}\n", "latitude and longitude"},
+		{"hours", "airports:\n  EGLC:\n    opening_hours: \"6am-10pm\"\n", "not of the form"},
+		{"runway config", "airports:\n  EGLL:\n    runway_configs: [{arrival: 27L}]\n", "arrival and departure"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "overrides.yaml")
+			writeResource(t, path, tc.yaml)
+			if _, err := loadOverrides(path); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error = %v; want %q", err, tc.want)
+			}
+		})
+	}
+
+	if o, err := loadOverrides(""); err != nil || len(o.Controllers) != 0 {
+		t.Errorf("no overrides file = %v, %v", o, err)
+	}
+	if o, err := loadOverrides("resources/overrides.example.yaml"); err != nil || len(o.Controllers) != 4 {
+		t.Errorf("example overrides file = %v, %v", o, err)
+	}
+}
+
+func TestOverridesApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	writeResource(t, path, `
+controllers:
+  - action: replace
+    icao: EGLL
+    role: twr
+    frequencies: [118.5, 118.7]
+  - action: remove
+    icao: EGLL
+    role: gnd
+  - action: add
+    icao: EGKK
+    role: tracon
+    name: Gatwick Director
+    frequencies: [126.825]
+    polygon: [[51.35, -0.45], [51.35, 0.10], [50.95, 0.10], [50.95, -0.45]]
+    ceiling: 6000
+  - action: update
+    icao: EGTT
+    role: ctr
+    opening_hours: "0600-2200"
+airports:
+  EGLL:
+    transition_altitude: 7000
+    runway_configs:
+      - {arrival: 27L, departure: 27R}
+    noise: {max_tailwind_kt: 5, avoid_runways: [09L]}
+    opening_hours: "0600-2300"
+  KJFK:
+    transition_altitude: 18000
+`)
+	o, err := loadOverrides(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tower := &Controller{Name: "Heathrow Tower", ICAO: "EGLL", RoleID: 3, Freqs: []int{118500}, IsPoint: true}
+	ground := &Controller{Name: "Heathrow Ground", ICAO: "EGLL", RoleID: 2, Freqs: []int{121900}, IsPoint: true}
+	london := &Controller{Name: "London Control", ICAO: "EGTT", RoleID: 6}
+	egll := &Airport{
+		ICAO: "EGLL", Lat: 51.4775, Lon: -0.4614, TransAlt: 6000,
+		Runways:     map[string]*Runway{"27L": {Name: "27L"}, "27R": {Name: "27R"}, "09L": {Name: "09L"}},
+		Controllers: []*Controller{tower, ground},
+	}
+	airports := map[string]*Airport{"EGLL": egll}
+
+	controllers, err := o.apply([]*Controller{tower, ground, london}, airports)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(controllers) != 3 || controllers[0] != london {
+		t.Fatalf("controllers = %v; want London, the new tower and Gatwick", controllers)
+	}
+	newTower, director := controllers[1], controllers[2]
+	if newTower.RoleID != 3 || len(newTower.Freqs) != 2 || newTower.Freqs[1] != 118700 || !newTower.IsPoint ||
+		newTower.Lat != egll.Lat || newTower.Name != "EGLL Tower" {
+		t.Errorf("replaced tower = %+v", newTower)
+	}
+	if director.IsPoint || len(director.Airspaces) != 1 || director.Airspaces[0].Ceiling != 6000 ||
+		director.Airspaces[0].MaxLat != 51.35 || director.Airspaces[0].MinLon != -0.45 {
+		t.Errorf("added director = %+v", director)
+	}
+	if london.Hours == nil || london.Hours.String() != "0600-2200" || len(london.Freqs) != 0 {
+		t.Errorf("updated London hours = %v, frequencies %v", london.Hours, london.Freqs)
+	}
+	if len(egll.Controllers) != 1 || egll.Controllers[0] != newTower {
+		t.Errorf("EGLL controllers = %v; want the replaced tower only", egll.Controllers)
+	}
+
+	if egll.TransAlt != 7000 || len(egll.RunwayConfigs) != 1 || egll.Noise.MaxTailwindKt != 5 || !egll.Noise.Avoids("09L") {
+		t.Errorf("EGLL operations = %+v", egll)
+	}
+	if egll.IsOpen(time.Date(2026, 1, 1, 5, 59, 0, 0, time.UTC)) || !egll.IsOpen(time.Date(2026, 1, 1, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("EGLL hours %v not applied", egll.Hours)
+	}
+
+	// runways are checked against those of the airport
+	egll.Runways = map[string]*Runway{"09R": {Name: "09R"}}
+	if _, err := o.apply(nil, airports); err == nil || !strings.Contains(err.Error(), "no runway 27L") {
+		t.Errorf("error = %v; want the unknown runway", err)
+	}
+}
+
+func TestOpeningHours(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 1, h, m, 0, 0, time.UTC) }
+
+	night, err := parseOpeningHours("2200-0600")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tc := range []struct {
+		t    time.Time
+		open bool
+	}{
+		{at(21, 59), false},
+		{at(22, 0), true},
+		{at(3, 0), true},
+		{at(6, 0), false},
+	} {
+		if got := night.IsOpen(tc.t); got != tc.open {
+			t.Errorf("%s open at %s = %v; want %v", night, tc.t.Format("1504"), got, tc.open)
+		}
+	}
+
+	var always *OpeningHours
+	if !always.IsOpen(at(3, 0)) {
+		t.Error("nil hours are closed")
+	}
+	if h, err := parseOpeningHours("H24"); h != nil || err != nil {
+		t.Errorf("H24 = %v, %v; want always open", h, err)
+	}
+	if _, err := parseOpeningHours("0600-2460"); err == nil {
+		t.Error("invalid time parsed")
+	}
+}
@@ -0,0 +1,408 @@
+package atc
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/curbz/decimal-niner/internal/pcl"
+	"github.com/curbz/decimal-niner/pkg/geometry"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+// phraseContext is the typed PCL context of a transmission. Providers are shared by all transmissions and the
+// runway and weather of the airport are only looked up when a phrase uses them.
+type phraseContext struct {
+	s         *Service
+	ac        *Aircraft
+	role      string
+	phaseICAO string
+
+	rwy         *Runway
+	rwyResolved bool
+	wx          *Weather
+	pinned      map[string]interface{} // values fixed for the transmission, e.g. readback items
+}
+
+// phraseProvider resolves a PCL variable or macro for the context
+type phraseProvider func(c *phraseContext, args ...string) interface{}
+
+// newPCLContext returns the PCL context for a transmission by the role (PILOT or a facility type) about the aircraft
+func (s *Service) newPCLContext(ac *Aircraft, role string) *phraseContext {
+	return &phraseContext{s: s, ac: ac, role: role, phaseICAO: getAirportICAObyPhaseClass(ac)}
+}
+
+// Resolve returns the value of the PCL variable or macro, implementing pcl.Context
+func (c *phraseContext) Resolve(key string, args []string) (interface{}, bool) {
+	if v, ok := c.pinned[key]; ok {
+		return v, true
+	}
+	p, ok := phraseProviders[key]
+	if !ok {
+		return nil, false
+	}
+	return p(c, args...), true
+}
+
+// pin fixes the value of the key for the rest of the transmission
+func (c *phraseContext) pin(key string, v interface{}) {
+	if c.pinned == nil {
+		c.pinned = make(map[string]interface{})
+	}
+	c.pinned[key] = v
+}
+
+// provider returns the provider of the key bound to the context, or nil if the key is unknown
+func (c *phraseContext) provider(key string) pcl.VariableProvider {
+	if _, ok := phraseProviders[key]; !ok {
+		return nil
+	}
+	return func(args ...string) interface{} {
+		v, _ := c.Resolve(key, args)
+		return v
+	}
+}
+
+// runway returns the runway assigned to the aircraft
+func (c *phraseContext) runway() *Runway {
+	if !c.rwyResolved {
+		c.rwy = c.ac.Flight.AssignedRunway
+		if c.rwy == nil {
+			c.rwy = c.s.GetAirportRunwayByICAO(c.phaseICAO, c.ac.Flight.AssignedRunwayName)
+		}
+		c.rwyResolved = true
+	}
+	return c.rwy
+}
+
+// weather returns the weather reported by the controller, which is that of the airport the aircraft is
+// operating at or to
+func (c *phraseContext) weather() *Weather {
+	if c.wx == nil {
+		c.wx = c.s.GetAirportWeather(c.phaseICAO)
+	}
+	return c.wx
+}
+
+// phraseProviders holds the provider of every PCL variable and macro
+var phraseProviders = map[string]phraseProvider{
+	// --- RAW DATA ($) ---
+	"$VECTORING": func(c *phraseContext, args ...string) interface{} { return c.ac.Flight.Vectoring },
+	"$LVP": func(c *phraseContext, args ...string) interface{} {
+		if ap := c.s.GetAirportByICAO(c.phaseICAO); ap != nil {
+			return ap.LVP
+		}
+		return false
+	},
+	"$ALTITUDE": func(c *phraseContext, args ...string) interface{} {
+		return int(math.Round(c.ac.Flight.Position.Altitude))
+	},
+	"$CALLSIGN": func(c *phraseContext, args ...string) interface{} { return strings.ToLower(c.ac.Flight.Comms.Callsign) },
+	"$FACILITY": func(c *phraseContext, args ...string) interface{} {
+		if c.ac.Flight.Comms.Controller != nil {
+			return c.ac.Flight.Comms.Controller.Name
+		} else {
+			util.LogWarnWithLabel(c.ac.Registration, "no controller assigned for facility resolution")
+			return ""
+		}
+	},
+	"$SQUAWK": func(c *phraseContext, args ...string) interface{} { return c.ac.Flight.Squawk },
+	"$REASON": func(c *phraseContext, args ...string) interface{} {
+		if c.ac.Flight.WeatherReason == "" && c.ac.Flight.Diversion != nil {
+			return c.ac.Flight.Diversion.Reason
+		}
+		return c.ac.Flight.WeatherReason
+	},
+	"$EMERGENCY": func(c *phraseContext, args ...string) interface{} {
+		if c.ac.Flight.Emergency == nil {
+			return EmergencyNone.String()
+		}
+		return c.ac.Flight.Emergency.Type.String()
+	},
+	"$HEADING": func(c *phraseContext, args ...string) interface{} {
+		return fmt.Sprintf("%03d", int(math.Round(geometry.NormalizeHeading(c.ac.Flight.Position.Heading))))
+	},
+	"$ATC_HEADING": func(c *phraseContext, args ...string) interface{} {
+		return fmt.Sprintf("%03d", int(math.Round(geometry.NormalizeHeading(c.ac.Flight.TargetHeading))))
+	},
+	"$RUNWAY":        func(c *phraseContext, args ...string) interface{} { return c.ac.Flight.AssignedRunwayName },
+	"$DESTINATION":   func(c *phraseContext, args ...string) interface{} { return c.ac.Flight.Destination },
+	"$BARO_SEALEVEL": func(c *phraseContext, args ...string) interface{} { return int(math.Round(c.weather().Baro.Sealevel)) },
+	"$BARO_AIRCRAFT": func(c *phraseContext, args ...string) interface{} { return int(math.Round(c.s.Weather.Baro.Flight)) },
+	"$WIND_SPEED":    func(c *phraseContext, args ...string) interface{} { return c.weather().Wind.Speed },
+	"$WIND_SHEAR":    func(c *phraseContext, args ...string) interface{} { return c.weather().Wind.Shear },
+	"$TURBULENCE":    func(c *phraseContext, args ...string) interface{} { return c.weather().Turbulence },
+	"$PARKING":       func(c *phraseContext, args ...string) interface{} { return c.ac.Flight.AssignedParkingName },
+	"$APPROACH_TYPE": func(c *phraseContext, args ...string) interface{} {
+		rwy := c.runway()
+		if rwy != nil {
+			util.LogDebugWithLabel(c.ac.Registration, "controller says highest precision approach is %s", rwy.HighestPrecisionApproach)
+			return rwy.HighestPrecisionApproach
+		} else {
+			return ""
+		}
+	},
+	"$HOLD_FIX_NAME": func(c *phraseContext, args ...string) interface{} {
+		holding := c.ac.Flight.Holding
+		if holding == nil {
+			return ""
+		}
+		holdfix := holding.AssignedHold
+		if holdfix == nil {
+			return ""
+		} else {
+			util.LogDebugWithLabel(c.ac.Registration, "controller says nearest hold is %s", holdfix.FullName)
+			return holdfix.FullName
+		}
+	},
+	"$HOLD_FIX_IDENT": func(c *phraseContext, args ...string) interface{} {
+		holding := c.ac.Flight.Holding
+		if holding == nil {
+			return ""
+		}
+		holdfix := holding.AssignedHold
+		if holdfix == nil {
+			return ""
+		} else {
+			util.LogDebugWithLabel(c.ac.Registration, "controller says nearest hold identifier is %s", holdfix.Ident)
+			return holdfix.Ident
+		}
+	},
+	"$MA_HEADING": func(c *phraseContext, args ...string) interface{} {
+		rwy := c.runway()
+		if rwy != nil {
+			util.LogDebugWithLabel(c.ac.Registration, "controller says missed approach heading is %d", rwy.MAHeading)
+			return rwy.MAHeading
+		} else {
+			return 0
+		}
+	},
+	"$MA_ALTITUDE": func(c *phraseContext, args ...string) interface{} {
+		rwy := c.runway()
+		if rwy != nil {
+			util.LogDebugWithLabel(c.ac.Registration, "controller says missed approach altitude is %d", rwy.MAalt)
+			return rwy.MAalt
+		} else {
+			return 0
+		}
+	},
+	"$MA_FIX": func(c *phraseContext, args ...string) interface{} {
+		rwy := c.runway()
+		if rwy != nil {
+			util.LogDebugWithLabel(c.ac.Registration, "controller says missed approach fix is %s", rwy.MAFix)
+			return rwy.MAFix
+		} else {
+			return ""
+		}
+	},
+	"$FA_ALTITUDE": func(c *phraseContext, args ...string) interface{} {
+		rwy := c.runway()
+		if rwy != nil {
+			util.LogDebugWithLabel(c.ac.Registration, "controller says final fix approach altitude is %d", rwy.FAFalt)
+			return rwy.FAFalt
+		} else {
+			return 0
+		}
+	},
+
+	// --- FORMATTED MACROS (@) ---
+	// --- RUNWAY & TAXI ---
+	"@RUNWAY": func(c *phraseContext, args ...string) interface{} {
+		return pcl.Value{Data: c.ac.Flight.AssignedRunwayName, Text: translateRunwayLanguage(c.ac.Flight.AssignedRunwayName, c.ac.Flight.Comms.Language)}
+	},
+	"@RUNWAY_HOLD": func(c *phraseContext, args ...string) interface{} {
+		return formatRunwayHold(c.ac)
+	},
+	"@RUNWAY_EXIT": func(c *phraseContext, args ...string) interface{} {
+		return formatRunwayExit(c.ac)
+	},
+	"@TAXIPATH": func(c *phraseContext, args ...string) interface{} {
+		return collateTaxipath(c.ac)
+	},
+	"@CLOSURES": func(c *phraseContext, args ...string) interface{} {
+		if c.phaseICAO == "" {
+			return ""
+		}
+		return formatClosures(routeClosures(c.ac, c.s.ActiveClosures(c.phaseICAO)), c.ac.Flight.Comms.Language)
+	},
+	"@PARKING": func(c *phraseContext, args ...string) interface{} {
+		var icao string
+		if c.ac.Flight.Comms.Controller == nil {
+			icao = c.s.GetClosestAirport(c.ac.Flight.Position.Lat, c.ac.Flight.Position.Long, 10000)
+		} else {
+			icao = c.ac.Flight.Comms.Controller.ICAO
+		}
+		return formatParking(c.ac.Flight.AssignedParkingName, isNorthAmerica(icao))
+	},
+
+	// --- DEPARTURE & ARRIVAL ---
+	"@SID": func(c *phraseContext, args ...string) interface{} {
+		includeClimbAltitude := true //default
+		transLevel := 0
+		if len(args) > 0 {
+			includeClimbAltitude, _ = strconv.ParseBool(args[0])
+			transLevel = c.s.getAircraftTransitionLevel(c.ac)
+		}
+		// transition Level is not strictly required for SID formatting but is required if we are including altitude
+		return formatSID(c.ac, includeClimbAltitude, transLevel, c.s.usesMetricAltitudes(c.ac))
+	},
+	"@STAR": func(c *phraseContext, args ...string) interface{} {
+		includeDescentAltitude := true //default
+		transLevel := 0
+		if len(args) > 0 {
+			includeDescentAltitude, _ = strconv.ParseBool(args[0])
+			transLevel = c.s.getAircraftTransitionLevel(c.ac)
+		}
+		// transition Level is not strictly required for STAR formatting but is required if we are including altitude
+		return formatSTAR(c.ac, includeDescentAltitude, transLevel, c.s.usesMetricAltitudes(c.ac))
+	},
+	"@APPROACH_TYPE": func(c *phraseContext, args ...string) interface{} {
+		rwy := c.runway()
+		res := ""
+		if rwy != nil {
+			util.LogDebugWithLabel(c.ac.Registration, "controller says highest precision approach is %s", rwy.HighestPrecisionApproach)
+			res = rwy.HighestPrecisionApproach
+		}
+		return strings.TrimSpace(res + " approach")
+	},
+	"@DESTINATION": func(c *phraseContext, args ...string) interface{} {
+		if c.ac.Flight.Destination == "" {
+			return "as filed"
+		}
+		return formatAirportName(c.ac.Flight.Destination, c.s.GetAirports())
+	},
+	"@ATC_HEADING": func(c *phraseContext, args ...string) interface{} {
+		// calculate whether the heading is a left or right turn from the current heading to the target heading
+		// 1. calculate the shortest signed difference (-180 to +180)
+		turnDiff := geometry.NormalizeDiffDegrees(c.ac.Flight.TargetHeading, c.ac.Flight.Position.Heading)
+		// 2. determine turn direction
+		turnDirection := "right"
+		if turnDiff < 0 {
+			turnDirection = "left"
+		}
+		heading := int(math.Round(geometry.NormalizeHeading(c.ac.Flight.TargetHeading)))
+		return pcl.Value{Data: heading, Text: fmt.Sprintf("turn %s heading %03d", turnDirection, heading)}
+	},
+	// --- MISSED APPROACH LOGIC ---
+	"@MA_HEADING": func(c *phraseContext, args ...string) interface{} {
+		rwy := c.runway()
+		if rwy != nil && rwy.MAHeading > 0 {
+			r := fmt.Sprintf("heading %d", rwy.MAHeading)
+			util.LogDebugWithLabel(c.ac.Registration, "controller says missed approach heading is %s", r)
+			return r
+		}
+		return "runway heading"
+	},
+	"@MA_ALTITUDE": func(c *phraseContext, args ...string) interface{} {
+		rwy := c.runway()
+		if rwy != nil && rwy.MAalt > 0 {
+			transLevel := c.s.getAircraftTransitionLevel(c.ac)
+			r := formatAltitude(float64(rwy.MAalt), transLevel, c.ac.Flight.Phase, c.s.usesMetricAltitudes(c.ac))
+			util.LogDebugWithLabel(c.ac.Registration, "controller says missed approach altitude is %s", r)
+			return r
+		}
+		return "missed approach altitude"
+	},
+	"@MA_FIX": func(c *phraseContext, args ...string) interface{} {
+		rwy := c.runway()
+		var r string
+		if rwy != nil && rwy.MAFix != "" {
+			r = rwy.MAFix
+			util.LogDebugWithLabel(c.ac.Registration, "controller says missed approach fix/hold is %s", r)
+		} else {
+			r = "published hold"
+		}
+		return r
+	},
+
+	// --- ALTITUDE & BARO ---
+	"@ALTITUDE": func(c *phraseContext, args ...string) interface{} {
+		transitionLevel := c.s.getAircraftTransitionLevel(c.ac)
+		return formatAltitude(c.ac.Flight.Position.Altitude, transitionLevel, c.ac.Flight.Phase, c.s.usesMetricAltitudes(c.ac))
+	},
+	"@ALT_CLEARANCE": func(c *phraseContext, args ...string) interface{} {
+		transLevel := c.s.getAircraftTransitionLevel(c.ac)
+		clearance := determineAltClearance(c.ac, c.s.GetAirportByICAO(c.phaseICAO), c.runway())
+		term := generateAltClearance(c.ac.Flight.Position.Altitude, transLevel, clearance, c.ac.Flight.Phase, c.s.usesMetricAltitudes(c.ac))
+		return pcl.Value{Data: clearance, Text: applyAltClearanceWording(term, getPhraseologyStandard(c.ac))}
+	},
+	"@BARO": func(c *phraseContext, args ...string) interface{} {
+		var icao string
+		pascals := c.weather().Baro.Sealevel
+		if c.ac.Flight.Comms.Controller == nil {
+			pascals = 101325 // standard pressure as default if no controller assigned to avoid errors in baro formatting
+			icao = c.s.GetClosestAirport(c.ac.Flight.Position.Lat, c.ac.Flight.Position.Long, 10000)
+		} else {
+			icao = c.ac.Flight.Comms.Controller.ICAO
+		}
+		r := formatBaro(pascals, isNorthAmerica(icao))
+		util.LogDebugWithLabel(c.ac.Registration, "controller says barometric pressure is %s", r)
+		return r
+	},
+
+	// --- WEATHER & CONTROLLER ---
+	"@WIND":       func(c *phraseContext, args ...string) interface{} { return c.s.formatWind(c.weather()) },
+	"@SHEAR":      func(c *phraseContext, args ...string) interface{} { return c.s.formatWindShear(c.weather()) },
+	"@TURBULENCE": func(c *phraseContext, args ...string) interface{} { return c.s.formatTurbulence(c.weather(), c.role) },
+	"@HANDOFF": func(c *phraseContext, args ...string) interface{} {
+		phrase, freq := c.s.generateHandoff(c.ac)
+		return pcl.Value{Data: freq, Text: phrase}
+	},
+	"@VALEDICTION": func(c *phraseContext, args ...string) interface{} {
+		factor := 5 //default
+		if len(args) > 0 {
+			factor, err := strconv.Atoi(args[0])
+			if err != nil || factor < 1 {
+				factor = 1
+			}
+		}
+		return c.s.generateValediction(factor)
+	},
+	// --- EMERGENCIES ---
+	"@DISTRESS": func(c *phraseContext, args ...string) interface{} {
+		ack := len(args) > 0 && args[0] == "ack"
+		return formatDistress(c.ac.Flight.Emergency, ack)
+	},
+	"@EMERGENCY": func(c *phraseContext, args ...string) interface{} {
+		return formatEmergencyNature(c.ac.Flight.Emergency)
+	},
+	"@DIVERT": func(c *phraseContext, args ...string) interface{} { return formatDivert(c.ac, c.s.GetAirports()) },
+	"@HOLD_FIX": func(c *phraseContext, args ...string) interface{} {
+		var r string
+		if c.ac.Flight.Comms.Controller == nil {
+			r = "published hold"
+		} else {
+			holding := c.ac.Flight.Holding
+			if holding == nil {
+				r = "published hold"
+			} else {
+				holdfix := holding.AssignedHold
+				if holdfix != nil {
+					if holdfix.FullName != "" {
+						r = holdfix.FullName
+					} else {
+						r = holdfix.Ident
+					}
+					util.LogDebugWithLabel(c.ac.Registration, "controller says hold fix is %s", r)
+				} else {
+					r = "published hold"
+				}
+			}
+		}
+		return r
+	},
+	"@HOLD_PATTERN": func(c *phraseContext, args ...string) interface{} {
+		holding := c.ac.Flight.Holding
+		if holding == nil {
+			return formatHoldPattern(nil, 0)
+		}
+		alt := holding.TargetHoldAlt
+		if alt == 0 {
+			alt = c.ac.Flight.Position.Altitude
+		}
+		r := formatHoldPattern(holding.AssignedHold, alt)
+		util.LogDebugWithLabel(c.ac.Registration, "controller says holding pattern is %s", r)
+		return r
+	},
+}
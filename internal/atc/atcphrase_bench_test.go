@@ -0,0 +1,48 @@
+package atc
+
+import (
+	"testing"
+
+	"github.com/curbz/decimal-niner/internal/flightclass"
+	"github.com/curbz/decimal-niner/internal/flightphase"
+	"github.com/curbz/decimal-niner/internal/pcl"
+)
+
+func BenchmarkPhraseRendering(b *testing.B) {
+	s := &Service{
+		Config: &config{},
+		Weather: &Weather{
+			Baro: &Baro{Sealevel: 101325, Flight: 101325},
+			Wind: &Wind{},
+		},
+		Airports: map[string]*Airport{
+			"EGLL": {ICAO: "EGLL", Runways: map[string]*Runway{"27L": {Name: "27L"}, "27R": {Name: "27R"}}},
+		},
+	}
+	ac := &Aircraft{
+		Registration: "G-TEST",
+		Flight: Flight{
+			Origin:             "EGLL",
+			AssignedRunwayName: "27L",
+			TargetHeading:      270,
+			Squawk:             "4721",
+			Comms:              Comms{Callsign: "SPEEDBIRD 1", Controller: &Controller{Name: "Heathrow Tower"}},
+			Phase:              flightphase.Phase{Current: flightphase.Climbout.Index(), Class: flightclass.Departing},
+		},
+	}
+
+	// a typical departure clearance with logic, exercising the template cache and lazy runway lookup
+	phrase := "{$CALLSIGN}, {$FACILITY}, {WHEN $SQUAWK GT 0 SAY `squawk {$SQUAWK}, `}runway {@RUNWAY} cleared for takeoff, {@ATC_HEADING}."
+	if _, err := compilePhrase(phrase); err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, _, err := pcl.ResolvePhrase(phrase, s.newPCLContext(ac, "Tower")); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package atc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	d9 "github.com/curbz/decimal-niner/internal"
+)
+
+// PhraseIssue is a problem found in a phrase file by LintPhrases
+type PhraseIssue struct {
+	File     string
+	PhaseKey string
+	Exchange int    // 1-based index of the exchange, 0 if the issue is with the phase key
+	Side     string // "pilot", "atc" or "readback", empty if the issue is with the phase key or exchange
+	Warning  bool   // warnings are reported but do not fail the lint
+	Msg      string
+}
+
+func (i PhraseIssue) String() string {
+	level := "error"
+	if i.Warning {
+		level = "warning"
+	}
+	loc := i.File
+	if i.PhaseKey != "" {
+		loc += " [" + i.PhaseKey + "]"
+	}
+	if i.Exchange > 0 {
+		loc += fmt.Sprintf(" exchange %d", i.Exchange)
+	}
+	if i.Side != "" {
+		loc += " (" + i.Side + ")"
+	}
+	return fmt.Sprintf("%s: %s: %s", loc, level, i.Msg)
+}
+
+// subPhaseKeys are the phase keys selected by startComms in addition to those of atcFacilityByPhaseMap
+var subPhaseKeys = []string{"cruise_tod", "go_around_weather", "holding_weather", "emergency", "emergency_radio_failure", "diversion"}
+
+// LintPhrases checks phrases.json and phrases_unicom.json, and any regional phraseology or native language
+// phrase files, in the resources directory. Unlike loading the phrases at startup, every problem is reported
+// rather than only the first.
+func LintPhrases() ([]PhraseIssue, error) {
+	files := []struct {
+		name     string
+		complete bool // the file must define every phase key
+		unicom   bool
+	}{
+		{"phrases.json", true, false},
+		{"phrases_unicom.json", true, true},
+	}
+	var optional []string
+	for standard := range phraseologyPrefixes {
+		optional = append(optional, "phrases_"+standard+".json", "phrases_unicom_"+standard+".json")
+	}
+	for _, lang := range countryLanguages {
+		optional = append(optional, "phrases_"+lang+".json")
+	}
+	sort.Strings(optional)
+	for i, name := range optional {
+		if i > 0 && name == optional[i-1] {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(d9.Resources, name)); err == nil {
+			files = append(files, struct {
+				name     string
+				complete bool
+				unicom   bool
+			}{name, false, strings.HasPrefix(name, "phrases_unicom_")})
+		}
+	}
+
+	var issues []PhraseIssue
+	for _, f := range files {
+		fileIssues, err := lintPhraseFile(filepath.Join(d9.Resources, f.name), f.complete, f.unicom)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, fileIssues...)
+	}
+	return issues, nil
+}
+
+// lintPhraseFile checks a single phrase file. When complete is true the file must define every phase key of
+// atcFacilityByPhaseMap, which is a warning for Unicom files as some phases have no Unicom call.
+func lintPhraseFile(path string, complete, unicom bool) ([]PhraseIssue, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string][]Exchange
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", path, err)
+	}
+
+	file := filepath.Base(path)
+	var issues []PhraseIssue
+
+	knownKeys := make(map[string]bool)
+	for _, pf := range atcFacilityByPhaseMap {
+		knownKeys[pf.atcPhase] = true
+	}
+	for _, key := range subPhaseKeys {
+		knownKeys[key] = true
+	}
+
+	if complete {
+		var missing []string
+		for _, pf := range atcFacilityByPhaseMap {
+			if len(data[pf.atcPhase]) == 0 {
+				missing = append(missing, pf.atcPhase)
+			}
+		}
+		sort.Strings(missing)
+		for _, key := range missing {
+			issues = append(issues, PhraseIssue{File: file, PhaseKey: key, Warning: unicom,
+				Msg: "no exchanges for the phase key, aircraft in this phase will not transmit"})
+		}
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !knownKeys[key] {
+			issues = append(issues, PhraseIssue{File: file, PhaseKey: key, Warning: true,
+				Msg: "phase key is never selected for transmission"})
+		}
+		for i, ex := range data[key] {
+			issue := PhraseIssue{File: file, PhaseKey: key, Exchange: i + 1}
+			if ex.Initiator != "pilot" && ex.Initiator != "atc" {
+				issue.Msg = fmt.Sprintf("invalid initiator '%s'", ex.Initiator)
+				issues = append(issues, issue)
+			}
+			if _, err := compilePhrase(ex.Pilot); err != nil {
+				issue.Side, issue.Msg = "pilot", err.Error()
+				issues = append(issues, issue)
+			}
+			_, atcErr := compilePhrase(ex.ATC)
+			if atcErr != nil {
+				issue.Side, issue.Msg = "atc", atcErr.Error()
+				issues = append(issues, issue)
+			}
+			// a readback of a phrase which does not compile is not checked as it would repeat the error
+			if atcErr == nil && readsBack(ex, unicom) {
+				issue.Side = "readback"
+				for _, msg := range lintReadback(ex.ATC) {
+					issue.Msg = msg
+					issues = append(issues, issue)
+				}
+			}
+		}
+	}
+	return issues, nil
+}
+
+// readsBack returns true if the pilot reads back the ATC phrase of the exchange, see startComms
+func readsBack(ex Exchange, unicom bool) bool {
+	if ex.ATC == "" || strings.Contains(ex.ATC, "{NOREADBACK}") {
+		return false
+	}
+	if ex.Initiator == "pilot" {
+		return !unicom
+	}
+	return ex.Pilot == ""
+}
+
+// lintReadback returns the ways in which autoReadback will mangle the ATC phrase when the pilot reads it back
+func lintReadback(atcPhrase string) []string {
+	var msgs []string
+
+	opening, closing := strings.Count(atcPhrase, "["), strings.Count(atcPhrase, "]")
+	if opening != closing {
+		msgs = append(msgs, fmt.Sprintf("unbalanced square brackets (%d '[' and %d ']'), text will be read back that should not be", opening, closing))
+	}
+
+	trimmed := strings.TrimSpace(atcPhrase)
+	if !strings.HasPrefix(trimmed, "{$CALLSIGN}") && !strings.HasPrefix(trimmed, "$CALLSIGN") {
+		msgs = append(msgs, "phrase does not start with {$CALLSIGN}, the readback will repeat the start of the phrase")
+	} else if trimmed != atcPhrase {
+		msgs = append(msgs, "leading whitespace stops {$CALLSIGN} being moved to the end of the readback")
+	}
+
+	readback := autoReadback(atcPhrase)
+	if _, err := compilePhrase(readback); err != nil {
+		msgs = append(msgs, fmt.Sprintf("readback %q does not compile: %v", readback, err))
+		return msgs
+	}
+
+	body := strings.TrimSpace(strings.TrimSuffix(readback, " {$CALLSIGN}"))
+	switch {
+	case body == "":
+		msgs = append(msgs, "readback is only the callsign, use {NOREADBACK} if none is wanted")
+	case strings.HasPrefix(body, ",") || strings.HasPrefix(body, "."):
+		msgs = append(msgs, fmt.Sprintf("readback %q starts with punctuation", readback))
+	case strings.HasSuffix(body, ".") || strings.HasSuffix(body, ","):
+		msgs = append(msgs, fmt.Sprintf("readback %q has punctuation before the callsign", readback))
+	}
+	return msgs
+}
@@ -0,0 +1,133 @@
+package atc
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	d9 "github.com/curbz/decimal-niner/internal"
+)
+
+func TestLintReadback(t *testing.T) {
+	tests := []struct {
+		name   string
+		phrase string
+		want   string // substring of the expected message, empty for none
+	}{
+		{"clean", "{$CALLSIGN}, cleared to land runway {@RUNWAY}.", ""},
+		{"bracketed text removed", "{$CALLSIGN}, cleared to land runway {@RUNWAY}[, wind {@WIND}].", ""},
+		{"callsign not first", "{$FACILITY}, {$CALLSIGN}, cleared to land.", "does not start with {$CALLSIGN}"},
+		{"unbalanced brackets", "{$CALLSIGN}, [contact ground.", "unbalanced square brackets"},
+		{"only callsign", "{$CALLSIGN}, [roger].", "readback is only the callsign"},
+		{"punctuation before callsign", "{$CALLSIGN}, cleared to land. [wind {@WIND}]", "punctuation before the callsign"},
+		{"brackets break logic block", "{$CALLSIGN} [{WHEN $LVP SAY `lvp`]}", "does not compile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs := lintReadback(tt.phrase)
+			if tt.want == "" {
+				if len(msgs) > 0 {
+					t.Errorf("lintReadback(%q) = %v; want no messages", tt.phrase, msgs)
+				}
+				return
+			}
+			if !strings.Contains(strings.Join(msgs, "\n"), tt.want) {
+				t.Errorf("lintReadback(%q) = %v; want message containing %q", tt.phrase, msgs, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintPhraseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "phrases.json")
+	phrases := `{
+		"takeoff": [
+			{ "initiator": "pilot", "pilot": "{$FACILITY}, {$CALLSIGN} ready", "atc": "{$CALLSIGN} cleared for takeoff {@RUNWAY_LENGTH}" },
+			{ "initiator": "tower", "atc": "{$CALLSIGN} line up {NOREADBACK}" }
+		],
+		"departed": [
+			{ "initiator": "atc", "atc": "{$CALLSIGN} contact departure {WHEN $ALTITUDE GT 1000 ` + "`now`" + `}" }
+		],
+		"climbout": [
+			{ "initiator": "atc", "atc": "{$CALLSIGN} {WHEN $NOPE EQ 1 SAY climb}" },
+			{ "initiator": "atc", "atc": "{LET $X = $NOPE}{$CALLSIGN}" },
+			{ "initiator": "atc", "atc": "{$CALLSIGN} passing {SAY ROUND($NOPE, 100)}" }
+		]
+	}`
+	if err := os.WriteFile(path, []byte(phrases), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := lintPhraseFile(path, true, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make([]string, len(issues))
+	for i, issue := range issues {
+		got[i] = issue.String()
+	}
+	all := strings.Join(got, "\n")
+
+	for _, want := range []string{
+		"phrases.json [startup]: error: no exchanges for the phase key",
+		"phrases.json [departed]: warning: phase key is never selected",
+		"phrases.json [departed] exchange 1 (atc): error: PCL logic error: WHEN block missing mandatory SAY",
+		"phrases.json [takeoff] exchange 1 (atc): error: unknown PCL tag: @RUNWAY_LENGTH",
+		"phrases.json [takeoff] exchange 2: error: invalid initiator 'tower'",
+		"phrases.json [climbout] exchange 1 (atc): error: unknown PCL tag: $NOPE",
+		"phrases.json [climbout] exchange 2 (atc): error: unknown PCL tag: $NOPE",
+		"phrases.json [climbout] exchange 3 (atc): error: unknown PCL tag: $NOPE",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("missing issue %q in:\n%s", want, all)
+		}
+	}
+	if strings.Contains(all, "[takeoff]: error") {
+		t.Errorf("takeoff phase key reported as missing:\n%s", all)
+	}
+
+	// missing phase keys are only warnings for Unicom phrases
+	issues, _ = lintPhraseFile(path, true, true)
+	for _, issue := range issues {
+		if issue.PhaseKey == "startup" && !issue.Warning {
+			t.Errorf("missing Unicom phase key reported as an error: %s", issue)
+		}
+	}
+}
+
+func TestLintPhrasesResources(t *testing.T) {
+	d9.Resources = "resources"
+	issues, err := LintPhrases()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, issue := range issues {
+		if !issue.Warning {
+			t.Errorf("%s", issue)
+		}
+	}
+}
+
+func TestRenderPhrases(t *testing.T) {
+	d9.Resources = "resources"
+	var buf bytes.Buffer
+	if err := RenderPhrases("config.yaml", &buf, PhraseRenderOptions{PhaseKey: "taxi_out"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, "=== departing EGLL: taxi_out (Heathrow Ground, ICAO phraseology) ===") {
+		t.Errorf("missing taxi_out header in:\n%s", out)
+	}
+	if strings.Contains(out, "=== arriving") {
+		t.Errorf("phase key filter not applied:\n%s", out)
+	}
+	if strings.Contains(out, "PCL error") || strings.Contains(out, "{") {
+		t.Errorf("unresolved phrase in:\n%s", out)
+	}
+	if !strings.Contains(out, "runway two seven right") {
+		t.Errorf("fixture runway not rendered in:\n%s", out)
+	}
+}
@@ -0,0 +1,67 @@
+package atc
+
+import (
+	"strings"
+)
+
+// phraseology standards. The base phrase files are ICAO (Doc 4444 / UK CAP413) phraseology, other standards
+// override them per phase key with phrases_<standard>.json and phrases_unicom_<standard>.json resource files
+const (
+	PhraseologyICAO = "icao"
+	PhraseologyFAA  = "faa" // FAA JO 7110.65
+)
+
+// phraseologyPrefixes maps each regional phraseology standard to the ICAO prefixes of the controllers using it
+var phraseologyPrefixes = map[string][]string{
+	PhraseologyFAA: {"K", "PA", "PG", "PH", "TI", "TJ"},
+}
+
+// phraseologyStandard returns the phraseology standard used by the controller with the ICAO code, which is the
+// standard with the longest matching prefix or ICAO if none match
+func phraseologyStandard(icao string) string {
+	standard := PhraseologyICAO
+	bestLen := 0
+	for std, prefixes := range phraseologyPrefixes {
+		for _, p := range prefixes {
+			if len(p) > bestLen && strings.HasPrefix(icao, p) {
+				standard, bestLen = std, len(p)
+			}
+		}
+	}
+	return standard
+}
+
+// getPhraseologyStandard returns the phraseology standard of the aircraft's controller
+func getPhraseologyStandard(ac *Aircraft) string {
+	if ac.Flight.Comms.Controller == nil {
+		return PhraseologyICAO
+	}
+	return phraseologyStandard(ac.Flight.Comms.Controller.ICAO)
+}
+
+// getExchanges returns the exchanges for the phase key in the phraseology standard, falling back to the base
+// phrase set if the standard does not override the phase key
+func (pc *PhraseClasses) getExchanges(phraseKey, standard string, unicom bool) []Exchange {
+	base, regional := pc.phrases, pc.regional
+	if unicom {
+		base, regional = pc.phrasesUnicom, pc.regionalUnicom
+	}
+	if exchanges := regional[standard][phraseKey]; len(exchanges) > 0 {
+		return exchanges
+	}
+	return base[phraseKey]
+}
+
+// applyAltClearanceWording converts an altitude clearance to the wording of the phraseology standard, e.g.
+// "climb to" becomes "climb and maintain" for FAA controllers
+func applyAltClearanceWording(term, standard string) string {
+	if standard != PhraseologyFAA {
+		return term
+	}
+	for _, instruction := range []string{"climb", "descend"} {
+		if strings.HasPrefix(term, instruction+" to ") {
+			return instruction + " and maintain " + strings.TrimPrefix(term, instruction+" to ")
+		}
+	}
+	return term
+}
@@ -0,0 +1,100 @@
+package atc
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestPhraseologyStandard(t *testing.T) {
+	for icao, want := range map[string]string{
+		"KJFK": PhraseologyFAA,
+		"PHNL": PhraseologyFAA,
+		"TJSJ": PhraseologyFAA,
+		"EGLL": PhraseologyICAO,
+		"PKMJ": PhraseologyICAO,
+		"":     PhraseologyICAO,
+	} {
+		if got := phraseologyStandard(icao); got != want {
+			t.Errorf("phraseologyStandard(%q) = %s; want %s", icao, got, want)
+		}
+	}
+}
+
+func TestGetExchangesFallback(t *testing.T) {
+	pc := &PhraseClasses{
+		phrases: map[string][]Exchange{
+			"takeoff": {{ID: "base_takeoff"}},
+			"final":   {{ID: "base_final"}},
+		},
+		phrasesUnicom: map[string][]Exchange{"final": {{ID: "unicom_final"}}},
+		regional: map[string]map[string][]Exchange{
+			PhraseologyFAA: {"takeoff": {{ID: "faa_takeoff"}}},
+		},
+	}
+
+	if got := pc.getExchanges("takeoff", PhraseologyFAA, false); len(got) != 1 || got[0].ID != "faa_takeoff" {
+		t.Errorf("expected FAA override for takeoff, got %+v", got)
+	}
+	if got := pc.getExchanges("final", PhraseologyFAA, false); len(got) != 1 || got[0].ID != "base_final" {
+		t.Errorf("expected base fallback for final, got %+v", got)
+	}
+	if got := pc.getExchanges("takeoff", PhraseologyICAO, false); len(got) != 1 || got[0].ID != "base_takeoff" {
+		t.Errorf("expected base takeoff for ICAO, got %+v", got)
+	}
+	if got := pc.getExchanges("final", PhraseologyFAA, true); len(got) != 1 || got[0].ID != "unicom_final" {
+		t.Errorf("expected base unicom fallback, got %+v", got)
+	}
+}
+
+func TestApplyAltClearanceWording(t *testing.T) {
+	tests := []struct {
+		term, standard, want string
+	}{
+		{"climb to flight level 240", PhraseologyFAA, "climb and maintain flight level 240"},
+		{"descend to 5 thousand", PhraseologyFAA, "descend and maintain 5 thousand"},
+		{"maintain 5 thousand", PhraseologyFAA, "maintain 5 thousand"},
+		{"climb to flight level 240", PhraseologyICAO, "climb to flight level 240"},
+	}
+	for _, tt := range tests {
+		if got := applyAltClearanceWording(tt.term, tt.standard); got != tt.want {
+			t.Errorf("applyAltClearanceWording(%q, %s) = %q; want %q", tt.term, tt.standard, got, tt.want)
+		}
+	}
+}
+
+func TestRegionalPhraseFiles(t *testing.T) {
+	base := loadPhraseFileForTest(t, "resources/phrases.json")
+	for standard := range phraseologyPrefixes {
+		path := "resources/phrases_" + standard + ".json"
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		for key, exchanges := range loadPhraseFileForTest(t, path) {
+			if _, ok := base[key]; !ok {
+				t.Errorf("%s: phase key %s does not override a base phase key", path, key)
+			}
+			for i, ex := range exchanges {
+				if _, err := compilePhrase(ex.Pilot); err != nil {
+					t.Errorf("%s [%s] exchange %d (Pilot): %v", path, key, i+1, err)
+				}
+				if _, err := compilePhrase(ex.ATC); err != nil {
+					t.Errorf("%s [%s] exchange %d (ATC): %v", path, key, i+1, err)
+				}
+			}
+		}
+	}
+}
+
+func loadPhraseFileForTest(t *testing.T, path string) map[string][]Exchange {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	var phrases map[string][]Exchange
+	if err := json.Unmarshal(data, &phrases); err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+	return phrases
+}
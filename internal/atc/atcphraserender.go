@@ -0,0 +1,319 @@
+package atc
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	d9 "github.com/curbz/decimal-niner/internal"
+	"github.com/curbz/decimal-niner/internal/flightclass"
+	"github.com/curbz/decimal-niner/internal/flightphase"
+	"github.com/curbz/decimal-niner/internal/pcl"
+	"github.com/curbz/decimal-niner/internal/simdata"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+// PhraseRenderOptions selects the exchanges printed by RenderPhrases
+type PhraseRenderOptions struct {
+	PhaseKey string // only render exchanges for the phase key, every phase key if empty
+	Unicom   bool   // render the Unicom phrases instead of those of controlled airports
+}
+
+// phraseFixture is a synthetic flight state used to render the exchanges of its phase keys
+type phraseFixture struct {
+	name  string
+	class flightclass.PhaseClass
+	keys  []string
+}
+
+// phraseFixtures cover every phase key that startComms selects. All fixtures are a flight from EGLL to KJFK.
+var phraseFixtures = []phraseFixture{
+	{"departing EGLL", flightclass.Departing, []string{"pre_flight_parked", "startup", "taxi_out", "takeoff", "climb_out", "departure"}},
+	{"cruising to KJFK", flightclass.Cruising, []string{"cruise", "cruise_tod"}},
+	{"arriving KJFK", flightclass.Arriving, []string{"arrival", "approach", "final", "go_around", "braking", "taxi_in", "post_flight_parked"}},
+	{"holding for KJFK", flightclass.Arriving, []string{"holding", "holding_weather", "go_around_weather"}},
+	{"emergency inbound to KJFK", flightclass.Arriving, []string{"emergency", "emergency_radio_failure", "diversion"}},
+}
+
+// fixtureAltitudes are the altitudes (feet) of fixture aircraft in the airborne phases
+var fixtureAltitudes = map[flightphase.FlightPhase]float64{
+	flightphase.Climbout:  3000,
+	flightphase.Departure: 9000,
+	flightphase.Cruise:    35000,
+	flightphase.Arrival:   14000,
+	flightphase.Approach:  5000,
+	flightphase.Holding:   7000,
+	flightphase.Final:     1500,
+	flightphase.GoAround:  2000,
+}
+
+// fixtureSimTime provides a fixed sim time of 10:00 local so that valedictions are rendered
+type fixtureSimTime struct{}
+
+func (fixtureSimTime) GetSimTime() (simdata.XPlaneTime, error) {
+	return simdata.XPlaneTime{LocalTimeSecs: 10 * 3600}, nil
+}
+
+// RenderPhrases prints every exchange of the phrase files in the resources directory as it would be spoken
+// to synthetic aircraft, so that phrases can be reviewed without flying
+func RenderPhrases(cfgPath string, w io.Writer, opts PhraseRenderOptions) error {
+	cfg, err := util.LoadConfig[config](cfgPath)
+	if err != nil {
+		return err
+	}
+	phraseClasses, err := loadPhraseClasses()
+	if err != nil {
+		return err
+	}
+	s, err := newPhraseFixtureService(cfg)
+	if err != nil {
+		return err
+	}
+	s.VoiceManager = &VoiceManager{PhraseClasses: phraseClasses}
+
+	rendered := make(map[string]bool)
+	for _, fixture := range phraseFixtures {
+		for _, key := range fixture.keys {
+			rendered[key] = true
+			if opts.PhaseKey != "" && key != opts.PhaseKey {
+				continue
+			}
+			s.renderFixtureExchanges(w, fixture, key, opts.Unicom)
+		}
+	}
+
+	// phase keys which are defined but have no fixture are listed so that they are not silently skipped
+	base := phraseClasses.phrases
+	if opts.Unicom {
+		base = phraseClasses.phrasesUnicom
+	}
+	var unrendered []string
+	for key := range base {
+		if !rendered[key] && (opts.PhaseKey == "" || key == opts.PhaseKey) {
+			unrendered = append(unrendered, key)
+		}
+	}
+	sort.Strings(unrendered)
+	if len(unrendered) > 0 {
+		fmt.Fprintf(w, "no fixture for phase keys: %s\n", strings.Join(unrendered, ", "))
+	}
+	return nil
+}
+
+// renderFixtureExchanges prints the exchanges of the phase key in the order they are transmitted by startComms
+func (s *Service) renderFixtureExchanges(w io.Writer, fixture phraseFixture, key string, unicom bool) {
+	ac := s.newFixtureAircraft(fixture, key, unicom)
+	standard := getPhraseologyStandard(ac)
+	phraseClasses := s.VoiceManager.phrases()
+	exchanges := phraseClasses.getExchanges(key, standard, unicom)
+	if len(exchanges) == 0 {
+		return
+	}
+
+	ctrl := ac.Flight.Comms.Controller
+	role := roleNameMap[ctrl.RoleID]
+	fmt.Fprintf(w, "=== %s: %s (%s %s, %s phraseology) ===\n", fixture.name, key, ctrl.Name, role, strings.ToUpper(standard))
+
+	say := func(phrase, speaker string, ctx *phraseContext) []pcl.Token {
+		text, tokens, err := renderPhrase(phrase, ctx, "")
+		if err != nil {
+			text = "PCL error: " + err.Error()
+		}
+		fmt.Fprintf(w, "    %-10s %s\n", speaker+":", text)
+		return tokens
+	}
+
+	for i, ex := range exchanges {
+		fmt.Fprintf(w, "  #%d\n", i+1)
+		var atcTokens []pcl.Token
+		if ex.Initiator == "pilot" {
+			say(ex.Pilot, "PILOT", s.newPCLContext(ac, "PILOT"))
+			if !unicom {
+				atcTokens = say(ex.ATC, strings.ToUpper(role), s.newPCLContext(ac, role))
+			}
+		} else {
+			atcTokens = say(ex.ATC, strings.ToUpper(role), s.newPCLContext(ac, role))
+			if ex.Pilot != "" {
+				say(ex.Pilot, "PILOT", s.newPCLContext(ac, "PILOT"))
+			}
+		}
+		if readsBack(ex, unicom) && ac.Flight.Phase.Current != flightphase.Shutdown.Index() {
+			ctx := s.newPCLContext(ac, "PILOT")
+			pinReadbackTokens(ctx, atcTokens)
+			say(autoReadback(ex.ATC), "PILOT", ctx)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// newFixtureAircraft returns the fixture aircraft in the flight phase of the phase key, talking to the
+// controller for the phase at the airport of the phase class
+func (s *Service) newFixtureAircraft(fixture phraseFixture, key string, unicom bool) *Aircraft {
+	ap := s.GetAirports()["KJFK"]
+	if fixture.class == flightclass.Departing {
+		ap = s.GetAirports()["EGLL"]
+	}
+	rwy := ap.Runways[fixtureRunways[ap.ICAO]]
+
+	ac := &Aircraft{
+		Registration: "G-PCLR",
+		Flight: Flight{
+			Number:              175,
+			Origin:              "EGLL",
+			Destination:         "KJFK",
+			Phase:               flightphase.Phase{Class: fixture.class},
+			Comms:               Comms{Callsign: "Speedbird 175", CountryCode: "EG"},
+			CruiseAlt:           35000,
+			AssignedParkingName: fixtureParking[ap.ICAO],
+			AssignedParkingSpot: ap.Parking[fixtureParking[ap.ICAO]],
+			AssignedRunwayName:  rwy.Name,
+			AssignedRunway:      rwy,
+			Squawk:              "4721",
+			Position:            Position{Lat: rwy.Lat, Long: rwy.Lon, Altitude: ap.Elevation, Heading: rwy.Heading},
+			TargetHeading:       rwy.Heading + 20,
+			TargetAltitude:      4000,
+		},
+	}
+	if fixture.class == flightclass.Departing {
+		ac.Flight.AssignedSID = rwy.SIDs[0]
+		ac.Flight.DepartureAccess = rwy.DepartureAccess["A1"]
+	} else {
+		ac.Flight.AssignedSTAR = rwy.STARs[0]
+		ac.Flight.ArrivalAccess = rwy.ArrivalAccess["K"]
+	}
+
+	// sub-phase keys are the phase key of the flight phase with a suffix, or set the state that startComms
+	// selects them by
+	phaseKey := key
+	switch {
+	case strings.HasSuffix(key, "_tod"):
+		phaseKey = strings.TrimSuffix(key, "_tod")
+		ac.Flight.ClearedTOD = true
+	case strings.HasSuffix(key, "_weather"):
+		phaseKey = strings.TrimSuffix(key, "_weather")
+		ac.Flight.WeatherReason = "windshear"
+	case key == "emergency":
+		phaseKey = "arrival"
+		ac.Flight.Emergency = &Emergency{Type: EmergencyEngineFailure, DivertICAO: "KEWR"}
+	case key == "emergency_radio_failure":
+		phaseKey = "arrival"
+		ac.Flight.Emergency = &Emergency{Type: EmergencyRadioFailure}
+		ac.Flight.Squawk = "7600"
+	case key == "diversion":
+		phaseKey = "arrival"
+		ac.Flight.Diversion = &Diversion{ICAO: "KEWR", Reason: "crosswind"}
+	}
+
+	roleID := 0
+	for phase, pf := range atcFacilityByPhaseMap {
+		if pf.atcPhase == phaseKey {
+			ac.Flight.Phase.Current = phase.Index()
+			roleID = pf.roleId
+			if alt, ok := fixtureAltitudes[phase]; ok {
+				ac.Flight.Position.Altitude = alt
+			}
+			break
+		}
+	}
+	if ac.Flight.Phase.Current == flightphase.Holding.Index() {
+		ac.Flight.Holding = &Holding{AssignedHold: ap.Holds[0]}
+	}
+	if unicom {
+		roleID = 0
+	}
+	for _, c := range ap.Controllers {
+		if c.RoleID == roleID {
+			ac.Flight.Comms.Controller = c
+		}
+	}
+	return ac
+}
+
+// fixtureRunways and fixtureParking are the runway and stand assigned to fixture aircraft at each airport
+var (
+	fixtureRunways = map[string]string{"EGLL": "27R", "KJFK": "22L"}
+	fixtureParking = map[string]string{"EGLL": "521", "KJFK": "B32"}
+)
+
+// newPhraseFixtureService returns a service with the airports, controllers and weather used by the fixtures
+func newPhraseFixtureService(cfg *config) (*Service, error) {
+	if cfg.ATC.Voices.HandoffValedictionFactor <= 0 {
+		cfg.ATC.Voices.HandoffValedictionFactor = 1
+	}
+
+	transitionRules, err := loadTransitionRules(filepath.Join(d9.Resources, "transition_levels.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	fixtureController := func(icao, name string, roleID, freq int, lat, lon float64) *Controller {
+		return &Controller{Name: name, ICAO: icao, RoleID: roleID, Freqs: []int{freq}, Lat: lat, Lon: lon, IsPoint: true}
+	}
+
+	egll := &Airport{
+		ICAO: "EGLL", Name: "London Heathrow", Lat: 51.4706, Lon: -0.4619, Elevation: 83, TransAlt: 6000, Region: "EG",
+		Runways: map[string]*Runway{
+			"27R": {Name: "27R", Lat: 51.4775, Lon: -0.4332, Heading: 270, ThresholdElevation: 78,
+				FAFalt: 2500, MAalt: 6000, MAHeading: 270, MAFix: "EPM", HighestPrecisionApproach: "ILS",
+				SIDs: []*Procedure{{Name: "CPT3F", Entry: &ProcedureFix{ConstraintAlt: 6000}, Exit: &ProcedureFix{ConstraintAlt: 6000}}},
+				STARs: []*Procedure{{Name: "LAM1X", Type: 1, Entry: &ProcedureFix{ConstraintAlt: 15000},
+					Exit: &ProcedureFix{ConstraintAlt: 7000}}},
+				DepartureAccess: map[string]*AccessPoint{"A1": {Name: "A1", TaxiwayName: "A"}},
+				ArrivalAccess:   map[string]*AccessPoint{"K": {Name: "K", TaxiwayName: "K"}}},
+		},
+		Parking: map[string]*ParkingSpot{"521": {Name: "521", Type: "Gate", TaxiwayName: "B"}},
+		Holds:   []*Hold{{Ident: "BNN", FullName: "Bovingdon", ICAO: "EGLL", MinAlt: 7000, MaxAlt: 15000, Lat: 51.7261, Lon: -0.5499}},
+	}
+	egll.Controllers = []*Controller{
+		fixtureController("EGLL", "Heathrow", 0, 122800, egll.Lat, egll.Lon),
+		fixtureController("EGLL", "Heathrow", 1, 121980, egll.Lat, egll.Lon),
+		fixtureController("EGLL", "Heathrow", 2, 121900, egll.Lat, egll.Lon),
+		fixtureController("EGLL", "Heathrow", 3, 118500, egll.Lat, egll.Lon),
+		fixtureController("EGLL", "London", 4, 120405, egll.Lat, egll.Lon),
+		fixtureController("EGLL", "London", 5, 119730, egll.Lat, egll.Lon),
+		fixtureController("EGLL", "London", 6, 132600, egll.Lat, egll.Lon),
+	}
+
+	kjfk := &Airport{
+		ICAO: "KJFK", Name: "John F Kennedy Intl", Lat: 40.6398, Lon: -73.7789, Elevation: 13, TransAlt: 18000, Region: "K6",
+		Runways: map[string]*Runway{
+			"22L": {Name: "22L", Lat: 40.6622, Lon: -73.7569, Heading: 224, ThresholdElevation: 12,
+				FAFalt: 1900, MAalt: 3000, MAHeading: 224, MAFix: "DPK", HighestPrecisionApproach: "ILS",
+				SIDs: []*Procedure{{Name: "JFK5", Entry: &ProcedureFix{ConstraintAlt: 5000}, Exit: &ProcedureFix{ConstraintAlt: 5000}}},
+				STARs: []*Procedure{{Name: "LENDY8", Type: 1, Entry: &ProcedureFix{ConstraintAlt: 19000},
+					Exit: &ProcedureFix{ConstraintAlt: 10000}}},
+				DepartureAccess: map[string]*AccessPoint{"A1": {Name: "A1", TaxiwayName: "A"}},
+				ArrivalAccess:   map[string]*AccessPoint{"K": {Name: "K", TaxiwayName: "K", IsHighSpeed: true}}},
+		},
+		Parking: map[string]*ParkingSpot{"B32": {Name: "B32", Type: "Gate", TaxiwayName: "B"}},
+		Holds:   []*Hold{{Ident: "CAMRN", FullName: "Camrn", ICAO: "KJFK", MinAlt: 7000, MaxAlt: 14000, Lat: 40.0173, Lon: -73.8613}},
+	}
+	kjfk.Controllers = []*Controller{
+		fixtureController("KJFK", "Kennedy", 0, 122950, kjfk.Lat, kjfk.Lon),
+		fixtureController("KJFK", "Kennedy", 1, 135050, kjfk.Lat, kjfk.Lon),
+		fixtureController("KJFK", "Kennedy", 2, 121900, kjfk.Lat, kjfk.Lon),
+		fixtureController("KJFK", "Kennedy", 3, 119100, kjfk.Lat, kjfk.Lon),
+		fixtureController("KJFK", "New York", 4, 135900, kjfk.Lat, kjfk.Lon),
+		fixtureController("KJFK", "New York", 5, 127400, kjfk.Lat, kjfk.Lon),
+		fixtureController("KJFK", "New York", 6, 128300, kjfk.Lat, kjfk.Lon),
+	}
+
+	kewr := &Airport{ICAO: "KEWR", Name: "Newark Liberty Intl", Lat: 40.6925, Lon: -74.1687, Elevation: 18, Region: "K6"}
+
+	var controllers []*Controller
+	controllers = append(controllers, egll.Controllers...)
+	controllers = append(controllers, kjfk.Controllers...)
+
+	s := &Service{
+		Config:          cfg,
+		Controllers:     controllers,
+		Airports:        map[string]*Airport{"EGLL": egll, "KJFK": kjfk, "KEWR": kewr},
+		Weather:         &Weather{Wind: &Wind{Direction: 250, Speed: 6}, Baro: &Baro{Sealevel: 101325, Flight: 101325}},
+		TransitionRules: transitionRules,
+		DataProvider:    fixtureSimTime{},
+	}
+	s.AirportService = s
+	return s, nil
+}
@@ -0,0 +1,366 @@
+package atc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+// PronunciationToken is a spoken word which is not covered by the pronunciation dictionaries
+type PronunciationToken struct {
+	Token   string   // lower case after cleanPhrase, as looked up by PhoneticEngine.Apply, e.g. "zurich"
+	Written string   // the word as first written in the names, e.g. "zürich"
+	Count   int      // number of names the word appears in
+	Sources []string // the first few names the word appears in, e.g. "fix BOVINGDON"
+	Reasons []string // why the word is likely to be mispronounced, empty if it is probably fine
+}
+
+// PronunciationReport lists the words of the fix, procedure, airport and airline callsign names of a region
+// which are not covered by the pronunciation dictionaries of a language
+type PronunciationReport struct {
+	Region    string
+	Language  string
+	Names     int // names checked
+	Words     int // distinct words spoken
+	Covered   int // distinct words found in the dictionaries
+	Uncovered []*PronunciationToken
+}
+
+// maxTokenSources is the number of names recorded for each uncovered word
+const maxTokenSources = 3
+
+// englishOnsets are the three consonant clusters that English words commonly start with
+var englishOnsets = map[string]bool{
+	"str": true, "spr": true, "scr": true, "spl": true, "thr": true, "shr": true, "chr": true, "phr": true, "sph": true,
+}
+
+// englishCodas are the three consonant clusters that English words commonly end with, besides plurals
+var englishCodas = map[string]bool{
+	"ght": true, "tch": true, "nch": true, "rch": true, "rth": true, "nth": true, "rst": true, "nst": true, "rld": true, "rnt": true,
+}
+
+// foreignLetterPairs are letter combinations that are rare in English but common in other languages
+var foreignLetterPairs = []string{"aa", "ii", "uu", "ij", "cz", "sz", "zs", "dz", "tx", "kj", "gj", "hv", "dj", "zh", "xh"}
+
+// PronunciationCoverage loads the navdata, airports and airlines of a region using the data files of the
+// config and reports the spoken words that the pronunciation dictionaries of the language do not cover.
+// The region is an ICAO region or airport prefix, e.g. "EG" or "K", and every region is checked if empty.
+func PronunciationCoverage(cfgPath, region, language string) (*PronunciationReport, error) {
+	cfg, err := util.LoadConfig[config](cfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading configuration file: %v", err)
+	}
+
+	required, err := regionAirports(cfg.ATC.AirportsDataFile, region)
+	if err != nil {
+		return nil, err
+	}
+	allHolds, airportHolds, allFixes, err := loadHolds(cfg.ATC.AtcNavDataFile, cfg.ATC.AtcHoldsFile, cfg.ATC.AtcFixesFile, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error loading hold data: %v", err)
+	}
+	_, airports, err := parseAirports(cfg, required, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing airports data file: %v", err)
+	}
+	if err := loadAirports(cfg.ATC.AirportCIFPDir, airports, required, airportHolds, allHolds, allFixes, nil); err != nil {
+		return nil, fmt.Errorf("error loading airport data from CIFP files: %v", err)
+	}
+	byICAO, byName, byCountry, err := loadAirlines(cfg.ATC.AirlinesFile)
+	if err != nil {
+		return nil, err
+	}
+	dictionaries, errs := loadDictionaries()
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	vm := &VoiceManager{}
+	vm.setDictionaries(dictionaries)
+	s := &Service{
+		Config:                cfg,
+		Holds:                 allHolds,
+		Airports:              airports,
+		AirlineByICAO:         byICAO,
+		AirlineByName:         byName,
+		AirlineCodesByCountry: byCountry,
+		VoiceManager:          vm,
+	}
+	return s.pronunciationCoverage(region, language), nil
+}
+
+// regionAirports scans the airport headers of apt.dat for the airports whose ICAO code starts with the region
+func regionAirports(path, region string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open airports data file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	required := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		p := strings.Fields(scanner.Text())
+		if len(p) < 5 || (p[0] != "1" && p[0] != "16" && p[0] != "17") {
+			continue
+		}
+		if strings.HasPrefix(p[4], region) {
+			required[p[4]] = true
+		}
+	}
+	return required, scanner.Err()
+}
+
+// pronunciationCoverage walks the spoken names of the loaded holds, SIDs, STARs, airports and airlines of the
+// region and checks each word against the pronunciation dictionaries of the language
+func (s *Service) pronunciationCoverage(region, language string) *PronunciationReport {
+	report := &PronunciationReport{Region: region, Language: language}
+
+	var dictionaries []*PhoneticEngine
+	base := strings.ToUpper(language)
+	s.VoiceManager.mu.RLock()
+	for code, engine := range s.VoiceManager.dictionaries {
+		if code == base || strings.HasPrefix(code, base+"_") {
+			dictionaries = append(dictionaries, engine)
+		}
+	}
+	s.VoiceManager.mu.RUnlock()
+
+	tokens := make(map[string]*PronunciationToken)
+	covered := make(map[string]bool)
+	addName := func(kind, name string) {
+		if strings.TrimSpace(name) == "" {
+			return
+		}
+		report.Names++
+		for _, word := range spokenWords(name) {
+			if covered[word.key] {
+				continue
+			}
+			if inDictionaries(dictionaries, word.key) {
+				covered[word.key] = true
+				continue
+			}
+			t, ok := tokens[word.key]
+			if !ok {
+				t = &PronunciationToken{Token: word.key, Written: word.written, Reasons: pronunciationFlags(word.written)}
+				tokens[word.key] = t
+			}
+			t.Count++
+			if len(t.Sources) < maxTokenSources {
+				t.Sources = append(t.Sources, kind+" "+name)
+			}
+		}
+	}
+
+	for _, h := range s.Holds {
+		if strings.HasPrefix(h.Region, region) || strings.HasPrefix(h.ICAO, region) {
+			addName("fix", h.FullName)
+		}
+	}
+	for icao, ap := range s.GetAirports() {
+		if !strings.HasPrefix(icao, region) {
+			continue
+		}
+		addName("airport", cleanAirportName(ap.Name))
+		procedures := make(map[string]bool)
+		for _, rwy := range ap.Runways {
+			for _, proc := range append(append([]*Procedure{}, rwy.SIDs...), rwy.STARs...) {
+				if !procedures[proc.Name] {
+					procedures[proc.Name] = true
+					addName("procedure", procedureWord(proc.Name))
+				}
+			}
+		}
+	}
+	s.airlineMu.RLock()
+	for _, info := range s.AirlineByICAO {
+		if info.CountryCode == "" {
+			continue
+		}
+		if strings.HasPrefix(info.CountryCode, region) || strings.HasPrefix(region, info.CountryCode) {
+			addName("callsign", info.Callsign)
+		}
+	}
+	s.airlineMu.RUnlock()
+
+	for _, t := range tokens {
+		report.Uncovered = append(report.Uncovered, t)
+	}
+	// flagged words first, then the most frequent
+	sort.Slice(report.Uncovered, func(i, j int) bool {
+		a, b := report.Uncovered[i], report.Uncovered[j]
+		if (len(a.Reasons) > 0) != (len(b.Reasons) > 0) {
+			return len(a.Reasons) > 0
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Token < b.Token
+	})
+	report.Covered = len(covered)
+	report.Words = len(covered) + len(tokens)
+	return report
+}
+
+// spokenWord is a word of a name as written and as looked up by PhoneticEngine.Apply
+type spokenWord struct {
+	written string // lower case, e.g. "zürich"
+	key     string // after cleanPhrase has folded the accents and removed other characters, e.g. "zurich"
+}
+
+// spokenWords splits a name into the words that PhoneticEngine.Apply looks up once cleanPhrase has run. Words
+// containing digits and single letters are left out as they are read as numbers or the phonetic alphabet.
+func spokenWords(name string) []spokenWord {
+	var words []spokenWord
+	for _, field := range strings.Fields(name) {
+		written := strings.ToLower(strings.Trim(field, ".,!?;:\"()"))
+		key := strings.ToLower(strings.Trim(cleanPhrase(field), ".,!?;:\"()"))
+		if len([]rune(key)) < 2 || strings.ContainsAny(key, "0123456789") {
+			continue
+		}
+		words = append(words, spokenWord{written: written, key: key})
+	}
+	return words
+}
+
+// procedureWord returns the spoken name of a SID or STAR without its number and transition letter, e.g.
+// "DAYNE" for "DAYNE1A"
+func procedureWord(name string) string {
+	if i := strings.IndexFunc(name, unicode.IsDigit); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+func inDictionaries(dictionaries []*PhoneticEngine, word string) bool {
+	for _, d := range dictionaries {
+		if _, ok := d.Dictionaries[word]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pronunciationFlags returns the reasons that an English voice is likely to mispronounce the word
+func pronunciationFlags(word string) []string {
+	var reasons []string
+	for _, r := range word {
+		if unicode.IsLetter(r) && r > unicode.MaxASCII {
+			reasons = append(reasons, "non-English letters")
+			break
+		}
+	}
+
+	ascii := foldAccents(word)
+	if !strings.ContainsAny(ascii, "aeiouy") && len(ascii) > 2 {
+		reasons = append(reasons, "no vowels")
+	}
+
+	// an unusual cluster at the start or end of a word or four or more consonants anywhere
+	cluster := ""
+	for _, part := range strings.FieldsFunc(ascii, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		run := 0
+		for j, r := range part {
+			if strings.ContainsRune("aeiouy", r) {
+				run = 0
+				continue
+			}
+			run++
+			if cluster == "" && run == 3 && j == 2 && !englishOnsets[part[:3]] {
+				cluster = part[:3]
+			}
+			if cluster == "" && run == 4 {
+				cluster = part[j-3 : j+1]
+			}
+		}
+		if end := len(part) - 3; cluster == "" && run == 3 && end > 0 && !englishCodas[part[end:]] && !strings.HasSuffix(part, "s") {
+			cluster = part[end:]
+		}
+	}
+	if cluster != "" && strings.ContainsAny(ascii, "aeiouy") {
+		reasons = append(reasons, fmt.Sprintf("consonant cluster '%s'", cluster))
+	}
+
+	for _, pair := range foreignLetterPairs {
+		if strings.Contains(ascii, pair) {
+			reasons = append(reasons, fmt.Sprintf("'%s' is rare in English", pair))
+			break
+		}
+	}
+	if i := strings.IndexRune(ascii, 'q'); i != -1 && !strings.HasPrefix(ascii[i:], "qu") {
+		reasons = append(reasons, "'q' without 'u'")
+	}
+	return reasons
+}
+
+// foldAccents removes accents from letters, e.g. "zürich" becomes "zurich"
+func foldAccents(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, _ := transform.String(t, s)
+	return folded
+}
+
+// Flagged returns the uncovered words that are likely to be mispronounced
+func (r *PronunciationReport) Flagged() []*PronunciationToken {
+	var flagged []*PronunciationToken
+	for _, t := range r.Uncovered {
+		if len(t.Reasons) > 0 {
+			flagged = append(flagged, t)
+		}
+	}
+	return flagged
+}
+
+// Write prints the report, listing the flagged words with their reasons and sources before the remaining
+// uncovered words
+func (r *PronunciationReport) Write(w io.Writer) {
+	region := r.Region
+	if region == "" {
+		region = "all regions"
+	}
+	fmt.Fprintf(w, "%s, %s dictionaries: %d names, %d distinct words, %d covered, %d not covered\n",
+		region, r.Language, r.Names, r.Words, r.Covered, len(r.Uncovered))
+
+	flagged := r.Flagged()
+	if len(flagged) > 0 {
+		fmt.Fprintf(w, "\nlikely to be mispronounced (%d):\n", len(flagged))
+		for _, t := range flagged {
+			fmt.Fprintf(w, "  %-20s x%-4d %s [%s]\n", t.Token, t.Count, strings.Join(t.Reasons, ", "), strings.Join(t.Sources, "; "))
+		}
+	}
+
+	rest := r.Uncovered[len(flagged):]
+	if len(rest) > 0 {
+		fmt.Fprintf(w, "\nother words not covered (%d):\n", len(rest))
+		for _, t := range rest {
+			fmt.Fprintf(w, "  %-20s x%d\n", t.Token, t.Count)
+		}
+	}
+}
+
+// WriteStub writes the flagged words as a dictionary with empty respellings, which can be merged into
+// en-dictionary.json once each respelling has been filled in
+func (r *PronunciationReport) WriteStub(w io.Writer) error {
+	stub := make(map[string]string)
+	for _, t := range r.Flagged() {
+		stub[t.Token] = ""
+	}
+	data, err := json.MarshalIndent(stub, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
@@ -0,0 +1,148 @@
+package atc
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPronunciationFlags(t *testing.T) {
+	tests := []struct {
+		word string
+		want string // substring of the expected reasons, empty for none
+	}{
+		{"london", ""},
+		{"street", ""},
+		{"speedbird", ""},
+		{"zürich", "non-English letters"},
+		{"camrn", "consonant cluster 'mrn'"},
+		{"bpk", "no vowels"},
+		{"schwechat", "consonant cluster 'sch'"},
+		{"łódź", "non-English letters"},
+		{"szczecin", "is rare in English"},
+		{"schiphol", "consonant cluster"},
+		{"qatari", "'q' without 'u'"},
+		{"quebec", ""},
+		{"midhurst", ""},
+		{"knight", ""},
+	}
+	for _, tt := range tests {
+		got := strings.Join(pronunciationFlags(tt.word), ", ")
+		if tt.want == "" && got != "" {
+			t.Errorf("pronunciationFlags(%q) = %q; want none", tt.word, got)
+		}
+		if tt.want != "" && !strings.Contains(got, tt.want) {
+			t.Errorf("pronunciationFlags(%q) = %q; want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestPronunciationCoverage(t *testing.T) {
+	vm := &VoiceManager{}
+	vm.setDictionaries(map[string]*PhoneticEngine{
+		"EN":    {Dictionaries: map[string]string{"schiphol": "skipol", "zurich": "zoorik"}},
+		"EN_GB": {Dictionaries: map[string]string{"heathrow": "heethrow"}},
+		"FR":    {Dictionaries: map[string]string{"brookmans": "brookmans"}},
+	})
+	sid := &Procedure{Name: "BPK7G", Type: PROC_TYPE_SID}
+	s := &Service{
+		VoiceManager: vm,
+		Holds: map[string]*Hold{
+			"BNN_EG":   {Ident: "BNN", Region: "EG", ICAO: "EGLL", FullName: "BOVINGDON"},
+			"BPK_EG":   {Ident: "BPK", Region: "EG", ICAO: "ENRT", FullName: "BROOKMANS PARK"},
+			"SPY_EH":   {Ident: "SPY", Region: "EH", ICAO: "EHAM", FullName: "SPIJKERBOOR"},
+			"CAMRN_K6": {Ident: "CAMRN", Region: "K6", ICAO: "KJFK", FullName: "CAMRN"},
+			"ZUR_EG":   {Ident: "ZUR", Region: "EG", ICAO: "ENRT", FullName: "ZÜRICH"},
+			"MUN_EG":   {Ident: "MUN", Region: "EG", ICAO: "ENRT", FullName: "MÜNSTER"},
+		},
+		Airports: map[string]*Airport{
+			"EGLL": {ICAO: "EGLL", Name: "London Heathrow Intl", Runways: map[string]*Runway{
+				"27R": {Name: "27R", SIDs: []*Procedure{sid}},
+				"27L": {Name: "27L", SIDs: []*Procedure{sid}},
+			}},
+			"EHAM": {ICAO: "EHAM", Name: "amsterdam schiphol"},
+		},
+		AirlineByICAO: map[string]*AirlineInfo{
+			"BAW": {ICAO: "BAW", Callsign: "Speedbird", CountryCode: "EG"},
+			"EXS": {ICAO: "EXS", Callsign: "Channex", CountryCode: "EG"},
+			"KLM": {ICAO: "KLM", Callsign: "KLM", CountryCode: "EH"},
+			"XXX": {ICAO: "XXX", Callsign: "Nowhere"},
+		},
+	}
+
+	report := s.pronunciationCoverage("EG", "en")
+	got := make(map[string]*PronunciationToken)
+	for _, tk := range report.Uncovered {
+		got[tk.Token] = tk
+	}
+
+	// words of other regions and those in the EN and EN_GB dictionaries are left out
+	for _, word := range []string{"spijkerboor", "camrn", "amsterdam", "klm", "heathrow", "intl", "nowhere", "zurich", "zürich"} {
+		if _, ok := got[word]; ok {
+			t.Errorf("%q reported for region EG", word)
+		}
+	}
+	// the French dictionary does not cover English words
+	for _, word := range []string{"bovingdon", "brookmans", "park", "london", "speedbird", "channex", "bpk"} {
+		if _, ok := got[word]; !ok {
+			t.Errorf("%q not reported as uncovered", word)
+		}
+	}
+	if report.Covered != 2 || report.Names != 8 {
+		t.Errorf("got %d covered words from %d names; want 2 from 8", report.Covered, report.Names)
+	}
+	// words are looked up as cleanPhrase leaves them, and flagged as written
+	if tk := got["munster"]; tk == nil || tk.Written != "münster" || !strings.Contains(strings.Join(tk.Reasons, ","), "non-English letters") {
+		t.Errorf("accented word reported as %+v", tk)
+	}
+	if tk := got["bpk"]; tk == nil || tk.Count != 1 || !reflect.DeepEqual(tk.Sources, []string{"procedure BPK"}) {
+		t.Errorf("SID shared by two runways reported as %+v", tk)
+	}
+	if flagged := report.Flagged(); len(flagged) != 2 || flagged[0].Token != "bpk" || report.Uncovered[0] != flagged[0] {
+		t.Errorf("flagged words = %v; want bpk and munster first", flagged)
+	}
+
+	var out bytes.Buffer
+	report.Write(&out)
+	if !strings.Contains(out.String(), "likely to be mispronounced (2)") || !strings.Contains(out.String(), "bovingdon") {
+		t.Errorf("unexpected report:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := report.WriteStub(&out); err != nil {
+		t.Fatal(err)
+	}
+	var stub map[string]string
+	if err := json.Unmarshal(out.Bytes(), &stub); err != nil {
+		t.Fatalf("stub is not a dictionary: %v", err)
+	}
+	if !reflect.DeepEqual(stub, map[string]string{"bpk": "", "munster": ""}) {
+		t.Errorf("stub = %v", stub)
+	}
+}
+
+func TestRegionAirports(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apt.dat")
+	apt := `I
+1100 Version
+1      83 0 0 EGLL London Heathrow
+100 60.00 1 0 0.25 0 2 1 09L
+16     0 0 0 EGLW London Heliport
+1      13 0 0 KJFK John F Kennedy Intl
+99
+`
+	if err := os.WriteFile(path, []byte(apt), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := regionAirports(path, "EG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"EGLL": true, "EGLW": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("regionAirports = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,275 @@
+package atc
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/curbz/decimal-niner/internal/pcl"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+// ReadbackErrorsConfig controls how often pilots make mistakes when reading back instructions
+type ReadbackErrorsConfig struct {
+	Factor         int      `yaml:"factor"`          // one in Factor readbacks contains an error, zero disables
+	OmissionFactor int      `yaml:"omission_factor"` // one in OmissionFactor errors omits the item rather than misreading it
+	Items          []string `yaml:"items"`           // any of runway, heading, altitude, frequency; empty allows all
+}
+
+// readback item categories
+const (
+	readbackRunway    = "runway"
+	readbackHeading   = "heading"
+	readbackAltitude  = "altitude"
+	readbackFrequency = "frequency"
+)
+
+// readbackItems maps the PCL tokens that carry a clearance item to the category of that item
+var readbackItems = map[string]string{
+	"$RUNWAY":        readbackRunway,
+	"@RUNWAY":        readbackRunway,
+	"$ATC_HEADING":   readbackHeading,
+	"@ATC_HEADING":   readbackHeading,
+	"@ALT_CLEARANCE": readbackAltitude,
+	"@HANDOFF":       readbackFrequency,
+}
+
+// readbackError describes a single erroneous readback item
+type readbackError struct {
+	key        string    // the PCL token that is misread
+	wrong      pcl.Value // the value the pilot reads back
+	correction string    // the controller's correction, without the leading "negative"
+}
+
+// readback makes the pilot read back the controller's instruction. The resolved tokens of the
+// controller's transmission are pinned so that the readback repeats exactly what was said. Based
+// on the configured factor, the readback may contain an error which the controller corrects
+// before the pilot reads back again.
+func (s *Service) readback(atcPhrase string, atcTokens []pcl.Token, atcRole string, ac *Aircraft) {
+
+	phrase := autoReadback(atcPhrase)
+	ctx := s.newPCLContext(ac, "PILOT")
+	pinReadbackTokens(ctx, atcTokens)
+
+	rbErr, ok := s.selectReadbackError(phrase, ctx, atcTokens, ac)
+	if !ok {
+		s.transmitPhrase(phrase, "PILOT", ac, ctx)
+		return
+	}
+
+	util.LogWithLabel(ac.Registration, "pilot readback error on %s: %q", rbErr.key, rbErr.wrong.Text)
+
+	wrongCtx := s.newPCLContext(ac, "PILOT")
+	pinReadbackTokens(wrongCtx, atcTokens)
+	wrongCtx.pin(rbErr.key, rbErr.wrong)
+	s.transmitPhrase(phrase, "PILOT", ac, wrongCtx)
+
+	// controller corrects the pilot, who then reads back correctly
+	s.preparePhrase(fmt.Sprintf("{$CALLSIGN} negative, %s", rbErr.correction), atcRole, ac)
+	s.transmitPhrase(phrase, "PILOT", ac, ctx)
+}
+
+// pinReadbackTokens overrides the clearance item providers in ctx with the values resolved for the
+// controller's transmission, so that values which are recalculated on each call remain consistent
+func pinReadbackTokens(ctx *phraseContext, tokens []pcl.Token) {
+	for _, tok := range tokens {
+		if _, ok := readbackItems[tok.Key]; !ok {
+			continue
+		}
+		ctx.pin(tok.Key, pcl.Value{Data: tok.Data, Text: tok.Text})
+	}
+}
+
+// selectReadbackError decides whether the readback of phrase contains an error and, if so, which
+// item is affected. Only items which the controller said and which appear in the readback qualify.
+func (s *Service) selectReadbackError(phrase string, ctx pcl.Context, atcTokens []pcl.Token, ac *Aircraft) (readbackError, bool) {
+
+	// corrections are only phrased in English
+	if ac.Flight.Comms.Language != "" {
+		return readbackError{}, false
+	}
+
+	cfg := s.Config.ATC.Voices.ReadbackErrors
+	if cfg.Factor <= 0 || rand.Intn(cfg.Factor) != 0 {
+		return readbackError{}, false
+	}
+
+	_, rbTokens, err := pcl.ResolvePhrase(phrase, ctx)
+	if err != nil {
+		return readbackError{}, false
+	}
+
+	var candidates []pcl.Token
+	for _, tok := range atcTokens {
+		category, ok := readbackItems[tok.Key]
+		if !ok || (len(cfg.Items) > 0 && !slices.Contains(cfg.Items, category)) {
+			continue
+		}
+		if !slices.ContainsFunc(rbTokens, func(rb pcl.Token) bool { return rb.Key == tok.Key }) {
+			continue
+		}
+		candidates = append(candidates, tok)
+	}
+	if len(candidates) == 0 {
+		return readbackError{}, false
+	}
+
+	tok := candidates[rand.Intn(len(candidates))]
+	correction, ok := s.readbackCorrection(tok, ac)
+	if !ok {
+		return readbackError{}, false
+	}
+
+	if cfg.OmissionFactor > 0 && rand.Intn(cfg.OmissionFactor) == 0 {
+		return readbackError{key: tok.Key, correction: correction}, true
+	}
+
+	wrong, ok := s.misreadToken(tok, ac)
+	if !ok {
+		return readbackError{}, false
+	}
+	return readbackError{key: tok.Key, wrong: wrong, correction: correction}, true
+}
+
+// readbackCorrection returns the phrase used by the controller to restate the correct value of tok
+func (s *Service) readbackCorrection(tok pcl.Token, ac *Aircraft) (string, bool) {
+	switch readbackItems[tok.Key] {
+	case readbackRunway:
+		if rwy, ok := tok.Data.(string); ok && rwy != "" {
+			return "runway " + translateRunway(rwy), true
+		}
+	case readbackHeading:
+		if hdg, ok := tokenInt(tok.Data); ok && hdg > 0 {
+			return fmt.Sprintf("heading %03d", hdg), true
+		}
+	case readbackAltitude:
+		if alt, ok := tokenInt(tok.Data); ok && alt > 0 {
+			transLevel := s.getAircraftTransitionLevel(ac)
+			return formatAltitude(float64(alt), transLevel, ac.Flight.Phase, s.usesMetricAltitudes(ac)), true
+		}
+	case readbackFrequency:
+		if freq, ok := tokenInt(tok.Data); ok && freq > 0 {
+			return formatFrequency(freq), true
+		}
+	}
+	return "", false
+}
+
+// misreadToken returns a plausible but incorrect version of the value behind tok
+func (s *Service) misreadToken(tok pcl.Token, ac *Aircraft) (pcl.Value, bool) {
+	switch readbackItems[tok.Key] {
+	case readbackRunway:
+		rwy, _ := tok.Data.(string)
+		var others []string
+		if ap := s.GetAirportByICAO(getAirportICAObyPhaseClass(ac)); ap != nil {
+			for name := range ap.Runways {
+				others = append(others, name)
+			}
+		}
+		wrong := misreadRunway(rwy, others)
+		if wrong == "" {
+			return pcl.Value{}, false
+		}
+		text := wrong
+		if strings.HasPrefix(tok.Key, "@") {
+			text = translateRunway(wrong)
+		}
+		return pcl.Value{Data: wrong, Text: text}, true
+
+	case readbackHeading:
+		hdg, _ := tokenInt(tok.Data)
+		wrong := misreadHeading(hdg, rand.Intn(2) == 0)
+		text := strings.Replace(tok.Text, fmt.Sprintf("%03d", hdg), fmt.Sprintf("%03d", wrong), 1)
+		if strings.HasPrefix(tok.Key, "$") {
+			return pcl.Value{Data: fmt.Sprintf("%03d", wrong), Text: text}, true
+		}
+		return pcl.Value{Data: wrong, Text: text}, true
+
+	case readbackAltitude:
+		alt, _ := tokenInt(tok.Data)
+		wrong := alt + 1000
+		if rand.Intn(2) == 0 && alt > 1000 {
+			wrong = alt - 1000
+		}
+		transLevel := s.getAircraftTransitionLevel(ac)
+		term := generateAltClearance(ac.Flight.Position.Altitude, transLevel, wrong, ac.Flight.Phase, s.usesMetricAltitudes(ac))
+		return pcl.Value{Data: wrong, Text: applyAltClearanceWording(term, getPhraseologyStandard(ac))}, true
+
+	case readbackFrequency:
+		freq, _ := tokenInt(tok.Data)
+		wrong := misreadFrequency(freq, rand.Intn(2) == 0)
+		text := strings.Replace(tok.Text, formatFrequency(freq), formatFrequency(wrong), 1)
+		return pcl.Value{Data: wrong, Text: text}, true
+	}
+	return pcl.Value{}, false
+}
+
+// misreadRunway returns a runway the pilot could confuse with rwy. A parallel runway at the
+// same airport is preferred, then a swapped designator suffix, then any other runway.
+func misreadRunway(rwy string, others []string) string {
+	if rwy == "" {
+		return ""
+	}
+	number := strings.TrimRight(rwy, "LRC")
+	suffix := strings.TrimPrefix(rwy, number)
+
+	slices.Sort(others)
+	for _, o := range others {
+		if o != rwy && strings.TrimRight(o, "LRC") == number {
+			return o
+		}
+	}
+
+	switch suffix {
+	case "L":
+		return number + "R"
+	case "R":
+		return number + "L"
+	case "C":
+		return number + "L"
+	}
+
+	for _, o := range others {
+		if o != rwy {
+			return o
+		}
+	}
+	return ""
+}
+
+// misreadHeading returns a heading ten degrees either side of hdg, wrapped to 1-360
+func misreadHeading(hdg int, right bool) int {
+	delta := -10
+	if right {
+		delta = 10
+	}
+	wrong := (hdg + delta + 360) % 360
+	if wrong == 0 {
+		wrong = 360
+	}
+	return wrong
+}
+
+// misreadFrequency shifts the first decimal digit of a frequency in kHz up or down by one
+func misreadFrequency(freq int, up bool) int {
+	if up {
+		return freq + 100
+	}
+	return freq - 100
+}
+
+// tokenInt converts a resolved token value to an int
+func tokenInt(data interface{}) (int, bool) {
+	switch v := data.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	case string:
+		i, err := strconv.Atoi(v)
+		return i, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,146 @@
+package atc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/curbz/decimal-niner/internal/flightclass"
+	"github.com/curbz/decimal-niner/internal/flightphase"
+	"github.com/curbz/decimal-niner/internal/pcl"
+)
+
+func TestMisreadRunway(t *testing.T) {
+	tests := []struct {
+		name   string
+		rwy    string
+		others []string
+		want   string
+	}{
+		{"parallel at airport preferred", "27L", []string{"09R", "27L", "27R", "09L"}, "27R"},
+		{"centre parallel", "18C", []string{"18C", "18R", "18L"}, "18L"},
+		{"suffix swapped without airport data", "09R", nil, "09L"},
+		{"other runway when no parallel", "27", []string{"27", "33"}, "33"},
+		{"no alternative", "27", []string{"27"}, ""},
+		{"empty runway", "", []string{"27"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := misreadRunway(tt.rwy, tt.others); got != tt.want {
+				t.Errorf("misreadRunway(%q, %v) = %q; want %q", tt.rwy, tt.others, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMisreadHeadingAndFrequency(t *testing.T) {
+	tests := []struct {
+		hdg   int
+		right bool
+		want  int
+	}{
+		{270, true, 280},
+		{270, false, 260},
+		{355, true, 5},
+		{10, false, 360},
+	}
+	for _, tt := range tests {
+		if got := misreadHeading(tt.hdg, tt.right); got != tt.want {
+			t.Errorf("misreadHeading(%d, %v) = %d; want %d", tt.hdg, tt.right, got, tt.want)
+		}
+	}
+
+	if got := misreadFrequency(118500, true); got != 118600 {
+		t.Errorf("misreadFrequency up = %d; want 118600", got)
+	}
+	if got := misreadFrequency(118500, false); got != 118400 {
+		t.Errorf("misreadFrequency down = %d; want 118400", got)
+	}
+}
+
+func TestSelectReadbackError(t *testing.T) {
+	s := &Service{
+		Config: &config{},
+		Weather: &Weather{
+			Baro: &Baro{Sealevel: 101325, Flight: 101325},
+			Wind: &Wind{},
+		},
+		Airports: map[string]*Airport{
+			"EGLL": {ICAO: "EGLL", Runways: map[string]*Runway{"27L": {Name: "27L"}, "27R": {Name: "27R"}}},
+		},
+	}
+	s.Config.ATC.Voices.ReadbackErrors.Factor = 1
+
+	ac := &Aircraft{
+		Registration: "G-TEST",
+		Flight: Flight{
+			Origin:             "EGLL",
+			AssignedRunwayName: "27L",
+			TargetHeading:      270,
+			Comms:              Comms{Callsign: "SPEEDBIRD 1"},
+			Position:           Position{Heading: 300},
+			Phase:              flightphase.Phase{Current: flightphase.Climbout.Index(), Class: flightclass.Departing},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		atcPhrase      string
+		items          []string
+		omission       bool
+		wantKey        string
+		wantCorrection string
+		wantOK         bool
+	}{
+		{"runway misread", "{$CALLSIGN} runway {@RUNWAY} cleared for takeoff", nil, false, "@RUNWAY", "runway 27left", true},
+		{"heading misread", "{$CALLSIGN} {@ATC_HEADING}", nil, false, "@ATC_HEADING", "heading 270", true},
+		{"runway omitted", "{$CALLSIGN} line up runway {@RUNWAY}", nil, true, "@RUNWAY", "runway 27left", true},
+		{"item not enabled", "{$CALLSIGN} runway {@RUNWAY} cleared for takeoff", []string{"altitude"}, false, "", "", false},
+		{"item only in square brackets", "{$CALLSIGN} [runway {@RUNWAY}] cleared for takeoff", nil, false, "", "", false},
+		{"no clearance items", "{$CALLSIGN} radar contact", nil, false, "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.Config.ATC.Voices.ReadbackErrors.Items = tt.items
+			s.Config.ATC.Voices.ReadbackErrors.OmissionFactor = 0
+			if tt.omission {
+				s.Config.ATC.Voices.ReadbackErrors.OmissionFactor = 1
+			}
+
+			_, atcTokens, _ := pcl.ResolvePhrase(tt.atcPhrase, s.newPCLContext(ac, "Tower"))
+			ctx := s.newPCLContext(ac, "PILOT")
+			pinReadbackTokens(ctx, atcTokens)
+
+			got, ok := s.selectReadbackError(autoReadback(tt.atcPhrase), ctx, atcTokens, ac)
+			if ok != tt.wantOK {
+				t.Fatalf("selectReadbackError ok = %v; want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.key != tt.wantKey || got.correction != tt.wantCorrection {
+				t.Errorf("got key %q correction %q; want %q %q", got.key, got.correction, tt.wantKey, tt.wantCorrection)
+			}
+
+			switch {
+			case tt.omission:
+				if got.wrong.Text != "" {
+					t.Errorf("omitted item rendered as %q; want empty", got.wrong.Text)
+				}
+			case tt.wantKey == "@RUNWAY":
+				if got.wrong.Data != "27R" || got.wrong.Text != "27right" {
+					t.Errorf("wrong runway = %v (%q); want 27R", got.wrong.Data, got.wrong.Text)
+				}
+			case tt.wantKey == "@ATC_HEADING":
+				hdg := got.wrong.Data.(int)
+				if hdg != 260 && hdg != 280 {
+					t.Errorf("wrong heading = %d; want 260 or 280", hdg)
+				}
+				if !strings.HasPrefix(got.wrong.Text, "turn left heading ") || strings.Contains(got.wrong.Text, "270") {
+					t.Errorf("wrong heading text = %q", got.wrong.Text)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package atc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	d9 "github.com/curbz/decimal-niner/internal"
+	"github.com/curbz/decimal-niner/internal/logger"
+)
+
+// resourceSet is a group of resource files which are validated and reloaded together while the service is
+// running, e.g. all of the phrase files
+type resourceSet struct {
+	name   string
+	files  func() []string // paths of the files in the set
+	reload func() error    // loads and validates the files, replacing the loaded data only if there is no error
+	stamp  string          // modification times and sizes of the files when last checked
+}
+
+// resourceSets returns the resource files that are watched for changes
+func (s *Service) resourceSets() []*resourceSet {
+	sets := []*resourceSet{
+		{name: "phrases", files: globResources("phrases*.json"), reload: s.reloadPhrases},
+		{name: "pronunciation dictionaries", files: globResources("*-dictionary.json"), reload: s.reloadDictionaries},
+		{name: "airlines", files: func() []string { return []string{s.Config.ATC.AirlinesFile} }, reload: s.reloadAirlines},
+	}
+	if s.Config.ATC.NotamFile != "" {
+		sets = append(sets, &resourceSet{name: "NOTAMs", files: func() []string { return []string{s.Config.ATC.NotamFile} }, reload: s.reloadNOTAMs})
+	}
+	for _, set := range sets {
+		set.stamp = stampFiles(set.files())
+	}
+	return sets
+}
+
+// watchResources checks the resource files for changes at the interval and reloads those that have changed
+func (s *Service) watchResources(interval time.Duration) {
+	sets := s.resourceSets()
+	logger.Log.Infof("watching resource files for changes every %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		checkResources(sets)
+	}
+}
+
+// checkResources reloads every resource set whose files have changed since the last check. A set that fails
+// to reload keeps its previous data and is not retried until its files change again.
+func checkResources(sets []*resourceSet) {
+	for _, set := range sets {
+		stamp := stampFiles(set.files())
+		if stamp == set.stamp {
+			continue
+		}
+		set.stamp = stamp
+		if err := set.reload(); err != nil {
+			logger.Log.Errorf("reload of %s rejected, keeping previously loaded data: %v", set.name, err)
+			continue
+		}
+		logger.Log.Infof("reloaded %s", set.name)
+	}
+}
+
+// globResources returns a function listing the files in the resources directory matching the pattern
+func globResources(pattern string) func() []string {
+	return func() []string {
+		files, _ := filepath.Glob(filepath.Join(d9.Resources, pattern))
+		return files
+	}
+}
+
+// stampFiles returns a value which changes when any of the files is modified, created or removed
+func stampFiles(files []string) string {
+	sort.Strings(files)
+	var sb strings.Builder
+	for _, f := range files {
+		sb.WriteString(f)
+		if info, err := os.Stat(f); err == nil {
+			fmt.Fprintf(&sb, ":%d:%d", info.ModTime().UnixNano(), info.Size())
+		}
+		sb.WriteString(";")
+	}
+	return sb.String()
+}
+
+// reloadPhrases loads and compiles the phrase files and replaces the phrase classes in use
+func (s *Service) reloadPhrases() error {
+	phraseClasses, err := loadPhraseClasses()
+	if err != nil {
+		return err
+	}
+	s.VoiceManager.setPhrases(phraseClasses)
+	return nil
+}
+
+// reloadDictionaries loads the pronunciation dictionaries and replaces those in use. All of the dictionaries
+// must load.
+func (s *Service) reloadDictionaries() error {
+	dictionaries, errs := loadDictionaries()
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	s.VoiceManager.setDictionaries(dictionaries)
+	return nil
+}
+
+// reloadAirlines loads the airlines file and replaces the airline maps
+func (s *Service) reloadAirlines() error {
+	byICAO, byName, byCountry, err := loadAirlines(s.Config.ATC.AirlinesFile)
+	if err != nil {
+		return err
+	}
+	s.airlineMu.Lock()
+	defer s.airlineMu.Unlock()
+	s.AirlineByICAO, s.AirlineByName, s.AirlineCodesByCountry = byICAO, byName, byCountry
+	return nil
+}
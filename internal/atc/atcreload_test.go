@@ -0,0 +1,104 @@
+package atc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	d9 "github.com/curbz/decimal-niner/internal"
+)
+
+func writeResource(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckResourcesAirlines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "airlines.json")
+	writeResource(t, path, `{"BAW": {"airline_name": "British Airways", "callsign": "Speedbird", "icao_country_code": "EG"}}`)
+
+	s := &Service{Config: &config{}}
+	s.Config.ATC.AirlinesFile = path
+	if err := s.reloadAirlines(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sets := []*resourceSet{{name: "airlines", files: func() []string { return []string{path} }, reload: s.reloadAirlines}}
+	sets[0].stamp = stampFiles(sets[0].files())
+
+	// a valid change is swapped in
+	writeResource(t, path, `{"BAW": {"airline_name": "British Airways", "callsign": "Speedbird", "icao_country_code": "EG"},
+		"EZY": {"airline_name": "easyJet", "callsign": "Easy", "icao_country_code": "EG"}}`)
+	checkResources(sets)
+	if s.GetAirlineByCode("EZY") == nil || s.GetAirlineByName("easyJet") == nil || len(s.AirlineCodesByCountry["EG"]) != 2 {
+		t.Fatalf("airlines not reloaded: %v", s.AirlineByICAO)
+	}
+
+	// bad files are rejected and the previous airlines kept
+	for _, bad := range []string{`{"BAW": {"airline_name": "British Airways"`, `{}`, `{"BAW": {"airline_name": "British Airways"}}`} {
+		writeResource(t, path, bad)
+		checkResources(sets)
+		if s.GetAirlineByCode("EZY") == nil || s.GetAirlineByCode("BAW").Callsign != "Speedbird" {
+			t.Fatalf("airlines replaced by bad file %s", bad)
+		}
+	}
+}
+
+func TestReloadPhrasesAndDictionaries(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"phrases.json", "phrases_unicom.json", "en-dictionary.json"} {
+		data, err := os.ReadFile(filepath.Join("resources", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeResource(t, filepath.Join(dir, name), string(data))
+	}
+	resources := d9.Resources
+	d9.Resources = dir
+	t.Cleanup(func() { d9.Resources = resources })
+
+	s := &Service{VoiceManager: &VoiceManager{}}
+	if err := s.reloadPhrases(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.reloadDictionaries(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	phrases := s.VoiceManager.phrases()
+
+	// a phrase file with an unknown tag is rejected
+	writeResource(t, filepath.Join(dir, "phrases_unicom.json"), `{"startup": [{"initiator": "pilot", "pilot": "{$CALLSIGN} {@NOPE}"}]}`)
+	if err := s.reloadPhrases(); err == nil {
+		t.Fatal("expected error for unknown PCL tag")
+	}
+	got := s.VoiceManager.phrases()
+	if len(got.phrasesUnicom) != len(phrases.phrasesUnicom) || len(got.phrases) != len(phrases.phrases) {
+		t.Error("phrases replaced by rejected file")
+	}
+
+	// a valid phrase file replaces all of the phrases
+	writeResource(t, filepath.Join(dir, "phrases_unicom.json"), `{"startup": [{"initiator": "pilot", "pilot": "{$CALLSIGN} starting"}]}`)
+	if err := s.reloadPhrases(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := s.VoiceManager.phrases(); len(got.phrasesUnicom) != 1 || len(got.phrases) != len(phrases.phrases) {
+		t.Errorf("phrases not reloaded: %d unicom phase keys", len(got.phrasesUnicom))
+	}
+
+	// a malformed dictionary is rejected
+	writeResource(t, filepath.Join(dir, "fr-dictionary.json"), `{"wien": `)
+	if err := s.reloadDictionaries(); err == nil {
+		t.Fatal("expected error for malformed dictionary")
+	}
+	if _, ok := s.VoiceManager.dictionary("EN"); !ok {
+		t.Error("dictionaries replaced by rejected file")
+	}
+	writeResource(t, filepath.Join(dir, "fr-dictionary.json"), `{"wien": "vienne"}`)
+	if err := s.reloadDictionaries(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := s.VoiceManager.dictionary("FR"); !ok {
+		t.Error("new dictionary not loaded")
+	}
+}
@@ -0,0 +1,156 @@
+package atc
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/curbz/decimal-niner/internal/logger"
+)
+
+// globalAirportsPack is the scenery_packs.ini entry for X-Plane's global airports, which are read from the
+// airports data file of the config
+const globalAirportsPack = "*GLOBAL_AIRPORTS*"
+
+// sceneryPack is an enabled scenery pack with airport data, in the order of priority of scenery_packs.ini
+type sceneryPack struct {
+	name    string // as listed in scenery_packs.ini, e.g. "Custom Scenery/EGLL Heathrow/"
+	aptPath string // path of the pack's apt.dat, empty for the global airports
+}
+
+// readSceneryPacks returns the enabled scenery packs of the X-Plane installation that have an apt.dat, highest
+// priority first. The global airports are included at their position in the list, or last if not listed.
+func readSceneryPacks(root string) ([]sceneryPack, error) {
+	path := filepath.Join(root, "Custom Scenery", "scenery_packs.ini")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scenery packs file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var packs []sceneryPack
+	global := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// the pack path may contain spaces, e.g. SCENERY_PACK Custom Scenery/EGLL Heathrow/
+		record, name, found := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !found || record != "SCENERY_PACK" {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		if name == globalAirportsPack {
+			packs = append(packs, sceneryPack{name: name})
+			global = true
+			continue
+		}
+
+		dir := filepath.FromSlash(name)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		apt := filepath.Join(dir, "Earth nav data", "apt.dat")
+		if _, err := os.Stat(apt); err == nil {
+			packs = append(packs, sceneryPack{name: name, aptPath: apt})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading scenery packs file %s: %w", path, err)
+	}
+	if !global {
+		packs = append(packs, sceneryPack{name: globalAirportsPack})
+	}
+	return packs, nil
+}
+
+// parseAirports parses the airports and airport controllers of the airports data file. Required airports that are
+// not in fullAirports are parsed as stubs, unless fullAirports is nil. When the config has an X-Plane root, each
+// required airport is instead taken from the highest priority enabled scenery pack that defines it, along with its
+// controllers, so that parking, taxiways and frequencies match the sim.
+func parseAirports(cfg *config, requiredAirports, fullAirports map[string]bool, diag *ParseDiagnostics) ([]*Controller, map[string]*Airport, error) {
+	if cfg.ATC.XPlaneRoot == "" {
+		return parseApt(cfg.ATC.AirportsDataFile, requiredAirports, fullAirports, diag)
+	}
+	packs, err := readSceneryPacks(cfg.ATC.XPlaneRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var controllers []*Controller
+	airports := make(map[string]*Airport)
+	claimed := make(map[string]bool) // ICAOs defined by a higher priority pack
+	afterGlobal := false
+	custom := 0
+
+	for _, pack := range packs {
+		global := pack.aptPath == ""
+		path := pack.aptPath
+		if global {
+			path = cfg.ATC.AirportsDataFile
+		}
+
+		remaining := make(map[string]bool)
+		for icao := range requiredAirports {
+			if _, supplied := airports[icao]; !supplied {
+				remaining[icao] = true
+			}
+		}
+
+		packControllers, packAirports, err := parseApt(path, remaining, fullAirports, diag)
+		if err != nil {
+			if global {
+				return nil, nil, err
+			}
+			logger.Log.Warnf("skipping scenery pack %s: %v", pack.name, err)
+			continue
+		}
+
+		for icao, ap := range packAirports {
+			if !global {
+				ap.SceneryPack = pack.name
+				custom++
+				logger.Log.Infof("airport %s supplied by scenery pack %s", icao, pack.name)
+			}
+			airports[icao] = ap
+		}
+		// the controllers of an airport come from the same pack as the airport. Packs below the global
+		// airports only add the airports that no other pack has.
+		for _, c := range packControllers {
+			if claimed[c.ICAO] || (afterGlobal && packAirports[c.ICAO] == nil) {
+				continue
+			}
+			controllers = append(controllers, c)
+		}
+
+		if global {
+			afterGlobal = true
+		} else if defined, err := regionAirports(pack.aptPath, ""); err == nil {
+			for icao := range defined {
+				claimed[icao] = true
+			}
+		}
+		for icao := range packAirports {
+			claimed[icao] = true
+		}
+	}
+
+	logger.Log.Infof("%d airports supplied by custom scenery packs, %d by the global airports", custom, len(airports)-custom)
+	return controllers, airports, nil
+}
+
+// sceneryPackFiles returns the scenery_packs.ini file and the apt.dat files of the enabled scenery packs, so
+// that the navdata cache is rebuilt when packs are added, removed, reordered or edited
+func sceneryPackFiles(root string) []string {
+	if root == "" {
+		return nil
+	}
+	files := []string{filepath.Join(root, "Custom Scenery", "scenery_packs.ini")}
+	packs, _ := readSceneryPacks(root)
+	for _, pack := range packs {
+		if pack.aptPath != "" {
+			files = append(files, pack.aptPath)
+		}
+	}
+	return files
+}
@@ -0,0 +1,139 @@
+package atc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSceneryPack writes an apt.dat to the Custom Scenery pack directory under the X-Plane root
+func writeSceneryPack(t *testing.T, root, pack, apt string) {
+	t.Helper()
+	dir := filepath.Join(root, "Custom Scenery", pack, "Earth nav data")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "apt.dat"), []byte(apt), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseAirportsSceneryPacks(t *testing.T) {
+	root := t.TempDir()
+	global := filepath.Join(root, "apt.dat")
+	writeResource(t, global, `I
+1100 Version
+1      83 0 0 EGLL London Heathrow
+1302 datum_lat 51.4775
+1302 datum_lon -0.4614
+1054 118500 HEATHROW TOWER
+1300 51.4700 -0.4500 90.0 gate jets 101
+1301 E airline baw
+1      13 0 0 KJFK John F Kennedy Intl
+1302 datum_lat 40.6398
+1302 datum_lon -73.7789
+1054 119100 KENNEDY TOWER
+99
+`)
+	writeSceneryPack(t, root, "EGLL Heathrow", `I
+1100 Version
+1      83 0 0 EGLL London Heathrow
+1302 datum_lat 51.4775
+1302 datum_lon -0.4614
+1054 118700 HEATHROW TOWER
+1300 51.4710 -0.4510 90.0 gate jets 521
+1301 E airline baw
+99
+`)
+	// a disabled pack and a pack below the global airports do not replace them
+	writeSceneryPack(t, root, "Old Heathrow", `I
+1100 Version
+1      83 0 0 EGLL London Heathrow
+1054 120000 HEATHROW TOWER
+99
+`)
+	writeSceneryPack(t, root, "Kennedy Low", `I
+1100 Version
+1      13 0 0 KJFK John F Kennedy Intl
+1054 120500 KENNEDY TOWER
+99
+`)
+	writeResource(t, filepath.Join(root, "Custom Scenery", "scenery_packs.ini"), `I
+1000 Version
+SCENERY
+
+SCENERY_PACK_DISABLED Custom Scenery/Old Heathrow/
+SCENERY_PACK Custom Scenery/EGLL Heathrow/
+SCENERY_PACK Custom Scenery/No Airports/
+SCENERY_PACK *GLOBAL_AIRPORTS*
+SCENERY_PACK Custom Scenery/Kennedy Low/
+`)
+
+	cfg := &config{}
+	cfg.ATC.AirportsDataFile = global
+	cfg.ATC.XPlaneRoot = root
+
+	packs, err := readSceneryPacks(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(packs) != 3 || packs[0].name != "Custom Scenery/EGLL Heathrow/" || packs[1].name != globalAirportsPack {
+		t.Fatalf("packs = %+v", packs)
+	}
+
+	controllers, airports, err := parseAirports(cfg, map[string]bool{"EGLL": true, "KJFK": true}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	egll, kjfk := airports["EGLL"], airports["KJFK"]
+	if egll == nil || egll.SceneryPack != "Custom Scenery/EGLL Heathrow/" || egll.Parking["521"] == nil || egll.Parking["101"] != nil {
+		t.Errorf("EGLL not supplied by the custom pack: %+v", egll)
+	}
+	if kjfk == nil || kjfk.SceneryPack != "" {
+		t.Errorf("KJFK not supplied by the global airports: %+v", kjfk)
+	}
+
+	freqs := make(map[string][]int)
+	for _, c := range controllers {
+		if c.RoleID == 3 {
+			freqs[c.ICAO] = append(freqs[c.ICAO], c.Freqs...)
+		}
+	}
+	if len(freqs["EGLL"]) != 1 || freqs["EGLL"][0] != 118700 {
+		t.Errorf("EGLL tower frequencies = %v; want only the custom pack's 118700", freqs["EGLL"])
+	}
+	if len(freqs["KJFK"]) != 1 || freqs["KJFK"][0] != 119100 {
+		t.Errorf("KJFK tower frequencies = %v; want only the global 119100", freqs["KJFK"])
+	}
+	if len(egll.Controllers) == 0 || egll.Controllers[0].Freqs[0] != 118700 {
+		t.Errorf("EGLL airport controllers = %v", egll.Controllers)
+	}
+
+	// airports loaded as the user flies are read from the same packs
+	idx, err := indexAirports(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loaded, err := idx.parse(map[string]bool{"EGLL": true, "KJFK": true, "ZZZZ": true}, nil)
+	if err != nil || len(loaded) != 2 {
+		t.Fatalf("indexed airports = %v, %v; want EGLL and KJFK", loaded, err)
+	}
+	if egll := loaded["EGLL"]; egll.SceneryPack != "Custom Scenery/EGLL Heathrow/" || egll.Parking["521"] == nil {
+		t.Errorf("indexed EGLL not read from the custom pack: %+v", egll)
+	}
+	if kjfk := loaded["KJFK"]; kjfk.SceneryPack != "" || kjfk.Elevation != 13 {
+		t.Errorf("indexed KJFK not read from the global airports: %+v", kjfk)
+	}
+	for _, c := range loaded["KJFK"].Controllers {
+		if c.RoleID == 3 && c.Freqs[0] != 119100 {
+			t.Errorf("indexed KJFK tower frequencies = %v; want the global 119100", c.Freqs)
+		}
+	}
+
+	// without an X-Plane root only the airports data file is read
+	cfg.ATC.XPlaneRoot = ""
+	_, airports, err = parseAirports(cfg, map[string]bool{"EGLL": true}, nil, nil)
+	if err != nil || airports["EGLL"] == nil || airports["EGLL"].Parking["101"] == nil {
+		t.Errorf("global EGLL not parsed: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package atc
+
+import (
+	"math"
+	"sort"
+
+	"github.com/curbz/decimal-niner/pkg/geometry"
+)
+
+// spatialCellDeg is the size in degrees of the grid cells of the spatial index
+const spatialCellDeg = 1.0
+
+// spatialIndex indexes the airports, controllers and holds of the service by position
+type spatialIndex struct {
+	airports     *geometry.GridIndex // ids index airportICAOs
+	airportICAOs []string
+	points       *geometry.GridIndex // point controllers, ids index Service.Controllers
+	airspaces    *geometry.GridIndex // airspace bounding boxes, ids index Service.Controllers
+	holds        *geometry.GridIndex // ids index holdList
+	holdList     []*Hold
+
+	// the data indexed, so that the index is rebuilt when the data is replaced
+	nAirports, nControllers, nHolds int
+	firstController                 *Controller
+}
+
+// spatial returns the spatial index, building it on first use and rebuilding it when the airports,
+// controllers or holds have been replaced since it was built
+func (s *Service) spatial() *spatialIndex {
+	s.spatialMu.Lock()
+	defer s.spatialMu.Unlock()
+
+	var first *Controller
+	if len(s.Controllers) > 0 {
+		first = s.Controllers[0]
+	}
+	si := s.spatialIdx
+	if si == nil || si.nAirports != len(s.GetAirports()) || si.nControllers != len(s.Controllers) ||
+		si.nHolds != len(s.Holds) || si.firstController != first {
+		si = buildSpatialIndex(s.GetAirports(), s.Controllers, s.Holds)
+		s.spatialIdx = si
+	}
+	return si
+}
+
+func buildSpatialIndex(airports map[string]*Airport, controllers []*Controller, holds map[string]*Hold) *spatialIndex {
+	si := &spatialIndex{
+		airports:     geometry.NewGridIndex(spatialCellDeg),
+		points:       geometry.NewGridIndex(spatialCellDeg),
+		airspaces:    geometry.NewGridIndex(spatialCellDeg),
+		holds:        geometry.NewGridIndex(spatialCellDeg),
+		nAirports:    len(airports),
+		nControllers: len(controllers),
+		nHolds:       len(holds),
+	}
+
+	// airports and holds are indexed in key order so that ties are resolved the same way every time
+	for icao := range airports {
+		si.airportICAOs = append(si.airportICAOs, icao)
+	}
+	sort.Strings(si.airportICAOs)
+	for i, icao := range si.airportICAOs {
+		si.airports.InsertPoint(i, airports[icao].Lat, airports[icao].Lon)
+	}
+
+	if len(controllers) > 0 {
+		si.firstController = controllers[0]
+	}
+	for i, c := range controllers {
+		if c.IsPoint {
+			si.points.InsertPoint(i, c.Lat, c.Lon)
+		}
+		for _, poly := range c.Airspaces {
+			si.airspaces.InsertBox(i, poly.MinLat, poly.MaxLat, poly.MinLon, poly.MaxLon)
+		}
+	}
+
+	keys := make([]string, 0, len(holds))
+	for key, h := range holds {
+		// holds without coordinates were not found in the fix data
+		if h.X != 0 || h.Y != 0 || h.Z != 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		h := holds[key]
+		si.holdList = append(si.holdList, h)
+		si.holds.InsertPoint(i, h.Lat, h.Lon)
+	}
+	return si
+}
+
+// nearestHold returns the hold closest to the position, or nil if no hold has coordinates
+func (si *spatialIndex) nearestHold(lat, lon float64) *Hold {
+	if i, _, ok := si.holds.Nearest(lat, lon, math.Inf(1)); ok {
+		return si.holdList[i]
+	}
+	return nil
+}
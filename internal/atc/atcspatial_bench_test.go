@@ -0,0 +1,120 @@
+package atc
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/curbz/decimal-niner/internal/logger"
+	"github.com/curbz/decimal-niner/pkg/geometry"
+)
+
+// worldService returns a service with roughly as many airports, point controllers, centre airspaces and
+// holds as apt.dat, atc.dat and earth_hold.dat for the whole world
+func worldService() *Service {
+	rng := rand.New(rand.NewSource(1))
+	s := &Service{Airports: make(map[string]*Airport), Holds: make(map[string]*Hold)}
+	for i := 0; i < 40000; i++ {
+		icao := fmt.Sprintf("X%04d", i)
+		lat, lon := rng.Float64()*140-70, rng.Float64()*360-180
+		s.Airports[icao] = &Airport{ICAO: icao, Lat: lat, Lon: lon}
+		if i%4 == 0 {
+			for role := 1; role <= 3; role++ {
+				s.Controllers = append(s.Controllers, &Controller{Name: icao, ICAO: icao, RoleID: role, Freqs: []int{118000 + role*100}, IsPoint: true, Lat: lat, Lon: lon})
+			}
+		}
+	}
+	// 5x5 degree centre sectors
+	for lat := -70.0; lat < 70; lat += 5 {
+		for lon := -180.0; lon < 180; lon += 5 {
+			poly := Airspace{Floor: 0, Ceiling: 66000, Points: [][2]float64{{lat, lon}, {lat + 5, lon}, {lat + 5, lon + 5}, {lat, lon + 5}},
+				MinLat: lat, MaxLat: lat + 5, MinLon: lon, MaxLon: lon + 5}
+			s.Controllers = append(s.Controllers, &Controller{Name: fmt.Sprintf("Centre %v %v", lat, lon), RoleID: 6, Freqs: []int{132000}, IsRegion: true, Airspaces: []Airspace{poly}})
+		}
+	}
+	for i := 0; i < 20000; i++ {
+		h := &Hold{Ident: fmt.Sprintf("H%04d", i), Region: "XX", Lat: rng.Float64()*140 - 70, Lon: rng.Float64()*360 - 180}
+		h.InitUnitVector()
+		s.Holds[h.Ident+"_XX"] = h
+	}
+	return s
+}
+
+func benchmarkPositions() [][2]float64 {
+	rng := rand.New(rand.NewSource(2))
+	positions := make([][2]float64, 1024)
+	for i := range positions {
+		positions[i] = [2]float64{rng.Float64()*140 - 70, rng.Float64()*360 - 180}
+	}
+	return positions
+}
+
+func BenchmarkGetClosestAirport(b *testing.B) {
+	s := worldService()
+	positions := benchmarkPositions()
+	s.spatial()
+
+	b.Run("SpatialIndex", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p := positions[i%len(positions)]
+			s.GetClosestAirport(p[0], p[1], 30)
+		}
+	})
+
+	// the linear scan that GetClosestAirport replaced
+	b.Run("LinearScan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p := positions[i%len(positions)]
+			best := 30.0
+			for _, ap := range s.Airports {
+				if d := geometry.DistNM(p[0], p[1], ap.Lat, ap.Lon); d < best {
+					best = d
+				}
+			}
+		}
+	})
+}
+
+// quietLogs stops the per call logging of the lookups for the rest of the benchmark
+func quietLogs(b *testing.B) {
+	level := logger.Log.GetLevel()
+	logger.Log.SetLevel(logrus.ErrorLevel)
+	b.Cleanup(func() { logger.Log.SetLevel(level) })
+}
+
+func BenchmarkLocateController(b *testing.B) {
+	quietLogs(b)
+	s := worldService()
+	positions := benchmarkPositions()
+	s.spatial()
+	b.ResetTimer()
+
+	b.Run("Tower", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p := positions[i%len(positions)]
+			s.locateController("bench", 0, 3, p[0], p[1], 1000, "")
+		}
+	})
+	b.Run("Centre", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p := positions[i%len(positions)]
+			s.locateController("bench", 132000, RoleNone, p[0], p[1], 35000, "")
+		}
+	})
+}
+
+func BenchmarkAssignHoldGlobal(b *testing.B) {
+	quietLogs(b)
+	s := worldService()
+	positions := benchmarkPositions()
+	s.spatial()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		p := positions[i%len(positions)]
+		ac := &Aircraft{Flight: Flight{Position: Position{Lat: p[0], Long: p[1]}}}
+		s.AssignHold(ac, "", true)
+	}
+}
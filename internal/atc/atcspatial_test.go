@@ -0,0 +1,72 @@
+package atc
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/curbz/decimal-niner/pkg/geometry"
+)
+
+func TestSpatialIndexMatchesLinearScan(t *testing.T) {
+	s := worldService()
+	for _, p := range benchmarkPositions()[:200] {
+		// nearest hold by unit vector, as AssignHold did before the index
+		ux, uy, uz := toUnit(geometry.DegToRad(p[0]), geometry.DegToRad(p[1]))
+		var want *Hold
+		bestDot := -2.0
+		for _, h := range s.Holds {
+			if dot := ux*h.X + uy*h.Y + uz*h.Z; dot > bestDot {
+				bestDot, want = dot, h
+			}
+		}
+		if got := s.spatial().nearestHold(p[0], p[1]); got != want {
+			t.Errorf("nearestHold(%v) = %s; want %s", p, got.Ident, want.Ident)
+		}
+	}
+}
+
+func TestNearestAirportMatchesLinearScan(t *testing.T) {
+	s := worldService()
+	for _, suffix := range []string{"7", "000"} {
+		accept := func(ap *Airport) bool { return strings.HasSuffix(ap.ICAO, suffix) }
+		for _, p := range benchmarkPositions()[:50] {
+			var want *Airport
+			wantDist := math.Inf(1)
+			for _, ap := range s.Airports {
+				if d := geometry.DistNM(p[0], p[1], ap.Lat, ap.Lon); d < wantDist && accept(ap) {
+					want, wantDist = ap, d
+				}
+			}
+			if got := s.NearestAirport(p[0], p[1], accept); got != want {
+				t.Errorf("NearestAirport(%v) of *%s = %v; want %s", p, suffix, got, want.ICAO)
+			}
+		}
+	}
+	if got := s.NearestAirport(0, 0, func(*Airport) bool { return false }); got != nil {
+		t.Errorf("NearestAirport accepting none = %s; want nil", got.ICAO)
+	}
+}
+
+func TestSpatialIndexRebuild(t *testing.T) {
+	s := &Service{Airports: map[string]*Airport{"EGLL": {ICAO: "EGLL", Lat: 51.47, Lon: -0.45}}}
+	if got := s.GetClosestAirport(51.15, -0.19, 30); got != "EGLL" {
+		t.Fatalf("GetClosestAirport = %q; want EGLL", got)
+	}
+
+	// an airport added after the index was built is found
+	s.Airports["EGKK"] = &Airport{ICAO: "EGKK", Lat: 51.148, Lon: -0.190}
+	if got := s.GetClosestAirport(51.15, -0.19, 30); got != "EGKK" {
+		t.Errorf("GetClosestAirport = %q; want EGKK", got)
+	}
+
+	// replacing the controllers rebuilds the index
+	s.Controllers = []*Controller{{Name: "Heathrow", ICAO: "EGLL", RoleID: 3, IsPoint: true, Lat: 51.47, Lon: -0.45}}
+	if c := s.locateController("test", 0, 3, 51.47, -0.45, 0, ""); c == nil || c.Name != "Heathrow" {
+		t.Fatalf("locateController = %v; want Heathrow", c)
+	}
+	s.Controllers = []*Controller{{Name: "Gatwick", ICAO: "EGKK", RoleID: 3, IsPoint: true, Lat: 51.148, Lon: -0.190}}
+	if c := s.locateController("test", 0, 3, 51.15, -0.19, 0, ""); c == nil || c.Name != "Gatwick" {
+		t.Errorf("locateController = %v; want Gatwick", c)
+	}
+}
@@ -337,6 +337,36 @@ var numericMap = map[rune]string{
 	'9': "niner",
 }
 
+// countryLanguages maps ISO country codes to the language (ISO 639-1) spoken by their ground and tower
+// controllers to domestic airlines when native language transmissions are enabled
+var countryLanguages = map[string]string{
+	"FR": "fr",
+	"DE": "de",
+	"ES": "es",
+	"IT": "it",
+}
+
+// localeNumericMaps holds the digit words of each native transmission language
+var localeNumericMaps = map[string]map[rune]string{
+	"fr": {'0': "zéro", '1': "un", '2': "deux", '3': "trois", '4': "quatre",
+		'5': "cinq", '6': "six", '7': "sept", '8': "huit", '9': "neuf"},
+	"de": {'0': "null", '1': "eins", '2': "zwo", '3': "drei", '4': "vier",
+		'5': "fünf", '6': "sechs", '7': "sieben", '8': "acht", '9': "neun"},
+	"es": {'0': "cero", '1': "uno", '2': "dos", '3': "tres", '4': "cuatro",
+		'5': "cinco", '6': "seis", '7': "siete", '8': "ocho", '9': "nueve"},
+	"it": {'0': "zero", '1': "uno", '2': "due", '3': "tre", '4': "quattro",
+		'5': "cinque", '6': "sei", '7': "sette", '8': "otto", '9': "nove"},
+}
+
+// localeRunwaySuffixes holds the words for the left, right and centre runway designators of each native
+// transmission language
+var localeRunwaySuffixes = map[string][3]string{
+	"fr": {"gauche", "droite", "centre"},
+	"de": {"links", "rechts", "mitte"},
+	"es": {"izquierda", "derecha", "centro"},
+	"it": {"sinistra", "destra", "centro"},
+}
+
 var atcFacilityByPhaseMap = map[flightphase.FlightPhase]PhaseFacility{
 	// PRE-FLIGHT & DEPARTURE
 	flightphase.Parked: {
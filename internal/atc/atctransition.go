@@ -0,0 +1,158 @@
+package atc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/curbz/decimal-niner/internal/constants"
+)
+
+// transition level methods
+const (
+	TransitionMethodComputed = "computed"  // TL is the first flight level at least 1000ft above TA for the QNH
+	TransitionMethodFixed    = "fixed"     // TL is published and does not vary with QNH
+	TransitionMethodQNHTable = "qnh_table" // TL is looked up from bands of QNH
+)
+
+// TransitionRules holds the transition altitude and level rules for ICAO regions and countries
+type TransitionRules struct {
+	Rules []*TransitionRule `json:"rules"`
+}
+
+// TransitionRule describes how the transition altitude and level are set in the airspace of the airports
+// matching its ICAO prefixes. The rule with the longest matching prefix applies.
+type TransitionRule struct {
+	Name            string          `json:"name"`
+	Prefixes        []string        `json:"prefixes"` // ICAO region ("K") or country ("EG") prefixes
+	TransitionAlt   int             `json:"ta"`       // feet, used when the airport does not publish its own
+	Method          string          `json:"method"`
+	TransitionLevel int             `json:"tl"` // flight level, for the fixed method
+	QNHTable        []TransitionQNH `json:"qnh_table"`
+	Units           string          `json:"units"` // feet (default) or metric
+}
+
+// TransitionQNH is a band of a QNH table: the transition level applies when QNH is at or above MinHPa
+type TransitionQNH struct {
+	MinHPa          float64 `json:"min_hpa"`
+	TransitionLevel int     `json:"tl"`
+}
+
+// loadTransitionRules reads the transition altitude and level rules from the JSON resource file
+func loadTransitionRules(path string) (*TransitionRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules TransitionRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	for i, r := range rules.Rules {
+		switch r.Method {
+		case "":
+			r.Method = TransitionMethodComputed
+		case TransitionMethodComputed:
+		case TransitionMethodFixed:
+			if r.TransitionLevel <= 0 {
+				return nil, fmt.Errorf("rule %d (%s): fixed method requires a transition level", i+1, r.Name)
+			}
+		case TransitionMethodQNHTable:
+			if len(r.QNHTable) == 0 {
+				return nil, fmt.Errorf("rule %d (%s): qnh_table method requires a QNH table", i+1, r.Name)
+			}
+			for j := 1; j < len(r.QNHTable); j++ {
+				if r.QNHTable[j].MinHPa >= r.QNHTable[j-1].MinHPa {
+					return nil, fmt.Errorf("rule %d (%s): QNH table must be in descending order of pressure", i+1, r.Name)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("rule %d (%s): unknown method '%s'", i+1, r.Name, r.Method)
+		}
+		switch r.Units {
+		case "":
+			r.Units = AltitudeUnitsFeet
+		case AltitudeUnitsFeet, AltitudeUnitsMetric:
+		default:
+			return nil, fmt.Errorf("rule %d (%s): unknown altitude units '%s'", i+1, r.Name, r.Units)
+		}
+		if len(r.Prefixes) == 0 {
+			return nil, fmt.Errorf("rule %d (%s): no ICAO prefixes", i+1, r.Name)
+		}
+	}
+	return &rules, nil
+}
+
+// ruleFor returns the rule with the longest ICAO prefix matching the airport or controller ICAO. The continental
+// standard transition altitude is returned with a computed level if no rule matches.
+func (t *TransitionRules) ruleFor(icao string) *TransitionRule {
+	var best *TransitionRule
+	bestLen := 0
+	if t != nil {
+		for _, r := range t.Rules {
+			for _, p := range r.Prefixes {
+				if len(p) > bestLen && strings.HasPrefix(icao, p) {
+					best, bestLen = r, len(p)
+				}
+			}
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	// If ICAO starts with E or L (Europe), use 6000, otherwise 18000
+	ta := constants.TransitionAltRegionOtherFt
+	if strings.HasPrefix(icao, "E") || strings.HasPrefix(icao, "L") {
+		ta = constants.TransitionAltRegionEUFt
+	}
+	return &TransitionRule{TransitionAlt: ta, Method: TransitionMethodComputed}
+}
+
+// transitionLevel returns the transition level for the transition altitude and sea level pressure (Pascals).
+// The computed level is used if the rule would put the transition level below the transition altitude.
+func (r *TransitionRule) transitionLevel(transitionAlt int, currBaroPascals float64) int {
+	tl := 0
+	switch r.Method {
+	case TransitionMethodFixed:
+		tl = r.TransitionLevel
+	case TransitionMethodQNHTable:
+		qnh := currBaroPascals / 100
+		for _, band := range r.QNHTable {
+			tl = band.TransitionLevel
+			if qnh >= band.MinHPa {
+				break
+			}
+		}
+	}
+	if tl*100 < transitionAlt {
+		return getTransitionLevel(transitionAlt, currBaroPascals)
+	}
+	return tl
+}
+
+// getTransitionICAO returns the ICAO whose transition rules apply to the aircraft: that of its controller, or
+// the nearest airport if it has no controller, or the airport of its flight phase if none is nearby
+func (s *Service) getTransitionICAO(ac *Aircraft) string {
+	if ac.Flight.Comms.Controller != nil && ac.Flight.Comms.Controller.ICAO != "" {
+		return ac.Flight.Comms.Controller.ICAO
+	}
+	if s.AirportService != nil {
+		if icao := s.AirportService.GetClosestAirport(ac.Flight.Position.Lat, ac.Flight.Position.Long, 30.0); icao != "" {
+			return icao
+		}
+	}
+	return getAirportICAObyPhaseClass(ac)
+}
+
+// getAircraftTransitionLevel returns the transition level in the airspace of the aircraft for the current QNH.
+// Altitudes at or above the level are given as flight levels.
+func (s *Service) getAircraftTransitionLevel(ac *Aircraft) int {
+	icao := s.getTransitionICAO(ac)
+	baro := 101325.0
+	if w := s.GetAirportWeather(icao); w != nil && w.Baro != nil {
+		baro = w.Baro.Sealevel
+	}
+	return s.TransitionRules.ruleFor(icao).transitionLevel(s.getTransistionAltitude(ac), baro)
+}
@@ -0,0 +1,68 @@
+package atc
+
+import (
+	"testing"
+)
+
+func TestLoadTransitionRules(t *testing.T) {
+	rules, err := loadTransitionRules("resources/transition_levels.json")
+	if err != nil {
+		t.Fatalf("unexpected error loading transition rules: %v", err)
+	}
+	if len(rules.Rules) == 0 {
+		t.Fatalf("expected transition rules to be loaded")
+	}
+
+	tests := []struct {
+		icao   string
+		wantTA int
+	}{
+		{"KJFK", 18000},
+		{"CYYZ", 18000},
+		{"EGLL", 6000},
+		{"LEMD", 6000},
+		{"EDDF", 5000},
+		{"LFPG", 5000},
+		{"RJTT", 14000},
+		{"YSSY", 10000},
+		{"SBGR", 18000}, // no rule, continental fallback
+	}
+	for _, tt := range tests {
+		if got := rules.ruleFor(tt.icao).TransitionAlt; got != tt.wantTA {
+			t.Errorf("ruleFor(%s).TransitionAlt = %d; want %d", tt.icao, got, tt.wantTA)
+		}
+	}
+}
+
+func TestTransitionRuleLevel(t *testing.T) {
+	us := &TransitionRule{Method: TransitionMethodQNHTable, QNHTable: []TransitionQNH{
+		{MinHPa: 1013.2, TransitionLevel: 180},
+		{MinHPa: 979.3, TransitionLevel: 190},
+		{MinHPa: 0, TransitionLevel: 200},
+	}}
+	japan := &TransitionRule{Method: TransitionMethodFixed, TransitionLevel: 140}
+	computed := &TransitionRule{Method: TransitionMethodComputed}
+
+	tests := []struct {
+		name string
+		rule *TransitionRule
+		ta   int
+		baro float64
+		want int
+	}{
+		{"us high pressure", us, 18000, 102000, 180},
+		{"us low pressure", us, 18000, 100000, 190},
+		{"us very low pressure", us, 18000, 96000, 200},
+		{"fixed ignores pressure", japan, 14000, 98000, 140},
+		{"computed standard", computed, 6000, 101325, 70},
+		{"computed low pressure", computed, 6000, 100000, 80},
+		{"table below airport TA is computed", us, 18000 + 1000, 102000, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.transitionLevel(tt.ta, tt.baro); got != tt.want {
+				t.Errorf("transitionLevel(%d, %.0f) = %d; want %d", tt.ta, tt.baro, got, tt.want)
+			}
+		})
+	}
+}
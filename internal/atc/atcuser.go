@@ -53,12 +53,14 @@ func (s *Service) NotifyUserStateChange(pos Position, tunedFreqs, tunedFacilityR
 			util.LogWithLabel(fmt.Sprintf("User_COM%d", idx), "controller found for user on COM%d %d: %s %s Role: %s (%d)", idx, uFreq,
 				controller.Name, controller.ICAO, roleNameMap[controller.RoleID], controller.RoleID)
 		} else {
-			util.LogWithLabel(fmt.Sprintf("User_COM%d", idx), "No nearby controller found for user on COM%d %d", idx, uFreq)
+			// a closed facility is not found, so its frequency stays silent
+			delete(s.UserState.ActiveFacilities, idx)
+			util.LogWithLabel(fmt.Sprintf("User_COM%d", idx), "No nearby open controller found for user on COM%d %d", idx, uFreq)
 		}
 	}
 
 	nearestICAO := s.AirportService.GetClosestAirport(pos.Lat, pos.Long, 1000)
-	if apt, found := s.Airports[nearestICAO]; found {
+	if apt, found := s.GetAirports()[nearestICAO]; found {
 		s.UserState.NearestAirport = apt
 	} else {
 		s.UserState.NearestAirport = nil
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"math"
 	"math/rand"
 	"os/exec"
 	"regexp"
@@ -26,17 +25,18 @@ import (
 	"github.com/curbz/decimal-niner/internal/logger"
 	"github.com/curbz/decimal-niner/internal/pcl"
 
-	"github.com/curbz/decimal-niner/pkg/geometry"
 	"github.com/curbz/decimal-niner/pkg/util"
 )
 
 type VoicesConfig struct {
-	PhrasesFile              string `yaml:"phrases_file"`
-	UnicomPhrasesFile        string `yaml:"unicom_phrases_file"`
-	Piper                    Piper  `yaml:"piper"`
-	Sox                      Sox    `yaml:"sox"`
-	HandoffValedictionFactor int    `yaml:"handoff_valediction_factor"`
-	SayAgainFactor           int    `yaml:"say_again_factor"`
+	PhrasesFile              string               `yaml:"phrases_file"`
+	UnicomPhrasesFile        string               `yaml:"unicom_phrases_file"`
+	Piper                    Piper                `yaml:"piper"`
+	Sox                      Sox                  `yaml:"sox"`
+	HandoffValedictionFactor int                  `yaml:"handoff_valediction_factor"`
+	SayAgainFactor           int                  `yaml:"say_again_factor"`
+	ReadbackErrors           ReadbackErrorsConfig `yaml:"readback_errors"`
+	NativeLanguage           bool                 `yaml:"native_language"` // ground and tower speak the local language to domestic airlines
 }
 
 // +----------------------------------------------------------+
@@ -49,6 +49,7 @@ type ATCMessage struct {
 	Text           string
 	CountryCode    string
 	ControllerName string
+	Language       string // language of a native language transmission, empty for English
 }
 
 type Exchange struct {
@@ -125,12 +126,9 @@ func (s *Service) startComms() {
 				continue
 			}
 
-			var phraseSource map[string][]Exchange
-			if ac.Flight.Comms.Controller.RoleID == 0 {
-				phraseSource = s.VoiceManager.PhraseClasses.phrasesUnicom
-			} else {
-				phraseSource = s.VoiceManager.PhraseClasses.phrases
-			}
+			isUnicom := ac.Flight.Comms.Controller.RoleID == 0
+			standard := getPhraseologyStandard(ac)
+			language := s.getExchangeLanguage(ac)
 
 			phraseKey := phaseFacility.atcPhase
 
@@ -140,8 +138,23 @@ func (s *Service) startComms() {
 			// - cruise sector handoffs: when Flight.Comms.CruiseHandoff is not equal to NoHandoff (default)
 			// - "cruise_tod": 	when Flight.ClearedTOD is true in cruise phase, indicating the aircraft has passed its top of descent point
 
+			// emergency declarations and diversions take priority over all other sub-phases and are only announced once
+			isEmergencyCall := ac.Flight.Emergency != nil && !ac.Flight.Emergency.Announced
+			isDiversionCall := !isEmergencyCall && ac.Flight.Diversion != nil && !ac.Flight.Diversion.Announced
+			if isEmergencyCall {
+				if ac.Flight.Emergency.Type == EmergencyRadioFailure && ac.Flight.Comms.Controller.RoleID == 0 {
+					// no one to call the aircraft on unicom, the squawk is the only indication
+					util.LogWithLabel(ac.Registration, "radio failure on unicom, no transmission")
+					continue
+				}
+				phraseKey = emergencyPhraseKey(ac.Flight.Emergency)
+			}
+			if isDiversionCall {
+				phraseKey = "diversion"
+			}
+
 			// cruise sector handoffs
-			if ac.Flight.Comms.CruiseHandoff != NoHandoff {
+			if !isEmergencyCall && !isDiversionCall && ac.Flight.Comms.CruiseHandoff != NoHandoff {
 				//TODO handoff phrases should be defined in phrases.json for maximum flexibility and variety
 				switch ac.Flight.Comms.CruiseHandoff {
 				case HandoffEnterSector:
@@ -160,7 +173,7 @@ func (s *Service) startComms() {
 						continue
 					}
 					freqStr := formatFrequency(ac.Flight.Comms.NextController.Freqs[0])
-					phrase := fmt.Sprintf("{$CALLSIGN} [contact] %s [on] %s {{$VALEDICTION}}", ac.Flight.Comms.Controller.Name, freqStr)
+					phrase := fmt.Sprintf("{$CALLSIGN} [contact] %s [on] %s {@VALEDICTION}", ac.Flight.Comms.Controller.Name, freqStr)
 					s.preparePhrase(phrase, roleNameMap[phaseFacility.roleId], ac)
 					s.preparePhrase(autoReadback(phrase), "PILOT", ac)
 					util.GoSafe(func() {
@@ -179,14 +192,28 @@ func (s *Service) startComms() {
 			}
 
 			// "cruise-tod" detection. the condition should only allow for this to be triggered once
-			if ac.Flight.Phase.Current == flightphase.Cruise.Index() && ac.Flight.ClearedTOD {
+			if !isEmergencyCall && !isDiversionCall && ac.Flight.Phase.Current == flightphase.Cruise.Index() && ac.Flight.ClearedTOD {
 				phraseKey = fmt.Sprintf("%s_tod", phraseKey)
 			}
 
+			// go-arounds and holds caused by weather give the reason
+			if ac.Flight.WeatherReason != "" && (ac.Flight.Phase.Current == flightphase.GoAround.Index() ||
+				ac.Flight.Phase.Current == flightphase.Holding.Index()) {
+				phraseKey = fmt.Sprintf("%s_weather", phraseKey)
+			}
+
 			// ----------- end of sub-phase detection --------------
 
-			exchanges, exists := phraseSource[phraseKey]
-			if !exists || len(exchanges) == 0 {
+			phraseClasses := s.VoiceManager.phrases()
+			exchanges := phraseClasses.getExchanges(phraseKey, standard, isUnicom)
+			// native language exchanges replace the English ones for the phase keys they define
+			ac.Flight.Comms.Language = ""
+			if nativeExchanges := phraseClasses.getLanguageExchanges(phraseKey, language); len(nativeExchanges) > 0 {
+				util.LogWithLabel(ac.Registration, "native language exchange selected: %s", language)
+				exchanges = nativeExchanges
+				ac.Flight.Comms.Language = language
+			}
+			if len(exchanges) == 0 {
 				util.LogErrWithLabel(ac.Registration, "error: no phrases found for flight phase %d", ac.Flight.Phase.Current)
 				continue
 			}
@@ -195,7 +222,10 @@ func (s *Service) startComms() {
 			exchange := exchanges[rand.Intn(len(exchanges))]
 
 			// didSayAgain bool ensures 'say again' cannot be repeated for the same pilot/controller exchange
-			didSayAgain := false
+			// emergency calls and native language exchanges are never asked to be repeated
+			didSayAgain := isEmergencyCall || ac.Flight.Comms.Language != ""
+			// atcTokens holds the values resolved for the controller's transmission so the readback can repeat them
+			var atcTokens []pcl.Token
 			if exchange.Initiator == "pilot" {
 				// pilot's initial phrase
 				s.preparePhrase(exchange.Pilot, "PILOT", ac)
@@ -209,31 +239,31 @@ func (s *Service) startComms() {
 						s.preparePhrase(exchange.Pilot, "PILOT", ac)
 					}
 					// atc responds
-					s.preparePhrase(exchange.ATC, roleNameMap[phaseFacility.roleId], ac)
+					atcTokens = s.preparePhrase(exchange.ATC, roleNameMap[phaseFacility.roleId], ac)
 					// pilot reads back atc instructions, but not for shutdown to avoid unecessary repetition
 					// also check if read back is explicitly precluded
 					if ac.Flight.Phase.Current != flightphase.Shutdown.Index() &&
 						!strings.Contains(exchange.ATC, "{NOREADBACK}") {
-						s.preparePhrase(autoReadback(exchange.ATC), "PILOT", ac)
+						s.readback(exchange.ATC, atcTokens, roleNameMap[phaseFacility.roleId], ac)
 					}
 				}
 			}
 
 			if exchange.Initiator == "atc" {
 				// atc initiates call to pilot
-				s.preparePhrase(exchange.ATC, roleNameMap[phaseFacility.roleId], ac)
+				atcTokens = s.preparePhrase(exchange.ATC, roleNameMap[phaseFacility.roleId], ac)
 				// randomised 'say again'
 				if rand.Intn(s.Config.ATC.Voices.SayAgainFactor) == 0 && !didSayAgain {
 					// pilot asks atc to repeat request
 					s.preparePhrase("{$FACILITY} say again", "PILOT", ac)
 					// atc repeats instructions
-					s.preparePhrase(exchange.ATC, roleNameMap[phaseFacility.roleId], ac)
+					atcTokens = s.preparePhrase(exchange.ATC, roleNameMap[phaseFacility.roleId], ac)
 				}
 				if exchange.Pilot == "" {
 					// if the selected exchange does not specify a pilot response and the ATC exchange phrase does not
 					// explicitly preclude readback, the pilot will read back atc instructions
 					if !strings.Contains(exchange.ATC, "{NOREADBACK}") {
-						s.preparePhrase(autoReadback(exchange.ATC), "PILOT", ac)
+						s.readback(exchange.ATC, atcTokens, roleNameMap[phaseFacility.roleId], ac)
 					}
 				} else {
 					// else the pilot responds with the specified exchange phrase
@@ -285,32 +315,31 @@ func autoReadback(phrase string) string {
 	return phrase
 }
 
+var squareBracketedPhrase = regexp.MustCompile(`\[[^\]]*\]`)
+
 func removeSquareBracketedPhrases(input string) string {
-	re := regexp.MustCompile((`\[[^\]]*\]`))
-	result := re.ReplaceAllString(input, "")
-	return result
+	return squareBracketedPhrase.ReplaceAllString(input, "")
 }
 
 // preparePhrase prepares the phrase and creates an ATC message
 // role is either "PILOT" or the facility type e.g "Tower"
-func (s *Service) preparePhrase(phrase, role string, ac *Aircraft) {
+// the PCL tokens resolved for the phrase are returned
+func (s *Service) preparePhrase(phrase, role string, ac *Aircraft) []pcl.Token {
+	return s.transmitPhrase(phrase, role, ac, s.newPCLContext(ac, role))
+}
 
-	// call PCL interpreter
-	phrase, err := pcl.ProcessPhrase(phrase, s.newPCLContext(ac, role))
+// transmitPhrase prepares the phrase using the given PCL context and sends it to the radio queue
+func (s *Service) transmitPhrase(phrase, role string, ac *Aircraft, ctx pcl.Context) []pcl.Token {
+
+	phrase, tokens, err := renderPhrase(phrase, ctx, ac.Flight.Comms.Language)
 	if err != nil {
 		logger.Log.Errorf("Unexpected PCL error: %v", err)
+		return nil
 	}
 
-	// --- remove PCL statements ---
-	if strings.Contains(phrase, "{NOREADBACK}") {
-		phrase = strings.ReplaceAll(phrase, "{NOREADBACK}", "")
-	}
-
-	phrase = translateNumerics(phrase)
-	phrase = cleanPhrase(phrase)
-
 	msg := &ATCMessage{ac.Flight.Comms.Controller.ICAO, ac, role,
 		phrase, ac.Flight.Comms.CountryCode, ac.Flight.Comms.Controller.Name,
+		ac.Flight.Comms.Language,
 	}
 
 	util.LogWithLabel(msg.AircraftSnap.Registration, "sending phrase to radio queue for speech generation: %s", msg.Text)
@@ -322,286 +351,43 @@ func (s *Service) preparePhrase(phrase, role string, ac *Aircraft) {
 	default:
 		util.LogWarnWithLabel(msg.AircraftSnap.Registration, "radio queue is full. speech generation skipped")
 	}
+
+	return tokens
 }
 
-func (s *Service) newPCLContext(ac *Aircraft, role string) pcl.PCLContext {
+// renderPhrase resolves the phrase with the PCL context and returns the text to be spoken in the language
+func renderPhrase(phrase string, ctx pcl.Context, language string) (string, []pcl.Token, error) {
 
-	var rwy *Runway
-	if ac.Flight.AssignedRunway == nil {
-		phaseICAO := getAirportICAObyPhaseClass(ac)
-		rwy = s.GetAirportRunwayByICAO(phaseICAO, ac.Flight.AssignedRunwayName)
-	} else {
-		rwy = ac.Flight.AssignedRunway
+	// call PCL interpreter
+	phrase, tokens, err := pcl.ResolvePhrase(phrase, ctx)
+	if err != nil {
+		return "", nil, err
 	}
 
-	return pcl.PCLContext{
-		// --- RAW DATA ($) ---
-		"$VECTORING": func(args ...string) interface{} { return ac.Flight.Vectoring },
-		"$ALTITUDE":  func(args ...string) interface{} { return int(math.Round(ac.Flight.Position.Altitude)) },
-		"$CALLSIGN":  func(args ...string) interface{} { return strings.ToLower(ac.Flight.Comms.Callsign) },
-		"$FACILITY": func(args ...string) interface{} {
-			if ac.Flight.Comms.Controller != nil {
-				return ac.Flight.Comms.Controller.Name
-			} else {
-				util.LogWarnWithLabel(ac.Registration, "no controller assigned for facility resolution")
-				return ""
-			}
-		},
-		"$SQUAWK": func(args ...string) interface{} { return ac.Flight.Squawk },
-		"$HEADING": func(args ...string) interface{} {
-			return fmt.Sprintf("%03d", int(math.Round(geometry.NormalizeHeading(ac.Flight.Position.Heading))))
-		},
-		"$ATC_HEADING": func(args ...string) interface{} {
-			return fmt.Sprintf("%03d", int(math.Round(geometry.NormalizeHeading(ac.Flight.TargetHeading))))
-		},
-		"$RUNWAY":        func(args ...string) interface{} { return ac.Flight.AssignedRunwayName },
-		"$DESTINATION":   func(args ...string) interface{} { return ac.Flight.Destination },
-		"$BARO_SEALEVEL": func(args ...string) interface{} { return int(math.Round(s.Weather.Baro.Sealevel)) },
-		"$BARO_AIRCRAFT": func(args ...string) interface{} { return int(math.Round(s.Weather.Baro.Flight)) },
-		"$WIND_SPEED":    func(args ...string) interface{} { return s.Weather.Wind.Speed },
-		"$WIND_SHEAR":    func(args ...string) interface{} { return s.Weather.Wind.Shear },
-		"$TURBULENCE":    func(args ...string) interface{} { return s.Weather.Turbulence },
-		"$PARKING":       func(args ...string) interface{} { return ac.Flight.AssignedParkingName },
-		"$APPROACH_TYPE": func(args ...string) interface{} {
-			if rwy != nil {
-				util.LogDebugWithLabel(ac.Registration, "controller says highest precision approach is %s", rwy.HighestPrecisionApproach)
-				return rwy.HighestPrecisionApproach
-			} else {
-				return ""
-			}
-		},
-		"$HOLD_FIX_NAME": func(args ...string) interface{} {
-			holding := ac.Flight.Holding
-			if holding == nil {
-				return ""
-			}
-			holdfix := holding.AssignedHold
-			if holdfix == nil {
-				return ""
-			} else {
-				util.LogDebugWithLabel(ac.Registration, "controller says nearest hold is %s", holdfix.FullName)
-				return holdfix.FullName
-			}
-		},
-		"$HOLD_FIX_IDENT": func(args ...string) interface{} {
-			holding := ac.Flight.Holding
-			if holding == nil {
-				return ""
-			}
-			holdfix := holding.AssignedHold
-			if holdfix == nil {
-				return ""
-			} else {
-				util.LogDebugWithLabel(ac.Registration, "controller says nearest hold identifier is %s", holdfix.Ident)
-				return holdfix.Ident
-			}
-		},
-		"$MA_HEADING": func(args ...string) interface{} {
-			if rwy != nil {
-				util.LogDebugWithLabel(ac.Registration, "controller says missed approach heading is %d", rwy.MAHeading)
-				return rwy.MAHeading
-			} else {
-				return 0
-			}
-		},
-		"$MA_ALTITUDE": func(args ...string) interface{} {
-			if rwy != nil {
-				util.LogDebugWithLabel(ac.Registration, "controller says missed approach altitude is %d", rwy.MAalt)
-				return rwy.MAalt
-			} else {
-				return 0
-			}
-		},
-		"$MA_FIX": func(args ...string) interface{} {
-			if rwy != nil {
-				util.LogDebugWithLabel(ac.Registration, "controller says missed approach fix is %s", rwy.MAFix)
-				return rwy.MAFix
-			} else {
-				return ""
-			}
-		},
-		"$FA_ALTITUDE": func(args ...string) interface{} {
-			if rwy != nil {
-				util.LogDebugWithLabel(ac.Registration, "controller says final fix approach altitude is %d", rwy.FAFalt)
-				return rwy.FAFalt
-			} else {
-				return 0
-			}
-		},
-
-		// --- FORMATTED MACROS (@) ---
-		// --- RUNWAY & TAXI ---
-		"@RUNWAY": func(args ...string) interface{} {
-			return translateRunway(ac.Flight.AssignedRunwayName)
-		},
-		"@RUNWAY_HOLD": func(args ...string) interface{} {
-			return formatRunwayHold(ac)
-		},
-		"@RUNWAY_EXIT": func(args ...string) interface{} {
-			return formatRunwayExit(ac)
-		},
-		"@TAXIPATH": func(args ...string) interface{} {
-			return collateTaxipath(ac)
-		},
-		"@PARKING": func(args ...string) interface{} {
-			var icao string
-			if ac.Flight.Comms.Controller == nil {
-				icao = s.GetClosestAirport(ac.Flight.Position.Lat, ac.Flight.Position.Long, 10000)
-			} else {
-				icao = ac.Flight.Comms.Controller.ICAO
-			}
-			return formatParking(ac.Flight.AssignedParkingName, isNorthAmerica(icao))
-		},
-
-		// --- DEPARTURE & ARRIVAL ---
-		"@SID": func(args ...string) interface{} {
-			includeClimbAltitude := true //default
-			transLevel := 0
-			if len(args) > 0 {
-				includeClimbAltitude, _ = strconv.ParseBool(args[0])
-				transLevel = getTransitionLevel(s.getTransistionAltitude(ac), s.Weather.Baro.Sealevel)
-			}
-			// transition Level is not strictly required for SID formatting but is required if we are including altitude
-			return formatSID(ac, includeClimbAltitude, transLevel)
-		},
-		"@STAR": func(args ...string) interface{} {
-			includeDescentAltitude := true //default
-			transLevel := 0
-			if len(args) > 0 {
-				includeDescentAltitude, _ = strconv.ParseBool(args[0])
-				transLevel = getTransitionLevel(s.getTransistionAltitude(ac), s.Weather.Baro.Sealevel)
-			}
-			// transition Level is not strictly required for STAR formatting but is required if we are including altitude
-			return formatSTAR(ac, includeDescentAltitude, transLevel)
-		},
-		"@APPROACH_TYPE": func(args ...string) interface{} {
-			res := ""
-			if rwy != nil {
-				util.LogDebugWithLabel(ac.Registration, "controller says highest precision approach is %s", rwy.HighestPrecisionApproach)
-				res = rwy.HighestPrecisionApproach
-			}
-			return strings.TrimSpace(res + " approach")
-		},
-		"@DESTINATION": func(args ...string) interface{} {
-			if ac.Flight.Destination == "" {
-				return "as filed"
-			}
-			return formatAirportName(ac.Flight.Destination, s.Airports)
-		},
-		"@ATC_HEADING": func(args ...string) interface{} {
-			// calculate whether the heading is a left or right turn from the current heading to the target heading
-			// 1. calculate the shortest signed difference (-180 to +180)
-			turnDiff := geometry.NormalizeDiffDegrees(ac.Flight.TargetHeading, ac.Flight.Position.Heading)
-			// 2. determine turn direction
-			turnDirection := "right"
-			if turnDiff < 0 {
-				turnDirection = "left"
-			}
-			return fmt.Sprintf("turn %s heading %03d", turnDirection, int(math.Round(geometry.NormalizeHeading(ac.Flight.TargetHeading))))
-		},
-		// --- MISSED APPROACH LOGIC ---
-		"@MA_HEADING": func(args ...string) interface{} {
-			if rwy != nil && rwy.MAHeading > 0 {
-				r := fmt.Sprintf("heading %d", rwy.MAHeading)
-				util.LogDebugWithLabel(ac.Registration, "controller says missed approach heading is %s", r)
-				return r
-			}
-			return "runway heading"
-		},
-		"@MA_ALTITUDE": func(args ...string) interface{} {
-			if rwy != nil && rwy.MAalt > 0 {
-				transAlt := s.getTransistionAltitude(ac)
-				transLevel := getTransitionLevel(transAlt, s.Weather.Baro.Sealevel)
-				r := formatAltitude(float64(rwy.MAalt), transLevel, ac.Flight.Phase)
-				util.LogDebugWithLabel(ac.Registration, "controller says missed approach altitude is %s", r)
-				return r
-			}
-			return "missed approach altitude"
-		},
-		"@MA_FIX": func(args ...string) interface{} {
-			var r string
-			if rwy != nil && rwy.MAFix != "" {
-				r = rwy.MAFix
-				util.LogDebugWithLabel(ac.Registration, "controller says missed approach fix/hold is %s", r)
-			} else {
-				r = "published hold"
-			}
-			return r
-		},
-
-		// --- ALTITUDE & BARO ---
-		"@ALTITUDE": func(args ...string) interface{} {
-			transitionAlt := s.getTransistionAltitude(ac)
-			transitionLevel := getTransitionLevel(transitionAlt, s.Weather.Baro.Sealevel)
-			return formatAltitude(ac.Flight.Position.Altitude, transitionLevel, ac.Flight.Phase)
-		},
-		"@ALT_CLEARANCE": func(args ...string) interface{} {
-			transAlt := s.getTransistionAltitude(ac)
-			transLevel := getTransitionLevel(transAlt, s.Weather.Baro.Sealevel)
-			clearance := determineAltClearance(ac, s.GetAirportByICAO(getAirportICAObyPhaseClass(ac)), rwy)
-			return generateAltClearance(ac.Flight.Position.Altitude, transLevel, clearance, ac.Flight.Phase)
-		},
-		"@BARO": func(args ...string) interface{} {
-			var icao string
-			pascals := s.Weather.Baro.Sealevel
-			if ac.Flight.Comms.Controller == nil {
-				pascals = 101325 // standard pressure as default if no controller assigned to avoid errors in baro formatting
-				icao = s.GetClosestAirport(ac.Flight.Position.Lat, ac.Flight.Position.Long, 10000)
-			} else {
-				icao = ac.Flight.Comms.Controller.ICAO
-			}
-			r := formatBaro(pascals, isNorthAmerica(icao))
-			util.LogDebugWithLabel(ac.Registration, "controller says barometric pressure is %s", r)
-			return r
-		},
-
-		// --- WEATHER & CONTROLLER ---
-		"@WIND":       func(args ...string) interface{} { return s.formatWind() },
-		"@SHEAR":      func(args ...string) interface{} { return s.formatWindShear() },
-		"@TURBULENCE": func(args ...string) interface{} { return s.formatTurbulence(role) },
-		"@HANDOFF":    func(args ...string) interface{} { return s.generateHandoffPhrase(ac) },
-		"@VALEDICTION": func(args ...string) interface{} {
-			factor := 5 //default
-			if len(args) > 0 {
-				factor, err := strconv.Atoi(args[0])
-				if err != nil || factor < 1 {
-					factor = 1
-				}
-			}
-			return s.generateValediction(factor)
-		},
-		"@HOLD_FIX": func(args ...string) interface{} {
-			var r string
-			if ac.Flight.Comms.Controller == nil {
-				r = "published hold"
-			} else {
-				holding := ac.Flight.Holding
-				if holding == nil {
-					r = "published hold"
-				} else {
-					holdfix := holding.AssignedHold
-					if holdfix != nil {
-						if holdfix.FullName != "" {
-							r = holdfix.FullName
-						} else {
-							r = holdfix.Ident
-						}
-						util.LogDebugWithLabel(ac.Registration, "controller says hold fix is %s", r)
-					} else {
-						r = "published hold"
-					}
-				}
-			}
-			return r
-		},
+	// --- remove PCL statements ---
+	if strings.Contains(phrase, "{NOREADBACK}") {
+		phrase = strings.ReplaceAll(phrase, "{NOREADBACK}", "")
 	}
+
+	phrase = translateNumerics(phrase, language)
+	phrase = cleanPhraseLanguage(phrase, language)
+
+	return phrase, tokens, nil
 }
 
 func cleanPhrase(phrase string) string {
+	return cleanPhraseLanguage(phrase, "")
+}
+
+// cleanPhraseLanguage removes the characters that are not spoken from the phrase. Accents are removed from
+// English phrases but kept in other languages, e.g. "réduisez" in French.
+func cleanPhraseLanguage(phrase, language string) string {
 
 	// 1. Decompose accents (é becomes e + ´)
-	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
-	phrase, _, _ = transform.String(t, phrase)
+	if language == "" {
+		t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+		phrase, _, _ = transform.String(t, phrase)
+	}
 
 	phrase = strings.ReplaceAll(phrase, "  ", " ")
 	phrase = strings.ReplaceAll(phrase, " .", ".")
@@ -610,6 +396,9 @@ func cleanPhrase(phrase string) string {
 	phrase = re.ReplaceAllString(phrase, ".")
 
 	var reSanitize = regexp.MustCompile(`[^a-zA-Z0-9\s\.,\-\']`)
+	if language != "" {
+		reSanitize = regexp.MustCompile(`[^\p{L}0-9\s\.,\-\']`)
+	}
 	phrase = reSanitize.ReplaceAllString(phrase, "")
 
 	phrase = strings.TrimSuffix(phrase, ",")
@@ -648,14 +437,14 @@ func PrepSpeech(piperPath string, vm *VoiceManager) {
 		localeCode := strings.ToUpper(voice[0:5])
 
 		// global/base language replacements
-		if baseEngine, ok := vm.dictionaries[baseLang]; ok {
+		if baseEngine, ok := vm.dictionary(baseLang); ok {
 			msg.Text = baseEngine.Apply(msg.Text)
 		}
 
 		// country replacements
 		// will only run if the locale is specific and exists
 		if localeCode != baseLang {
-			if localeEngine, ok := vm.dictionaries[localeCode]; ok {
+			if localeEngine, ok := vm.dictionary(localeCode); ok {
 				msg.Text = localeEngine.Apply(msg.Text)
 			}
 		}
@@ -840,11 +629,16 @@ func noiseType(role string, flightPhase int) string {
 	return "brownnoise"
 }
 
-// translateNumerics converts numeric digits in a string to their word equivalents
-func translateNumerics(msg string) string {
+// translateNumerics converts numeric digits in a string to their word equivalents in the language, which
+// is English if empty or without its own digit words
+func translateNumerics(msg, language string) string {
+	digits := numericMap
+	if m, ok := localeNumericMaps[language]; ok {
+		digits = m
+	}
 	var result strings.Builder
 	for _, ch := range msg {
-		if word, exists := numericMap[ch]; exists {
+		if word, exists := digits[ch]; exists {
 			result.WriteString(" ")
 			result.WriteString(word)
 			result.WriteString(" ")
@@ -884,24 +678,24 @@ func formatRunwayExit(ac *Aircraft) string {
 	return "exit when able"
 }
 
-func formatSID(ac *Aircraft, includeClimbAltitude bool, transLevel int) string {
+func formatSID(ac *Aircraft, includeClimbAltitude bool, transLevel int, metric bool) string {
 	if ac.Flight.AssignedSID != nil {
 		climbAlt := ""
 		if includeClimbAltitude && ac.Flight.AssignedSID.Entry.ConstraintAlt > 0 {
 			climbAlt = fmt.Sprintf(" and climb to %s",
-				formatAltitude(float64(ac.Flight.AssignedSID.Entry.ConstraintAlt), transLevel, ac.Flight.Phase))
+				formatAltitude(float64(ac.Flight.AssignedSID.Entry.ConstraintAlt), transLevel, ac.Flight.Phase, metric))
 		}
 		return fmt.Sprintf("%s departure %s", phoneticiseAlphaLast(ac.Flight.AssignedSID.Name), climbAlt)
 	}
 	return "assigned departure"
 }
 
-func formatSTAR(ac *Aircraft, includeDescentAltitude bool, transLevel int) string {
+func formatSTAR(ac *Aircraft, includeDescentAltitude bool, transLevel int, metric bool) string {
 	if ac.Flight.AssignedSTAR != nil {
 		descendAlt := ""
 		if includeDescentAltitude && ac.Flight.AssignedSTAR.Entry.ConstraintAlt > 0 {
 			descendAlt = fmt.Sprintf(" and descend to %s",
-				formatAltitude(float64(ac.Flight.AssignedSTAR.Entry.ConstraintAlt), transLevel, ac.Flight.Phase))
+				formatAltitude(float64(ac.Flight.AssignedSTAR.Entry.ConstraintAlt), transLevel, ac.Flight.Phase, metric))
 		}
 		return fmt.Sprintf("%s arrival %s", phoneticiseAlphaLast(ac.Flight.AssignedSTAR.Name), descendAlt)
 	}
@@ -964,9 +758,17 @@ func formatBaro(pascals float64, isNorthAmerica bool) string {
 	return fmt.Sprintf("%s %s", prefix, digits)
 }
 
-func formatAltitude(rawAlt float64, transitionLevel int, phase flightphase.Phase) string {
+func formatAltitude(rawAlt float64, transitionLevel int, phase flightphase.Phase, metric bool) string {
 
-	scaledAlt, flightLevelScale := scaleAltitude(rawAlt, transitionLevel, phase)
+	scaledAlt, flightLevelScale := scaleAltitude(rawAlt, transitionLevel, phase, metric)
+
+	if metric {
+		// Returns "flight level 8 thousand 9 hundred metres" or "9 hundred metres"
+		if flightLevelScale {
+			return "flight level " + formatMetres(scaledAlt)
+		}
+		return formatMetres(scaledAlt)
+	}
 
 	if flightLevelScale {
 		// Returns "flight level 330"
@@ -1070,7 +872,7 @@ func determineAltClearance(ac *Aircraft, ap *Airport, rwy *Runway) int {
 
 // generateAltClearance builds an altitude clearance phrase
 // one of "descend to", "maintain", "climb to" or ""
-func generateAltClearance(rawAlt float64, transitionLevel, clearance int, phase flightphase.Phase) string {
+func generateAltClearance(rawAlt float64, transitionLevel, clearance int, phase flightphase.Phase, metric bool) string {
 
 	instruction := ""
 	term := ""
@@ -1079,8 +881,8 @@ func generateAltClearance(rawAlt float64, transitionLevel, clearance int, phase
 		return term
 	}
 
-	scaledClearedAlt, clearedScaleIsFlightLevel := scaleAltitude(float64(clearance), transitionLevel, phase)
-	scaledAlt, scaleIsFlightLevel := scaleAltitude(rawAlt, transitionLevel, phase)
+	scaledClearedAlt, clearedScaleIsFlightLevel := scaleAltitude(float64(clearance), transitionLevel, phase, metric)
+	scaledAlt, scaleIsFlightLevel := scaleAltitude(rawAlt, transitionLevel, phase, metric)
 
 	if scaleIsFlightLevel != clearedScaleIsFlightLevel {
 		// scales are different
@@ -1103,14 +905,17 @@ func generateAltClearance(rawAlt float64, transitionLevel, clearance int, phase
 		}
 	}
 
-	term = fmt.Sprintf("%s %s", instruction, formatAltitude(float64(clearance), transitionLevel, phase))
+	term = fmt.Sprintf("%s %s", instruction, formatAltitude(float64(clearance), transitionLevel, phase, metric))
 
 	return term
 }
 
 // scaleAltitude rounds the altitude and scales to either feet or flight level. The returned bool value
 // is true when the scale is flight levels and false when the returned value is an altitude in feet
-func scaleAltitude(rawAlt float64, transitionLevel int, phase flightphase.Phase) (int, bool) {
+func scaleAltitude(rawAlt float64, transitionLevel int, phase flightphase.Phase, metric bool) (int, bool) {
+	if metric {
+		return scaleMetricAltitude(rawAlt, transitionLevel, phase)
+	}
 
 	var roundedAlt int
 	alt := int(rawAlt)
@@ -1281,10 +1086,17 @@ func toPhonetics(s string) string {
 
 // generateHandoffPhrase creates a controller handoff phrase and automatically includes valediction (based on configured factor)
 func (s *Service) generateHandoffPhrase(ac *Aircraft) string {
+	phrase, _ := s.generateHandoff(ac)
+	return phrase
+}
+
+// generateHandoff creates the handoff phrase and also returns the frequency handed off to, or zero
+// when no controller could be found
+func (s *Service) generateHandoff(ac *Aircraft) (string, int) {
 	// Identify the 'Next Role' based on the new phase
 	nextRole, exists := handoffMap[flightphase.FlightPhase(ac.Flight.Phase.Current)]
 	if !exists {
-		return ""
+		return "", 0
 	}
 
 	// Locate the "Next" controller
@@ -1294,10 +1106,19 @@ func (s *Service) generateHandoffPhrase(ac *Aircraft) string {
 	nextController := s.locateController(label,
 		0, nextRole, pos.Lat, pos.Long, pos.Altitude, searchICAO)
 
+	// a closed facility is skipped and the aircraft handed to the airport's Unicom
+	if nextController == nil {
+		if closed := s.closedController(searchICAO, nextRole); closed != nil {
+			util.LogWithLabel(label, "%s is closed, handing off to Unicom", closed.Name)
+			nextRole = 0
+			nextController = s.locateController(label, 0, nextRole, pos.Lat, pos.Long, pos.Altitude, searchICAO)
+		}
+	}
+
 	if nextController == nil {
 		util.LogWithLabel(label, "no controller found for handoff: role=%s (%d), searchICAO=%s",
 			roleNameMap[nextRole], nextRole, searchICAO)
-		return ""
+		return "", 0
 	} else {
 		util.LogWithLabel(label, "controller found: %s %s Role ID: %s (%d)",
 			nextController.Name, nextController.ICAO, roleNameMap[nextController.RoleID], nextController.RoleID)
@@ -1312,7 +1133,7 @@ func (s *Service) generateHandoffPhrase(ac *Aircraft) string {
 		facilityName = nextController.Name
 	}
 
-	return fmt.Sprintf(" [contact] %s %s on %s %s", facilityName, roleNameMap[nextRole], freqStr, s.generateValediction(s.Config.ATC.Voices.HandoffValedictionFactor))
+	return fmt.Sprintf(" [contact] %s %s on %s %s", facilityName, roleNameMap[nextRole], freqStr, s.generateValediction(s.Config.ATC.Voices.HandoffValedictionFactor)), nextController.Freqs[0]
 
 }
 
@@ -1350,13 +1171,13 @@ func (s *Service) generateValediction(factor int) string {
 	return fmt.Sprintf("[%s]", valediction)
 }
 
-func (s *Service) formatWind() string {
+func (s *Service) formatWind(w *Weather) string {
 
 	const mpsToKnots = 1.94384
-	speedKt := s.Weather.Wind.Speed * mpsToKnots
+	speedKt := w.Wind.Speed * mpsToKnots
 
 	// 2. Convert to Magnetic and Round to nearest 10
-	magDir := s.Weather.Wind.Direction - float64(s.Weather.MagVar)
+	magDir := w.Wind.Direction - float64(w.MagVar)
 	if magDir <= 0 {
 		magDir += 360
 	}
@@ -1376,10 +1197,10 @@ func (s *Service) formatWind() string {
 	} else {
 		windPhrase = fmt.Sprintf("%03d at %d knots", roundedDir, int(speedKt))
 		gustKt := 0.0
-		if s.Weather.Turbulence > 0.2 {
+		if w.Turbulence > 0.2 {
 			// Simple heuristic: Turbulence adds a gust factor
 			// A turb of 0.5 adds roughly 10-15 knots of gust
-			gustKt = speedKt + (s.Weather.Turbulence * 25.0)
+			gustKt = speedKt + (w.Turbulence * 25.0)
 		}
 		if gustKt > speedKt+9 {
 			windPhrase += fmt.Sprintf(" gusting %d", int(gustKt))
@@ -1389,13 +1210,13 @@ func (s *Service) formatWind() string {
 	return windPhrase
 }
 
-func (s *Service) formatWindShear() string {
+func (s *Service) formatWindShear(w *Weather) string {
 
 	var phrase string
 	const mpsToKnots = 1.94384
 
 	// Wind Shear (Converted from m/s to knots)
-	shearKt := s.Weather.Wind.Shear * mpsToKnots
+	shearKt := w.Wind.Shear * mpsToKnots
 
 	if shearKt >= 15 {
 		// Round to nearest 5
@@ -1406,15 +1227,15 @@ func (s *Service) formatWindShear() string {
 	return phrase
 }
 
-func (s *Service) formatTurbulence(role string) string {
+func (s *Service) formatTurbulence(w *Weather, role string) string {
 
 	phrase := ""
 	turbClass := ""
 
 	// Turbulence Magnitude
-	if s.Weather.Turbulence >= 0.7 {
+	if w.Turbulence >= 0.7 {
 		turbClass = "severe"
-	} else if s.Weather.Turbulence >= 0.4 {
+	} else if w.Turbulence >= 0.4 {
 		turbClass = "moderate"
 	}
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/curbz/decimal-niner/internal/flightclass"
 	"github.com/curbz/decimal-niner/internal/flightphase"
+	"github.com/curbz/decimal-niner/internal/pcl"
 	"github.com/curbz/decimal-niner/internal/simdata"
 )
 
@@ -65,7 +66,7 @@ func TestScaleAltitude(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ph := flightphase.Phase{Current: tt.phaseCurrent}
-			gotVal, gotFL := scaleAltitude(tt.rawAlt, tt.transitionLevel, ph)
+			gotVal, gotFL := scaleAltitude(tt.rawAlt, tt.transitionLevel, ph, false)
 			if gotVal != tt.wantVal || gotFL != tt.wantIsFL {
 				t.Fatalf("%s: scaleAltitude(%v,%d,phase) = (%d,%v); want (%d,%v)", tt.name, tt.rawAlt, tt.transitionLevel, gotVal, gotFL, tt.wantVal, tt.wantIsFL)
 			}
@@ -89,7 +90,7 @@ func TestTranslateNumerics(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.in, func(t *testing.T) {
-			got := translateNumerics(tt.in)
+			got := translateNumerics(tt.in, "")
 			if got != tt.want {
 				t.Fatalf("translateNumerics(%q) = %q; want %q", tt.in, got, tt.want)
 			}
@@ -204,7 +205,7 @@ func TestGenerateAltClearance(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ph := flightphase.Phase{Current: tt.phaseCurrent}
-			got := generateAltClearance(tt.rawAlt, tt.transitionLevel, tt.clearance, ph)
+			got := generateAltClearance(tt.rawAlt, tt.transitionLevel, tt.clearance, ph, false)
 			if !strings.HasPrefix(got, tt.wantPrefix) {
 				t.Fatalf("%s: generateAltClearance -> %q; want prefix %q", tt.name, got, tt.wantPrefix)
 			}
@@ -417,7 +418,7 @@ func TestFormatWindVariants(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &Service{Weather: tt.weather}
-			got := s.formatWind()
+			got := s.formatWind(s.Weather)
 			if tt.wantExact != "" {
 				if got != tt.wantExact {
 					t.Fatalf("formatWind %s = %q; want %q", tt.name, got, tt.wantExact)
@@ -446,7 +447,7 @@ func TestFormatWindShear(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &Service{Weather: &Weather{Wind: &Wind{Shear: tt.shear}}}
-			got := s.formatWindShear()
+			got := s.formatWindShear(s.Weather)
 
 			if tt.wantMention {
 				if !strings.Contains(strings.ToLower(got), "wind shear") {
@@ -471,12 +472,25 @@ func TestFormatWindShear(t *testing.T) {
 
 func TestFormatTurbulence(t *testing.T) {
 	s := &Service{Weather: &Weather{Turbulence: 0.8}}
-	if s.formatTurbulence("PILOT") != "experiencing severe turbulence" {
+	if s.formatTurbulence(s.Weather, "PILOT") != "experiencing severe turbulence" {
 		t.Fatalf("formatTurbulence severe pilot phrasing mismatch")
 	}
-	if s.formatTurbulence("ATC") != "severe turbulence [reported]" {
+	if s.formatTurbulence(s.Weather, "ATC") != "severe turbulence [reported]" {
 		t.Fatalf("formatTurbulence severe ATC phrasing mismatch")
 	}
+
+	// the turbulence of an airport with a METAR is that of its gusts, not the sim weather
+	s.METARs = map[string]*METAR{"EGLL": {Weather: &Weather{Wind: &Wind{}, Turbulence: 0.5}}}
+	ac := &Aircraft{}
+	ac.Flight.Phase.Class = flightclass.Arriving
+	ac.Flight.Destination = "EGLL"
+	ctx := s.newPCLContext(ac, "Approach")
+	if got, _ := ctx.Resolve("@TURBULENCE", nil); got != "moderate turbulence [reported]" {
+		t.Errorf("@TURBULENCE at EGLL = %v; want moderate from the METAR", got)
+	}
+	if got, _ := ctx.Resolve("$TURBULENCE", nil); got != 0.5 {
+		t.Errorf("$TURBULENCE at EGLL = %v; want 0.5 from the METAR", got)
+	}
 }
 
 func TestCleanPhrase(t *testing.T) {
@@ -506,6 +520,24 @@ func TestCleanPhrase(t *testing.T) {
 	}
 }
 
+func TestRenderPhraseKeepsAccents(t *testing.T) {
+	phrase := "{$CALLSIGN}, réduisez à 180 nœuds, piste 27L, contactez la tour."
+	ctx := pcl.PCLContext{"$CALLSIGN": func(args ...string) interface{} { return "air france 123" }}
+
+	got, _, err := renderPhrase(phrase, ctx, "fr")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, word := range []string{"réduisez", "à", "nœuds"} {
+		if !strings.Contains(got, word) {
+			t.Errorf("French phrase %q lost %q", got, word)
+		}
+	}
+	if got, _, _ := renderPhrase("Café [test]", pcl.PCLContext{}, ""); got != "Cafe test" {
+		t.Errorf("English phrase = %q; want accents removed", got)
+	}
+}
+
 func TestFormatAltitude(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -547,7 +579,7 @@ func TestFormatAltitude(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ph := flightphase.Phase{Current: tt.phaseCurrent}
-			got := formatAltitude(tt.rawAlt, tt.transitionLevel, ph)
+			got := formatAltitude(tt.rawAlt, tt.transitionLevel, ph, false)
 			if got != tt.want {
 				t.Fatalf("%s: formatAltitude(...) = %q; want %q", tt.name, got, tt.want)
 			}
@@ -603,14 +635,14 @@ func TestPCL_StressFallbacks(t *testing.T) {
 		ctx := s.newPCLContext(acGhost, "PILOT")
 
 		// Test @BARO fallback logic
-		baroFunc := ctx["@BARO"]
+		baroFunc := ctx.provider("@BARO")
 		res := baroFunc().(string)
 		if res == "" {
 			t.Error("@BARO returned empty string; expected formatted default")
 		}
 
 		// Test @HOLD_FIX fallback logic
-		holdFunc := ctx["@HOLD_FIX"]
+		holdFunc := ctx.provider("@HOLD_FIX")
 		if holdFunc() != "published hold" {
 			t.Errorf("Expected 'published hold' for nil controller, got %v", holdFunc())
 		}
@@ -631,7 +663,7 @@ func TestPCL_StressFallbacks(t *testing.T) {
 		ctx := s.newPCLContext(acHandoff, "PILOT")
 
 		// Ensure $FACILITY handles nil Current Controller
-		facilityFunc := ctx["$FACILITY"]
+		facilityFunc := ctx.provider("$FACILITY")
 		if facilityFunc() != "" {
 			t.Errorf("Expected empty facility name, got %v", facilityFunc())
 		}
@@ -15,27 +15,11 @@ import (
 	"github.com/curbz/decimal-niner/internal/constants"
 	"github.com/curbz/decimal-niner/internal/flightclass"
 	"github.com/curbz/decimal-niner/internal/logger"
+	"github.com/curbz/decimal-niner/internal/pcl"
 	"github.com/curbz/decimal-niner/pkg/geometry"
 	"github.com/curbz/decimal-niner/pkg/util"
 )
 
-var (
-	// validPCLTags includes both Raw ($) and Formatted (@) tags found in newPCLContext
-	validPCLTags = map[string]bool{
-		"$ALTITUDE": true, "$CALLSIGN": true, "$FACILITY": true, "$SQUAWK": true,
-		"$HEADING": true, "$RUNWAY": true, "$DESTINATION": true, "$BARO_SEALEVEL": true,
-		"$BARO_AIRCRAFT": true, "$WIND_SPEED": true, "$WIND_SHEAR": true, "$TURBULENCE": true,
-		"$PARKING": true, "$APPROACH_TYPE": true, "$HOLD_FIX_NAME": true, "$HOLD_FIX_IDENT": true,
-		"$MA_HEADING": true, "$MA_ALTITUDE": true, "$MA_FIX": true, "$FA_ALTITUDE": true,
-		"$VECTORING": true, "$ATC_HEADING": true,
-		"@RUNWAY":    true, "@TAXIPATH": true, "@PARKING": true, "@DESTINATION": true, "@APPROACH_TYPE": true,
-		"@MA_HEADING": true, "@MA_ALTITUDE": true, "@MA_FIX": true, "@ALTITUDE": true,
-		"@ALT_CLEARANCE": true, "@BARO": true, "@WIND": true, "@SHEAR": true,
-		"@TURBULENCE": true, "@HANDOFF": true, "@VALEDICTION": true, "@HOLD_FIX": true,
-		"@RUNWAY_HOLD": true, "@RUNWAY_EXIT": true, "@SID": true, "@STAR": true, "@ATC_HEADING": true,
-	}
-)
-
 // VoiceSession stores the metadata for an active assignment
 // VoiceSession now stores the specific speaker key
 type VoiceSession struct {
@@ -51,31 +35,36 @@ const (
 )
 
 type VoiceManager struct {
-	PhraseClasses     PhraseClasses
-	sessions          map[string]VoiceSession
-	mu                sync.RWMutex
-	voiceDir          string
-	rng               *rand.Rand
-	countryVoicePools map[string][]string
-	regionVoicePools  map[string][]string
-	globalVoicePool   []string
-	voiceLocks        sync.Map // Map of string -> *sync.Mutex
-	allowedSpeakerIDs map[string][]int
-	dictionaries      map[string]*PhoneticEngine
+	PhraseClasses      PhraseClasses
+	sessions           map[string]VoiceSession
+	mu                 sync.RWMutex
+	voiceDir           string
+	rng                *rand.Rand
+	countryVoicePools  map[string][]string
+	regionVoicePools   map[string][]string
+	languageVoicePools map[string][]string // keyed by language e.g. "fr"
+	globalVoicePool    []string
+	voiceLocks         sync.Map // Map of string -> *sync.Mutex
+	allowedSpeakerIDs  map[string][]int
+	dictionaries       map[string]*PhoneticEngine
 }
 
 type PhraseClasses struct {
-	phrases       map[string][]Exchange
-	phrasesUnicom map[string][]Exchange
+	phrases        map[string][]Exchange
+	phrasesUnicom  map[string][]Exchange
+	regional       map[string]map[string][]Exchange // phraseology standard -> phase key overrides
+	regionalUnicom map[string]map[string][]Exchange
+	languages      map[string]map[string][]Exchange // language -> phase key native language exchanges
 }
 
 func NewVoiceManager(cfg *config) *VoiceManager {
 	vm := &VoiceManager{
-		sessions:          make(map[string]VoiceSession),
-		voiceDir:          cfg.ATC.Voices.Piper.VoiceDirectory,
-		rng:               rand.New(rand.NewSource(time.Now().UnixNano())),
-		countryVoicePools: make(map[string][]string),
-		regionVoicePools:  make(map[string][]string),
+		sessions:           make(map[string]VoiceSession),
+		voiceDir:           cfg.ATC.Voices.Piper.VoiceDirectory,
+		rng:                rand.New(rand.NewSource(time.Now().UnixNano())),
+		countryVoicePools:  make(map[string][]string),
+		regionVoicePools:   make(map[string][]string),
+		languageVoicePools: make(map[string][]string),
 	}
 
 	vm.loadPhrases()
@@ -91,74 +80,141 @@ func NewVoiceManager(cfg *config) *VoiceManager {
 }
 
 func (vm *VoiceManager) loadPhrases() {
-	// ... [Previous binary and directory checks remain unchanged] ...
+	phraseClasses, err := loadPhraseClasses()
+	if err != nil {
+		logger.Log.Fatal(err)
+		return
+	}
+	vm.setPhrases(phraseClasses)
 
-	// Helper to load and validate a phrase map
-	loadAndValidate := func(filePath string) (map[string][]Exchange, error) {
-		file, err := os.Open(filePath)
-		if err != nil {
-			return nil, err
-		}
-		defer file.Close()
+	logger.Log.Info("VoiceManager: All phrase files loaded and PCL templates compiled successfully.")
+}
 
-		var data map[string][]Exchange
-		if err := json.NewDecoder(file).Decode(&data); err != nil {
-			return nil, err
-		}
+// loadPhraseFile loads a phrase file and compiles every phrase in it, and the pilot's readback of ATC phrases,
+// so that errors are reported at load and the templates are cached before the phrases are first spoken
+func loadPhraseFile(filePath string) (map[string][]Exchange, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
-		// Validate every phrase in the file
-		for category, exchanges := range data {
-			for i, ex := range exchanges {
-				// Validate Pilot side
-				if err := validatePhrase(ex.Pilot); err != nil {
-					return nil, fmt.Errorf("[%s] Exchange %d (Pilot): %v", category, i+1, err)
-				}
-				// Validate ATC side
-				if err := validatePhrase(ex.ATC); err != nil {
-					return nil, fmt.Errorf("[%s] Exchange %d (ATC): %v", category, i+1, err)
-				}
-				// Validate required metadata
-				if ex.Initiator != "pilot" && ex.Initiator != "atc" {
-					return nil, fmt.Errorf("[%s] Exchange %d: invalid initiator '%s'", category, i+1, ex.Initiator)
+	var data map[string][]Exchange
+	if err := json.NewDecoder(file).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	for category, exchanges := range data {
+		for i, ex := range exchanges {
+			// Compile Pilot side
+			if _, err := compilePhrase(ex.Pilot); err != nil {
+				return nil, fmt.Errorf("[%s] Exchange %d (Pilot): %v", category, i+1, err)
+			}
+			// Compile ATC side
+			if _, err := compilePhrase(ex.ATC); err != nil {
+				return nil, fmt.Errorf("[%s] Exchange %d (ATC): %v", category, i+1, err)
+			}
+			if ex.ATC != "" {
+				if _, err := compilePhrase(autoReadback(ex.ATC)); err != nil {
+					return nil, fmt.Errorf("[%s] Exchange %d (readback): %v", category, i+1, err)
 				}
 			}
+			// Validate required metadata
+			if ex.Initiator != "pilot" && ex.Initiator != "atc" {
+				return nil, fmt.Errorf("[%s] Exchange %d: invalid initiator '%s'", category, i+1, ex.Initiator)
+			}
 		}
-		return data, nil
 	}
+	return data, nil
+}
 
+// loadPhraseClasses loads the main and Unicom phrase files from the resources directory together with the
+// optional regional phraseology and native language files
+func loadPhraseClasses() (PhraseClasses, error) {
 	// Process Main Phrases
 	phraseFile := filepath.Join(d9.Resources, "phrases.json")
-	phrases, err := loadAndValidate(phraseFile)
+	phrases, err := loadPhraseFile(phraseFile)
 	if err != nil {
-		logger.Log.Fatalf("PCL Syntax Error in %s: %v", phraseFile, err)
-		return
+		return PhraseClasses{}, fmt.Errorf("PCL Syntax Error in %s: %v", phraseFile, err)
 	}
 
 	// Process Unicom Phrases
 	unicomPhraseFile := filepath.Join(d9.Resources, "phrases_unicom.json")
-	unicomPhrases, err := loadAndValidate(unicomPhraseFile)
+	unicomPhrases, err := loadPhraseFile(unicomPhraseFile)
 	if err != nil {
-		logger.Log.Fatalf("PCL Syntax Error in %s: %v", unicomPhraseFile, err)
-		return
+		return PhraseClasses{}, fmt.Errorf("PCL Syntax Error in %s: %v", unicomPhraseFile, err)
+	}
+
+	// Process Regional Phraseology Overrides, which are optional
+	regional := make(map[string]map[string][]Exchange)
+	regionalUnicom := make(map[string]map[string][]Exchange)
+	for standard := range phraseologyPrefixes {
+		for _, r := range []struct {
+			file   string
+			target map[string]map[string][]Exchange
+		}{
+			{"phrases_" + standard + ".json", regional},
+			{"phrases_unicom_" + standard + ".json", regionalUnicom},
+		} {
+			regionalFile := filepath.Join(d9.Resources, r.file)
+			if _, err := os.Stat(regionalFile); os.IsNotExist(err) {
+				continue
+			}
+			overrides, err := loadPhraseFile(regionalFile)
+			if err != nil {
+				return PhraseClasses{}, fmt.Errorf("PCL Syntax Error in %s: %v", regionalFile, err)
+			}
+			r.target[standard] = overrides
+			logger.Log.Infof("VoiceManager: loaded %s phraseology overrides for %d phase keys from %s", strings.ToUpper(standard), len(overrides), r.file)
+		}
 	}
 
-	vm.PhraseClasses = PhraseClasses{
-		phrases:       phrases,
-		phrasesUnicom: unicomPhrases,
+	// Process Native Language Phrases, which are optional
+	languages := make(map[string]map[string][]Exchange)
+	for _, lang := range countryLanguages {
+		if _, loaded := languages[lang]; loaded {
+			continue
+		}
+		langFile := filepath.Join(d9.Resources, "phrases_"+lang+".json")
+		if _, err := os.Stat(langFile); os.IsNotExist(err) {
+			continue
+		}
+		langPhrases, err := loadPhraseFile(langFile)
+		if err != nil {
+			return PhraseClasses{}, fmt.Errorf("PCL Syntax Error in %s: %v", langFile, err)
+		}
+		languages[lang] = langPhrases
+		logger.Log.Infof("VoiceManager: loaded native language (%s) phrases for %d phase keys from %s", lang, len(langPhrases), filepath.Base(langFile))
 	}
 
-	logger.Log.Info("VoiceManager: All phrase files loaded and PCL syntax validated successfully.")
+	return PhraseClasses{
+		phrases:        phrases,
+		phrasesUnicom:  unicomPhrases,
+		regional:       regional,
+		regionalUnicom: regionalUnicom,
+		languages:      languages,
+	}, nil
 }
 
 func (vm *VoiceManager) LoadDictionaries() {
-	vm.dictionaries = make(map[string]*PhoneticEngine)
+	dictionaries, errs := loadDictionaries()
+	for _, err := range errs {
+		logger.Log.Error(err)
+	}
+	vm.setDictionaries(dictionaries)
+}
+
+// loadDictionaries loads the pronunciation dictionaries in the resources directory keyed by upper case ISO code,
+// e.g. "EN" or "EN_GB". Dictionaries which fail to load are left out and their errors returned.
+func loadDictionaries() (map[string]*PhoneticEngine, []error) {
+	dictionaries := make(map[string]*PhoneticEngine)
 
 	files, err := os.ReadDir(d9.Resources)
 	if err != nil {
-		logger.Log.Errorf("error: failed to scan resources directory %s, for dictionaries: %v", d9.Resources, err)
-		return
+		return dictionaries, []error{fmt.Errorf("error: failed to scan resources directory %s, for dictionaries: %v", d9.Resources, err)}
 	}
 
+	var errs []error
 	for _, file := range files {
 		// Only process files like "en-dictionary.json" or "en_GB-dictionary.json"
 		if file.IsDir() || !strings.HasSuffix(file.Name(), "-dictionary.json") {
@@ -171,18 +227,48 @@ func (vm *VoiceManager) LoadDictionaries() {
 		fullPath := filepath.Join(d9.Resources, file.Name())
 		engine, err := NewPhoneticEngine(fullPath)
 		if err != nil {
-			logger.Log.Errorf("error loading pronunciation dictionary %s: %v", file.Name(), err)
+			errs = append(errs, fmt.Errorf("error loading pronunciation dictionary %s: %v", file.Name(), err))
 			continue
 		}
 
-		vm.dictionaries[strings.ToUpper(isoCode)] = engine
+		dictionaries[strings.ToUpper(isoCode)] = engine
 		logger.Log.Infof("Registered pronunciation dictionary: %s", isoCode)
 	}
+	return dictionaries, errs
+}
+
+// phrases returns the loaded phrase classes. The phrase classes are replaced as a whole when the phrase files
+// are reloaded, so a caller always sees a consistent set.
+func (vm *VoiceManager) phrases() PhraseClasses {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+	return vm.PhraseClasses
+}
+
+func (vm *VoiceManager) setPhrases(pc PhraseClasses) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	vm.PhraseClasses = pc
+}
+
+// dictionary returns the pronunciation dictionary for the upper case ISO code
+func (vm *VoiceManager) dictionary(code string) (*PhoneticEngine, bool) {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+	engine, ok := vm.dictionaries[code]
+	return engine, ok
+}
+
+func (vm *VoiceManager) setDictionaries(dictionaries map[string]*PhoneticEngine) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	vm.dictionaries = dictionaries
 }
 
 func (vm *VoiceManager) initialisePools() error {
 	vm.countryVoicePools = make(map[string][]string)
 	vm.regionVoicePools = make(map[string][]string)
+	vm.languageVoicePools = make(map[string][]string)
 	vm.globalVoicePool = []string{}
 
 	files, err := os.ReadDir(vm.voiceDir)
@@ -207,6 +293,12 @@ func (vm *VoiceManager) initialisePools() error {
 			code = strings.ToUpper(baseName[3:5])
 		}
 
+		// 2b. Extract language (e.g., "fr" from "fr_FR...")
+		var lang string
+		if len(baseName) >= 2 {
+			lang = strings.ToLower(baseName[:2])
+		}
+
 		// 3. Register every speaker as a unique person in our pools
 		for i := range numSpeakers {
 			// Unique VoiceKey format: "filename#id"
@@ -214,6 +306,9 @@ func (vm *VoiceManager) initialisePools() error {
 			if code != "" {
 				vm.countryVoicePools[code] = append(vm.countryVoicePools[code], voiceKey)
 			}
+			if lang != "" {
+				vm.languageVoicePools[lang] = append(vm.languageVoicePools[lang], voiceKey)
+			}
 		}
 
 		// filter pools based on config include list (if provided)
@@ -226,6 +321,10 @@ func (vm *VoiceManager) initialisePools() error {
 			}
 		}
 
+		for lang, pool := range vm.languageVoicePools {
+			vm.languageVoicePools[lang] = vm.filterByIncludeList(pool)
+		}
+
 		for region, pool := range vm.regionVoicePools {
 			vm.regionVoicePools[region] = vm.filterByIncludeList(pool)
 
@@ -336,6 +435,12 @@ func (vm *VoiceManager) getSymmetricKeys(msg *ATCMessage) (string, string) {
 	// The ATC ICAO comes from the message context, not the aircraft's permanent stats
 	atcID := msg.ControllerICAO + "_" + msg.Role
 
+	// native language sessions are kept apart so the exchange is spoken by a voice for the language
+	if msg.Language != "" {
+		planeID += "_" + msg.Language
+		atcID += "_" + msg.Language
+	}
+
 	var key, partnerKey string
 
 	if msg.Role == "PILOT" {
@@ -378,6 +483,15 @@ func (vm *VoiceManager) selectVoice(msg *ATCMessage, partnerVoice string) string
 
 	util.LogWithLabel(logLabel, "voice selection started - ISO code: %s (country code %s)", targetISO, countryCode)
 
+	// 0. TIER 0: Native language exchanges must be spoken by a voice for the language
+	if msg.Language != "" {
+		if voice := vm.findBestInPool(vm.languageVoicePools[msg.Language], partnerVoice); voice != "" {
+			util.LogWithLabel(logLabel, "voice selection on language %s successful: %s", msg.Language, voice)
+			return voice
+		}
+		util.LogWarnWithLabel(logLabel, "voice selection did not find a voice for language: %s", msg.Language)
+	}
+
 	// 1. TIER 1: Primary Country Match
 	if pool, ok := vm.countryVoicePools[targetISO]; ok {
 		if voice := vm.findBestInPool(pool, partnerVoice); voice != "" {
@@ -671,135 +785,18 @@ func inferCommsCountryCode(ac *Aircraft, defaultCode string) {
 	}
 }
 
-func validatePhrase(phrase string) error {
-	if phrase == "" {
-		return nil
-	}
-
-	// 1. Check for balanced braces
-	stack := 0
-	for _, char := range phrase {
-		switch char {
-		case '{':
-			stack++
-		case '}':
-			stack--
-		}
-		if stack < 0 {
-			return fmt.Errorf("unexpected closing brace '}'")
-		}
-	}
-	if stack != 0 {
-		return fmt.Errorf("unclosed opening brace '{'")
-	}
-
-	// 2. Validate Tags and Logic
-	start := -1
-	for i, char := range phrase {
-		if char == '{' {
-			start = i
-		} else if char == '}' && start != -1 {
-			tagContent := phrase[start+1 : i]
-			start = -1
-
-			// Handle Functional PCL Logic (WHEN/SAY/OTHERWISE)
-			if strings.HasPrefix(tagContent, "WHEN") {
-				if err := validateLogicBlock(tagContent); err != nil {
-					return err
-				}
-				continue
-			}
-
-			// Ignore simple functional markers
-			if tagContent == "NOREADBACK" {
-				continue
-			}
-
-			// Standard Variable Validation ($ or @)
-			baseTag := tagContent
-			if idx := strings.Index(tagContent, "("); idx != -1 {
-				baseTag = tagContent[:idx]
-			}
-
-			if !validPCLTags[baseTag] {
-				return fmt.Errorf("unknown PCL tag: %s", baseTag)
-			}
-		}
-	}
-	return nil
-}
-
-func validateLogicBlock(content string) error {
-	if !strings.HasPrefix(content, "WHEN") {
-		return fmt.Errorf("PCL logic error: logic block must start with WHEN")
-	}
-
-	// Find SAY and OTHERWISE at the CURRENT level only
-	idxSay := findKeywordAtLevel(content, "SAY")
-	if idxSay == -1 {
-		return fmt.Errorf("PCL logic error: WHEN block missing mandatory SAY statement")
-	}
-
-	idxOtherwise := findKeywordAtLevel(content, "OTHERWISE")
-
-	// Validate the condition (between WHEN and the first SAY)
-	condition := strings.TrimSpace(content[4:idxSay])
-	if condition == "" {
-		return fmt.Errorf("PCL logic error: WHEN condition is empty")
-	}
-
-	// Validate the 'SAY' branch (recursively check if it contains more PCL)
-	sayBranch := strings.TrimSpace(content[idxSay+3 : func() int {
-		if idxOtherwise != -1 {
-			return idxOtherwise
-		}
-		return len(content)
-	}()])
-
-	if err := validatePhrase(sayBranch); err != nil {
-		return fmt.Errorf("error in SAY branch: %v", err)
-	}
-
-	// Validate the 'OTHERWISE' branch if it exists
-	if idxOtherwise != -1 {
-		remaining := content[idxOtherwise+9:] // after "OTHERWISE"
-		idxSecondSay := findKeywordAtLevel(remaining, "SAY")
-		if idxSecondSay == -1 {
-			return fmt.Errorf("PCL logic error: OTHERWISE block missing follow-up SAY statement")
-		}
-
-		otherwiseBranch := strings.TrimSpace(remaining[idxSecondSay+3:])
-		if err := validatePhrase(otherwiseBranch); err != nil {
-			return fmt.Errorf("error in OTHERWISE branch: %v", err)
-		}
+// compilePhrase parses the phrase into its cached PCL template and checks that every variable or macro used
+// in it, whether spoken or an operand of a condition, binding or function, has a provider
+func compilePhrase(phrase string) (*pcl.Template, error) {
+	tmpl, err := pcl.Compile(phrase)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
-}
-
-// findKeywordAtLevel locates a keyword (SAY, OTHERWISE) only if it's not inside another { }
-func findKeywordAtLevel(content, keyword string) int {
-	stack := 0
-	// We iterate by byte to find the exact starting index of the keyword
-	for i := 0; i < len(content); i++ {
-		if content[i] == '{' {
-			stack++
-		} else if content[i] == '}' {
-			stack--
-		} else if stack == 0 && strings.HasPrefix(content[i:], keyword) {
-			// Ensure it's a "whole word" match by checking surrounding characters
-			endIdx := i + len(keyword)
-
-			// Check character before keyword (must be start of string or whitespace)
-			isStart := i == 0 || content[i-1] == ' '
-
-			// Check character after keyword (must be end of string or whitespace)
-			isEnd := endIdx == len(content) || content[endIdx] == ' '
-
-			if isStart && isEnd {
-				return i
-			}
+	for _, ref := range tmpl.References() {
+		if _, ok := phraseProviders[ref.Key]; !ok {
+			return nil, fmt.Errorf("unknown PCL tag: %s (position %d)", ref.Key, ref.Pos+1)
 		}
 	}
-	return -1
+	return tmpl, nil
 }
@@ -326,7 +326,7 @@ func TestInferCommsCountryCode(t *testing.T) {
 	}
 }
 
-func TestValidatePhrase(t *testing.T) {
+func TestCompilePhrase(t *testing.T) {
 	tests := []struct {
 		name    string
 		phrase  string
@@ -340,19 +340,42 @@ func TestValidatePhrase(t *testing.T) {
 		},
 		{
 			name:    "Valid Space-Separated Logic",
-			phrase:  "{WHEN $SPEED GT 250 SAY `Slow down` OTHERWISE SAY `Proceed`}",
+			phrase:  "{WHEN $WIND_SPEED GT 25 SAY `Slow down` OTHERWISE SAY `Proceed`}",
 			wantErr: false,
 		},
 		{
 			name:    "Valid Nested Logic",
-			phrase:  "{WHEN $IS_NA SAY {WHEN $SPEED GT 250 SAY `Slow`} OTHERWISE SAY `Normal`}",
+			phrase:  "{WHEN $LVP SAY {WHEN $WIND_SPEED GT 25 SAY `Slow`} OTHERWISE SAY `Normal`}",
 			wantErr: false,
 		},
 		{
 			name:    "Valid Complex Nested in OTHERWISE",
-			phrase:  "{WHEN $IS_NA SAY `Altimeter` OTHERWISE SAY {WHEN $METRIC SAY `hPa` OTHERWISE SAY `mb` }}",
+			phrase:  "{WHEN $LVP SAY `Altimeter` OTHERWISE SAY {WHEN $VECTORING SAY `hPa` OTHERWISE SAY `mb` }}",
 			wantErr: false,
 		},
+		{
+			name:    "Valid LET Local",
+			phrase:  "{LET $ABOVE = $ALTITUDE - $FA_ALTITUDE}{WHEN $ABOVE GT 1000 SAY `high`}",
+			wantErr: false,
+		},
+		{
+			name:    "Invalid: Unknown Tag in Condition",
+			phrase:  "{WHEN $NOPE EQ 1 SAY x}",
+			wantErr: true,
+			errSub:  "unknown PCL tag: $NOPE",
+		},
+		{
+			name:    "Invalid: Unknown Tag in LET",
+			phrase:  "{LET $X = $NOPE}",
+			wantErr: true,
+			errSub:  "unknown PCL tag: $NOPE",
+		},
+		{
+			name:    "Invalid: Unknown Tag in Function",
+			phrase:  "{SAY ROUND($NOPE)}",
+			wantErr: true,
+			errSub:  "unknown PCL tag: $NOPE",
+		},
 		{
 			name:    "Invalid: Unclosed Brace",
 			phrase:  "Hello {$CALLSIGN",
@@ -394,13 +417,13 @@ func TestValidatePhrase(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validatePhrase(tt.phrase)
+			_, err := compilePhrase(tt.phrase)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("validatePhrase() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("compilePhrase() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if err != nil && tt.errSub != "" && !strings.Contains(err.Error(), tt.errSub) {
-				t.Errorf("validatePhrase() error = %v, must contain %q", err, tt.errSub)
+				t.Errorf("compilePhrase() error = %v, must contain %q", err, tt.errSub)
 			}
 		})
 	}
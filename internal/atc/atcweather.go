@@ -1,15 +1,59 @@
 package atc
 
+import (
+	"math"
+
+	"github.com/curbz/decimal-niner/internal/constants"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
 type Weather struct {
-	Wind       *Wind
+	Wind       *Wind // surface wind
+	Layers     []WeatherLayer
+	Clouds     []CloudLayer
 	Baro       *Baro
-	Temp       float64
-	Vis        float64
-	Humidity   float64
+	Temp       float64 // surface, degrees C
+	Dewpoint   float64 // surface, degrees C
+	Vis        float64 // meters, zero if unknown
+	Ceiling    float64 // base of the lowest broken or overcast layer in feet MSL, zero if there is none
+	Precip     float64 // precipitation intensity 0-1
+	Humidity   float64 // surface relative humidity, percent
 	MagVar     float64
 	Turbulence float64 // magnitude 0-10
 }
 
+// WeatherLayer holds the wind and temperature at one altitude. Layers are ordered by increasing altitude.
+type WeatherLayer struct {
+	AltitudeFt float64 // feet MSL
+	Wind       Wind
+	Turbulence float64
+	Temp       float64 // degrees C
+	Dewpoint   float64 // degrees C
+}
+
+// CloudLayer holds one cloud layer, ordered as reported by the sim
+type CloudLayer struct {
+	BaseFt   float64 // feet MSL
+	TopsFt   float64 // feet MSL, zero if unknown
+	Coverage float64 // fraction of the sky covered 0-1
+}
+
+// ApproachCatIII is the approach type for ILS CAT III operations on runways where IsCatIIorIII is true
+const ApproachCatIII = "CAT III"
+
+// approachMinima holds the typical minimum visibility (meters) and ceiling (feet above the airport) for each
+// approach type. The empty key applies to runways without an instrument approach.
+var approachMinima = map[string]struct{ visM, ceilingFt float64 }{
+	"CAT III":  {75, 0},
+	"ILS":      {550, 200},
+	"LOC":      {1000, 400},
+	"RNAV":     {1000, 350},
+	"VOR":      {1500, 500},
+	"NDB":      {1500, 600},
+	"Circling": {2400, 800},
+	"":         {5000, 1500},
+}
+
 type Wind struct {
 	Direction float64 // degrees
 	Speed     float64 // m/s
@@ -37,3 +81,161 @@ func getTransitionLevel(transitionAlt int, currBaroPascals float64) int {
 	// If pressure is low, we need more space, so we add an extra level
 	return (transitionAlt / 100) + 20 // e.g., 6000ft -> FL80
 }
+
+// WindAt returns the wind at the altitude (feet MSL), interpolated between the layers either side. The surface
+// wind is returned if no layers are known and the nearest layer is used outside of the reported altitudes.
+func (w *Weather) WindAt(altitudeFt float64) Wind {
+	if len(w.Layers) == 0 {
+		if w.Wind == nil {
+			return Wind{}
+		}
+		return *w.Wind
+	}
+	lower, upper, frac := w.layersAt(altitudeFt)
+	if lower == upper {
+		return lower.Wind
+	}
+
+	// interpolate the wind vectors so that a change of direction through north is handled correctly
+	lowerRad := lower.Wind.Direction * math.Pi / 180.0
+	upperRad := upper.Wind.Direction * math.Pi / 180.0
+	x := lerp(lower.Wind.Speed*math.Sin(lowerRad), upper.Wind.Speed*math.Sin(upperRad), frac)
+	y := lerp(lower.Wind.Speed*math.Cos(lowerRad), upper.Wind.Speed*math.Cos(upperRad), frac)
+
+	dir := math.Mod(math.Atan2(x, y)*180.0/math.Pi+360.0, 360.0)
+	return Wind{
+		Direction: dir,
+		Speed:     math.Hypot(x, y),
+		Shear:     lerp(lower.Wind.Shear, upper.Wind.Shear, frac),
+	}
+}
+
+// TempAt returns the temperature (degrees C) at the altitude (feet MSL), interpolated between the layers either
+// side. If no layers are known the surface temperature is reduced at the standard lapse rate.
+func (w *Weather) TempAt(altitudeFt float64) float64 {
+	if len(w.Layers) == 0 {
+		return w.Temp - altitudeFt/1000.0*constants.StandardLapseRateCPer1000Ft
+	}
+	lower, upper, frac := w.layersAt(altitudeFt)
+	return lerp(lower.Temp, upper.Temp, frac)
+}
+
+// layersAt returns the layers either side of the altitude and the fraction of the way between them.
+// Both layers are the nearest layer if the altitude is outside of the reported layers.
+func (w *Weather) layersAt(altitudeFt float64) (lower, upper *WeatherLayer, frac float64) {
+	first, last := &w.Layers[0], &w.Layers[len(w.Layers)-1]
+	if altitudeFt <= first.AltitudeFt {
+		return first, first, 0
+	}
+	if altitudeFt >= last.AltitudeFt {
+		return last, last, 0
+	}
+	for i := 1; i < len(w.Layers); i++ {
+		upper = &w.Layers[i]
+		if altitudeFt <= upper.AltitudeFt {
+			lower = &w.Layers[i-1]
+			if span := upper.AltitudeFt - lower.AltitudeFt; span > 0 {
+				frac = (altitudeFt - lower.AltitudeFt) / span
+			}
+			return lower, upper, frac
+		}
+	}
+	return last, last, 0
+}
+
+func lerp(a, b, frac float64) float64 {
+	return a + (b-a)*frac
+}
+
+// RelativeHumidity returns the relative humidity (percent) for the temperature and dewpoint in degrees C
+func RelativeHumidity(tempC, dewpointC float64) float64 {
+	// Magnus approximation
+	const b, c = 17.625, 243.04
+	rh := 100.0 * math.Exp(b*dewpointC/(c+dewpointC)) / math.Exp(b*tempC/(c+tempC))
+	return math.Max(0, math.Min(100, rh))
+}
+
+// Components returns the headwind and crosswind components of the wind in knots for a heading or track.
+// A negative headwind is a tailwind.
+func (wd Wind) Components(heading float64) (headwindKt, crosswindKt float64) {
+	speedKt := wd.Speed * constants.MpsToKnots
+	rad := (wd.Direction - heading) * math.Pi / 180.0
+	return speedKt * math.Cos(rad), math.Abs(speedKt * math.Sin(rad))
+}
+
+// WindComponents returns the headwind and crosswind components in knots for a runway heading. The gust
+// estimated from turbulence is included so that limits are assessed against the worst case. A negative
+// headwind is a tailwind.
+func (w *Weather) WindComponents(rwyHeading float64) (headwindKt, crosswindKt float64) {
+	wind := *w.Wind
+	if w.Turbulence > 0.2 {
+		// same gust heuristic as the controller's wind report
+		wind.Speed += w.Turbulence * 25.0 / constants.MpsToKnots
+	}
+	return wind.Components(rwyHeading)
+}
+
+// GetApproachMinima returns the minimum visibility in meters and ceiling in feet above the airport for an
+// approach type such as a runway's HighestPrecisionApproach
+func GetApproachMinima(approachType string) (visM, ceilingFt float64) {
+	m, ok := approachMinima[approachType]
+	if !ok {
+		m = approachMinima[""]
+	}
+	return m.visM, m.ceilingFt
+}
+
+// IsLowVisibility returns true if the visibility or cloud base at an airport of the given elevation (feet)
+// requires low visibility procedures. When active is true the higher cancel thresholds are applied so that
+// procedures are not repeatedly started and cancelled in marginal conditions.
+func (w *Weather) IsLowVisibility(elevationFt float64, active bool) bool {
+	visM, ceilingFt := constants.LVPVisM, constants.LVPCeilingFt
+	if active {
+		visM, ceilingFt = constants.LVPCancelVisM, constants.LVPCancelCeilingFt
+	}
+	if w.Vis > 0 && w.Vis < visM {
+		return true
+	}
+	return w.Ceiling > 0 && w.Ceiling-elevationFt < ceilingFt
+}
+
+// UpdateLowVisibility starts or cancels low visibility procedures at the airport from the current weather.
+// Returns true if the state changed.
+func (s *Service) UpdateLowVisibility(ap *Airport) bool {
+	if ap == nil || s.Weather == nil {
+		return false
+	}
+	w := s.GetAirportWeather(ap.ICAO)
+	lvp := w.IsLowVisibility(ap.Elevation, ap.LVP)
+	if lvp == ap.LVP {
+		return false
+	}
+	ap.LVP = lvp
+	if lvp {
+		util.LogWithLabel(ap.ICAO, "low visibility procedures in operation (visibility %.0fm, ceiling %.0fft)", w.Vis, w.Ceiling)
+	} else {
+		util.LogWithLabel(ap.ICAO, "low visibility procedures cancelled")
+	}
+	return true
+}
+
+// Diversion records a diversion to an alternate airport which is not the result of an emergency
+type Diversion struct {
+	ICAO      string
+	Reason    string // e.g. "crosswind"
+	Announced bool   // set once the diversion has been sent for phrase generation
+}
+
+// DivertAircraft records the diversion of the aircraft to an alternate airport and generates the
+// diversion exchange with the controller
+func (s *Service) DivertAircraft(ac *Aircraft, icao, reason string) {
+
+	ac.Flight.Diversion = &Diversion{ICAO: icao, Reason: reason}
+
+	util.LogWithLabel(ac.Registration, "diverting to %s due to %s", icao, reason)
+
+	if s.transmitSnapshot(ac, "diversion") {
+		// the diversion is only ever announced once
+		ac.Flight.Diversion.Announced = true
+	}
+}
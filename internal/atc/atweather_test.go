@@ -1,6 +1,9 @@
 package atc
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestGetTransitionLevel(t *testing.T) {
 	tests := []struct {
@@ -27,3 +30,106 @@ func TestGetTransitionLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestIsLowVisibility(t *testing.T) {
+	tests := []struct {
+		name    string
+		weather Weather
+		active  bool
+		want    bool
+	}{
+		{"unknown visibility and no ceiling", Weather{}, false, false},
+		{"good visibility", Weather{Vis: 5000, Ceiling: 1500}, false, false},
+		{"visibility below threshold", Weather{Vis: 400}, false, true},
+		{"ceiling below threshold", Weather{Vis: 5000, Ceiling: 250}, false, true},
+		{"marginal visibility does not start procedures", Weather{Vis: 700}, false, false},
+		{"marginal visibility does not cancel procedures", Weather{Vis: 700}, true, true},
+		{"marginal ceiling does not cancel procedures", Weather{Vis: 5000, Ceiling: 320}, true, true},
+		{"improved conditions cancel procedures", Weather{Vis: 1000, Ceiling: 500}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// airport elevation of 100ft, ceilings are feet MSL
+			if got := tt.weather.IsLowVisibility(100, tt.active); got != tt.want {
+				t.Errorf("IsLowVisibility() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateLowVisibility(t *testing.T) {
+	s := &Service{Weather: &Weather{Vis: 300}}
+	ap := &Airport{ICAO: "EGLL"}
+
+	if !s.UpdateLowVisibility(ap) || !ap.LVP {
+		t.Fatalf("expected low visibility procedures to start")
+	}
+	if s.UpdateLowVisibility(ap) {
+		t.Errorf("expected no state change while conditions persist")
+	}
+	s.Weather.Vis = 2000
+	if !s.UpdateLowVisibility(ap) || ap.LVP {
+		t.Errorf("expected low visibility procedures to be cancelled")
+	}
+}
+
+func TestWindAt(t *testing.T) {
+	w := &Weather{
+		Wind: &Wind{Direction: 90, Speed: 3},
+		Layers: []WeatherLayer{
+			{AltitudeFt: 1000, Wind: Wind{Direction: 350, Speed: 10}},
+			{AltitudeFt: 3000, Wind: Wind{Direction: 10, Speed: 10}},
+			{AltitudeFt: 30000, Wind: Wind{Direction: 270, Speed: 50, Shear: 4}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		alt      float64
+		wantDir  float64
+		wantSpd  float64
+		tolerDir float64
+	}{
+		{"below lowest layer", 0, 350, 10, 0.01},
+		{"at layer", 3000, 10, 10, 0.01},
+		{"above highest layer", 40000, 270, 50, 0.01},
+		{"interpolates through north", 2000, 0, 10 * math.Cos(10*math.Pi/180), 0.01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := w.WindAt(tt.alt)
+			dirDiff := math.Abs(math.Mod(got.Direction-tt.wantDir+540, 360) - 180)
+			if dirDiff > tt.tolerDir || math.Abs(got.Speed-tt.wantSpd) > 0.01 {
+				t.Errorf("WindAt(%v) = %03.1f/%.2f; want %03.1f/%.2f", tt.alt, got.Direction, got.Speed, tt.wantDir, tt.wantSpd)
+			}
+		})
+	}
+
+	noLayers := &Weather{Wind: &Wind{Direction: 90, Speed: 3}}
+	if got := noLayers.WindAt(35000); got != *noLayers.Wind {
+		t.Errorf("WindAt() without layers = %+v; want surface wind %+v", got, *noLayers.Wind)
+	}
+}
+
+func TestTempAt(t *testing.T) {
+	w := &Weather{Temp: 15, Layers: []WeatherLayer{{AltitudeFt: 0, Temp: 15}, {AltitudeFt: 10000, Temp: -5}}}
+	if got := w.TempAt(5000); math.Abs(got-5) > 0.001 {
+		t.Errorf("TempAt(5000) = %v; want 5", got)
+	}
+
+	noLayers := &Weather{Temp: 15}
+	if got := noLayers.TempAt(10000); math.Abs(got-(15-19.8)) > 0.001 {
+		t.Errorf("TempAt(10000) without layers = %v; want standard lapse rate", got)
+	}
+}
+
+func TestRelativeHumidity(t *testing.T) {
+	if got := RelativeHumidity(15, 15); math.Abs(got-100) > 0.001 {
+		t.Errorf("RelativeHumidity(15, 15) = %v; want 100", got)
+	}
+	if got := RelativeHumidity(20, 10); got < 50 || got > 55 {
+		t.Errorf("RelativeHumidity(20, 10) = %v; want about 52", got)
+	}
+}
@@ -64,8 +64,13 @@ const (
 	RunwayElevationOffsetFt = 100
 
 	// Squawk generation
-	SquawkMin   = 1200
-	SquawkRange = 5800
+	SquawkMin          = 1200
+	SquawkRange        = 5800
+	SquawkEmergency    = 7700
+	SquawkRadioFailure = 7600
+
+	// Emergency handling
+	EmergencyDescentAltFt = 10000 // maximum altitude following a pressurization failure
 
 	// Sentinels
 	AirspaceFloorSentinel   = -99999
@@ -85,4 +90,21 @@ const (
 	// Wind/check thresholds
 	WindDirShiftDeg   = 15.0
 	WindSpeedDeltaKts = 5.0
+	WindShearLimitKts = 15.0 // shear at or above which approaches are discontinued
+
+	// Low visibility procedures are started when the visibility or cloud base (feet above the airport) falls below
+	// the LVP thresholds and are only cancelled once both are at or above the cancel thresholds
+	LVPVisM            = 600.0
+	LVPCeilingFt       = 200.0
+	LVPCancelVisM      = 800.0
+	LVPCancelCeilingFt = 300.0
+
+	// Weather conversions
+	MpsToKnots           = 1.94384
+	MetersToFeet         = 3.28084
+	MetersPerStatuteMile = 1609.344
+	PascalsPerInHg       = 3386.389
+	CeilingMinCoverage   = 0.625 // cloud cover fraction (5 oktas) from which a layer is broken and forms a ceiling
+
+	StandardLapseRateCPer1000Ft = 1.98 // ISA temperature lapse rate
 )
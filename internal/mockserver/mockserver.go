@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -63,6 +64,16 @@ var (
 		simdata.DRSimWeatherRegionWindDirection:      "float",
 		simdata.DRSimWeatherAircraftBarometer:        "float",
 		simdata.DRSimWeatherRegionSeaLevelPressure:   "double",
+		simdata.DRSimWeatherRegionVisibility:         "float",
+		simdata.DRSimWeatherRegionCloudBase:          "float",
+		simdata.DRSimWeatherRegionCloudCoverage:      "float",
+		simdata.DRSimWeatherRegionCloudTops:          "float",
+		simdata.DRSimWeatherRegionWindAltitude:       "float",
+		simdata.DRSimWeatherRegionTemperaturesAloft:  "float",
+		simdata.DRSimWeatherRegionDewpoint:           "float",
+		simdata.DRSimWeatherRegionSeaLevelTemp:       "float",
+		simdata.DRSimWeatherSeaLevelDewpoint:         "float",
+		simdata.DRSimWeatherRegionRainPercent:        "float",
 
 		simdata.DRTrafficEngineAIPositionLat:     "float[]",
 		simdata.DRTrafficEngineAIPositionLong:    "float[]",
@@ -357,13 +368,35 @@ func samplePayloadForName(name, vt string, iter int) interface{} {
 	case simdata.DRSimFlightmodelPositionMagVariation:
 		return 1.1
 	case simdata.DRSimWeatherRegionTurbulence:
-		return []float64{0.2 + float64(iter/10)}
+		return []float64{0.2 + float64(iter/10), 0.1, 0.0, 0.0}
 	case simdata.DRSimWeatherRegionShearSpeed:
-		return []float64{1.0 + float64(iter/2)}
+		return []float64{1.0 + float64(iter/2), 1.0, 0.0, 0.0}
 	case simdata.DRSimWeatherRegionWindSpeed:
-		return []float64{5.0 + float64(iter)}
+		return []float64{5.0 + float64(iter), 10.0 + float64(iter), 25.0, 45.0}
 	case simdata.DRSimWeatherRegionWindDirection:
-		return []float64{90.0 + float64(iter*4)}
+		return []float64{90.0 + float64(iter*4), 110.0, 250.0, 270.0}
+	case simdata.DRSimWeatherRegionWindAltitude:
+		// meters MSL, surface layer followed by winds aloft
+		return []float64{300.0, 1500.0, 5000.0, 10000.0}
+	case simdata.DRSimWeatherRegionTemperaturesAloft:
+		return []float64{15.0, 8.0, -18.0, -50.0}
+	case simdata.DRSimWeatherRegionDewpoint:
+		return []float64{10.0, 4.0, -25.0, -60.0}
+	case simdata.DRSimWeatherRegionSeaLevelTemp:
+		return 17.0
+	case simdata.DRSimWeatherSeaLevelDewpoint:
+		return 11.0
+	case simdata.DRSimWeatherRegionVisibility:
+		// statute miles, reducing to simulate deteriorating conditions
+		return math.Max(0.25, 10.0-float64(iter)*0.5)
+	case simdata.DRSimWeatherRegionCloudBase:
+		return []float64{600.0, 1500.0, 3000.0}
+	case simdata.DRSimWeatherRegionCloudCoverage:
+		return []float64{0.25, 0.75, 1.0}
+	case simdata.DRSimWeatherRegionCloudTops:
+		return []float64{900.0, 2500.0, 4500.0}
+	case simdata.DRSimWeatherRegionRainPercent:
+		return 0.3
 
 	// --- AI Aircraft Data (Moving around EGLL) ---
 	case simdata.DRTrafficEngineAIPositionLat:
@@ -0,0 +1,75 @@
+package pcl
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// function is a built-in PCL function, e.g. {SAY ROUND($ALTITUDE, 100)}
+type function struct {
+	minArgs, maxArgs int
+	call             func(args []interface{}) (interface{}, error)
+}
+
+func (f function) arity() string {
+	switch {
+	case f.minArgs == f.maxArgs && f.minArgs == 1:
+		return "1 argument"
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+}
+
+var functions = map[string]function{
+	"UPPER": {1, 1, func(args []interface{}) (interface{}, error) {
+		return strings.ToUpper(formatValue(args[0])), nil
+	}},
+	"LOWER": {1, 1, func(args []interface{}) (interface{}, error) {
+		return strings.ToLower(formatValue(args[0])), nil
+	}},
+	"SPELL": {1, 1, func(args []interface{}) (interface{}, error) {
+		return spell(formatValue(args[0])), nil
+	}},
+	"ROUND": {1, 2, func(args []interface{}) (interface{}, error) {
+		v, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		step := 1.0
+		if len(args) == 2 {
+			if step, err = toNumber(args[1]); err != nil {
+				return nil, err
+			}
+			if step <= 0 {
+				return nil, fmt.Errorf("ROUND step must be greater than zero")
+			}
+		}
+		return math.Round(v/step) * step, nil
+	}},
+}
+
+// phoneticAlphabet holds the ICAO spelling alphabet used by SPELL
+var phoneticAlphabet = map[rune]string{
+	'A': "alpha", 'B': "bravo", 'C': "charlie", 'D': "delta", 'E': "echo", 'F': "foxtrot",
+	'G': "golf", 'H': "hotel", 'I': "india", 'J': "juliett", 'K': "kilo", 'L': "lima",
+	'M': "mike", 'N': "november", 'O': "oscar", 'P': "papa", 'Q': "quebec", 'R': "romeo",
+	'S': "sierra", 'T': "tango", 'U': "uniform", 'V': "victor", 'W': "whiskey", 'X': "x-ray",
+	'Y': "yankee", 'Z': "zulu",
+}
+
+// spell spells out the letters of s with the phonetic alphabet and separates its digits, which are
+// translated to words later in the same way as any other digits
+func spell(s string) string {
+	var words []string
+	for _, r := range strings.ToUpper(s) {
+		if word, ok := phoneticAlphabet[r]; ok {
+			words = append(words, word)
+		} else if r >= '0' && r <= '9' {
+			words = append(words, string(r))
+		}
+	}
+	return strings.Join(words, " ")
+}
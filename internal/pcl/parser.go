@@ -0,0 +1,744 @@
+package pcl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseError reports a syntax error and the position (byte offset) in the phrase at which it was found.
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s (position %d)", e.Msg, e.Pos+1)
+}
+
+// Node is an element of a parsed phrase: text, a variable reference, a statement or an expression.
+type Node interface {
+	Position() int
+}
+
+// Template is a parsed phrase.
+type Template struct {
+	Source string
+	Nodes  []Node
+}
+
+// TextNode is literal text that is spoken as-is.
+type TextNode struct {
+	Pos  int
+	Text string
+}
+
+// VarNode references a raw variable ($) or formatted macro (@), with its arguments if any.
+type VarNode struct {
+	Pos  int
+	Key  string // including the $ or @ prefix
+	Args []string
+	Raw  string // source text, spoken when the key has no provider
+}
+
+// SayNode speaks its items separated by spaces.
+type SayNode struct {
+	Pos   int
+	Items []Node
+}
+
+// WhenNode speaks Then if Cond is true, otherwise Else, which may be nil.
+type WhenNode struct {
+	Pos  int
+	Cond Node
+	Then *SayNode
+	Else Node
+}
+
+// OneOfNode speaks one of its choices at random, in proportion to their weights.
+type OneOfNode struct {
+	Pos     int
+	Choices []Choice
+}
+
+// Choice is an alternative of a ONEOF statement.
+type Choice struct {
+	Say    *SayNode
+	Weight float64
+}
+
+// LetNode binds the values of expressions to local variables for the rest of the phrase.
+type LetNode struct {
+	Pos      int
+	Bindings []Binding
+}
+
+// Binding is a single local variable of a LET statement.
+type Binding struct {
+	Name  string // including the $ prefix
+	Value Node
+}
+
+// DirectiveNode is a marker such as {NOREADBACK} that is not spoken.
+type DirectiveNode struct {
+	Pos  int
+	Name string
+}
+
+// NumberLit, StringLit and WordLit are literal operands. The content of a backtick string is itself a template.
+type NumberLit struct {
+	Pos   int
+	Value float64
+}
+
+type StringLit struct {
+	Pos      int
+	Template *Template
+}
+
+type WordLit struct {
+	Pos  int
+	Word string
+}
+
+// UnaryExpr is negation (-) or logical NOT.
+type UnaryExpr struct {
+	Pos     int
+	Op      string
+	Operand Node
+}
+
+// BinaryExpr is an arithmetic, comparison or logical operation.
+type BinaryExpr struct {
+	Pos         int
+	Op          string
+	Left, Right Node
+}
+
+// CallExpr calls one of the built-in functions.
+type CallExpr struct {
+	Pos  int
+	Func string
+	Args []Node
+}
+
+func (n *TextNode) Position() int      { return n.Pos }
+func (n *VarNode) Position() int       { return n.Pos }
+func (n *SayNode) Position() int       { return n.Pos }
+func (n *WhenNode) Position() int      { return n.Pos }
+func (n *OneOfNode) Position() int     { return n.Pos }
+func (n *LetNode) Position() int       { return n.Pos }
+func (n *DirectiveNode) Position() int { return n.Pos }
+func (n *NumberLit) Position() int     { return n.Pos }
+func (n *StringLit) Position() int     { return n.Pos }
+func (n *WordLit) Position() int       { return n.Pos }
+func (n *UnaryExpr) Position() int     { return n.Pos }
+func (n *BinaryExpr) Position() int    { return n.Pos }
+func (n *CallExpr) Position() int      { return n.Pos }
+
+// directives are the markers that may appear on their own in a block
+var directives = map[string]bool{"NOREADBACK": true}
+
+// comparators are the comparison operators of conditions
+var comparators = map[string]bool{"EQ": true, "NE": true, "LT": true, "LE": true, "GT": true, "GE": true}
+
+// keywords cannot be used as bare word literals
+var keywords = map[string]bool{
+	"WHEN": true, "SAY": true, "OTHERWISE": true, "ONEOF": true, "LET": true,
+	"AND": true, "OR": true, "NOT": true,
+	"EQ": true, "NE": true, "LT": true, "LE": true, "GT": true, "GE": true,
+}
+
+// token kinds of the lexer inside blocks
+const (
+	tokEOF = iota
+	tokLBrace
+	tokRBrace
+	tokString
+	tokVar
+	tokWord
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind int
+	text string // word, operator, string content or variable key
+	pos  int
+	args []string // variable arguments
+	raw  string   // variable source text
+}
+
+type parser struct {
+	src  string
+	pos  int
+	base int // offset of src in the phrase, for the positions of nested templates
+}
+
+// Parse parses a phrase into a template, returning a ParseError for invalid syntax.
+func Parse(input string) (*Template, error) {
+	return parseTemplate(input, 0)
+}
+
+func parseTemplate(src string, base int) (*Template, error) {
+	p := &parser{src: src, base: base}
+	nodes, err := p.parseText()
+	if err != nil {
+		return nil, err
+	}
+	return &Template{Source: src, Nodes: nodes}, nil
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &ParseError{Pos: p.base + pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseText parses literal text containing blocks and bare variable references
+func (p *parser) parseText() ([]Node, error) {
+	var nodes []Node
+	var text strings.Builder
+	textStart := 0
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, &TextNode{Pos: p.base + textStart, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '{':
+			flush()
+			n, err := p.parseBlock()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+		case c == '}':
+			return nil, p.errorf(p.pos, "unexpected closing brace '}'")
+		case (c == '$' || c == '@') && p.pos+1 < len(p.src) && isNameByte(p.src[p.pos+1]):
+			flush()
+			tok := p.lexVar()
+			nodes = append(nodes, &VarNode{Pos: p.base + tok.pos, Key: tok.text, Args: tok.args, Raw: tok.raw})
+		default:
+			if text.Len() == 0 {
+				textStart = p.pos
+			}
+			text.WriteByte(c)
+			p.pos++
+		}
+	}
+	flush()
+	return nodes, nil
+}
+
+// parseBlock parses a {...} block into its statement
+func (p *parser) parseBlock() (Node, error) {
+	start := p.next().pos // {
+
+	n, err := p.parseStatement(start)
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.next()
+	switch tok.kind {
+	case tokRBrace:
+		return n, nil
+	case tokEOF:
+		return nil, p.errorf(start, "unclosed opening brace '{'")
+	default:
+		return nil, p.errorf(tok.pos, "unexpected '%s' in block", tok.text)
+	}
+}
+
+func (p *parser) parseStatement(blockPos int) (Node, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokEOF:
+		return nil, p.errorf(blockPos, "unclosed opening brace '{'")
+	case tokRBrace:
+		return nil, p.errorf(blockPos, "empty block")
+	case tokWord:
+		switch {
+		case tok.text == "WHEN":
+			return p.parseWhen()
+		case tok.text == "SAY":
+			p.next()
+			return p.parseSay(tok.pos)
+		case tok.text == "ONEOF":
+			return p.parseOneOf()
+		case tok.text == "LET":
+			return p.parseLet()
+		case directives[tok.text]:
+			p.next()
+			return &DirectiveNode{Pos: p.base + tok.pos, Name: tok.text}, nil
+		case p.isCall(tok):
+			return p.parseSay(tok.pos)
+		}
+		return nil, p.errorf(tok.pos, "unknown PCL tag: %s", tok.text)
+	}
+	// implied SAY, e.g. {$CALLSIGN}
+	return p.parseSay(tok.pos)
+}
+
+// parseSay parses the items of a SAY statement, which end at OTHERWISE, '|', ':' or the end of the block
+func (p *parser) parseSay(pos int) (*SayNode, error) {
+	say := &SayNode{Pos: p.base + pos}
+	for {
+		tok := p.peek()
+		if tok.kind == tokEOF || tok.kind == tokRBrace || (tok.kind == tokWord && tok.text == "OTHERWISE") ||
+			(tok.kind == tokOp && (tok.text == "|" || tok.text == ":")) {
+			break
+		}
+		var item Node
+		var err error
+		switch {
+		case tok.kind == tokLBrace:
+			item, err = p.parseBlock()
+		case p.startsExpr(tok):
+			item, err = p.parseExpr()
+		default:
+			item = p.parseLiteral()
+		}
+		if err != nil {
+			return nil, err
+		}
+		say.Items = append(say.Items, item)
+	}
+	if len(say.Items) == 0 {
+		return nil, p.errorf(pos, "SAY statement has nothing to say")
+	}
+	return say, nil
+}
+
+// startsExpr reports whether a SAY item is an expression rather than literal text: a variable reference, a
+// function call, a backtick string or a parenthesised expression
+func (p *parser) startsExpr(tok token) bool {
+	switch tok.kind {
+	case tokVar, tokString:
+		return true
+	case tokOp:
+		return tok.text == "(" || tok.text == "`"
+	case tokWord:
+		return p.isCall(tok)
+	}
+	return false
+}
+
+// parseLiteral reads the words of a SAY item up to the next expression, block or the end of the statement as
+// text spoken as written, e.g. {SAY follow-up}, {SAY runway 09} or {SAY hello, world}
+func (p *parser) parseLiteral() Node {
+	start := p.peek().pos
+	end := start
+	for {
+		tok := p.peek()
+		if tok.kind == tokEOF || tok.kind == tokLBrace || tok.kind == tokRBrace || p.startsExpr(tok) ||
+			(tok.kind == tokWord && tok.text == "OTHERWISE") || (tok.kind == tokOp && (tok.text == "|" || tok.text == ":")) {
+			break
+		}
+		p.next()
+		end = p.pos
+	}
+	return &TextNode{Pos: p.base + start, Text: p.src[start:end]}
+}
+
+func (p *parser) parseWhen() (Node, error) {
+	when := p.next()
+	if tok := p.peek(); tok.kind == tokWord && tok.text == "SAY" || tok.kind == tokRBrace || tok.kind == tokEOF {
+		return nil, p.errorf(when.pos, "PCL logic error: WHEN condition is empty")
+	}
+	cond, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	say := p.next()
+	if say.kind != tokWord || say.text != "SAY" {
+		return nil, p.errorf(say.pos, "PCL logic error: WHEN block missing mandatory SAY statement")
+	}
+	then, err := p.parseSay(say.pos)
+	if err != nil {
+		return nil, err
+	}
+	n := &WhenNode{Pos: p.base + when.pos, Cond: cond, Then: then}
+
+	if tok := p.peek(); tok.kind != tokWord || tok.text != "OTHERWISE" {
+		return n, nil
+	}
+	otherwise := p.next()
+	tok := p.peek()
+	switch {
+	case tok.kind == tokWord && tok.text == "SAY":
+		p.next()
+		n.Else, err = p.parseSay(tok.pos)
+	case tok.kind == tokWord && tok.text == "WHEN":
+		n.Else, err = p.parseWhen()
+	case tok.kind == tokLBrace:
+		n.Else, err = p.parseBlock()
+	default:
+		return nil, p.errorf(otherwise.pos, "PCL logic error: OTHERWISE block missing follow-up SAY statement")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// parseOneOf parses {ONEOF `a` | `b`:2 | `c`}, where the optional weight of a choice defaults to 1
+func (p *parser) parseOneOf() (Node, error) {
+	oneOf := p.next()
+	n := &OneOfNode{Pos: p.base + oneOf.pos}
+	for {
+		say, err := p.parseSay(p.peek().pos)
+		if err != nil {
+			return nil, err
+		}
+		choice := Choice{Say: say, Weight: 1}
+		if tok := p.peek(); tok.kind == tokOp && tok.text == ":" {
+			p.next()
+			w := p.next()
+			if w.kind != tokNumber {
+				return nil, p.errorf(w.pos, "ONEOF weight must be a number")
+			}
+			choice.Weight, _ = strconv.ParseFloat(w.text, 64)
+			if choice.Weight <= 0 {
+				return nil, p.errorf(w.pos, "ONEOF weight must be greater than zero")
+			}
+		}
+		n.Choices = append(n.Choices, choice)
+		if tok := p.peek(); tok.kind != tokOp || tok.text != "|" {
+			break
+		}
+		p.next()
+	}
+	return n, nil
+}
+
+// parseLet parses {LET $NAME = expression, $OTHER = expression}
+func (p *parser) parseLet() (Node, error) {
+	let := p.next()
+	n := &LetNode{Pos: p.base + let.pos}
+	for {
+		name := p.next()
+		if name.kind != tokVar || !strings.HasPrefix(name.text, "$") || name.args != nil {
+			return nil, p.errorf(name.pos, "LET requires a $ variable name")
+		}
+		if eq := p.next(); eq.kind != tokOp || eq.text != "=" {
+			return nil, p.errorf(eq.pos, "LET %s missing '='", name.text)
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		n.Bindings = append(n.Bindings, Binding{Name: name.text, Value: value})
+		if tok := p.peek(); tok.kind != tokOp || tok.text != "," {
+			break
+		}
+		p.next()
+	}
+	return n, nil
+}
+
+// --- expressions, in increasing order of precedence: OR, AND, NOT, comparison, + -, * / %, unary - ---
+
+func (p *parser) parseExpr() (Node, error) {
+	return p.parseBinary(0)
+}
+
+// binaryLevels lists the operators of each binary precedence level, lowest first
+var binaryLevels = [][]string{
+	{"OR"},
+	{"AND"},
+	nil, // NOT
+	{"EQ", "NE", "LT", "LE", "GT", "GE"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level int) (Node, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+	if binaryLevels[level] == nil {
+		if tok := p.peek(); tok.kind == tokWord && tok.text == "NOT" {
+			p.next()
+			operand, err := p.parseBinary(level)
+			if err != nil {
+				return nil, err
+			}
+			return &UnaryExpr{Pos: p.base + tok.pos, Op: "NOT", Operand: operand}, nil
+		}
+		return p.parseBinary(level + 1)
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if (tok.kind != tokWord && tok.kind != tokOp) || !containsString(binaryLevels[level], tok.text) {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Pos: p.base + tok.pos, Op: tok.text, Left: left, Right: right}
+		// comparisons do not chain
+		if comparators[tok.text] {
+			return left, nil
+		}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if tok := p.peek(); tok.kind == tokOp && tok.text == "-" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Pos: p.base + tok.pos, Op: "-", Operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		v, _ := strconv.ParseFloat(tok.text, 64)
+		return &NumberLit{Pos: p.base + tok.pos, Value: v}, nil
+	case tokString:
+		tmpl, err := parseTemplate(tok.text, p.base+tok.pos+1)
+		if err != nil {
+			return nil, err
+		}
+		return &StringLit{Pos: p.base + tok.pos, Template: tmpl}, nil
+	case tokVar:
+		return &VarNode{Pos: p.base + tok.pos, Key: tok.text, Args: tok.args, Raw: tok.raw}, nil
+	case tokOp:
+		if tok.text == "`" {
+			return nil, p.errorf(tok.pos, "unterminated backtick string")
+		}
+		if tok.text == "(" {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if closing := p.next(); closing.kind != tokOp || closing.text != ")" {
+				return nil, p.errorf(tok.pos, "missing closing parenthesis")
+			}
+			return expr, nil
+		}
+	case tokWord:
+		if p.isCall(tok) {
+			return p.parseCall(tok)
+		}
+		if !keywords[tok.text] {
+			return &WordLit{Pos: p.base + tok.pos, Word: tok.text}, nil
+		}
+	case tokEOF:
+		return nil, p.errorf(tok.pos, "unexpected end of phrase")
+	}
+	return nil, p.errorf(tok.pos, "unexpected '%s'", tok.text)
+}
+
+// isCall returns true if the word is a built-in function immediately followed by '('
+func (p *parser) isCall(tok token) bool {
+	_, ok := functions[tok.text]
+	end := tok.pos + len(tok.text)
+	return ok && end < len(p.src) && p.src[end] == '('
+}
+
+func (p *parser) parseCall(name token) (Node, error) {
+	p.next() // (
+	call := &CallExpr{Pos: p.base + name.pos, Func: name.text}
+	if tok := p.peek(); tok.kind == tokOp && tok.text == ")" {
+		p.next()
+	} else {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, arg)
+			tok := p.next()
+			if tok.kind == tokOp && tok.text == ")" {
+				break
+			}
+			if tok.kind != tokOp || tok.text != "," {
+				return nil, p.errorf(name.pos, "missing closing parenthesis for %s", name.text)
+			}
+		}
+	}
+	fn := functions[name.text]
+	if len(call.Args) < fn.minArgs || len(call.Args) > fn.maxArgs {
+		return nil, p.errorf(name.pos, "%s expects %s", name.text, fn.arity())
+	}
+	return call, nil
+}
+
+// --- lexer ---
+
+func (p *parser) peek() token {
+	pos := p.pos
+	tok := p.next()
+	p.pos = pos
+	return tok
+}
+
+func (p *parser) next() token {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n') {
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		return token{kind: tokEOF, pos: p.pos}
+	}
+
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case c == '{':
+		p.pos++
+		return token{kind: tokLBrace, text: "{", pos: start}
+	case c == '}':
+		p.pos++
+		return token{kind: tokRBrace, text: "}", pos: start}
+	case c == '`':
+		end := strings.IndexByte(p.src[start+1:], '`')
+		if end == -1 {
+			p.pos = len(p.src)
+			return token{kind: tokOp, text: "`", pos: start}
+		}
+		p.pos = start + 1 + end + 1
+		return token{kind: tokString, text: p.src[start+1 : start+1+end], pos: start}
+	case (c == '$' || c == '@') && p.pos+1 < len(p.src) && isNameByte(p.src[p.pos+1]):
+		return p.lexVar()
+	case strings.IndexByte("+-*/%()|:,=", c) != -1:
+		p.pos++
+		return token{kind: tokOp, text: string(c), pos: start}
+	case isWordByte(c):
+		for p.pos < len(p.src) && isWordByte(p.src[p.pos]) {
+			p.pos++
+		}
+		text := p.src[start:p.pos]
+		if c >= '0' && c <= '9' {
+			if _, err := strconv.ParseFloat(text, 64); err == nil {
+				return token{kind: tokNumber, text: text, pos: start}
+			}
+		}
+		return token{kind: tokWord, text: text, pos: start}
+	}
+	p.pos++
+	return token{kind: tokOp, text: string(c), pos: start}
+}
+
+// lexVar reads a $VAR or @MACRO(args) reference at the current position
+func (p *parser) lexVar() token {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && isNameByte(p.src[p.pos]) {
+		p.pos++
+	}
+	tok := token{kind: tokVar, text: p.src[start:p.pos], pos: start}
+	if p.pos < len(p.src) && p.src[p.pos] == '(' {
+		if end := strings.IndexByte(p.src[p.pos:], ')'); end != -1 {
+			inner := p.src[p.pos+1 : p.pos+end]
+			p.pos += end + 1
+			tok.args = []string{}
+			if inner != "" {
+				for _, a := range strings.Split(inner, ",") {
+					tok.args = append(tok.args, strings.TrimSpace(a))
+				}
+			}
+		}
+	}
+	tok.raw = p.src[start:p.pos]
+	return tok
+}
+
+// isNameByte reports whether c may appear in a variable name
+func isNameByte(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_'
+}
+
+// isWordByte reports whether c may appear in a bare word or number
+func isWordByte(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '.' || c >= 0x80
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Reference is a variable or macro used by a template.
+type Reference struct {
+	Key    string
+	Args   []string
+	Pos    int
+	Spoken bool // rendered into the phrase, rather than used as an operand of a condition, binding or function
+}
+
+// References returns the variables and macros used by the template in order of appearance. Local variables
+// bound by LET are not included.
+func (t *Template) References() []Reference {
+	locals := make(map[string]bool)
+	var refs []Reference
+	var walk func(n Node, spoken bool)
+	walkAll := func(nodes []Node, spoken bool) {
+		for _, n := range nodes {
+			walk(n, spoken)
+		}
+	}
+	walk = func(n Node, spoken bool) {
+		switch n := n.(type) {
+		case *VarNode:
+			refs = append(refs, Reference{Key: n.Key, Args: n.Args, Pos: n.Pos, Spoken: spoken})
+		case *StringLit:
+			walkAll(n.Template.Nodes, spoken)
+		case *SayNode:
+			walkAll(n.Items, true)
+		case *WhenNode:
+			walk(n.Cond, false)
+			walk(n.Then, true)
+			if n.Else != nil {
+				walk(n.Else, true)
+			}
+		case *OneOfNode:
+			for _, c := range n.Choices {
+				walk(c.Say, true)
+			}
+		case *LetNode:
+			for _, b := range n.Bindings {
+				locals[b.Name] = true
+				walk(b.Value, false)
+			}
+		case *UnaryExpr:
+			walk(n.Operand, false)
+		case *BinaryExpr:
+			walk(n.Left, false)
+			walk(n.Right, false)
+		case *CallExpr:
+			walkAll(n.Args, false)
+		}
+	}
+	walkAll(t.Nodes, true)
+
+	result := refs[:0]
+	for _, r := range refs {
+		if !locals[r.Key] {
+			result = append(result, r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+package pcl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		phrase  string
+		pos     int
+		message string
+	}{
+		{"unclosed brace", "Hello {$CALLSIGN", 7, "unclosed opening brace"},
+		{"stray closing brace", "Hello } there", 7, "unexpected closing brace"},
+		{"unknown tag", "Report at {FAKE_TAG}.", 12, "unknown PCL tag: FAKE_TAG"},
+		{"missing SAY", "{WHEN $A GT 1 `x`}", 15, "missing mandatory SAY"},
+		{"empty condition", "{WHEN SAY `x`}", 2, "condition is empty"},
+		{"missing parenthesis", "{WHEN ($A + 1 GT 2 SAY `x`}", 7, "missing closing parenthesis"},
+		{"unterminated string", "{SAY `open}", 6, "unterminated backtick string"},
+		{"function arity", "{SAY ROUND()}", 6, "ROUND expects 1 to 2 arguments"},
+		{"bad weight", "{ONEOF `a`:0 | `b`}", 12, "weight must be greater than zero"},
+		{"LET without variable", "{LET X = 1}", 6, "LET requires a $ variable name"},
+		{"error inside string", "{WHEN $A SAY `via {@STAR}`", 1, "unclosed opening brace"},
+		{"nested string error position", "{SAY `a {NOPE}`}", 10, "unknown PCL tag: NOPE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.phrase)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse(%q) error = %v; want ParseError", tt.phrase, err)
+			}
+			if !strings.Contains(perr.Msg, tt.message) || perr.Pos+1 != tt.pos {
+				t.Errorf("Parse(%q) error = %q at %d; want %q at %d", tt.phrase, perr.Msg, perr.Pos+1, tt.message, tt.pos)
+			}
+		})
+	}
+}
+
+func TestTemplateReferences(t *testing.T) {
+	tmpl, err := Parse("{LET $D = $ALTITUDE - $FA_ALTITUDE}{WHEN $D GT 1000 SAY `descend {@ALTITUDE}`} $CALLSIGN")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Reference{
+		{Key: "$ALTITUDE", Spoken: false},
+		{Key: "$FA_ALTITUDE", Spoken: false},
+		{Key: "@ALTITUDE", Spoken: true},
+		{Key: "$CALLSIGN", Spoken: true},
+	}
+	refs := tmpl.References()
+	if len(refs) != len(want) {
+		t.Fatalf("got %d references %+v; want %d", len(refs), refs, len(want))
+	}
+	for i, r := range refs {
+		if r.Key != want[i].Key || r.Spoken != want[i].Spoken {
+			t.Errorf("reference %d = %s (spoken %v); want %s (spoken %v)", i, r.Key, r.Spoken, want[i].Key, want[i].Spoken)
+		}
+	}
+}
@@ -1,186 +1,389 @@
 package pcl
 
 import (
+	"container/list"
 	"fmt"
-	"regexp"
+	"math"
+	"math/rand"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // VariableProvider handles both raw data ($) and formatted macros (@).
 // args will be populated if the syntax @MACRO(arg1, arg2) is used.
 type VariableProvider func(args ...string) interface{}
 
+// Context resolves the variables and macros of a template. Applications with many variables implement it
+// on a typed struct so that rendering a phrase does not build a map of providers.
+type Context interface {
+	Resolve(key string, args []string) (interface{}, bool)
+}
+
 // PCLContext maps keys (including $ or @ prefix) to provider functions.
 type PCLContext map[string]VariableProvider
 
-// ProcessPhrase is the high-level entry point for the PCL engine.
-func ProcessPhrase(input string, ctx PCLContext) (string, error) {
-	cache := make(map[string]string)
+// Resolve calls the provider of the key.
+func (c PCLContext) Resolve(key string, args []string) (interface{}, bool) {
+	provider, ok := c[key]
+	if !ok {
+		return nil, false
+	}
+	return provider(args...), true
+}
 
-	// 1. First Pass: Process Logic Blocks {WHEN ...} or {SAY ...} on the raw template
-	pclRegex := regexp.MustCompile(`\{[^{}]*(?:\{[^{}]*\}[^{}]*)*\}`)
-	resolved := input
+// Value allows a provider to return the structured data behind a token alongside
+// the text that is spoken. Providers that return plain values are rendered as-is.
+type Value struct {
+	Data interface{}
+	Text string
+}
 
-	for {
-		match := pclRegex.FindString(resolved)
-		if match == "" {
-			break
-		}
-		content := match[1 : len(match)-1]
-		replacement := executePCL(content, ctx, cache)
-		resolved = strings.Replace(resolved, match, replacement, 1)
-	}
+// String renders the spoken text so that a Value can be used anywhere a plain value can.
+func (v Value) String() string {
+	return v.Text
+}
 
-	// 2. Second Pass: Now substitute tokens into the final chosen message string
-	tokenRegex := regexp.MustCompile(`\{?([$@])([A-Z0-9_]+)(?:\((.*?)\))?\}?`)
-	finalOutput := tokenRegex.ReplaceAllStringFunc(resolved, func(fullMatch string) string {
-		match := tokenRegex.FindStringSubmatch(fullMatch)
-		if len(match) < 3 {
-			return fullMatch
-		}
+// Token records a single provider that was rendered into the output of a phrase.
+type Token struct {
+	Key  string   // lookup key including the $ or @ prefix
+	Args []string // macro arguments, if any
+	Data interface{}
+	Text string
+}
 
-		prefix := match[1]
-		varName := match[2]
-		argString := match[3]
-		lookupKey := prefix + varName
-
-		if provider, ok := ctx[lookupKey]; ok {
-			var args []string
-			if argString != "" {
-				args = strings.Split(argString, ",")
-				for i := range args {
-					args[i] = strings.TrimSpace(args[i])
-				}
-			}
-			return fmt.Sprintf("%v", provider(args...))
-		}
-		return fullMatch
-	})
+// maxCachedTemplates limits the number of compiled phrases kept, as phrases may be built at runtime
+const maxCachedTemplates = 4096
+
+// templateCache holds the compiled phrases, evicting the least recently used once full so that phrases built
+// at runtime, such as handoffs naming a frequency, do not crowd out the phrase files
+var templateCache = struct {
+	sync.Mutex
+	templates map[string]*list.Element // values are *cachedTemplate
+	order     *list.List               // most recently used first
+}{templates: make(map[string]*list.Element), order: list.New()}
 
-	return strings.Join(strings.Fields(finalOutput), " "), nil
+type cachedTemplate struct {
+	phrase string
+	tmpl   *Template
 }
 
-// executePCL determines if a block is a conditional or a direct instruction.
-func executePCL(statement string, ctx PCLContext, cache map[string]string) string {
-	statement = strings.TrimSpace(statement)
-	tokens := tokenizePCL(statement)
-	if len(tokens) == 0 {
-		return ""
+// Compile returns the parsed template of the phrase. Templates are cached, so each phrase is parsed only once
+// while it remains in use.
+func Compile(phrase string) (*Template, error) {
+	templateCache.Lock()
+	if el, ok := templateCache.templates[phrase]; ok {
+		templateCache.order.MoveToFront(el)
+		templateCache.Unlock()
+		return el.Value.(*cachedTemplate).tmpl, nil
 	}
+	templateCache.Unlock()
 
-	// Case: Explicit {SAY `text` or $VAR}
-	if tokens[0] == "SAY" {
-		if len(tokens) > 1 {
-			return fmt.Sprintf("%v", resolveValue(tokens[1], ctx, cache))
+	tmpl, err := Parse(phrase)
+	if err != nil {
+		return nil, err
+	}
+	templateCache.Lock()
+	defer templateCache.Unlock()
+	if _, ok := templateCache.templates[phrase]; !ok {
+		templateCache.templates[phrase] = templateCache.order.PushFront(&cachedTemplate{phrase: phrase, tmpl: tmpl})
+		if templateCache.order.Len() > maxCachedTemplates {
+			oldest := templateCache.order.Back()
+			templateCache.order.Remove(oldest)
+			delete(templateCache.templates, oldest.Value.(*cachedTemplate).phrase)
 		}
-		return ""
 	}
+	return tmpl, nil
+}
 
-	// Case: Implied SAY via raw injection {$VAR} or {@MACRO}
-	if strings.HasPrefix(tokens[0], "$") || strings.HasPrefix(tokens[0], "@") {
-		return fmt.Sprintf("%v", resolveValue(tokens[0], ctx, cache))
+// ProcessPhrase is the high-level entry point for the PCL engine.
+func ProcessPhrase(input string, ctx Context) (string, error) {
+	phrase, _, err := ResolvePhrase(input, ctx)
+	return phrase, err
+}
+
+// ResolvePhrase processes the phrase as ProcessPhrase does and additionally returns the
+// tokens that were rendered into the output, in order, together with their structured values.
+// Tokens only evaluated as part of a WHEN condition, LET binding or function argument are not included.
+func ResolvePhrase(input string, ctx Context) (string, []Token, error) {
+	tmpl, err := Compile(input)
+	if err != nil {
+		return "", nil, err
 	}
+	return tmpl.Resolve(ctx)
+}
 
-	// Case: Conditional {WHEN ... SAY ... OTHERWISE ...}
-	if tokens[0] == "WHEN" {
-		sayIdx := -1
-		for i, t := range tokens {
-			if t == "SAY" {
-				sayIdx = i
-				break
-			}
-		}
-		if sayIdx == -1 {
-			return ""
-		}
+// Resolve renders the template with the providers of ctx, returning the spoken text and the rendered tokens.
+func (t *Template) Resolve(ctx Context) (string, []Token, error) {
+	var tokens []Token
+	r := &renderer{ctx: ctx, tokens: &tokens, locals: make(map[string]interface{})}
+	var out strings.Builder
+	if err := r.renderNodes(t.Nodes, &out); err != nil {
+		return "", nil, err
+	}
+	return strings.Join(strings.Fields(out.String()), " "), tokens, nil
+}
 
-		conditionMet := evaluateComplexCondition(tokens[1:sayIdx], ctx, cache)
+// recordToken appends the resolved value to tokens and returns its rendered text.
+func recordToken(tokens *[]Token, key string, args []string, val interface{}) string {
+	text := fmt.Sprintf("%v", val)
+	if tokens == nil {
+		return text
+	}
+	data := val
+	if v, ok := val.(Value); ok {
+		data = v.Data
+	}
+	*tokens = append(*tokens, Token{Key: key, Args: args, Data: data, Text: strings.TrimSpace(text)})
+	return text
+}
 
-		// Find OTHERWISE if it exists
-		otherwiseIdx := -1
-		for i := sayIdx; i < len(tokens); i++ {
-			if tokens[i] == "OTHERWISE" {
-				otherwiseIdx = i
-				break
-			}
+// randFloat picks ONEOF choices, replaceable for tests
+var randFloat = rand.Float64
+
+// renderer holds the state of a single rendering of a template
+type renderer struct {
+	ctx    Context
+	tokens *[]Token // nil while evaluating operands, which are not recorded
+	locals map[string]interface{}
+}
+
+func (r *renderer) renderNodes(nodes []Node, out *strings.Builder) error {
+	for _, n := range nodes {
+		if err := r.render(n, out); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		if conditionMet {
-			return strings.Trim(tokens[sayIdx+1], "`")
-		} else if otherwiseIdx != -1 {
-			nextPart := tokens[otherwiseIdx+1]
-			// Handle nested OTHERWISE {WHEN...}
-			if strings.HasPrefix(nextPart, "{") {
-				return executePCL(nextPart[1:len(nextPart)-1], ctx, cache)
+// render writes the spoken text of a node
+func (r *renderer) render(n Node, out *strings.Builder) error {
+	switch n := n.(type) {
+	case *TextNode:
+		out.WriteString(n.Text)
+	case *VarNode:
+		if v, ok := r.locals[n.Key]; ok {
+			out.WriteString(formatValue(v))
+		} else if v, ok := r.ctx.Resolve(n.Key, n.Args); ok {
+			out.WriteString(recordToken(r.tokens, n.Key, n.Args, v))
+		} else {
+			out.WriteString(n.Raw)
+		}
+	case *StringLit:
+		return r.renderNodes(n.Template.Nodes, out)
+	case *SayNode:
+		for i, item := range n.Items {
+			if i > 0 {
+				out.WriteString(" ")
+			}
+			if err := r.render(item, out); err != nil {
+				return err
 			}
-			// Handle OTHERWISE SAY `...`
-			if nextPart == "SAY" && len(tokens) > otherwiseIdx+2 {
-				return strings.Trim(tokens[otherwiseIdx+2], "`")
+		}
+	case *WhenNode:
+		cond, err := r.eval(n.Cond)
+		if err != nil {
+			return err
+		}
+		if truthy(cond) {
+			return r.render(n.Then, out)
+		}
+		if n.Else != nil {
+			return r.render(n.Else, out)
+		}
+	case *OneOfNode:
+		return r.render(pickChoice(n.Choices), out)
+	case *LetNode:
+		for _, b := range n.Bindings {
+			v, err := r.eval(b.Value)
+			if err != nil {
+				return err
 			}
+			r.locals[b.Name] = v
 		}
+	case *DirectiveNode:
+		// markers are not spoken
+	default:
+		v, err := r.eval(n)
+		if err != nil {
+			return err
+		}
+		out.WriteString(formatValue(v))
 	}
-
-	return ""
+	return nil
 }
 
-// resolveValue pulls from the cache or invokes a provider for a specific token.
-func resolveValue(token string, ctx PCLContext, cache map[string]string) interface{} {
-	// Clean backticks
-	clean := strings.Trim(token, "`")
-
-	if strings.HasPrefix(clean, "$") || strings.HasPrefix(clean, "@") {
-		if val, ok := cache[clean]; ok {
-			return val
+// eval returns the value of an expression. Variables are resolved without being recorded as tokens.
+func (r *renderer) eval(n Node) (interface{}, error) {
+	switch n := n.(type) {
+	case *NumberLit:
+		return n.Value, nil
+	case *WordLit:
+		return n.Word, nil
+	case *StringLit:
+		var out strings.Builder
+		operand := &renderer{ctx: r.ctx, locals: r.locals}
+		if err := operand.renderNodes(n.Template.Nodes, &out); err != nil {
+			return nil, err
 		}
-
-		// Fallback regex for parameterized tokens inside WHEN blocks
-		tokenRegex := regexp.MustCompile(`([$@])([A-Z0-9_]+)(?:\((.*?)\))?`)
-		m := tokenRegex.FindStringSubmatch(clean)
-		if len(m) > 2 {
-			lookupKey := m[1] + m[2]
-			if provider, ok := ctx[lookupKey]; ok {
-				var args []string
-				if m[3] != "" {
-					args = strings.Split(m[3], ",")
-				}
-				return provider(args...)
+		return out.String(), nil
+	case *VarNode:
+		if v, ok := r.locals[n.Key]; ok {
+			return v, nil
+		}
+		if v, ok := r.ctx.Resolve(n.Key, n.Args); ok {
+			return v, nil
+		}
+		return n.Raw, nil
+	case *UnaryExpr:
+		v, err := r.eval(n.Operand)
+		if err != nil {
+			return nil, err
+		}
+		if n.Op == "NOT" {
+			return !truthy(v), nil
+		}
+		f, err := toNumber(v)
+		if err != nil {
+			return nil, &ParseError{Pos: n.Pos, Msg: err.Error()}
+		}
+		return -f, nil
+	case *BinaryExpr:
+		return r.evalBinary(n)
+	case *CallExpr:
+		args := make([]interface{}, len(n.Args))
+		for i, a := range n.Args {
+			v, err := r.eval(a)
+			if err != nil {
+				return nil, err
 			}
+			args[i] = v
+		}
+		v, err := functions[n.Func].call(args)
+		if err != nil {
+			return nil, &ParseError{Pos: n.Pos, Msg: fmt.Sprintf("%s: %v", n.Func, err)}
 		}
+		return v, nil
 	}
-	return clean
+	// statements used as values, e.g. a nested WHEN block
+	var out strings.Builder
+	operand := &renderer{ctx: r.ctx, locals: r.locals}
+	if err := operand.render(n, &out); err != nil {
+		return nil, err
+	}
+	return out.String(), nil
 }
 
-// evaluateComplexCondition handles logical AND/OR comparisons.
-func evaluateComplexCondition(tokens []string, ctx PCLContext, cache map[string]string) bool {
-	if len(tokens) == 0 {
-		return false
+func (r *renderer) evalBinary(n *BinaryExpr) (interface{}, error) {
+	left, err := r.eval(n.Left)
+	if err != nil {
+		return nil, err
 	}
-	result := false
-	currentOp := "OR"
 
-	for i := 0; i < len(tokens); i += 4 {
-		if i+2 >= len(tokens) {
-			break
+	// logical operators short-circuit
+	switch n.Op {
+	case "AND":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := r.eval(n.Right)
+		return err == nil && truthy(right), err
+	case "OR":
+		if truthy(left) {
+			return true, nil
 		}
+		right, err := r.eval(n.Right)
+		return err == nil && truthy(right), err
+	}
 
-		left := resolveValue(tokens[i], ctx, cache)
-		op := tokens[i+1]
-		right := resolveValue(tokens[i+2], ctx, cache)
+	right, err := r.eval(n.Right)
+	if err != nil {
+		return nil, err
+	}
+	if comparators[n.Op] {
+		return evaluateComparison(left, n.Op, right), nil
+	}
 
-		match := evaluateComparison(left, op, right)
+	l, err := toNumber(left)
+	if err != nil {
+		return nil, &ParseError{Pos: n.Pos, Msg: fmt.Sprintf("left operand of '%s': %v", n.Op, err)}
+	}
+	rv, err := toNumber(right)
+	if err != nil {
+		return nil, &ParseError{Pos: n.Pos, Msg: fmt.Sprintf("right operand of '%s': %v", n.Op, err)}
+	}
+	switch n.Op {
+	case "+":
+		return l + rv, nil
+	case "-":
+		return l - rv, nil
+	case "*":
+		return l * rv, nil
+	}
+	if rv == 0 {
+		return nil, &ParseError{Pos: n.Pos, Msg: fmt.Sprintf("division by zero in '%s'", n.Op)}
+	}
+	if n.Op == "%" {
+		return math.Mod(l, rv), nil
+	}
+	return l / rv, nil
+}
 
-		if currentOp == "AND" {
-			result = result && match
-		} else {
-			result = result || match
+// pickChoice selects a ONEOF choice at random in proportion to the weights
+func pickChoice(choices []Choice) *SayNode {
+	total := 0.0
+	for _, c := range choices {
+		total += c.Weight
+	}
+	pick := randFloat() * total
+	for _, c := range choices {
+		if pick < c.Weight {
+			return c.Say
 		}
+		pick -= c.Weight
+	}
+	return choices[len(choices)-1].Say
+}
 
-		if i+3 < len(tokens) {
-			currentOp = tokens[i+3]
-		}
+// toNumber converts a value to a number, as comparisons do
+func toNumber(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
 	}
-	return result
+	s := strings.TrimSpace(fmt.Sprintf("%v", v))
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", s)
+	}
+	return f, nil
+}
+
+// formatValue renders a value as text, without exponents or trailing zeros for numbers
+func formatValue(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// truthy returns the truth of a condition value: booleans as they are, numbers when not zero and text when
+// not empty, "false" or "0"
+func truthy(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case nil:
+		return false
+	}
+	if f, err := toNumber(v); err == nil {
+		return f != 0
+	}
+	s := strings.TrimSpace(fmt.Sprintf("%v", v))
+	return s != "" && s != "false"
 }
 
 func evaluateComparison(left interface{}, op string, right interface{}) bool {
@@ -219,43 +422,3 @@ func evaluateComparison(left interface{}, op string, right interface{}) bool {
 		return lStr == rStr
 	}
 }
-
-// tokenizePCL splits the statement while respecting backticks and nested braces.
-func tokenizePCL(s string) []string {
-	var tokens []string
-	var current strings.Builder
-	inQuotes := false
-	depth := 0
-	for i := 0; i < len(s); i++ {
-		char := s[i]
-
-		// Use backticks for quoting to allow apostrophes like don't
-		if char == '`' {
-			inQuotes = !inQuotes
-			current.WriteByte(char)
-			continue
-		}
-
-		if !inQuotes {
-			if char == '{' {
-				depth++
-			}
-			if char == '}' {
-				depth--
-			}
-		}
-
-		if char == ' ' && !inQuotes && depth == 0 {
-			if current.Len() > 0 {
-				tokens = append(tokens, current.String())
-				current.Reset()
-			}
-		} else {
-			current.WriteByte(char)
-		}
-	}
-	if current.Len() > 0 {
-		tokens = append(tokens, current.String())
-	}
-	return tokens
-}
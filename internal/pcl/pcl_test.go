@@ -165,3 +165,181 @@ func TestPCL_Comparators(t *testing.T) {
 		})
 	}
 }
+
+func TestResolvePhraseTokens(t *testing.T) {
+	ctx := PCLContext{
+		"$CALLSIGN": func(args ...string) interface{} { return "speedbird 123" },
+		"$SPEED":    func(args ...string) interface{} { return 260 },
+		"@RUNWAY": func(args ...string) interface{} {
+			return Value{Data: "27L", Text: "27 left"}
+		},
+		"@VALEDICTION": func(args ...string) interface{} { return "good day" },
+	}
+
+	tests := []struct {
+		name     string
+		phrase   string
+		expected string
+		keys     []string
+		data     []interface{}
+	}{
+		{
+			"Structured value renders text and exposes data",
+			"{$CALLSIGN} runway {@RUNWAY}",
+			"speedbird 123 runway 27 left",
+			[]string{"$CALLSIGN", "@RUNWAY"},
+			[]interface{}{"speedbird 123", "27L"},
+		},
+		{
+			"Condition operands are not recorded",
+			"{WHEN $SPEED GT 250 SAY `reduce speed`} @RUNWAY",
+			"reduce speed 27 left",
+			[]string{"@RUNWAY"},
+			[]interface{}{"27L"},
+		},
+		{
+			"Explicit SAY with macro arguments",
+			"{SAY @VALEDICTION(5)}",
+			"good day",
+			[]string{"@VALEDICTION"},
+			[]interface{}{"good day"},
+		},
+		{
+			"Unknown tokens are left untouched",
+			"{$UNKNOWN} $CALLSIGN",
+			"$UNKNOWN speedbird 123",
+			[]string{"$CALLSIGN"},
+			[]interface{}{"speedbird 123"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, tokens, err := ResolvePhrase(tc.phrase, ctx)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res != tc.expected {
+				t.Errorf("Result mismatch.\nGot:  %q\nWant: %q", res, tc.expected)
+			}
+			if len(tokens) != len(tc.keys) {
+				t.Fatalf("got %d tokens %+v; want %d", len(tokens), tokens, len(tc.keys))
+			}
+			for i, tok := range tokens {
+				if tok.Key != tc.keys[i] || tok.Data != tc.data[i] {
+					t.Errorf("token %d = %s:%v; want %s:%v", i, tok.Key, tok.Data, tc.keys[i], tc.data[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPCLExtensions(t *testing.T) {
+	ctx := PCLContext{
+		"$ALTITUDE":    func(args ...string) interface{} { return 4350.0 },
+		"$FA_ALTITUDE": func(args ...string) interface{} { return 3000 },
+		"$SPEED":       func(args ...string) interface{} { return 260 },
+		"$LVP":         func(args ...string) interface{} { return false },
+		"$CALLSIGN":    func(args ...string) interface{} { return "speedbird 123" },
+		"$STAND":       func(args ...string) interface{} { return "b32" },
+	}
+
+	tests := []struct {
+		name     string
+		phrase   string
+		expected string
+	}{
+		{"arithmetic in comparison", "{WHEN $ALTITUDE - $FA_ALTITUDE GT 1000 SAY `high` OTHERWISE SAY `ok`}", "high"},
+		{"multiplication before addition", "{SAY (2 + 3 * 4)}", "14"},
+		{"parentheses group", "{SAY (2 + 3) * 4}", "20"},
+		{"AND binds tighter than OR", "{WHEN $SPEED GT 300 AND $LVP OR $SPEED GT 250 SAY `slow` OTHERWISE SAY `fine`}", "slow"},
+		{"parentheses around logic", "{WHEN $SPEED GT 300 AND ($LVP OR $SPEED GT 250) SAY `slow` OTHERWISE SAY `fine`}", "fine"},
+		{"NOT", "{WHEN NOT $LVP SAY `normal ops`}", "normal ops"},
+		{"LET binding", "{LET $ABOVE = $ALTITUDE - $FA_ALTITUDE}{WHEN $ABOVE GT 1000 SAY `$ABOVE feet high`}", "1350 feet high"},
+		{"ROUND to step", "passing {SAY ROUND($ALTITUDE, 100)}", "passing 4400"},
+		{"UPPER", "{SAY UPPER($CALLSIGN)}", "SPEEDBIRD 123"},
+		{"SPELL", "stand {SPELL($STAND)}", "stand bravo 3 2"},
+		{"hyphenated words", "{SAY follow-up} {WHEN $LVP SAY `x` OTHERWISE SAY climb-out}", "follow-up climb-out"},
+		{"numbers spoken as written", "{SAY runway 09} {SAY 1.50}", "runway 09 1.50"},
+		{"comma in SAY", "{SAY hello, world}", "hello, world"},
+		{"text around an expression", "{SAY about ROUND($ALTITUDE, 100) feet}", "about 4400 feet"},
+		{"single ONEOF choice", "{ONEOF `cleared to land`} $CALLSIGN", "cleared to land speedbird 123"},
+		{"directive is silent", "{$CALLSIGN} go ahead{NOREADBACK}", "speedbird 123 go ahead"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := ProcessPhrase(tc.phrase, ctx)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res != tc.expected {
+				t.Errorf("Result mismatch.\nGot:  %q\nWant: %q", res, tc.expected)
+			}
+		})
+	}
+
+	if _, err := ProcessPhrase("{SAY $CALLSIGN + 1}", ctx); err == nil {
+		t.Errorf("expected error for arithmetic on text")
+	}
+}
+
+func TestPCLOneOfWeights(t *testing.T) {
+	defer func(f func() float64) { randFloat = f }(randFloat)
+
+	phrase := "{ONEOF `a` | `b`:2 | `c`}"
+	tests := []struct {
+		pick     float64
+		expected string
+	}{
+		{0.0, "a"},
+		{0.24, "a"},
+		{0.26, "b"},
+		{0.74, "b"},
+		{0.76, "c"},
+	}
+	for _, tt := range tests {
+		randFloat = func() float64 { return tt.pick }
+		res, err := ProcessPhrase(phrase, PCLContext{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if res != tt.expected {
+			t.Errorf("ONEOF with pick %.2f = %q; want %q", tt.pick, res, tt.expected)
+		}
+	}
+}
+
+func TestCompileCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	static, err := Compile("{$CALLSIGN}, contact tower.")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	first := "contact London on 0"
+	if _, err := Compile(first); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// phrases built at runtime fill the cache while the static phrase stays in use
+	for i := 1; i <= maxCachedTemplates; i++ {
+		if _, err := Compile(fmt.Sprintf("contact London on %d", i)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if i%100 == 0 {
+			if tmpl, _ := Compile("{$CALLSIGN}, contact tower."); tmpl != static {
+				t.Fatalf("static phrase parsed again after %d runtime phrases", i)
+			}
+		}
+	}
+
+	templateCache.Lock()
+	defer templateCache.Unlock()
+	if n := templateCache.order.Len(); n != maxCachedTemplates || len(templateCache.templates) != n {
+		t.Errorf("cache holds %d templates; want %d", n, maxCachedTemplates)
+	}
+	if _, ok := templateCache.templates[first]; ok {
+		t.Error("least recently used phrase not evicted")
+	}
+	if _, ok := templateCache.templates[fmt.Sprintf("contact London on %d", maxCachedTemplates)]; !ok {
+		t.Error("newest phrase not cached")
+	}
+}
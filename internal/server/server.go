@@ -24,14 +24,31 @@ type RadarBlip struct {
 	GroundSpeed  float64 `json:"gs"`
 }
 
+// RadarHold is a hold in use with the outline of its racetrack as lat/lon points
+type RadarHold struct {
+	atc.Hold
+	Racetrack [][2]float64 `json:"racetrack"`
+}
+
+// RadarClosure is a runway, taxiway or stand closed by a NOTAM, with the lat/lon points of the closed surface
+type RadarClosure struct {
+	Airport string       `json:"airport"`
+	Kind    string       `json:"kind"` // runway, taxiway or stand
+	Name    string       `json:"name"`
+	Reason  string       `json:"reason"`
+	Points  [][2]float64 `json:"points"`
+}
+
 // RadarSnapshot is the frame package sent on every tick
 type RadarSnapshot struct {
 	CenterLat float64      `json:"center_lat"` // The coordinate the scope should center on
 	CenterLng float64      `json:"center_lng"`
 	Timestamp time.Time    `json:"timestamp"`
 	Aircraft  []RadarBlip  `json:"aircraft"`
-	Holds     []atc.Hold   `json:"holds"`
+	Holds     []RadarHold  `json:"holds"`
 	Runways	  []atc.Runway `json:"runways"`
+	Metars    []string     `json:"metars"` // METARs of the airports with an active runway configuration
+	Closures  []RadarClosure `json:"closures"` // NOTAM closures in force at the loaded airports
 }
 
 type RadarServer struct {
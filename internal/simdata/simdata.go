@@ -26,6 +26,16 @@ const (
 	DRSimWeatherRegionShearSpeed         = "sim/weather/region/shear_speed_msc"
 	DRSimWeatherRegionWindSpeed          = "sim/weather/region/wind_speed_msc"
 	DRSimWeatherRegionWindDirection      = "sim/weather/region/wind_direction_degt"
+	DRSimWeatherRegionWindAltitude       = "sim/weather/region/wind_altitude_msl_m"
+	DRSimWeatherRegionTemperaturesAloft  = "sim/weather/region/temperatures_aloft_deg_c"
+	DRSimWeatherRegionDewpoint           = "sim/weather/region/dewpoint_deg_c"
+	DRSimWeatherRegionSeaLevelTemp       = "sim/weather/region/sealevel_temperature_c"
+	DRSimWeatherSeaLevelDewpoint         = "sim/weather/dewpoi_sealevel_c"
+	DRSimWeatherRegionVisibility         = "sim/weather/region/visibility_reported_sm"
+	DRSimWeatherRegionCloudBase          = "sim/weather/region/cloud_base_msl_m"
+	DRSimWeatherRegionCloudCoverage      = "sim/weather/region/cloud_coverage_percent"
+	DRSimWeatherRegionCloudTops          = "sim/weather/region/cloud_tops_msl_m"
+	DRSimWeatherRegionRainPercent        = "sim/weather/region/rain_percent"
 
 	DRSimFlightmodelPositionLatitude  = "sim/flightmodel/position/latitude"
 	DRSimFlightmodelPositionLongitude = "sim/flightmodel/position/longitude"
@@ -87,6 +97,26 @@ var SubscribeDatarefs = []xpapimodel.Dataref{
 		APIInfo: xpapimodel.DatarefInfo{}, Value: nil, DecodedDataType: "float_array"},
 	{Name: DRSimWeatherRegionWindDirection,
 		APIInfo: xpapimodel.DatarefInfo{}, Value: nil, DecodedDataType: "float_array"},
+	{Name: DRSimWeatherRegionWindAltitude, // one entry per wind layer, the other layered datarefs share its indices
+		APIInfo: xpapimodel.DatarefInfo{}, Value: nil, DecodedDataType: "float_array"},
+	{Name: DRSimWeatherRegionTemperaturesAloft,
+		APIInfo: xpapimodel.DatarefInfo{}, Value: nil, DecodedDataType: "float_array"},
+	{Name: DRSimWeatherRegionDewpoint,
+		APIInfo: xpapimodel.DatarefInfo{}, Value: nil, DecodedDataType: "float_array"},
+	{Name: DRSimWeatherRegionSeaLevelTemp, // surface temperature, the layered temperatures are aloft
+		APIInfo: xpapimodel.DatarefInfo{}},
+	{Name: DRSimWeatherSeaLevelDewpoint,
+		APIInfo: xpapimodel.DatarefInfo{}},
+	{Name: DRSimWeatherRegionVisibility,
+		APIInfo: xpapimodel.DatarefInfo{}},
+	{Name: DRSimWeatherRegionCloudBase, // one entry per cloud layer
+		APIInfo: xpapimodel.DatarefInfo{}, Value: nil, DecodedDataType: "float_array"},
+	{Name: DRSimWeatherRegionCloudCoverage,
+		APIInfo: xpapimodel.DatarefInfo{}, Value: nil, DecodedDataType: "float_array"},
+	{Name: DRSimWeatherRegionCloudTops,
+		APIInfo: xpapimodel.DatarefInfo{}, Value: nil, DecodedDataType: "float_array"},
+	{Name: DRSimWeatherRegionRainPercent, // ratio 0.0-1.0
+		APIInfo: xpapimodel.DatarefInfo{}},
 
 	//user position datarefs
 	{Name: DRSimFlightmodelPositionLatitude,
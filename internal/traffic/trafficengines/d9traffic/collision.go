@@ -122,8 +122,8 @@ func (e *D9TrafficEngine) turnDirectionWouldIntrude(ac *atc.Aircraft, direction
 	if ac == nil || ac.Flight.Schedule == nil {
 		return false
 	}
-	originAp := e.AtcService.Airports[ac.Flight.Schedule.IcaoOrigin]
-	destAp := e.AtcService.Airports[ac.Flight.Schedule.IcaoDest]
+	originAp := e.AtcService.GetAirports()[ac.Flight.Schedule.IcaoOrigin]
+	destAp := e.AtcService.GetAirports()[ac.Flight.Schedule.IcaoDest]
 	if originAp == nil && destAp == nil {
 		return false
 	}
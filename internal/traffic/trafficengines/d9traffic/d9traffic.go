@@ -32,11 +32,16 @@ type D9TrafficEngine struct {
 	AirportConfig    map[string]ActiveRunwaySet
 	RunwayLocks      map[string]*RunwayLock
 	RunwayQueues     map[string]map[string]time.Time
+	RunwayReleases   map[string]time.Time // time each runway lock was last released
+
+	emergencyIntervalMins int
+	emergencyTypes        []atc.EmergencyType
 }
 
 type D9TrafficConfig struct {
 	D9Traffic struct {
-		FlightPlanPath string `yaml:"flight_plan_directory"`
+		FlightPlanPath string          `yaml:"flight_plan_directory"`
+		Emergencies    EmergencyConfig `yaml:"emergencies"`
 	} `yaml:"d9traffic"`
 }
 
@@ -101,6 +106,30 @@ const (
 	// maximum number of aircraft allowed on approach for a single airport before
 	// new arrivals are sent to hold
 	MAX_APPROACH_ON_APPROACH = 3
+
+	// aircraft unable to land due to weather divert to an alternate after holding for this long or after
+	// this many go-arounds
+	WEATHER_HOLD_DIVERT_MINS = 20
+	WEATHER_MAX_GO_AROUNDS   = 2
+
+	// low visibility procedures: reduced arrival rate, increased departure delays, minimum time between a
+	// runway being vacated and the next departure, minimum time between aircraft starting to taxi and the
+	// wind limits for changing the flow to a CAT II/III runway
+	LVP_MAX_APPROACH_ON_APPROACH  = 1
+	LVP_DELAY_FACTOR              = 2
+	LVP_RUNWAY_SEPARATION_SECONDS = 120
+	LVP_TAXI_SPACING_SECONDS      = 90
+	LVP_MAX_TAILWIND_KTS          = 5.0
+	LVP_MAX_CROSSWIND_KTS         = 20.0
+
+	// lowest cruise ground speed as a fraction of the cruise airspeed, whatever the winds aloft
+	MIN_CRUISE_GROUND_SPEED_RATIO = 0.5
+
+	// holding patterns are flown with rate one turns, tripling the drift correction on the outbound leg up to
+	// a maximum, and intercepting the inbound course at up to the maximum intercept angle
+	HOLD_TURN_RATE_DEG_SEC      = 3.0
+	HOLD_MAX_OUTBOUND_DRIFT_DEG = 30.0
+	HOLD_MAX_INTERCEPT_DEG      = 45.0
 )
 
 func New(cfgPath string) (atc.TrafficEngine, error) {
@@ -110,6 +139,12 @@ func New(cfgPath string) (atc.TrafficEngine, error) {
 		return nil, err
 	}
 
+	emergencyTypes, err := parseEmergencyTypes(cfg.D9Traffic.Emergencies.Types)
+	if err != nil {
+		logger.Log.Errorf("Error reading emergency configuration: %v", err)
+		return nil, err
+	}
+
 	return &D9TrafficEngine{
 		FlightPlanPath:  cfg.D9Traffic.FlightPlanPath,
 		ActiveAircraft:  make(map[string]*atc.Aircraft),
@@ -117,6 +152,10 @@ func New(cfgPath string) (atc.TrafficEngine, error) {
 		AirportConfig:   make(map[string]ActiveRunwaySet),
 		RunwayLocks:     make(map[string]*RunwayLock), // Key is a unique Runway ID (e.g., "EGLL-09L-27R")
 		RunwayQueues:    make(map[string]map[string]time.Time),
+		RunwayReleases:  make(map[string]time.Time),
+
+		emergencyIntervalMins: cfg.D9Traffic.Emergencies.MeanIntervalMins,
+		emergencyTypes:        emergencyTypes,
 	}, nil
 }
 
@@ -158,19 +197,26 @@ func (e *D9TrafficEngine) Start() {
 			if currentMin != lastSpawnMin {
 				for _, icao := range relevantICAOs {
 					ap := e.AtcService.GetAirportByICAO(icao)
-					if ap == nil {
-						continue
+					if ap == nil || ap.Stub {
+						continue // stubs have no procedures or parking until loaded
 					}
 
-					if e.needsRunwayRefresh(ap) {
+					lvpChanged := e.AtcService.UpdateLowVisibility(ap)
+					if lvpChanged || e.needsRunwayRefresh(ap) {
 						e.refreshRunwayConfig(ap)
 					}
+					if !ap.IsOpen(currSimZTime) {
+						continue // outside the opening hours of the overrides file
+					}
 					e.checkForDepartureSpawns(icao, day, hour, currentMin)
 					e.checkForArrivalSpawns(icao, day, hour, currentMin)
 				}
+				e.checkForEmergency()
 				lastSpawnMin = currentMin
 			}
 
+			e.AtcService.SetFlightAirports(e.flightAirports())
+
 			// --- 2. FAST CYCLE (Every 10 Seconds) ---
 			// Existing aircraft MUST move frequently to avoid "stepping" or "teleporting"
 			e.updateActiveAircraft(relevantICAOs)
@@ -193,7 +239,17 @@ func (e *D9TrafficEngine) needsRunwayRefresh(ap *atc.Airport) bool {
 		return true
 	} // Initial load
 
-	currentWeather := e.AtcService.GetWeatherState()
+	// the airport has been loaded or evicted since the runways were chosen
+	if ap.Runways[config.Departure.Name] != config.Departure || ap.Runways[config.Arrival.Name] != config.Arrival {
+		return true
+	}
+
+	// a NOTAM has closed a runway in use
+	if e.AtcService.IsRunwayClosed(ap.ICAO, config.Departure.Name) || e.AtcService.IsRunwayClosed(ap.ICAO, config.Arrival.Name) {
+		return true
+	}
+
+	currentWeather := e.AtcService.GetAirportWeather(ap.ICAO)
 
 	// Check if wind shifted by more than configured degrees
 	// OR wind speed changed by more than configured knots
@@ -203,6 +259,16 @@ func (e *D9TrafficEngine) needsRunwayRefresh(ap *atc.Airport) bool {
 	return dirDelta > constants.WindDirShiftDeg || speedDelta > constants.WindSpeedDeltaKts
 }
 
+// flightAirports returns the origins and destinations of the active flights, which the ATC service keeps loaded
+func (e *D9TrafficEngine) flightAirports() map[string]bool {
+	icaos := make(map[string]bool)
+	for _, ac := range e.ActiveAircraft {
+		icaos[ac.Flight.Schedule.IcaoOrigin] = true
+		icaos[ac.Flight.Schedule.IcaoDest] = true
+	}
+	return icaos
+}
+
 func (e *D9TrafficEngine) RequiresAircraftData() bool {
 	return false
 }
@@ -381,8 +447,8 @@ func (e *D9TrafficEngine) spawnDepartureTraffic(f *flightplan.ScheduledFlight) {
 	}
 	ip := initialPhase.Index()
 
-	airport := e.AtcService.Airports[f.IcaoOrigin]
-	destApt := e.AtcService.Airports[f.IcaoDest]
+	airport := e.AtcService.GetAirports()[f.IcaoOrigin]
+	destApt := e.AtcService.GetAirports()[f.IcaoDest]
 	currSimZTime := e.AtcService.GetCurrentZuluTime()
 
 	airline := e.resolveAirline(f)
@@ -526,7 +592,7 @@ func (e *D9TrafficEngine) spawnDepartureTraffic(f *flightplan.ScheduledFlight) {
 		// If Cruise, flip to destination (arrival) runway BEFORE initializing
 		if ip == flightphase.Cruise.Index() {
 			rwy := e.getActiveRunway(f.IcaoDest, atc.ARRIVAL_CONTEXT)
-			destApt := e.AtcService.Airports[f.IcaoDest]
+			destApt := e.AtcService.GetAirports()[f.IcaoDest]
 			newAc.Flight.AssignedRunway = rwy
 			newAc.Flight.AssignedRunwayName = rwy.Name
 			// assign destination procedure
@@ -562,8 +628,8 @@ func (e *D9TrafficEngine) spawnArrivalTraffic(f *flightplan.ScheduledFlight) {
 		return
 	}
 
-	airport := e.AtcService.Airports[f.IcaoDest]
-	originAp := e.AtcService.Airports[f.IcaoOrigin]
+	airport := e.AtcService.GetAirports()[f.IcaoDest]
+	originAp := e.AtcService.GetAirports()[f.IcaoOrigin]
 
 	// 1. KINEMATIC SETUP
 	bearing := geometry.CalculateBearing(originAp.Lat, originAp.Lon, airport.Lat, airport.Lon)
@@ -739,7 +805,7 @@ func (e *D9TrafficEngine) getActiveRunway(icao string, arrOrDep int) *atc.Runway
 	}
 
 	// 2. Fallback: Get the first available runway from the global airport data
-	if apt, found := e.AtcService.Airports[icao]; found && len(apt.Runways) > 0 {
+	if apt, found := e.AtcService.GetAirports()[icao]; found && len(apt.Runways) > 0 {
 		// Just pick the first one available as a coordinate anchor
 		for _, r := range apt.Runways {
 			return r
@@ -927,7 +993,7 @@ func (e *D9TrafficEngine) updateActiveAircraft(relevantICAOs []string) {
 		} else {
 			targetICAO = f.IcaoOrigin
 		}
-		airport = e.AtcService.Airports[targetICAO]
+		airport = e.AtcService.GetAirports()[targetICAO]
 
 		// --- D9 Collision Avoidance: pre-movement detection & maneuver advancement ---
 		// If aircraft already executing a maneuver, advance it and skip other updates for this tick.
@@ -991,6 +1057,10 @@ func (e *D9TrafficEngine) updateActiveAircraft(relevantICAOs []string) {
 		case flightphase.Startup:
 			// Check for TaxiOut transition
 			if currSimZTime.After(ac.Flight.Phase.EstimatedNextTransition) {
+				if !e.isTaxiSpacingClear(airport, ac) {
+					util.LogWithLabel(ac.Registration, "low visibility taxi spacing in force at %s - remaining in Startup phase", airport.ICAO)
+					continue
+				}
 				ac.Flight.AssignedRunway = e.AirportConfig[airport.ICAO].Departure
 				ac.Flight.AssignedRunwayName = ac.Flight.AssignedRunway.Name
 				e.AtcService.AssignSID(ac, airport, ac.Flight.AssignedRunway)
@@ -1057,6 +1127,14 @@ func (e *D9TrafficEngine) updateActiveAircraft(relevantICAOs []string) {
 			}
 
 		case flightphase.Cruise:
+			// assign the STAR again if the destination was a stub when it was assigned and has since been loaded
+			if ac.Flight.STARFromStub && airport != nil && !airport.Stub {
+				if rwy := airport.Runways[ac.Flight.AssignedRunwayName]; rwy != nil {
+					ac.Flight.AssignedRunway = rwy
+					e.AtcService.AssignSTAR(ac, airport, rwy)
+				}
+			}
+
 			// 1. Run the cruise kinematics step.
 			// If the aircraft breaches the TOD window or the target boundary,
 			// updateCruisePosition will set ac.Flight.Phase.Current = flightphase.Arrival.Index()
@@ -1092,8 +1170,15 @@ func (e *D9TrafficEngine) updateActiveAircraft(relevantICAOs []string) {
 				ac.Flight.AssignedRunwayName = ac.Flight.AssignedRunway.Name
 
 				// Check for arrival saturation conditions
+				// an emergency aircraft is never held, other arrivals hold while an emergency is inbound or
+				// while the weather at the runway is outside the aircraft's limits
 				approachCount, holdingCount, _ := e.getArrivalSaturationStats(ac, airport)
-				if approachCount > MAX_APPROACH_ON_APPROACH || holdingCount > 0 {
+				ac.Flight.WeatherReason = ""
+				if ac.Flight.Emergency == nil {
+					ac.Flight.WeatherReason = e.checkRunwayWeather(airport, ac.Flight.AssignedRunway, ac.SizeClass)
+				}
+				if ac.Flight.Emergency == nil && (approachCount > getMaxApproachCount(airport) || holdingCount > 0 ||
+					e.hasInboundEmergency(airport.ICAO, ac) || ac.Flight.WeatherReason != "") {
 					// Send to hold due to traffic management constraints
 					e.sendToHold(ac, airport)
 				} else {
@@ -1112,6 +1197,11 @@ func (e *D9TrafficEngine) updateActiveAircraft(relevantICAOs []string) {
 				ac.Flight.Phase.PositionComplete = true
 				e.transitionToPhase(ac, flightphase.Approach, 0, 0)
 				e.updateLinearPosition(ac, airport)
+			} else if ac.Flight.WeatherReason != "" &&
+				currSimZTime.Sub(ac.Flight.Phase.Transition) > WEATHER_HOLD_DIVERT_MINS*time.Minute &&
+				e.divertForWeather(ac) {
+				// held for weather for too long, now diverting to an alternate
+				util.LogWithLabel(ac.Registration, "left hold at %s to divert to %s", airport.ICAO, ac.Flight.Destination)
 			} else {
 				// Normal operational path
 				// assign active runway
@@ -1130,7 +1220,18 @@ func (e *D9TrafficEngine) updateActiveAircraft(relevantICAOs []string) {
 		case flightphase.Final:
 			// Position-driven Braking transition
 			if ac.Flight.Phase.PositionComplete {
-				if !e.getRunwayLock(airport, ac.Flight.AssignedRunway, ac) {
+				ac.Flight.WeatherReason = ""
+				if ac.Flight.Emergency == nil {
+					ac.Flight.WeatherReason = e.checkRunwayWeather(airport, ac.Flight.AssignedRunway, ac.SizeClass)
+				}
+				if ac.Flight.WeatherReason != "" {
+					// go-around due to weather
+					ac.Flight.WeatherGoArounds++
+					util.LogWithLabel(ac.Registration, "on final: %s at runway %s at %s - initiating go-around",
+						ac.Flight.WeatherReason, ac.Flight.AssignedRunwayName, airport.ICAO)
+					e.transitionToPhase(ac, flightphase.GoAround, 0, 0)
+					e.updateGoAroundPosition(ac, airport)
+				} else if !e.getRunwayLock(airport, ac.Flight.AssignedRunway, ac) {
 					// go-around
 					util.LogWithLabel(ac.Registration, "on final: active arrival runway %s is occupied at %s - initiating go-around",
 						ac.Flight.AssignedRunwayName, airport.ICAO)
@@ -1150,8 +1251,17 @@ func (e *D9TrafficEngine) updateActiveAircraft(relevantICAOs []string) {
 
 		case flightphase.GoAround:
 			if ac.Flight.Phase.PositionComplete {
-				e.transitionToPhase(ac, flightphase.Approach, 0, 0)
-				e.updateLinearPosition(ac, airport)
+				switch {
+				case ac.Flight.WeatherReason != "" && ac.Flight.WeatherGoArounds >= WEATHER_MAX_GO_AROUNDS && e.divertForWeather(ac):
+					util.LogWithLabel(ac.Registration, "diverting to %s after %d go-arounds at %s", ac.Flight.Destination,
+						WEATHER_MAX_GO_AROUNDS, airport.ICAO)
+				case ac.Flight.WeatherReason != "":
+					// hold until the weather improves
+					e.sendToHold(ac, airport)
+				default:
+					e.transitionToPhase(ac, flightphase.Approach, 0, 0)
+					e.updateLinearPosition(ac, airport)
+				}
 			} else {
 				e.updateGoAroundPosition(ac, airport)
 			}
@@ -1453,7 +1563,7 @@ func (e *D9TrafficEngine) updateLinearPosition(ac *atc.Aircraft, ctxAp *atc.Airp
 				targetPos = atc.Position{Lat: targetLat, Long: targetLon}
 			}
 		} else {
-			originAp := e.AtcService.Airports[ac.Flight.Origin]
+			originAp := e.AtcService.GetAirports()[ac.Flight.Origin]
 			routeBearing := geometry.CalculateBearing(originAp.Lat, originAp.Lon, ctxAp.Lat, ctxAp.Lon)
 			reverseRouteBearing := geometry.NormalizeHeading(routeBearing + 180.0)
 
@@ -1717,9 +1827,9 @@ func getLastUpdateDeltaTimeSec(ac *atc.Aircraft, currSimZTime time.Time) float64
 // otherwise the destination airport is returned. The context, arrival or departure, is also returned.
 func (e *D9TrafficEngine) getActiveAirport(ac *atc.Aircraft) (*atc.Airport, int) {
 	if ac.Flight.Phase.Class >= flightclass.Cruising {
-		return e.AtcService.Airports[ac.Flight.Schedule.IcaoDest], atc.ARRIVAL_CONTEXT
+		return e.AtcService.GetAirports()[ac.Flight.Schedule.IcaoDest], atc.ARRIVAL_CONTEXT
 	}
-	return e.AtcService.Airports[ac.Flight.Schedule.IcaoOrigin], atc.DEPARTURE_CONTEXT
+	return e.AtcService.GetAirports()[ac.Flight.Schedule.IcaoOrigin], atc.DEPARTURE_CONTEXT
 }
 
 func (e *D9TrafficEngine) updateTaxiPosition(ac *atc.Aircraft, airport *atc.Airport, isOutbound bool) {
@@ -1894,6 +2004,7 @@ func (e *D9TrafficEngine) updateHoldingPosition(ac *atc.Aircraft, rwy *atc.Runwa
 		ac.Flight.Holding.ArrivedAtHoldFix = true
 		ac.Flight.Holding.PatternEntryTime = now
 		util.LogDebugWithLabel(ac.Registration, "arrived at hold fix %s", ac.Flight.Holding.AssignedHold.Ident)
+		joinHoldPattern(ac, e.getHoldPattern(ac, rwy))
 	}
 
 	// --- 4. EXIT PHASE LOGIC ---
@@ -1930,57 +2041,9 @@ func (e *D9TrafficEngine) updateHoldingPosition(ac *atc.Aircraft, rwy *atc.Runwa
 	}
 
 	// --- 5. RACETRACK MOVEMENT ---
-	elapsed := now.Sub(ac.Flight.Holding.PatternEntryTime).Seconds()
-
-	var inboundCourse float64
-	if ac.Flight.AssignedRunway != nil {
-		inboundCourse = geometry.CalculateBearing(hold.Lat, hold.Lon, ac.Flight.AssignedRunway.Lat, ac.Flight.AssignedRunway.Lon)
-	} else if rwy != nil {
-		inboundCourse = geometry.CalculateBearing(hold.Lat, hold.Lon, rwy.Lat, rwy.Lon)
-	} else {
-		inboundCourse = geometry.CalculateBearing(hold.Lat, hold.Lon, ac.Flight.Position.Lat, ac.Flight.Position.Long)
-	}
-	inboundCourse = geometry.NormalizeHeading(inboundCourse)
-	outboundCourse := geometry.NormalizeHeading(inboundCourse + 180.0)
-
-	holdSpeed := e.getPhaseGroundSpeedKts(ac.SizeClass, flightphase.Approach)
-	ac.Flight.GroundSpeed = holdSpeed
-
-	legMinutes := 1.0
-	legSeconds := legMinutes * 60.0
-	turnSeconds := 60.0 // 180 degrees at a standard rate 3 deg/sec takes exactly 60 seconds
-	cycleTime := (2 * legSeconds) + (2 * turnSeconds)
-
-	t := math.Mod(elapsed, cycleTime)
-	var targetHeading float64
-
-	// Use time-slicing purely to determine the instantaneous TARGET track heading
-	if t < legSeconds {
-		// Outbound straight leg
-		targetHeading = outboundCourse
-	} else if t < legSeconds+turnSeconds {
-		// Outbound turn to inbound course (Standard Right-Hand Turn)
-		tt := (t - legSeconds) / turnSeconds
-		targetHeading = geometry.NormalizeHeading(outboundCourse + (180.0 * tt))
-	} else if t < (legSeconds + turnSeconds + legSeconds) {
-		// Inbound straight leg
-		targetHeading = inboundCourse
-	} else {
-		// Inbound turn back to outbound course (Standard Right-Hand Turn back to fix)
-		tt := (t - (legSeconds + turnSeconds + legSeconds)) / turnSeconds
-		targetHeading = geometry.NormalizeHeading(inboundCourse + (180.0 * tt))
-	}
-
-	// 1. Update the aircraft's actual current heading smoothly toward the target heading
-	applySmoothTurnHeading(ac, targetHeading, 3.0, deltaTimeSeconds)
-
-	// 2. Project spatial position incrementally from CURRENT position along ACTUAL current heading
-	distanceMovedThisTick := (holdSpeed / 3600.0) * deltaTimeSeconds
-	posLat, posLon := geometry.Project(ac.Flight.Position.Lat, ac.Flight.Position.Long, ac.Flight.Position.Heading, distanceMovedThisTick)
-
-	ac.Flight.Position.Lat = posLat
-	ac.Flight.Position.Long = posLon
-	ac.Flight.TargetHeading = targetHeading
+	// Fly the entry and then the published racetrack, correcting for the wind at the holding altitude
+	holdSpeed := e.getHoldAirspeedKts(ac, e.getPhaseGroundSpeedKts(ac.SizeClass, flightphase.Approach))
+	flyHoldPattern(ac, e.getHoldPattern(ac, rwy), hold.Lat, hold.Lon, holdSpeed, e.getWindAt(ac), deltaTimeSeconds)
 
 	// Execute steady climb/descent matching the stack layer limits while executing the racetrack curves
 	e.smoothAltitudeAdjustment(ac, ac.Flight.Holding.TargetHoldAlt, deltaTimeSeconds)
@@ -2007,6 +2070,11 @@ func (e *D9TrafficEngine) manageHoldingReleases(relevantIcaos []string) {
 			continue
 		}
 
+		// no releases until any inbound emergency has landed
+		if e.hasInboundEmergency(icao, nil) {
+			continue
+		}
+
 		for _, rwy := range airport.Runways {
 			if rwy == nil {
 				continue
@@ -2032,9 +2100,16 @@ func (e *D9TrafficEngine) manageHoldingReleases(relevantIcaos []string) {
 
 						// If the localizer/approach corridor is too busy, remain in hold
 						approachCount, _, _ := e.getArrivalSaturationStats(ac, airport)
-						if approachCount > MAX_APPROACH_ON_APPROACH {
+						if approachCount > getMaxApproachCount(airport) {
+							continue
+						}
+
+						// remain in hold until the weather at the runway is within limits
+						if reason := e.checkRunwayWeather(airport, ac.Flight.AssignedRunway, ac.SizeClass); reason != "" {
+							ac.Flight.WeatherReason = reason
 							continue
 						}
+						ac.Flight.WeatherReason = ""
 
 						candidates = append(candidates, ac)
 					}
@@ -2162,8 +2237,8 @@ func (e *D9TrafficEngine) updateCruisePosition(ac *atc.Aircraft) {
 	// Advance the frame tick marker
 	ac.Flight.Phase.LastUpdateTime = currSimZTime
 
-	originAp := e.AtcService.Airports[ac.Flight.Schedule.IcaoOrigin]
-	destAp := e.AtcService.Airports[ac.Flight.Schedule.IcaoDest]
+	originAp := e.AtcService.GetAirports()[ac.Flight.Schedule.IcaoOrigin]
+	destAp := e.AtcService.GetAirports()[ac.Flight.Schedule.IcaoDest]
 
 	var startPos atc.Position
 	startAlt := atc.GetMinSafeAltitude(float64(constants.DefaultDepartureExitCruiseEntryAltFt), originAp)
@@ -2237,7 +2312,7 @@ func (e *D9TrafficEngine) updateCruisePosition(ac *atc.Aircraft) {
 		ac.Flight.Phase.InitialAltitude = ac.Flight.Position.Altitude
 	}
 
-	speedKts := e.getPhaseGroundSpeedKts(ac.SizeClass, flightphase.Cruise)
+	speedKts := e.getCruiseGroundSpeedKts(ac)
 	ac.Flight.GroundSpeed = speedKts
 	distanceMovedThisTick := speedKts * (deltaTimeSec / 3600.0)
 
@@ -2281,6 +2356,11 @@ func (e *D9TrafficEngine) updateCruisePosition(ac *atc.Aircraft) {
 		cruiseAlt = atc.GetMinSafeAltitude(float64(constants.DefaultDepartureExitCruiseEntryAltFt), destAp)
 		util.LogErrWithLabel(ac.Registration, "cruise altitude is set to %d - too low for local terrain, resetting to %d", ac.Flight.CruiseAlt, int(cruiseAlt))
 	}
+	if snappedAlt, ok := e.getMetricCruiseAltitude(ac, cruiseAlt, originAp, destAp); ok && snappedAlt != cruiseAlt {
+		util.LogWithLabel(ac.Registration, "cruise altitude %d snapped to metric flight level at %d", int(cruiseAlt), int(snappedAlt))
+		cruiseAlt = snappedAlt
+		ac.Flight.CruiseAlt = int(snappedAlt)
+	}
 
 	altitudeToLose := cruiseAlt - targetAlt
 
@@ -2346,6 +2426,14 @@ func (e *D9TrafficEngine) updateCruisePosition(ac *atc.Aircraft) {
 
 			util.LogDebugWithLabel(ac.Registration, "Performance Cruise Climb: current alt %0.2f climbing to %0.2f (%0.0f FPM)",
 				ac.Flight.Position.Altitude, cruiseAlt, vrateClimb)
+		} else if ac.Flight.Position.Altitude > cruiseAlt && ac.Flight.Emergency != nil {
+			// above the cruise altitude following an emergency, descend at twice the normal descent rate
+			vrateDescent := 2 * e.getPhaseVerticalRateFpm(ac.SizeClass, flightphase.Arrival)
+			allowedDeltaAlt := vrateDescent * (deltaTimeSec / 60.0) // allowedDeltaAlt is negative
+			calculatedAlt = math.Max(cruiseAlt, ac.Flight.Position.Altitude+allowedDeltaAlt)
+
+			util.LogDebugWithLabel(ac.Registration, "Emergency Descent: current alt %0.2f descending to %0.2f (%0.0f FPM)",
+				ac.Flight.Position.Altitude, cruiseAlt, vrateDescent)
 		} else {
 			// We are at or above cruise altitude, lock it in
 			calculatedAlt = cruiseAlt
@@ -2424,6 +2512,26 @@ func (e *D9TrafficEngine) updateCruisePosition(ac *atc.Aircraft) {
 
 }
 
+// getMetricCruiseAltitude returns the cruise altitude snapped to the metric flight level allocated to the track
+// of the flight, if the aircraft is in airspace where altitudes are given in metres. The bool value is false
+// otherwise.
+func (e *D9TrafficEngine) getMetricCruiseAltitude(ac *atc.Aircraft, cruiseAlt float64, originAp, destAp *atc.Airport) (float64, bool) {
+	icao := ac.Flight.Destination
+	if ac.Flight.Comms.Controller != nil && ac.Flight.Comms.Controller.ICAO != "" {
+		icao = ac.Flight.Comms.Controller.ICAO
+	}
+	if !e.AtcService.UsesMetricAltitudes(icao) {
+		return cruiseAlt, false
+	}
+
+	// the direction of flight is the track between the airports, which is not affected by turns en route
+	track := ac.Flight.Position.Heading
+	if originAp != nil && destAp != nil {
+		track = geometry.CalculateBearing(originAp.Lat, originAp.Lon, destAp.Lat, destAp.Lon)
+	}
+	return atc.SnapToMetricFlightLevel(cruiseAlt, track), true
+}
+
 func (e *D9TrafficEngine) endFlight(ac *atc.Aircraft) {
 	delete(e.ActiveAircraft, getActiveAircraftKey(ac))
 	if ac.Flight.AssignedParkingSpot != nil {
@@ -2432,7 +2540,7 @@ func (e *D9TrafficEngine) endFlight(ac *atc.Aircraft) {
 }
 
 func (e *D9TrafficEngine) positionAtOriginParking(ac *atc.Aircraft) {
-	airport := e.AtcService.Airports[ac.Flight.Origin]
+	airport := e.AtcService.GetAirports()[ac.Flight.Origin]
 	if ac.Flight.AssignedParkingSpot == nil {
 		e.assignParking(ac, airport)
 		if ac.Flight.AssignedParkingSpot == nil {
@@ -2453,7 +2561,7 @@ func (e *D9TrafficEngine) positionAtOriginParking(ac *atc.Aircraft) {
 }
 
 func (e *D9TrafficEngine) positionAtDestParking(ac *atc.Aircraft) {
-	airport := e.AtcService.Airports[ac.Flight.Destination]
+	airport := e.AtcService.GetAirports()[ac.Flight.Destination]
 	if ac.Flight.AssignedParkingSpot == nil {
 		e.assignParking(ac, airport)
 		if ac.Flight.AssignedParkingSpot == nil {
@@ -2493,7 +2601,7 @@ func (e *D9TrafficEngine) determineInitialDeparturePhase(minsToSchedDep int, f *
 		if found {
 			qKey := normalizeRunwayKey(f.IcaoOrigin, flow.Departure)
 			if len(e.RunwayQueues[qKey]) >= TRAFFIC_MANAGEMENT_RUNWAY_QUEUE_THRESHOLD {
-				delay = len(e.RunwayQueues[qKey]) * TRAFFIC_MANAGEMENT_PER_AIRCRAFT_DELAY_SECONDS
+				delay = e.getQueueDelaySeconds(f.IcaoOrigin, len(e.RunwayQueues[qKey]))
 				util.LogWithLabel(f.AircraftRegistration, "initial departure delay of %d seconds applied based on current traffic queue of %d for runway %s at %s",
 					delay, len(e.RunwayQueues[qKey]), flow.Departure.Name, f.IcaoOrigin)
 			}
@@ -2692,7 +2800,8 @@ func (e *D9TrafficEngine) reassignHoldStack(h *atc.Hold) {
 
 func (e *D9TrafficEngine) findAvailableParking(airport *atc.Airport, reqClass string, airlineICAO string) *atc.ParkingSpot {
 
-	for pass := 0; pass < 2; pass++ {
+	// passes: 0 prefers the airline's stands, 1 any stand, 2 also stands on a closed taxiway
+	for pass := 0; pass < 3; pass++ {
 		var candidates []*atc.ParkingSpot
 
 		for _, spot := range airport.Parking {
@@ -2702,11 +2811,17 @@ func (e *D9TrafficEngine) findAvailableParking(airport *atc.Airport, reqClass st
 				continue
 			}
 
-			// 2. Occupancy check
+			// 2. Occupancy and NOTAM closure check
 			key := fmt.Sprintf("%s_%s", airport.ICAO, spot.Name)
 			if _, occupied := e.OccupiedParking[key]; occupied {
 				continue
 			}
+			if e.AtcService.IsStandClosed(airport.ICAO, spot.Name) {
+				continue
+			}
+			if pass < 2 && e.AtcService.IsTaxiwayClosed(airport.ICAO, spot.TaxiwayName) {
+				continue
+			}
 
 			// 3. User proximity check
 			if e.AtcService.UserState.NearestAirport.ICAO == airport.ICAO &&
@@ -2742,13 +2857,24 @@ func (e *D9TrafficEngine) releaseParking(icao string, spot *atc.ParkingSpot) {
 }
 
 func (e *D9TrafficEngine) refreshRunwayConfig(ap *atc.Airport) {
-    weather := e.AtcService.GetWeatherState()
+    weather := e.AtcService.GetAirportWeather(ap.ICAO)
+
+    // Preferred runway configurations of the overrides file are kept while the wind is within the noise limits
+    if set, ok := e.getPreferredRunwaySet(ap, weather); ok {
+        e.AirportConfig[ap.ICAO] = set
+        util.LogWithLabel("D9TRAFFIC", "%s preferred runway config: arriving %s, departing %s, LVP %t",
+            ap.ICAO, set.Arrival.Name, set.Departure.Name, ap.LVP)
+        return
+    }
 
     // 1. Identify the primary active runway orientation via wind utility score
     var primaryRwy *atc.Runway
     highestScore := -1000.0
 
     for _, rwy := range ap.Runways {
+        if isAvoidedForNoise(ap, rwy, weather) || e.AtcService.IsRunwayClosed(ap.ICAO, rwy.Name) {
+            continue
+        }
         score := e.getRunwayUtilityScore(rwy, weather.Wind.Direction, weather.Wind.Speed)
         if score > highestScore {
             highestScore = score
@@ -2763,7 +2889,7 @@ func (e *D9TrafficEngine) refreshRunwayConfig(ap *atc.Airport) {
 
     // 2. Filter all viable runways matching the active magnetic orientation group
     activeOrientation := int(math.Round(primaryRwy.Heading / 10.0))
-    viable := e.getViableRunways(ap)
+    viable := withoutAvoidedRunways(ap, e.getViableRunways(ap), weather)
     orientations := e.groupByOrientation(viable)
 
     candidates, exists := orientations[activeOrientation]
@@ -2771,6 +2897,11 @@ func (e *D9TrafficEngine) refreshRunwayConfig(ap *atc.Airport) {
         candidates = []*atc.Runway{primaryRwy}
     }
 
+    // Low visibility procedures restrict operations to CAT II/III runways where available
+    if ap.LVP {
+        candidates = e.getLowVisRunways(ap, candidates, weather)
+    }
+
     // Single runway airport or single viable runway in this flow
     if len(candidates) == 1 {
         e.AirportConfig[ap.ICAO] = ActiveRunwaySet{
@@ -2805,8 +2936,8 @@ func (e *D9TrafficEngine) refreshRunwayConfig(ap *atc.Airport) {
         LastWindDir:   weather.Wind.Direction,
     }
 
-    util.LogWithLabel("D9TRAFFIC", "%s runway config update: arriving %s (%d STARs), departing %s (%d SIDs)",
-        ap.ICAO, bestArrival.Name, len(bestArrival.STARs), bestDeparture.Name, len(bestDeparture.SIDs))
+    util.LogWithLabel("D9TRAFFIC", "%s runway config update: arriving %s (%d STARs), departing %s (%d SIDs), LVP %t",
+        ap.ICAO, bestArrival.Name, len(bestArrival.STARs), bestDeparture.Name, len(bestDeparture.SIDs), ap.LVP)
 }
 
 // Selects best departure runway based on SID count, wind utility, and inboard preference
@@ -2904,6 +3035,9 @@ func (e *D9TrafficEngine) selectSecondaryRunway(ap *atc.Airport, candidates []*a
 func (e *D9TrafficEngine) getViableRunways(ap *atc.Airport) []*atc.Runway {
 	viable := []*atc.Runway{}
 	for _, rwy := range ap.Runways {
+		if e.AtcService.IsRunwayClosed(ap.ICAO, rwy.Name) {
+			continue
+		}
 		// Only consider runways longer than configured minimum (meters)
 		if rwy.Length >= constants.RunwayLengthNM*constants.MetersToNM {
 			viable = append(viable, rwy)
@@ -2961,7 +3095,7 @@ func (e *D9TrafficEngine) determineSizeClass(f *flightplan.ScheduledFlight, info
 }
 
 func (e *D9TrafficEngine) clampSizeToAirportCapability(icao string, estimatedSize string) string {
-	ap, ok := e.AtcService.Airports[icao]
+	ap, ok := e.AtcService.GetAirports()[icao]
 	if !ok {
 		return estimatedSize
 	}
@@ -2993,8 +3127,8 @@ func (e *D9TrafficEngine) resolveAirline(f *flightplan.ScheduledFlight) *atc.Air
 	// We will now find a code and immediately return its full info struct.
 	// 2. Matching Pairs (Airlines at both ends)
 	util.LogWarnWithLabel(f.AircraftRegistration, "airline %s not found - allocating by orign/destination gate pairing logic", f.AirlineName)
-	origin := e.AtcService.Airports[f.IcaoOrigin]
-	dest := e.AtcService.Airports[f.IcaoDest]
+	origin := e.AtcService.GetAirports()[f.IcaoOrigin]
+	dest := e.AtcService.GetAirports()[f.IcaoDest]
 	if origin != nil && dest != nil {
 		if code := getWeightedCommonAirline(origin, dest); code != "" {
 			airline := e.AtcService.GetAirlineByCode(code)
@@ -3040,8 +3174,8 @@ func (e *D9TrafficEngine) resolveAirline(f *flightplan.ScheduledFlight) *atc.Air
 }
 
 func (e *D9TrafficEngine) calculateFlightDistance(originICAO, destICAO string) float64 {
-	origin, okO := e.AtcService.Airports[originICAO]
-	dest, okD := e.AtcService.Airports[destICAO]
+	origin, okO := e.AtcService.GetAirports()[originICAO]
+	dest, okD := e.AtcService.GetAirports()[destICAO]
 
 	// If we don't have coordinate data for both airports,
 	// return a medium distance as a safe fallback for the size heuristic.
@@ -3283,6 +3417,11 @@ func (e *D9TrafficEngine) getRunwayLock(ap *atc.Airport, rwy *atc.Runway, ac *at
 			util.LogWarnWithLabel(ac.Registration, "runway lock for %s at %s has expired, overriding previous lock held by %s", rwy.Name, ap.ICAO, lock.OccupiedBy.Registration)
 		}
 	}
+	if !locked && ac.Flight.Phase.Class == flightclass.Departing && e.isWithinLowVisSeparation(ap, rwyLockKey) {
+		// departures are spaced from the previous runway movement under low visibility procedures
+		e.addToQueue(rwyLockKey, ac.Registration)
+		return false
+	}
 	if !locked {
 		// acquire lock on runway
 		e.RunwayLocks[rwyLockKey] = &RunwayLock{
@@ -3310,6 +3449,10 @@ func (e *D9TrafficEngine) releaseRunwayLock(ap *atc.Airport, rwy *atc.Runway, ac
 	lock, lockExists := e.RunwayLocks[rwyLockKey]
 	if lockExists && lock.OccupiedBy.Registration == ac.Registration {
 		delete(e.RunwayLocks, rwyLockKey)
+		if e.RunwayReleases == nil {
+			e.RunwayReleases = make(map[string]time.Time)
+		}
+		e.RunwayReleases[rwyLockKey] = e.AtcService.GetCurrentZuluTime()
 		util.LogWithLabel(ac.Registration, "released lock on runway %s at %s", rwy.Name, ap.ICAO)
 	}
 }
@@ -3421,10 +3564,10 @@ func (e *D9TrafficEngine) ServeRadarFrame(radarSrv *server.RadarServer) {
 	var blips []server.RadarBlip
 
 	var runways []atc.Runway
-	var holds []atc.Hold
+	var holds []server.RadarHold
 
 	runwaysMap := make(map[string]atc.Runway)
-	holdsMap := make(map[string]atc.Hold)
+	holdsMap := make(map[string]server.RadarHold)
 
 	// Lock or safely iterate through active aircraft
 	for _, ac := range e.ActiveAircraft {
@@ -3455,7 +3598,12 @@ func (e *D9TrafficEngine) ServeRadarFrame(radarSrv *server.RadarServer) {
 			runwaysMap[ac.Flight.AssignedRunway.Name] = *ac.Flight.AssignedRunway
 		}
 		if ac.Flight.Holding != nil && ac.Flight.Holding.AssignedHold != nil {
-			holdsMap[ac.Flight.Holding.AssignedHold.Ident] = *ac.Flight.Holding.AssignedHold
+			hold := ac.Flight.Holding.AssignedHold
+			holdSpeed := e.getHoldAirspeedKts(ac, e.getPhaseGroundSpeedKts(ac.SizeClass, flightphase.Approach))
+			holdsMap[hold.Ident] = server.RadarHold{
+				Hold:      *hold,
+				Racetrack: e.getHoldPattern(ac, ac.Flight.AssignedRunway).Outline(hold.Lat, hold.Lon, holdSpeed),
+			}
 		}
 	}
 
@@ -3467,6 +3615,34 @@ func (e *D9TrafficEngine) ServeRadarFrame(radarSrv *server.RadarServer) {
 		holds = append(holds, hold)
 	}
 
+	// METARs of the airports currently running a runway configuration
+	icaos := make([]string, 0, len(e.AirportConfig))
+	for icao := range e.AirportConfig {
+		icaos = append(icaos, icao)
+	}
+	sort.Strings(icaos)
+	var metars []string
+	for _, icao := range icaos {
+		if metar := e.AtcService.GetMETAR(icao); metar != "" {
+			metars = append(metars, metar)
+		}
+	}
+
+	// NOTAM closures in force at the loaded airports
+	var closures []server.RadarClosure
+	airports := e.AtcService.GetAirports()
+	for _, c := range e.AtcService.ActiveClosures("") {
+		ap, ok := airports[c.Airport]
+		if !ok {
+			continue
+		}
+		points := c.Outline(ap)
+		if len(points) == 0 {
+			continue
+		}
+		closures = append(closures, server.RadarClosure{Airport: c.Airport, Kind: c.Kind(), Name: c.Name(), Reason: c.Reason, Points: points})
+	}
+
 	userPos := e.AtcService.GetUserState().Position
 
 	snapshot := server.RadarSnapshot{
@@ -3476,6 +3652,8 @@ func (e *D9TrafficEngine) ServeRadarFrame(radarSrv *server.RadarServer) {
 		Aircraft:  blips,
 		Runways: runways,
 		Holds: holds,
+		Metars: metars,
+		Closures: closures,
 	}
 
 	// Ship it to the streaming server
@@ -697,3 +697,423 @@ func TestCollisionManeuverInitiationAndCompletion(t *testing.T) {
 	}
 }
 
+
+func TestFindDiversionAirport(t *testing.T) {
+	e := setupMockEngine()
+	e.AtcService.Airports = map[string]*atc.Airport{
+		"SHRT": {ICAO: "SHRT", Lat: 51.0, Lon: 0.0, Runways: map[string]*atc.Runway{"09": {Name: "09", Length: 1000}}},
+		"MEDM": {ICAO: "MEDM", Lat: 51.5, Lon: 0.0, Runways: map[string]*atc.Runway{"09": {Name: "09", Length: 2000}}},
+		"LONG": {ICAO: "LONG", Lat: 52.0, Lon: 0.0, Runways: map[string]*atc.Runway{"09": {Name: "09", Length: 3200}}},
+	}
+
+	tests := []struct {
+		sizeClass string
+		want      string
+	}{
+		{"A", "SHRT"},
+		{"C", "MEDM"},
+		{"F", "LONG"},
+	}
+
+	for _, tt := range tests {
+		ac := &atc.Aircraft{SizeClass: tt.sizeClass, Flight: atc.Flight{Position: atc.Position{Lat: 50.9, Long: 0.0}}}
+		got := e.findDiversionAirport(ac, nil)
+		if got == nil || got.ICAO != tt.want {
+			t.Errorf("size class %s: findDiversionAirport() = %v; want %s", tt.sizeClass, got, tt.want)
+		}
+	}
+}
+
+func TestDeclareEmergencyDiverts(t *testing.T) {
+	e := setupMockEngine()
+	rwy := &atc.Runway{Name: "27", Length: 3000}
+	e.AtcService.Airports = map[string]*atc.Airport{
+		"DEST": {ICAO: "DEST", Lat: 55.0, Lon: 0.0, Runways: map[string]*atc.Runway{"27": {Name: "27", Length: 3000}}},
+		"DIVT": {ICAO: "DIVT", Lat: 51.0, Lon: 0.1, Runways: map[string]*atc.Runway{"27": rwy}},
+	}
+	e.AirportConfig["DIVT"] = ActiveRunwaySet{Arrival: rwy, Departure: rwy}
+
+	sched := &flightplan.ScheduledFlight{IcaoOrigin: "ORIG", IcaoDest: "DEST"}
+	ac := &atc.Aircraft{
+		Registration: "EMG1",
+		SizeClass:    "C",
+		Flight: atc.Flight{
+			Number:      1,
+			Destination: "DEST",
+			CruiseAlt:   35000,
+			Schedule:    sched,
+			Phase:       flightphase.Phase{Current: flightphase.Departure.Index(), Previous: flightphase.Departure.Index()},
+			Position:    atc.Position{Lat: 51.0, Long: 0.0, Altitude: 20000},
+		},
+	}
+	e.ActiveAircraft[getActiveAircraftKey(ac)] = ac
+
+	e.declareEmergency(ac, atc.EmergencyPressurization)
+
+	if sched.IcaoDest != "DEST" {
+		t.Errorf("shared schedule modified: IcaoDest = %s; want DEST", sched.IcaoDest)
+	}
+	if ac.Flight.Schedule.IcaoDest != "DIVT" || ac.Flight.Destination != "DIVT" {
+		t.Errorf("diversion = %s/%s; want DIVT", ac.Flight.Schedule.IcaoDest, ac.Flight.Destination)
+	}
+	if ac.Flight.Emergency == nil || ac.Flight.Squawk != "7700" {
+		t.Fatalf("emergency not declared: %+v squawk %q", ac.Flight.Emergency, ac.Flight.Squawk)
+	}
+	if ac.Flight.CruiseAlt != constants.EmergencyDescentAltFt {
+		t.Errorf("CruiseAlt = %d; want %d", ac.Flight.CruiseAlt, constants.EmergencyDescentAltFt)
+	}
+	if ac.Flight.Phase.Current != flightphase.Cruise.Index() {
+		t.Errorf("phase = %s; want Cruise", flightphase.FlightPhase(ac.Flight.Phase.Current))
+	}
+	if !e.hasInboundEmergency("DIVT", nil) {
+		t.Errorf("hasInboundEmergency(DIVT) = false; want true")
+	}
+	if e.hasInboundEmergency("DIVT", ac) {
+		t.Errorf("hasInboundEmergency(DIVT) excluding the emergency aircraft = true; want false")
+	}
+}
+
+func TestCheckRunwayWeather(t *testing.T) {
+	e := setupMockEngine()
+	ap := &atc.Airport{ICAO: "EGLL", Elevation: 83}
+	ils := &atc.Runway{Name: "27L", Heading: 270, HighestPrecisionApproach: "ILS"}
+	ndb := &atc.Runway{Name: "27R", Heading: 270, HighestPrecisionApproach: "NDB"}
+
+	const ktToMps = 1 / constants.MpsToKnots
+
+	tests := []struct {
+		name      string
+		weather   atc.Weather
+		rwy       *atc.Runway
+		sizeClass string
+		want      string
+	}{
+		{"calm", atc.Weather{Wind: &atc.Wind{}}, ils, "C", ""},
+		{"headwind", atc.Weather{Wind: &atc.Wind{Direction: 270, Speed: 40 * ktToMps}}, ils, "C", ""},
+		{"windshear", atc.Weather{Wind: &atc.Wind{Direction: 270, Speed: 5, Shear: 20 * ktToMps}}, ils, "C", weatherReasonWindshear},
+		{"crosswind within limits for large aircraft", atc.Weather{Wind: &atc.Wind{Direction: 360, Speed: 25 * ktToMps}}, ils, "D", ""},
+		{"crosswind beyond limits for light aircraft", atc.Weather{Wind: &atc.Wind{Direction: 360, Speed: 25 * ktToMps}}, ils, "A", weatherReasonCrosswind},
+		{"tailwind", atc.Weather{Wind: &atc.Wind{Direction: 90, Speed: 12 * ktToMps}}, ils, "C", weatherReasonTailwind},
+		{"visibility above ILS minima", atc.Weather{Wind: &atc.Wind{}, Vis: 800}, ils, "C", ""},
+		{"visibility below NDB minima", atc.Weather{Wind: &atc.Wind{}, Vis: 800}, ndb, "C", weatherReasonVisibility},
+		{"ceiling above ILS minima", atc.Weather{Wind: &atc.Wind{}, Ceiling: 400}, ils, "C", ""},
+		{"ceiling below NDB minima", atc.Weather{Wind: &atc.Wind{}, Ceiling: 400}, ndb, "C", weatherReasonCeiling},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e.AtcService.Weather = &tt.weather
+			if got := e.checkRunwayWeather(ap, tt.rwy, tt.sizeClass); got != tt.want {
+				t.Errorf("checkRunwayWeather() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("CAT III minima under low visibility procedures", func(t *testing.T) {
+		e.AtcService.Weather = &atc.Weather{Wind: &atc.Wind{}, Vis: 200}
+		cat3 := &atc.Runway{Name: "27L", Heading: 270, HighestPrecisionApproach: "ILS", LowVisLighting: true}
+		lvpAp := &atc.Airport{ICAO: "EGLL", Elevation: 83, LVP: true}
+		if got := e.checkRunwayWeather(lvpAp, cat3, "C"); got != "" {
+			t.Errorf("checkRunwayWeather() = %q; want CAT III approach within minima", got)
+		}
+		if got := e.checkRunwayWeather(lvpAp, cat3, "A"); got != weatherReasonVisibility {
+			t.Errorf("checkRunwayWeather() = %q; want %q for light aircraft", got, weatherReasonVisibility)
+		}
+		if got := e.checkRunwayWeather(lvpAp, ils, "C"); got != weatherReasonVisibility {
+			t.Errorf("checkRunwayWeather() = %q; want %q without CAT II/III lighting", got, weatherReasonVisibility)
+		}
+	})
+}
+
+func TestDivertForWeather(t *testing.T) {
+	e := setupMockEngine()
+	e.AtcService.Weather = &atc.Weather{Wind: &atc.Wind{Direction: 360, Speed: 20}}
+
+	crossRwy := &atc.Runway{Name: "27", Heading: 270, Length: 3000}
+	alignedRwy := &atc.Runway{Name: "36", Heading: 360, Length: 3000}
+	xwndActive := &atc.Runway{Name: "27", Heading: 270, Length: 3000}
+	e.AtcService.Airports = map[string]*atc.Airport{
+		"DEST": {ICAO: "DEST", Lat: 51.0, Lon: 0.0, Runways: map[string]*atc.Runway{"27": crossRwy}},
+		"NEAR": {ICAO: "NEAR", Lat: 51.2, Lon: 0.0, Runways: map[string]*atc.Runway{"27": {Name: "27", Heading: 270, Length: 3000}}},
+		"ALTN": {ICAO: "ALTN", Lat: 51.5, Lon: 0.0, Runways: map[string]*atc.Runway{"36": alignedRwy}},
+		// an aligned runway that is not in use does not make an alternate
+		"XWND": {ICAO: "XWND", Lat: 51.3, Lon: 0.0, Runways: map[string]*atc.Runway{"27": xwndActive, "36": {Name: "36", Heading: 360, Length: 3000}}},
+	}
+	e.AirportConfig["ALTN"] = ActiveRunwaySet{Arrival: alignedRwy, Departure: alignedRwy}
+	e.AirportConfig["XWND"] = ActiveRunwaySet{Arrival: xwndActive, Departure: xwndActive}
+
+	ac := &atc.Aircraft{
+		Registration: "WX1",
+		SizeClass:    "C",
+		Flight: atc.Flight{
+			Destination:      "DEST",
+			Schedule:         &flightplan.ScheduledFlight{IcaoOrigin: "ORIG", IcaoDest: "DEST"},
+			Phase:            flightphase.Phase{Current: flightphase.GoAround.Index()},
+			Position:         atc.Position{Lat: 51.0, Long: 0.0, Altitude: 3000},
+			WeatherReason:    weatherReasonCrosswind,
+			WeatherGoArounds: WEATHER_MAX_GO_AROUNDS,
+		},
+	}
+
+	if !e.divertForWeather(ac) {
+		t.Fatalf("divertForWeather() = false; want true")
+	}
+	if ac.Flight.Destination != "ALTN" {
+		t.Errorf("diverted to %s; want ALTN", ac.Flight.Destination)
+	}
+	if ac.Flight.Diversion == nil || ac.Flight.Diversion.Reason != weatherReasonCrosswind {
+		t.Errorf("diversion = %+v; want reason %q", ac.Flight.Diversion, weatherReasonCrosswind)
+	}
+	if ac.Flight.WeatherReason != "" || ac.Flight.WeatherGoArounds != 0 {
+		t.Errorf("weather state not reset: reason %q, go-arounds %d", ac.Flight.WeatherReason, ac.Flight.WeatherGoArounds)
+	}
+}
+
+func TestGetLowVisRunways(t *testing.T) {
+	e := setupMockEngine()
+	weather := &atc.Weather{Wind: &atc.Wind{Direction: 270, Speed: 2}}
+
+	cat1 := &atc.Runway{Name: "27R", Heading: 270, Length: 3000, HighestPrecisionApproach: "ILS"}
+	cat3 := &atc.Runway{Name: "27L", Heading: 270, Length: 3000, HighestPrecisionApproach: "ILS", LowVisLighting: true}
+	reciprocal := &atc.Runway{Name: "09R", Heading: 90, Length: 3000, HighestPrecisionApproach: "ILS", LowVisLighting: true}
+
+	t.Run("filters candidates to CAT II/III", func(t *testing.T) {
+		ap := &atc.Airport{ICAO: "EGLL", Runways: map[string]*atc.Runway{"27R": cat1, "27L": cat3}}
+		got := e.getLowVisRunways(ap, []*atc.Runway{cat1, cat3}, weather)
+		if len(got) != 1 || got[0] != cat3 {
+			t.Fatalf("getLowVisRunways() = %v; want [27L]", got)
+		}
+	})
+
+	t.Run("changes flow to CAT II/III runway within wind limits", func(t *testing.T) {
+		ap := &atc.Airport{ICAO: "EGLL", Runways: map[string]*atc.Runway{"27R": cat1, "09R": reciprocal}}
+		got := e.getLowVisRunways(ap, []*atc.Runway{cat1}, weather)
+		if len(got) != 1 || got[0] != reciprocal {
+			t.Fatalf("getLowVisRunways() = %v; want [09R]", got)
+		}
+	})
+
+	t.Run("keeps candidates when tailwind exceeds limits", func(t *testing.T) {
+		ap := &atc.Airport{ICAO: "EGLL", Runways: map[string]*atc.Runway{"27R": cat1, "09R": reciprocal}}
+		strongWind := &atc.Weather{Wind: &atc.Wind{Direction: 270, Speed: 10}}
+		got := e.getLowVisRunways(ap, []*atc.Runway{cat1}, strongWind)
+		if len(got) != 1 || got[0] != cat1 {
+			t.Fatalf("getLowVisRunways() = %v; want [27R]", got)
+		}
+	})
+}
+
+func TestLowVisSpacing(t *testing.T) {
+	e := setupMockEngine()
+	now := e.AtcService.GetCurrentZuluTime()
+	ap := &atc.Airport{ICAO: "EGLL"}
+	rwyKey := normalizeRunwayKey(ap.ICAO, &atc.Runway{Name: "27L"})
+	e.RunwayReleases = map[string]time.Time{rwyKey: now.Add(-30 * time.Second)}
+
+	taxiing := &atc.Aircraft{Registration: "TAXI1", Flight: atc.Flight{
+		Origin: "EGLL",
+		Phase:  flightphase.Phase{Current: flightphase.TaxiOut.Index(), Transition: now.Add(-30 * time.Second)},
+	}}
+	ac := &atc.Aircraft{Registration: "NEXT1", Flight: atc.Flight{Origin: "EGLL"}}
+	e.ActiveAircraft[taxiing.Registration] = taxiing
+
+	if e.isWithinLowVisSeparation(ap, rwyKey) || !e.isTaxiSpacingClear(ap, ac) {
+		t.Fatalf("spacing applied without low visibility procedures")
+	}
+
+	ap.LVP = true
+	if !e.isWithinLowVisSeparation(ap, rwyKey) {
+		t.Errorf("isWithinLowVisSeparation() = false; want true shortly after runway release")
+	}
+	if e.isTaxiSpacingClear(ap, ac) {
+		t.Errorf("isTaxiSpacingClear() = true; want false shortly after another aircraft began taxiing")
+	}
+	if getMaxApproachCount(ap) != LVP_MAX_APPROACH_ON_APPROACH {
+		t.Errorf("getMaxApproachCount() = %d; want %d", getMaxApproachCount(ap), LVP_MAX_APPROACH_ON_APPROACH)
+	}
+
+	e.RunwayReleases[rwyKey] = now.Add(-LVP_RUNWAY_SEPARATION_SECONDS * time.Second)
+	taxiing.Flight.Phase.Transition = now.Add(-LVP_TAXI_SPACING_SECONDS * time.Second)
+	if e.isWithinLowVisSeparation(ap, rwyKey) || !e.isTaxiSpacingClear(ap, ac) {
+		t.Errorf("spacing still applied after the low visibility intervals elapsed")
+	}
+}
+
+func TestGetCruiseGroundSpeedKts(t *testing.T) {
+	e := setupMockEngine()
+	e.AtcService.Weather = &atc.Weather{
+		Wind: &atc.Wind{},
+		Layers: []atc.WeatherLayer{
+			{AltitudeFt: 1000, Wind: atc.Wind{}},
+			{AltitudeFt: 35000, Wind: atc.Wind{Direction: 270, Speed: 50 / constants.MpsToKnots}},
+		},
+	}
+	airspeed := e.getPhaseGroundSpeedKts("C", flightphase.Cruise)
+
+	ac := &atc.Aircraft{SizeClass: "C", Flight: atc.Flight{Position: atc.Position{Altitude: 35000, Heading: 270}}}
+	if got := e.getCruiseGroundSpeedKts(ac); math.Abs(got-(airspeed-50)) > 0.1 {
+		t.Errorf("headwind ground speed = %.1f; want %.1f", got, airspeed-50)
+	}
+
+	ac.Flight.Position.Heading = 90
+	if got := e.getCruiseGroundSpeedKts(ac); math.Abs(got-(airspeed+50)) > 0.1 {
+		t.Errorf("tailwind ground speed = %.1f; want %.1f", got, airspeed+50)
+	}
+
+	ac.Flight.Position.Altitude = 1000
+	if got := e.getCruiseGroundSpeedKts(ac); math.Abs(got-airspeed) > 0.1 {
+		t.Errorf("calm ground speed = %.1f; want %.1f", got, airspeed)
+	}
+}
+
+func TestGetMetricCruiseAltitude(t *testing.T) {
+	e := setupMockEngine()
+	e.AtcService.TransitionRules = &atc.TransitionRules{Rules: []*atc.TransitionRule{
+		{Prefixes: []string{"Z"}, TransitionAlt: 9800, Method: atc.TransitionMethodFixed, TransitionLevel: 118, Units: atc.AltitudeUnitsMetric},
+	}}
+	origin := &atc.Airport{ICAO: "ZSPD", Lat: 31.14, Lon: 121.80}
+	dest := &atc.Airport{ICAO: "ZBAA", Lat: 40.08, Lon: 116.58}
+
+	// Shanghai to Beijing is westbound of north, so FL290 is flown at 9200m
+	ac := &atc.Aircraft{Flight: atc.Flight{Destination: "ZBAA", Position: atc.Position{Heading: 90}}}
+	if got, ok := e.getMetricCruiseAltitude(ac, 29000, origin, dest); !ok || got != 30100 {
+		t.Errorf("getMetricCruiseAltitude() = %.0f, %v; want 30100, true", got, ok)
+	}
+
+	ac.Flight.Destination = "EGLL"
+	if got, ok := e.getMetricCruiseAltitude(ac, 29000, origin, dest); ok || got != 29000 {
+		t.Errorf("expected cruise altitude to be unchanged outside metric airspace, got %.0f, %v", got, ok)
+	}
+}
+
+// flyTestHold joins the hold at the fix on the heading and flies it for the duration, returning the lateral
+// distance from the inbound course on each crossing of the fix and the time of each inbound leg
+func flyTestHold(p atc.HoldPattern, heading, airspeed float64, wind atc.Wind, seconds int) (entry atc.HoldEntry, crossings, inboundSecs []float64, maxDistNM float64) {
+	const fixLat, fixLon = 51.646, 0.152
+	ac := &atc.Aircraft{Flight: atc.Flight{
+		Position: atc.Position{Lat: fixLat, Long: fixLon, Heading: heading},
+		Holding:  &atc.Holding{AssignedHold: &atc.Hold{Ident: "LAM"}, ArrivedAtHoldFix: true},
+	}}
+	joinHoldPattern(ac, p)
+
+	for i := 0; i < seconds; i++ {
+		leg, legSecs := ac.Flight.Holding.Leg, ac.Flight.Holding.LegSeconds
+		flyHoldPattern(ac, p, fixLat, fixLon, airspeed, wind, 1)
+		if leg == atc.HoldLegInbound && ac.Flight.Holding.Leg == atc.HoldLegOutboundTurn {
+			_, cross := holdFrame(p, fixLat, fixLon, ac.Flight.Position.Lat, ac.Flight.Position.Long)
+			crossings = append(crossings, cross)
+			inboundSecs = append(inboundSecs, legSecs+1)
+		}
+		maxDistNM = math.Max(maxDistNM, geometry.DistNM(fixLat, fixLon, ac.Flight.Position.Lat, ac.Flight.Position.Long))
+	}
+	return ac.Flight.Holding.Entry, crossings, inboundSecs, maxDistNM
+}
+
+func TestFlyHoldPattern(t *testing.T) {
+	const airspeed = 210.0
+	patterns := map[string]atc.HoldPattern{
+		"right": {InboundTrack: 360, LegMinutes: 1},
+		"left":  {InboundTrack: 263, LeftTurns: true, LegMinutes: 1},
+		"dist":  {InboundTrack: 90, LegNM: 5},
+	}
+	winds := map[string]atc.Wind{
+		"calm":      {},
+		"crosswind": {Direction: 300, Speed: 30 / constants.MpsToKnots},
+	}
+
+	for pName, p := range patterns {
+		legNM, r := p.LegLengthNM(airspeed), atc.TurnRadiusNM(airspeed)
+		for wName, wind := range winds {
+			for heading := 0.0; heading < 360; heading += 30 {
+				name := fmt.Sprintf("%s/%s/%03.0f", pName, wName, heading)
+				entry, crossings, _, maxDist := flyTestHold(p, heading, airspeed, wind, 1200)
+				if want := p.Entry(heading); entry != want {
+					t.Errorf("%s: entry %v; want %v", name, entry, want)
+				}
+				if len(crossings) < 3 {
+					t.Errorf("%s: crossed the fix inbound %d times in 20 minutes; want at least 3", name, len(crossings))
+					continue
+				}
+				// once established the fix is crossed on the inbound course
+				for _, cross := range crossings[1:] {
+					if math.Abs(cross) > 0.5 {
+						t.Errorf("%s: crossed the fix %.2fnm from the inbound course", name, cross)
+					}
+				}
+				if limit := legNM + 3*r + 2; maxDist > limit {
+					t.Errorf("%s: flew %.1fnm from the fix; want within %.1fnm", name, maxDist, limit)
+				}
+			}
+		}
+	}
+}
+
+func TestFlyHoldPatternWindTiming(t *testing.T) {
+	p := atc.HoldPattern{InboundTrack: 360, LegMinutes: 1}
+	// a strong headwind on the inbound leg is allowed for by shortening the outbound leg
+	wind := atc.Wind{Direction: 360, Speed: 40 / constants.MpsToKnots}
+	_, _, inboundSecs, _ := flyTestHold(p, 360, 210, wind, 900)
+	if len(inboundSecs) < 3 {
+		t.Fatalf("inbound legs %v", inboundSecs)
+	}
+	for _, secs := range inboundSecs[1:] {
+		if secs < 45 || secs > 80 {
+			t.Errorf("inbound leg took %.0fs; want about 60s", secs)
+		}
+	}
+}
+
+func TestGetPreferredRunwaySet(t *testing.T) {
+	e := setupMockEngine()
+	west := &atc.Runway{Name: "27L", Heading: 270, Length: 3000}
+	westDep := &atc.Runway{Name: "27R", Heading: 270, Length: 3000}
+	east := &atc.Runway{Name: "09L", Heading: 90, Length: 3000}
+	ap := &atc.Airport{
+		ICAO:          "EGLL",
+		Runways:       map[string]*atc.Runway{"27L": west, "27R": westDep, "09L": east},
+		RunwayConfigs: []atc.RunwayConfig{{Arrival: "27L", Departure: "27R"}},
+		Noise:         atc.NoisePreference{MaxTailwindKt: 5, AvoidRunways: []string{"09L"}},
+	}
+
+	// 2 m/s of tailwind is within the 5kt noise limit
+	light := &atc.Weather{Wind: &atc.Wind{Direction: 90, Speed: 2}}
+	set, ok := e.getPreferredRunwaySet(ap, light)
+	if !ok || set.Arrival != west || set.Departure != westDep {
+		t.Fatalf("getPreferredRunwaySet() = %+v, %v; want 27L/27R", set, ok)
+	}
+	if !isAvoidedForNoise(ap, east, light) {
+		t.Error("09L not avoided with the westerly runways usable")
+	}
+
+	// 5 m/s is not, and the avoided runway is then the only one the wind allows
+	strong := &atc.Weather{Wind: &atc.Wind{Direction: 90, Speed: 5}}
+	if _, ok := e.getPreferredRunwaySet(ap, strong); ok {
+		t.Error("preferred runways kept beyond the tailwind limit")
+	}
+	if isAvoidedForNoise(ap, east, strong) {
+		t.Error("09L avoided with no other runway within the wind limits")
+	}
+}
+
+func TestFindAvailableParkingAvoidsClosures(t *testing.T) {
+	e := setupMockEngine()
+	e.OccupiedParking = make(map[string]string)
+	e.AtcService.UserState.NearestAirport = &atc.Airport{ICAO: "EGKK"}
+	bravo := &atc.ParkingSpot{Name: "521", WidthClass: "E", TaxiwayName: "B"}
+	closed := &atc.ParkingSpot{Name: "35", WidthClass: "E", TaxiwayName: "A"}
+	alpha := &atc.ParkingSpot{Name: "36", WidthClass: "E", TaxiwayName: "A"}
+	ap := &atc.Airport{ICAO: "EGLL", Parking: map[string]*atc.ParkingSpot{"521": bravo, "35": closed, "36": alpha}}
+
+	e.AtcService.SetClosures([]atc.Closure{{Airport: "EGLL", Taxiway: "B"}, {Airport: "EGLL", Stand: "35"}})
+	for i := 0; i < 20; i++ {
+		if spot := e.findAvailableParking(ap, "C", ""); spot != alpha {
+			t.Fatalf("findAvailableParking() = %v; want stand 36 with taxiway B and stand 35 closed", spot)
+		}
+	}
+
+	// stands on a closed taxiway are used when nothing else is free
+	e.OccupiedParking["EGLL_36"] = "G-TEST"
+	if spot := e.findAvailableParking(ap, "C", ""); spot != bravo {
+		t.Errorf("findAvailableParking() = %v; want stand 521 as the only stand not closed", spot)
+	}
+}
@@ -0,0 +1,195 @@
+package d9traffic
+
+import (
+	"math/rand/v2"
+
+	"github.com/curbz/decimal-niner/internal/atc"
+	"github.com/curbz/decimal-niner/internal/constants"
+	"github.com/curbz/decimal-niner/internal/flightphase"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+type EmergencyConfig struct {
+	MeanIntervalMins int      `yaml:"mean_interval_mins"` // average sim minutes between emergencies, zero disables
+	Types            []string `yaml:"types"`              // any of medical, engine_failure, pressurization, radio_failure; empty allows all
+}
+
+// parseEmergencyTypes converts the configured emergency type names. All types are returned if none are configured.
+func parseEmergencyTypes(names []string) ([]atc.EmergencyType, error) {
+	if len(names) == 0 {
+		return []atc.EmergencyType{atc.EmergencyMedical, atc.EmergencyEngineFailure,
+			atc.EmergencyPressurization, atc.EmergencyRadioFailure}, nil
+	}
+	types := make([]atc.EmergencyType, 0, len(names))
+	for _, name := range names {
+		t, err := atc.ParseEmergencyType(name)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// checkForEmergency is called once per sim minute and, on average once every configured interval, places a
+// random airborne aircraft into an emergency
+func (e *D9TrafficEngine) checkForEmergency() {
+	if e.emergencyIntervalMins <= 0 || len(e.emergencyTypes) == 0 || !e.initialised {
+		return
+	}
+	if rand.IntN(e.emergencyIntervalMins) != 0 {
+		return
+	}
+
+	var candidates []*atc.Aircraft
+	for _, ac := range e.ActiveAircraft {
+		if ac == nil || ac.Flight.Schedule == nil || ac.Flight.Emergency != nil || ac.Flight.ActiveManeuver != nil {
+			continue
+		}
+		switch flightphase.FlightPhase(ac.Flight.Phase.Current) {
+		case flightphase.Climbout, flightphase.Departure, flightphase.Cruise, flightphase.Arrival:
+			candidates = append(candidates, ac)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	ac := candidates[rand.IntN(len(candidates))]
+	e.declareEmergency(ac, e.emergencyTypes[rand.IntN(len(e.emergencyTypes))])
+}
+
+// declareEmergency diverts the aircraft to the nearest suitable airport, notifies ATC and sends other
+// arrivals at the diversion airport into holds so that the emergency aircraft has priority
+func (e *D9TrafficEngine) declareEmergency(ac *atc.Aircraft, t atc.EmergencyType) {
+
+	divertAp := e.findDiversionAirport(ac, nil)
+	if divertAp == nil {
+		util.LogWarnWithLabel(ac.Registration, "no suitable diversion airport found for %s emergency", t)
+		return
+	}
+
+	if !e.divertTo(ac, divertAp) {
+		return
+	}
+
+	if t == atc.EmergencyPressurization && ac.Flight.CruiseAlt > constants.EmergencyDescentAltFt {
+		ac.Flight.CruiseAlt = constants.EmergencyDescentAltFt
+	}
+
+	e.AtcService.DeclareEmergency(ac, t, divertAp.ICAO)
+
+	// clear the approach for the emergency aircraft
+	for _, other := range e.ActiveAircraft {
+		if other == nil || other == ac || other.Flight.Schedule == nil || other.Flight.Schedule.IcaoDest != divertAp.ICAO {
+			continue
+		}
+		if other.Flight.Phase.Current == flightphase.Approach.Index() {
+			util.LogWithLabel(other.Registration, "sent to hold at %s for inbound emergency %s", divertAp.ICAO, ac.Registration)
+			e.sendToHold(other, divertAp)
+		}
+	}
+}
+
+// divertTo changes the aircraft's destination to divertAp and routes it direct. Returns false if there
+// is no active arrival runway at divertAp, in which case the aircraft is unchanged.
+func (e *D9TrafficEngine) divertTo(ac *atc.Aircraft, divertAp *atc.Airport) bool {
+
+	rwy := e.getArrivalRunway(divertAp)
+	if rwy == nil {
+		util.LogWarnWithLabel(ac.Registration, "no active arrival runway at diversion airport %s", divertAp.ICAO)
+		return false
+	}
+
+	// leave any hold, restacking the aircraft remaining in it
+	if ac.Flight.Holding != nil {
+		if hold := ac.Flight.Holding.AssignedHold; hold != nil {
+			ac.Flight.Holding.AssignedHold = nil
+			e.reassignHoldStack(hold)
+		}
+		ac.Flight.Holding = nil
+	}
+
+	// copy the schedule so that the shared timetable entry is not modified by the diversion
+	sched := *ac.Flight.Schedule
+	sched.IcaoDest = divertAp.ICAO
+	ac.Flight.Schedule = &sched
+	ac.Flight.Destination = divertAp.ICAO
+	ac.Flight.AssignedSTAR = nil
+	ac.Flight.STARFromStub = false
+	ac.Flight.AssignedRunway = rwy
+	ac.Flight.AssignedRunwayName = rwy.Name
+	ac.Flight.Vectoring = true
+	ac.Flight.ClearedTOD = false
+
+	// route direct via the cruise tracker, which hands over to arrival once inside the arrival gate.
+	// the phase change is not notified as the emergency or diversion call replaces the normal phase change call
+	if ac.Flight.Phase.Current != flightphase.Cruise.Index() {
+		e.transitionToPhase(ac, flightphase.Cruise, 0, 0)
+		ac.Flight.Phase.Previous = ac.Flight.Phase.Current
+	}
+	return true
+}
+
+// getArrivalRunway returns the active arrival runway of the airport, determining the runway configuration of
+// an airport without one, or nil if no runway is active
+func (e *D9TrafficEngine) getArrivalRunway(ap *atc.Airport) *atc.Runway {
+	if _, exists := e.AirportConfig[ap.ICAO]; !exists {
+		e.refreshRunwayConfig(ap)
+	}
+	return e.AirportConfig[ap.ICAO].Arrival
+}
+
+// findDiversionAirport returns the nearest airport with a runway long enough for the aircraft's size class.
+// If usable is not nil, the runway must also be accepted by usable.
+func (e *D9TrafficEngine) findDiversionAirport(ac *atc.Aircraft, usable func(ap *atc.Airport, rwy *atc.Runway) bool) *atc.Airport {
+	minLengthM := getMinRunwayLengthM(ac.SizeClass)
+
+	return e.AtcService.NearestAirport(ac.Flight.Position.Lat, ac.Flight.Position.Long, func(ap *atc.Airport) bool {
+		if ap.Lat == 0 && ap.Lon == 0 {
+			return false
+		}
+		for _, rwy := range ap.Runways {
+			if rwy != nil && rwy.Length >= minLengthM && (usable == nil || usable(ap, rwy)) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// hasInboundEmergency returns true if an aircraft other than exclude is inbound to icao in an emergency
+func (e *D9TrafficEngine) hasInboundEmergency(icao string, exclude *atc.Aircraft) bool {
+	for _, other := range e.ActiveAircraft {
+		if other == nil || other == exclude || other.Flight.Emergency == nil || other.Flight.Schedule == nil {
+			continue
+		}
+		if other.Flight.Schedule.IcaoDest != icao {
+			continue
+		}
+		if other.Flight.Phase.Current >= flightphase.Cruise.Index() && other.Flight.Phase.Current < flightphase.Braking.Index() {
+			return true
+		}
+	}
+	return false
+}
+
+// getMinRunwayLengthM returns the minimum runway length in meters required to land an aircraft of the size class
+func getMinRunwayLengthM(sizeClass string) float64 {
+	switch sizeClass {
+	case "A":
+		return 800.0
+	case "B":
+		return 1200.0
+	case "C":
+		return 1800.0
+	case "D":
+		return 2400.0
+	case "E":
+		return 2500.0
+	case "F":
+		return 3000.0
+	default:
+		return 1800.0
+	}
+}
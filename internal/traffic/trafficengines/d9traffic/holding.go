@@ -0,0 +1,242 @@
+package d9traffic
+
+import (
+	"math"
+
+	"github.com/curbz/decimal-niner/internal/atc"
+	"github.com/curbz/decimal-niner/internal/constants"
+	"github.com/curbz/decimal-niner/pkg/geometry"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+// getHoldPattern returns the racetrack flown by the aircraft at its assigned hold. Holds without a published
+// pattern are flown with right turns and an inbound course towards the runway.
+func (e *D9TrafficEngine) getHoldPattern(ac *atc.Aircraft, rwy *atc.Runway) atc.HoldPattern {
+	holding := ac.Flight.Holding
+	hold := holding.AssignedHold
+
+	var magVar float64
+	if w := e.AtcService.GetWeatherState(); w != nil {
+		magVar = w.MagVar
+	}
+	if hold.HasPattern() {
+		return hold.Pattern(magVar, holding.TargetHoldAlt)
+	}
+
+	var inboundCourse float64
+	if ac.Flight.AssignedRunway != nil {
+		inboundCourse = geometry.CalculateBearing(hold.Lat, hold.Lon, ac.Flight.AssignedRunway.Lat, ac.Flight.AssignedRunway.Lon)
+	} else if rwy != nil {
+		inboundCourse = geometry.CalculateBearing(hold.Lat, hold.Lon, rwy.Lat, rwy.Lon)
+	} else {
+		inboundCourse = geometry.CalculateBearing(hold.Lat, hold.Lon, ac.Flight.Position.Lat, ac.Flight.Position.Long)
+	}
+	return atc.HoldPattern{
+		InboundTrack: geometry.NormalizeHeading(inboundCourse),
+		LegMinutes:   atc.DefaultHoldLegMinutes(holding.TargetHoldAlt),
+	}
+}
+
+// getHoldAirspeedKts returns the airspeed flown in the hold, limited to the hold's published maximum
+func (e *D9TrafficEngine) getHoldAirspeedKts(ac *atc.Aircraft, approachSpeedKts float64) float64 {
+	if limit := ac.Flight.Holding.AssignedHold.SpeedKts; limit > 0 {
+		return math.Min(approachSpeedKts, float64(limit))
+	}
+	return approachSpeedKts
+}
+
+// getWindAt returns the wind at the aircraft's altitude, or calm if the weather is not known
+func (e *D9TrafficEngine) getWindAt(ac *atc.Aircraft) atc.Wind {
+	w := e.AtcService.GetWeatherState()
+	if w == nil {
+		return atc.Wind{}
+	}
+	return w.WindAt(ac.Flight.Position.Altitude)
+}
+
+// joinHoldPattern chooses the entry procedure from the aircraft's heading on reaching the hold fix and starts
+// its first leg
+func joinHoldPattern(ac *atc.Aircraft, p atc.HoldPattern) {
+	holding := ac.Flight.Holding
+	holding.Entry = p.Entry(ac.Flight.Position.Heading)
+	holding.LegSeconds = 0
+	if holding.Entry == atc.HoldEntryDirect {
+		holding.Leg = atc.HoldLegOutboundTurn
+	} else {
+		holding.Leg = atc.HoldLegEntryOutbound
+	}
+	util.LogDebugWithLabel(ac.Registration, "joining hold %s with %s entry, inbound track %03.0f",
+		holding.AssignedHold.Ident, holding.Entry, p.InboundTrack)
+}
+
+// flyHoldPattern moves the aircraft along the entry and racetrack of the hold at the fix for the elapsed seconds,
+// flying the airspeed (knots) with drift from the wind
+func flyHoldPattern(ac *atc.Aircraft, p atc.HoldPattern, fixLat, fixLon, airspeedKts float64, wind atc.Wind, dt float64) {
+	holding := ac.Flight.Holding
+	holding.LegSeconds += dt
+	along, cross := holdFrame(p, fixLat, fixLon, ac.Flight.Position.Lat, ac.Flight.Position.Long)
+
+	next := holding.Leg
+	switch holding.Leg {
+	case atc.HoldLegInbound:
+		// steer back onto the inbound course, towards the non-holding side if displaced to the holding side
+		intercept := math.Max(-HOLD_MAX_INTERCEPT_DEG, math.Min(HOLD_MAX_INTERCEPT_DEG, cross*30.0))
+		track := geometry.NormalizeHeading(p.InboundTrack - intercept*p.TurnSign())
+		ac.Flight.TargetHeading = windCorrectedHeading(track, airspeedKts, wind)
+		applySmoothTurnHeading(ac, ac.Flight.TargetHeading, HOLD_TURN_RATE_DEG_SEC, dt)
+		if along >= 0 {
+			next = atc.HoldLegOutboundTurn
+		}
+
+	case atc.HoldLegOutboundTurn:
+		ac.Flight.TargetHeading = outboundHeading(p, airspeedKts, wind)
+		if turnInDirection(ac, ac.Flight.TargetHeading, p.TurnSign(), dt) {
+			next = atc.HoldLegOutbound
+		}
+
+	case atc.HoldLegOutbound:
+		ac.Flight.TargetHeading = outboundHeading(p, airspeedKts, wind)
+		applySmoothTurnHeading(ac, ac.Flight.TargetHeading, HOLD_TURN_RATE_DEG_SEC, dt)
+		if holdLegComplete(p, holding.LegSeconds, -along, outboundLegSeconds(p, airspeedKts, wind)) {
+			next = atc.HoldLegInboundTurn
+		}
+
+	case atc.HoldLegInboundTurn:
+		ac.Flight.TargetHeading = windCorrectedHeading(p.InboundTrack, airspeedKts, wind)
+		if turnInDirection(ac, ac.Flight.TargetHeading, p.TurnSign(), dt) {
+			next = atc.HoldLegInbound
+		}
+
+	case atc.HoldLegEntryOutbound:
+		track := p.OutboundTrack()
+		if holding.Entry == atc.HoldEntryTeardrop {
+			track = p.TeardropTrack()
+		}
+		ac.Flight.TargetHeading = windCorrectedHeading(track, airspeedKts, wind)
+		applySmoothTurnHeading(ac, ac.Flight.TargetHeading, HOLD_TURN_RATE_DEG_SEC, dt)
+		if holdLegComplete(p, holding.LegSeconds, math.Hypot(along, cross), p.LegMinutes*60.0) {
+			// the teardrop turns in the direction of the pattern onto the inbound course, the parallel entry
+			// turns the other way through the holding side back towards the fix
+			if holding.Entry == atc.HoldEntryTeardrop {
+				next = atc.HoldLegInboundTurn
+			} else {
+				next = atc.HoldLegEntryTurn
+			}
+		}
+
+	case atc.HoldLegEntryTurn:
+		toFix := geometry.CalculateBearing(ac.Flight.Position.Lat, ac.Flight.Position.Long, fixLat, fixLon)
+		ac.Flight.TargetHeading = windCorrectedHeading(toFix, airspeedKts, wind)
+		if turnInDirection(ac, ac.Flight.TargetHeading, -p.TurnSign(), dt) {
+			next = atc.HoldLegInbound
+		}
+	}
+
+	if next != holding.Leg {
+		holding.Leg = next
+		holding.LegSeconds = 0
+	}
+	moveWithWind(ac, airspeedKts, wind, dt)
+}
+
+// holdFrame returns the position relative to the hold fix in nautical miles along the inbound course (negative
+// before the fix) and across it (positive on the holding side)
+func holdFrame(p atc.HoldPattern, fixLat, fixLon, lat, lon float64) (along, cross float64) {
+	north := (lat - fixLat) * 60.0
+	east := geometry.NormalizeDiffDegrees(lon, fixLon) * 60.0 * math.Cos(geometry.DegToRad(fixLat))
+	rad := geometry.DegToRad(p.InboundTrack)
+	ue, un := math.Sin(rad), math.Cos(rad)
+	along = east*ue + north*un
+	cross = (east*un - north*ue) * p.TurnSign()
+	return along, cross
+}
+
+// holdLegComplete returns true once a timed leg has been flown for its time, or a distance leg has reached its
+// length from the fix
+func holdLegComplete(p atc.HoldPattern, legSeconds, distNM, timedSeconds float64) bool {
+	if p.LegNM > 0 {
+		return distNM >= p.LegNM
+	}
+	return legSeconds >= timedSeconds
+}
+
+// outboundHeading returns the heading for the outbound leg, with three times the drift correction so that the
+// drift in the turns is also allowed for
+func outboundHeading(p atc.HoldPattern, airspeedKts float64, wind atc.Wind) float64 {
+	track := p.OutboundTrack()
+	drift := geometry.NormalizeDiffDegrees(windCorrectedHeading(track, airspeedKts, wind), track) * 3.0
+	drift = math.Max(-HOLD_MAX_OUTBOUND_DRIFT_DEG, math.Min(HOLD_MAX_OUTBOUND_DRIFT_DEG, drift))
+	return geometry.NormalizeHeading(track + drift)
+}
+
+// outboundLegSeconds returns the time of the outbound leg that gives an inbound leg of the published time,
+// allowing for the different ground speeds on the two legs and the wind carrying the aircraft along in the turns
+func outboundLegSeconds(p atc.HoldPattern, airspeedKts float64, wind atc.Wind) float64 {
+	legSeconds := p.LegMinutes * 60.0
+	inbound := trackGroundSpeedKts(p.InboundTrack, airspeedKts, wind)
+	outbound := trackGroundSpeedKts(p.OutboundTrack(), airspeedKts, wind)
+	if inbound <= 0 || outbound <= 0 {
+		return legSeconds
+	}
+
+	// a headwind on the inbound leg blows the aircraft away from the fix during both turns
+	turnSeconds := 180.0 / HOLD_TURN_RATE_DEG_SEC
+	headwindKt, _ := wind.Components(p.InboundTrack)
+	outboundNM := (inbound*legSeconds - 2*turnSeconds*headwindKt) / 3600.0
+	return math.Max(10.0, math.Min(legSeconds*2.0, outboundNM/outbound*3600.0))
+}
+
+// windCorrectedHeading returns the heading that makes good the track (degrees true) at the airspeed (knots)
+func windCorrectedHeading(track, airspeedKts float64, wind atc.Wind) float64 {
+	windKts := wind.Speed * constants.MpsToKnots
+	if windKts == 0 || airspeedKts <= 0 {
+		return track
+	}
+	// crosswind from the right of the track is positive and is corrected by turning right into it
+	crosswindKt := windKts * math.Sin(geometry.DegToRad(wind.Direction-track))
+	wca := math.Asin(math.Max(-1, math.Min(1, crosswindKt/airspeedKts))) * 180.0 / math.Pi
+	return geometry.NormalizeHeading(track + wca)
+}
+
+// trackGroundSpeedKts returns the ground speed along the track when the drift is corrected for
+func trackGroundSpeedKts(track, airspeedKts float64, wind atc.Wind) float64 {
+	headwindKt, _ := wind.Components(track)
+	crosswindKt := wind.Speed * constants.MpsToKnots * math.Sin(geometry.DegToRad(wind.Direction-track))
+	return math.Sqrt(math.Max(0, airspeedKts*airspeedKts-crosswindKt*crosswindKt)) - headwindKt
+}
+
+// turnInDirection turns the aircraft at rate one towards the heading in the direction (1 right, -1 left),
+// however far round that is, returning true once the heading is reached
+func turnInDirection(ac *atc.Aircraft, targetHeading, direction, dt float64) bool {
+	remaining := math.Mod((targetHeading-ac.Flight.Position.Heading)*direction+720.0, 360.0)
+	step := HOLD_TURN_RATE_DEG_SEC * dt
+	// a target just behind the aircraft in the direction of the turn has been reached, e.g. through the wind
+	// moving the corrected heading
+	if remaining <= step || remaining >= 360.0-step {
+		ac.Flight.Position.Heading = geometry.NormalizeHeading(targetHeading)
+		return true
+	}
+	ac.Flight.Position.Heading = geometry.NormalizeHeading(ac.Flight.Position.Heading + step*direction)
+	return false
+}
+
+// moveWithWind moves the aircraft along its heading at the airspeed (knots) for the elapsed seconds and drifts
+// it downwind, setting its ground speed
+func moveWithWind(ac *atc.Aircraft, airspeedKts float64, wind atc.Wind, dt float64) {
+	pos := &ac.Flight.Position
+	pos.Lat, pos.Long = geometry.Project(pos.Lat, pos.Long, pos.Heading, airspeedKts/3600.0*dt)
+
+	windKts := wind.Speed * constants.MpsToKnots
+	if windKts > 0 {
+		// the wind direction is where the wind blows from
+		downwind := geometry.NormalizeHeading(wind.Direction + 180.0)
+		pos.Lat, pos.Long = geometry.Project(pos.Lat, pos.Long, downwind, windKts/3600.0*dt)
+	}
+
+	headingRad := geometry.DegToRad(pos.Heading)
+	downwindRad := geometry.DegToRad(wind.Direction + 180.0)
+	ac.Flight.GroundSpeed = math.Hypot(
+		airspeedKts*math.Sin(headingRad)+windKts*math.Sin(downwindRad),
+		airspeedKts*math.Cos(headingRad)+windKts*math.Cos(downwindRad),
+	)
+}
@@ -0,0 +1,113 @@
+package d9traffic
+
+import (
+	"math"
+	"time"
+
+	"github.com/curbz/decimal-niner/internal/atc"
+	"github.com/curbz/decimal-niner/internal/flightphase"
+)
+
+// getLowVisRunways returns the CAT II/III capable runways from candidates. If none of the candidates are
+// capable, the capable runways of the airport with the wind within low visibility limits are returned instead.
+// The candidates are returned unchanged if the airport has no usable CAT II/III runway.
+func (e *D9TrafficEngine) getLowVisRunways(ap *atc.Airport, candidates []*atc.Runway, weather *atc.Weather) []*atc.Runway {
+	var lowVis []*atc.Runway
+	for _, rwy := range candidates {
+		if rwy.IsCatIIorIII() {
+			lowVis = append(lowVis, rwy)
+		}
+	}
+	if len(lowVis) > 0 {
+		return lowVis
+	}
+
+	// change the flow to the best scoring capable runway orientation
+	var best *atc.Runway
+	bestScore := math.Inf(-1)
+	for _, rwy := range e.getViableRunways(ap) {
+		if !rwy.IsCatIIorIII() {
+			continue
+		}
+		headwindKt, crosswindKt := weather.WindComponents(rwy.Heading)
+		if -headwindKt > LVP_MAX_TAILWIND_KTS || crosswindKt > LVP_MAX_CROSSWIND_KTS {
+			continue
+		}
+		if score := e.getRunwayUtilityScore(rwy, weather.Wind.Direction, weather.Wind.Speed); score > bestScore {
+			bestScore = score
+			best = rwy
+		}
+	}
+	if best == nil {
+		return candidates
+	}
+
+	orientation := int(math.Round(best.Heading / 10.0))
+	for _, rwy := range e.groupByOrientation(e.getViableRunways(ap))[orientation] {
+		if rwy.IsCatIIorIII() {
+			lowVis = append(lowVis, rwy)
+		}
+	}
+	return lowVis
+}
+
+// getMaxApproachCount returns the number of aircraft allowed on approach to a single runway before new arrivals
+// are sent to hold, which is reduced to increase arrival spacing while low visibility procedures are in operation
+func getMaxApproachCount(ap *atc.Airport) int {
+	if ap != nil && ap.LVP {
+		return LVP_MAX_APPROACH_ON_APPROACH
+	}
+	return MAX_APPROACH_ON_APPROACH
+}
+
+// getQueueDelaySeconds returns the departure delay for a runway queue of queueLen aircraft at the airport
+func (e *D9TrafficEngine) getQueueDelaySeconds(icao string, queueLen int) int {
+	delay := queueLen * TRAFFIC_MANAGEMENT_PER_AIRCRAFT_DELAY_SECONDS
+	if ap := e.AtcService.GetAirportByICAO(icao); ap != nil && ap.LVP {
+		delay *= LVP_DELAY_FACTOR
+	}
+	return delay
+}
+
+// isWithinLowVisSeparation returns true if low visibility procedures are in operation at the airport and the
+// runway was vacated too recently for a departure to be cleared without infringing the ILS sensitive area
+func (e *D9TrafficEngine) isWithinLowVisSeparation(ap *atc.Airport, rwyLockKey string) bool {
+	if !ap.LVP {
+		return false
+	}
+	released, ok := e.RunwayReleases[rwyLockKey]
+	if !ok {
+		return false
+	}
+	return e.AtcService.GetCurrentZuluTime().Sub(released) < LVP_RUNWAY_SEPARATION_SECONDS*time.Second
+}
+
+// isTaxiSpacingClear returns false if low visibility procedures are in operation at the airport and another
+// aircraft started taxiing there too recently for the aircraft to begin its own taxi
+func (e *D9TrafficEngine) isTaxiSpacingClear(ap *atc.Airport, ac *atc.Aircraft) bool {
+	if !ap.LVP {
+		return true
+	}
+	currSimZTime := e.AtcService.GetCurrentZuluTime()
+	for _, other := range e.ActiveAircraft {
+		if other == nil || other == ac {
+			continue
+		}
+		switch flightphase.FlightPhase(other.Flight.Phase.Current) {
+		case flightphase.TaxiOut:
+			if other.Flight.Origin != ap.ICAO {
+				continue
+			}
+		case flightphase.TaxiIn:
+			if other.Flight.Destination != ap.ICAO {
+				continue
+			}
+		default:
+			continue
+		}
+		if currSimZTime.Sub(other.Flight.Phase.Transition) < LVP_TAXI_SPACING_SECONDS*time.Second {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package d9traffic
+
+import (
+	"github.com/curbz/decimal-niner/internal/atc"
+)
+
+// getPreferredRunwaySet returns the first preferred runway configuration of the airport's overrides with both
+// runways open and the wind on them within the noise limits. While low visibility procedures are in operation
+// both runways must also be CAT II/III capable.
+func (e *D9TrafficEngine) getPreferredRunwaySet(ap *atc.Airport, weather *atc.Weather) (ActiveRunwaySet, bool) {
+	for _, rc := range ap.RunwayConfigs {
+		arr, dep := ap.Runways[rc.Arrival], ap.Runways[rc.Departure]
+		if arr == nil || dep == nil {
+			continue
+		}
+		if e.AtcService.IsRunwayClosed(ap.ICAO, arr.Name) || e.AtcService.IsRunwayClosed(ap.ICAO, dep.Name) {
+			continue
+		}
+		if !withinNoiseLimits(ap.Noise, arr, weather) || !withinNoiseLimits(ap.Noise, dep, weather) {
+			continue
+		}
+		if ap.LVP && (!arr.IsCatIIorIII() || !dep.IsCatIIorIII()) {
+			continue
+		}
+		return ActiveRunwaySet{
+			Arrival:       arr,
+			Departure:     dep,
+			LastWindSpeed: weather.Wind.Speed,
+			LastWindDir:   weather.Wind.Direction,
+		}, true
+	}
+	return ActiveRunwaySet{}, false
+}
+
+// withinNoiseLimits returns true if the tailwind and crosswind components on the runway are within the limits
+// of the airport's noise preferences
+func withinNoiseLimits(noise atc.NoisePreference, rwy *atc.Runway, weather *atc.Weather) bool {
+	headwindKt, crosswindKt := weather.WindComponents(rwy.Heading)
+	if -headwindKt > noise.MaxTailwindKt {
+		return false
+	}
+	return noise.MaxCrosswindKt == 0 || crosswindKt <= noise.MaxCrosswindKt
+}
+
+// isAvoidedForNoise returns true if the runway is avoided for noise abatement and the wind allows another
+// runway of the airport to be used instead
+func isAvoidedForNoise(ap *atc.Airport, rwy *atc.Runway, weather *atc.Weather) bool {
+	if !ap.Noise.Avoids(rwy.Name) {
+		return false
+	}
+	for _, other := range ap.Runways {
+		if !ap.Noise.Avoids(other.Name) && withinNoiseLimits(ap.Noise, other, weather) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutAvoidedRunways returns the runways that are not avoided for noise abatement
+func withoutAvoidedRunways(ap *atc.Airport, runways []*atc.Runway, weather *atc.Weather) []*atc.Runway {
+	var kept []*atc.Runway
+	for _, rwy := range runways {
+		if !isAvoidedForNoise(ap, rwy, weather) {
+			kept = append(kept, rwy)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,128 @@
+package d9traffic
+
+import (
+	"math"
+
+	"github.com/curbz/decimal-niner/internal/atc"
+	"github.com/curbz/decimal-niner/internal/constants"
+	"github.com/curbz/decimal-niner/internal/flightphase"
+	"github.com/curbz/decimal-niner/pkg/util"
+)
+
+// weather reasons given in phraseology
+const (
+	weatherReasonWindshear  = "windshear"
+	weatherReasonCrosswind  = "crosswind"
+	weatherReasonTailwind   = "tailwind"
+	weatherReasonVisibility = "visibility below minima"
+	weatherReasonCeiling    = "ceiling below minima"
+)
+
+// checkRunwayWeather returns the reason an aircraft of the size class is unable to land on the runway, or an
+// empty string if the conditions are within limits
+func (e *D9TrafficEngine) checkRunwayWeather(ap *atc.Airport, rwy *atc.Runway, sizeClass string) string {
+	if ap == nil || rwy == nil {
+		return ""
+	}
+	w := e.AtcService.GetAirportWeather(ap.ICAO)
+	if w == nil || w.Wind == nil {
+		return ""
+	}
+
+	if w.Wind.Shear*constants.MpsToKnots >= constants.WindShearLimitKts {
+		return weatherReasonWindshear
+	}
+
+	headwindKt, crosswindKt := w.WindComponents(rwy.Heading)
+	if crosswindKt > getCrosswindLimitKts(sizeClass) {
+		return weatherReasonCrosswind
+	}
+	if -headwindKt > getTailwindLimitKts(sizeClass) {
+		return weatherReasonTailwind
+	}
+
+	// the lower CAT III minima only apply under low visibility procedures and not to light aircraft
+	approachType := rwy.HighestPrecisionApproach
+	if ap.LVP && rwy.IsCatIIorIII() && sizeClass != "A" {
+		approachType = atc.ApproachCatIII
+	}
+	minVisM, minCeilingFt := atc.GetApproachMinima(approachType)
+	if w.Vis > 0 && w.Vis < minVisM {
+		return weatherReasonVisibility
+	}
+	if w.Ceiling > 0 && w.Ceiling-ap.Elevation < minCeilingFt {
+		return weatherReasonCeiling
+	}
+
+	return ""
+}
+
+// divertForWeather diverts the aircraft to the nearest airport whose active arrival runway is within the
+// weather limits for the aircraft. Returns false if no such airport can be found.
+func (e *D9TrafficEngine) divertForWeather(ac *atc.Aircraft) bool {
+	reason := ac.Flight.WeatherReason
+	dest := ac.Flight.Schedule.IcaoDest
+
+	// arrivals are always given the active runway, so an alternate only qualifies if that runway is usable
+	alternate := e.findDiversionAirport(ac, func(ap *atc.Airport, rwy *atc.Runway) bool {
+		return ap.ICAO != dest && rwy == e.getArrivalRunway(ap) && e.checkRunwayWeather(ap, rwy, ac.SizeClass) == ""
+	})
+	if alternate == nil {
+		util.LogWarnWithLabel(ac.Registration, "no alternate airport within weather limits found - remaining with %s", dest)
+		return false
+	}
+
+	if !e.divertTo(ac, alternate) {
+		return false
+	}
+
+	ac.Flight.WeatherReason = ""
+	ac.Flight.WeatherGoArounds = 0
+	e.AtcService.DivertAircraft(ac, alternate.ICAO, reason)
+	return true
+}
+
+// getCruiseGroundSpeedKts returns the ground speed of the aircraft in cruise, which is its nominal cruise airspeed
+// corrected for the wind at its altitude along its heading
+func (e *D9TrafficEngine) getCruiseGroundSpeedKts(ac *atc.Aircraft) float64 {
+	airspeedKts := e.getPhaseGroundSpeedKts(ac.SizeClass, flightphase.Cruise)
+	w := e.AtcService.GetWeatherState()
+	if w == nil {
+		return airspeedKts
+	}
+
+	headwindKt, crosswindKt := w.WindAt(ac.Flight.Position.Altitude).Components(ac.Flight.Position.Heading)
+	// part of the airspeed is used to correct for drift, the remainder is reduced by the headwind
+	gs := math.Sqrt(math.Max(0, airspeedKts*airspeedKts-crosswindKt*crosswindKt)) - headwindKt
+
+	// guard against unrealistic winds stalling the aircraft's progress
+	return math.Max(gs, airspeedKts*MIN_CRUISE_GROUND_SPEED_RATIO)
+}
+
+// getCrosswindLimitKts returns the maximum demonstrated crosswind (knots) for the aircraft size class
+func getCrosswindLimitKts(sizeClass string) float64 {
+	switch sizeClass {
+	case "A":
+		return 15.0
+	case "B":
+		return 20.0
+	case "C":
+		return 30.0
+	case "D":
+		return 33.0
+	case "E", "F":
+		return 35.0
+	default:
+		return 25.0
+	}
+}
+
+// getTailwindLimitKts returns the maximum tailwind component (knots) for landing for the aircraft size class
+func getTailwindLimitKts(sizeClass string) float64 {
+	switch sizeClass {
+	case "A":
+		return 5.0
+	default:
+		return 10.0
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -21,6 +22,7 @@ import (
 	"github.com/gorilla/websocket"
 
 	"github.com/curbz/decimal-niner/internal/atc"
+	"github.com/curbz/decimal-niner/internal/constants"
 	"github.com/curbz/decimal-niner/internal/flightclass"
 	"github.com/curbz/decimal-niner/internal/flightphase"
 	"github.com/curbz/decimal-niner/internal/logger"
@@ -622,6 +624,146 @@ func (xpc *XPConnect) updateWeatherData() {
 		return
 	}
 
+	// layers, visibility, cloud and precipitation are read separately so that a missing dataref does not prevent
+	// the surface wind and pressure update
+	xpc.updateWeatherLayers(w)
+	xpc.updateSurfaceTemperature(w)
+	xpc.updateVisibilityAndClouds(w)
+	xpc.updatePrecipitation(w)
+}
+
+// updateWeatherLayers sets the winds and temperatures aloft
+func (xpc *XPConnect) updateWeatherLayers(w *atc.Weather) {
+
+	var layers []atc.WeatherLayer
+	for i := 0; ; i++ {
+		alt, errA := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionWindAltitude, i)
+		speed, errSp := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionWindSpeed, i)
+		dir, errDr := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionWindDirection, i)
+		if errA != nil || errSp != nil || errDr != nil {
+			// no more layers
+			break
+		}
+		altM, okA := alt.(float64)
+		speedMs, okS := speed.(float64)
+		dirDeg, okD := dir.(float64)
+		if !okA || !okS || !okD {
+			logger.Log.Error("weather wind layer has unexpected type", alt, speed, dir)
+			break
+		}
+		layer := atc.WeatherLayer{
+			AltitudeFt: altM * constants.MetersToFeet,
+			Wind:       atc.Wind{Direction: dirDeg, Speed: speedMs},
+		}
+
+		// shear, turbulence and temperatures are optional for each layer
+		if v, err := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionShearSpeed, i); err == nil {
+			layer.Wind.Shear, _ = v.(float64)
+		}
+		if v, err := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionTurbulence, i); err == nil {
+			layer.Turbulence, _ = v.(float64)
+		}
+		if v, err := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionTemperaturesAloft, i); err == nil {
+			layer.Temp, _ = v.(float64)
+		}
+		if v, err := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionDewpoint, i); err == nil {
+			layer.Dewpoint, _ = v.(float64)
+		}
+		layers = append(layers, layer)
+	}
+	if len(layers) == 0 {
+		return
+	}
+
+	sort.Slice(layers, func(i, j int) bool { return layers[i].AltitudeFt < layers[j].AltitudeFt })
+	w.Layers = layers
+}
+
+// updateSurfaceTemperature sets the surface temperature, dewpoint and humidity
+func (xpc *XPConnect) updateSurfaceTemperature(w *atc.Weather) {
+	temp, errT := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionSeaLevelTemp, 0)
+	dew, errD := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherSeaLevelDewpoint, 0)
+	if errT != nil || errD != nil {
+		logErrors(errT, errD)
+		return
+	}
+	tempC, okT := temp.(float64)
+	dewC, okD := dew.(float64)
+	if !okT || !okD {
+		logger.Log.Error("weather surface temperature has unexpected type", temp, dew)
+		return
+	}
+	w.Temp = tempC
+	w.Dewpoint = dewC
+	w.Humidity = atc.RelativeHumidity(w.Temp, w.Dewpoint)
+}
+
+// updatePrecipitation sets the precipitation intensity
+func (xpc *XPConnect) updatePrecipitation(w *atc.Weather) {
+	rain, err := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionRainPercent, 0)
+	if err != nil {
+		logErrors(err)
+		return
+	}
+	if v, ok := rain.(float64); ok {
+		w.Precip = v
+	} else {
+		logger.Log.Error("weather precipitation has unexpected type", rain)
+	}
+}
+
+// updateVisibilityAndClouds sets the reported visibility, the cloud layers and the ceiling, which is the base
+// of the lowest cloud layer with at least broken cover
+func (xpc *XPConnect) updateVisibilityAndClouds(w *atc.Weather) {
+
+	vis, err := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionVisibility, 0)
+	if err != nil {
+		logErrors(err)
+		return
+	}
+	if v, ok := vis.(float64); ok {
+		w.Vis = v * constants.MetersPerStatuteMile
+	} else {
+		logger.Log.Error("weather visibility has unexpected type", vis)
+		return
+	}
+
+	ceiling := 0.0
+	var clouds []atc.CloudLayer
+	for i := 0; ; i++ {
+		base, errB := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionCloudBase, i)
+		cover, errC := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionCloudCoverage, i)
+		if errB != nil || errC != nil {
+			// no more layers
+			break
+		}
+		baseM, okB := base.(float64)
+		coverage, okC := cover.(float64)
+		if !okB || !okC {
+			logger.Log.Error("weather cloud layer has unexpected type", base, cover)
+			break
+		}
+		if coverage > 1 {
+			// tolerate coverage reported as a percentage
+			coverage /= 100
+		}
+		baseFt := baseM * constants.MetersToFeet
+		if coverage >= constants.CeilingMinCoverage && (ceiling == 0 || baseFt < ceiling) {
+			ceiling = baseFt
+		}
+
+		layer := atc.CloudLayer{BaseFt: baseFt, Coverage: coverage}
+		if tops, err := xpc.getMemDataRefValue(xpc.memSubscribeDataRefIndexMap, simdata.DRSimWeatherRegionCloudTops, i); err == nil {
+			if topsM, ok := tops.(float64); ok {
+				layer.TopsFt = topsM * constants.MetersToFeet
+			}
+		}
+		if coverage > 0 {
+			clouds = append(clouds, layer)
+		}
+	}
+	w.Ceiling = ceiling
+	w.Clouds = clouds
 }
 
 // determine if user has changed tuned frequencies and inform the ATC service if they have
@@ -136,3 +136,30 @@ func TestUnknownTransitionPreserved(t *testing.T) {
 			mockATC.ReceivedPreviousPhase, expectedUnknown)
 	}
 }
+
+func TestSurfaceTemperatureNotFromLowestLayer(t *testing.T) {
+	xpc := &XPConnect{
+		memSubscribeDataRefIndexMap: map[int]*xpapimodel.Dataref{
+			1: {Name: simdata.DRSimWeatherRegionWindAltitude, Value: []float64{1500.0, 300.0}, DecodedDataType: "float_array"},
+			2: {Name: simdata.DRSimWeatherRegionWindSpeed, Value: []float64{10.0, 5.0}, DecodedDataType: "float_array"},
+			3: {Name: simdata.DRSimWeatherRegionWindDirection, Value: []float64{110.0, 90.0}, DecodedDataType: "float_array"},
+			4: {Name: simdata.DRSimWeatherRegionTemperaturesAloft, Value: []float64{8.0, 12.0}, DecodedDataType: "float_array"},
+			5: {Name: simdata.DRSimWeatherRegionDewpoint, Value: []float64{4.0, 9.0}, DecodedDataType: "float_array"},
+			6: {Name: simdata.DRSimWeatherRegionSeaLevelTemp, Value: 17.0},
+			7: {Name: simdata.DRSimWeatherSeaLevelDewpoint, Value: 11.0},
+		},
+	}
+	w := &atc.Weather{}
+	xpc.updateWeatherLayers(w)
+	xpc.updateSurfaceTemperature(w)
+
+	if len(w.Layers) != 2 || w.Layers[0].Temp != 12.0 {
+		t.Fatalf("layers = %+v; want the 300m layer first", w.Layers)
+	}
+	if w.Temp != 17.0 || w.Dewpoint != 11.0 {
+		t.Errorf("surface temp/dewpoint = %v/%v; want 17/11 from the surface datarefs", w.Temp, w.Dewpoint)
+	}
+	if w.Humidity != atc.RelativeHumidity(17.0, 11.0) {
+		t.Errorf("humidity = %v; want it from the surface temperature and dewpoint", w.Humidity)
+	}
+}
@@ -0,0 +1,169 @@
+package geometry
+
+import (
+	"math"
+	"sort"
+)
+
+// GridIndex is a spatial index of points and lat/lon bounding boxes on a grid of equal angle cells. Items are
+// identified by an int, typically their index in the caller's slice, and queries return candidate ids in
+// ascending order so that callers iterating them see items in the same order as a linear scan would.
+type GridIndex struct {
+	cellDeg float64
+	rows    int
+	cols    int
+	cells   map[int][]int
+	points  map[int][2]float64 // lat/lon of the point items, for Nearest
+}
+
+// NewGridIndex returns an empty index with cells of the given size in degrees
+func NewGridIndex(cellDeg float64) *GridIndex {
+	return &GridIndex{
+		cellDeg: cellDeg,
+		rows:    int(math.Ceil(180 / cellDeg)),
+		cols:    int(math.Ceil(360 / cellDeg)),
+		cells:   make(map[int][]int),
+		points:  make(map[int][2]float64),
+	}
+}
+
+// Len returns the number of point items in the index
+func (g *GridIndex) Len() int {
+	return len(g.points)
+}
+
+func (g *GridIndex) row(lat float64) int {
+	r := int(math.Floor((lat + 90) / g.cellDeg))
+	return max(0, min(g.rows-1, r))
+}
+
+func (g *GridIndex) col(lon float64) int {
+	c := int(math.Floor((NormalizeLon(lon) + 180) / g.cellDeg))
+	return max(0, min(g.cols-1, c))
+}
+
+func (g *GridIndex) add(row, col, id int) {
+	key := row*g.cols + col
+	g.cells[key] = append(g.cells[key], id)
+}
+
+// InsertPoint adds a point item
+func (g *GridIndex) InsertPoint(id int, lat, lon float64) {
+	g.points[id] = [2]float64{lat, lon}
+	g.add(g.row(lat), g.col(lon), id)
+}
+
+// InsertBox adds a bounding box item to every cell it overlaps. A box crossing the antimeridian has a
+// minLon greater than its maxLon.
+func (g *GridIndex) InsertBox(id int, minLat, maxLat, minLon, maxLon float64) {
+	g.forCells(minLat, maxLat, minLon, maxLon, func(key int) {
+		g.cells[key] = append(g.cells[key], id)
+	})
+}
+
+// forCells calls fn with the key of every cell overlapping the box
+func (g *GridIndex) forCells(minLat, maxLat, minLon, maxLon float64, fn func(key int)) {
+	r0, r1 := g.row(minLat), g.row(maxLat)
+	c0, c1 := g.col(minLon), g.col(maxLon)
+	if maxLon-minLon >= 360 {
+		c0, c1 = 0, g.cols-1
+	}
+	for r := r0; r <= r1; r++ {
+		c := c0
+		for {
+			fn(r*g.cols + c)
+			if c == c1 {
+				break
+			}
+			c = (c + 1) % g.cols
+		}
+	}
+}
+
+// collect returns the distinct ids of the cells overlapping the box in ascending order
+func (g *GridIndex) collect(minLat, maxLat, minLon, maxLon float64) []int {
+	var ids []int
+	g.forCells(minLat, maxLat, minLon, maxLon, func(key int) {
+		ids = append(ids, g.cells[key]...)
+	})
+	sort.Ints(ids)
+	n := 0
+	for i, id := range ids {
+		if i == 0 || id != ids[n-1] {
+			ids[n] = id
+			n++
+		}
+	}
+	return ids[:n]
+}
+
+// Containing returns the ids of the items whose cells contain the position. Boxes are only indexed by cell,
+// so callers must still test the box and shape of each candidate.
+func (g *GridIndex) Containing(lat, lon float64) []int {
+	return g.collect(lat, lat, lon, lon)
+}
+
+// Within returns the ids of the items in cells within radiusNM of the position. Every point within the
+// radius is returned, along with some further away, so callers must still check the distance.
+func (g *GridIndex) Within(lat, lon, radiusNM float64) []int {
+	minLat, maxLat, minLon, maxLon := BoundingBox(lat, lon, radiusNM)
+	return g.collect(minLat, maxLat, minLon, maxLon)
+}
+
+// Nearest returns the id of the point item closest to the position and its distance, searching outwards
+// from the position up to maxNM. ok is false if there is no point within maxNM.
+func (g *GridIndex) Nearest(lat, lon, maxNM float64) (id int, distNM float64, ok bool) {
+	radius := math.Min(g.cellDeg*60, maxNM)
+	for {
+		distNM = radius
+		for _, candidate := range g.Within(lat, lon, radius) {
+			p, isPoint := g.points[candidate]
+			if !isPoint {
+				continue
+			}
+			if d := DistNM(lat, lon, p[0], p[1]); d <= distNM {
+				if !ok || d < distNM || (d == distNM && candidate < id) {
+					id, distNM, ok = candidate, d, true
+				}
+			}
+		}
+		// every point closer than the radius has been seen
+		if ok || radius >= maxNM || radius >= math.Pi*EarthRadiusNM {
+			return id, distNM, ok
+		}
+		radius = math.Min(radius*2, maxNM)
+	}
+}
+
+// BoundingBox returns the lat/lon box enclosing the circle of radiusNM around the position. The box spans
+// every longitude when the circle includes a pole, and has minLon greater than maxLon when it crosses the
+// antimeridian.
+func BoundingBox(lat, lon, radiusNM float64) (minLat, maxLat, minLon, maxLon float64) {
+	angle := radiusNM / EarthRadiusNM
+	if angle >= math.Pi/2 {
+		return -90, 90, -180, 180
+	}
+	minLat = lat - angle*180/math.Pi
+	maxLat = lat + angle*180/math.Pi
+	if minLat <= -90 || maxLat >= 90 {
+		return math.Max(minLat, -90), math.Min(maxLat, 90), -180, 180
+	}
+	s := math.Sin(angle) / math.Cos(DegToRad(lat))
+	if s >= 1 {
+		return minLat, maxLat, -180, 180
+	}
+	dLon := math.Asin(s) * 180 / math.Pi
+	return minLat, maxLat, NormalizeLon(lon - dLon), NormalizeLon(lon + dLon)
+}
+
+// NormalizeLon wraps a longitude into the range -180 to 180
+func NormalizeLon(lon float64) float64 {
+	if lon >= -180 && lon < 180 {
+		return lon
+	}
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
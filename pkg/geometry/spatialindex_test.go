@@ -0,0 +1,138 @@
+package geometry
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomPoints(n int, seed int64) [][2]float64 {
+	rng := rand.New(rand.NewSource(seed))
+	points := make([][2]float64, n)
+	for i := range points {
+		points[i] = [2]float64{rng.Float64()*180 - 90, rng.Float64()*360 - 180}
+	}
+	return points
+}
+
+func TestGridIndexNearestMatchesLinearScan(t *testing.T) {
+	points := randomPoints(5000, 1)
+	g := NewGridIndex(1)
+	for i, p := range points {
+		g.InsertPoint(i, p[0], p[1])
+	}
+
+	queries := append(randomPoints(500, 2),
+		[2]float64{89.9, 10}, [2]float64{-89.9, -170}, // poles
+		[2]float64{0.5, 179.9}, [2]float64{-12, -179.99}, // antimeridian
+	)
+	for _, maxNM := range []float64{30, 300, math.Inf(1)} {
+		for _, q := range queries {
+			want, wantDist := -1, maxNM
+			for i, p := range points {
+				if d := DistNM(q[0], q[1], p[0], p[1]); d < wantDist {
+					want, wantDist = i, d
+				}
+			}
+			got, gotDist, ok := g.Nearest(q[0], q[1], maxNM)
+			if want == -1 {
+				if ok && gotDist < maxNM {
+					t.Errorf("Nearest(%v, %v) = %d at %.2fnm; want none", q, maxNM, got, gotDist)
+				}
+				continue
+			}
+			if !ok || got != want {
+				t.Errorf("Nearest(%v, %v) = %d at %.2fnm; want %d at %.2fnm", q, maxNM, got, gotDist, want, wantDist)
+			}
+		}
+	}
+}
+
+func TestGridIndexWithin(t *testing.T) {
+	points := randomPoints(5000, 3)
+	g := NewGridIndex(1)
+	for i, p := range points {
+		g.InsertPoint(i, p[0], p[1])
+	}
+
+	for _, q := range append(randomPoints(200, 4), [2]float64{88, 0}, [2]float64{10, 179.5}) {
+		found := make(map[int]bool)
+		prev := -1
+		for _, id := range g.Within(q[0], q[1], 250) {
+			if id <= prev {
+				t.Fatalf("Within(%v) ids not ascending and distinct", q)
+			}
+			prev = id
+			found[id] = true
+		}
+		for i, p := range points {
+			if DistNM(q[0], q[1], p[0], p[1]) <= 250 && !found[i] {
+				t.Errorf("Within(%v) missed point %d at %.2fnm", q, i, DistNM(q[0], q[1], p[0], p[1]))
+			}
+		}
+	}
+}
+
+func TestGridIndexContaining(t *testing.T) {
+	g := NewGridIndex(1)
+	g.InsertBox(0, 49.5, 55.2, -6.3, 2.1)   // London
+	g.InsertBox(1, 50.0, 52.0, 170.5, -175) // crosses the antimeridian
+	g.InsertBox(2, -90, 90, -180, 180)      // whole world
+
+	tests := []struct {
+		lat, lon float64
+		want     []int
+	}{
+		{51.47, -0.45, []int{0, 2}},
+		{55.2, 2.1, []int{0, 2}},
+		{51.0, 179.9, []int{1, 2}},
+		{51.0, -179.9, []int{1, 2}},
+		{51.0, -170, []int{2}},
+		{-33.9, 151.2, []int{2}},
+	}
+	for _, tt := range tests {
+		got := g.Containing(tt.lat, tt.lon)
+		if len(got) != len(tt.want) {
+			t.Errorf("Containing(%v, %v) = %v; want %v", tt.lat, tt.lon, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Containing(%v, %v) = %v; want %v", tt.lat, tt.lon, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkNearest(b *testing.B) {
+	// roughly the number of airports in apt.dat
+	points := randomPoints(40000, 5)
+	queries := randomPoints(1024, 6)
+
+	b.Run("GridIndex", func(b *testing.B) {
+		g := NewGridIndex(1)
+		for i, p := range points {
+			g.InsertPoint(i, p[0], p[1])
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			q := queries[i%len(queries)]
+			g.Nearest(q[0], q[1], 1000)
+		}
+	})
+
+	b.Run("LinearScan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			q := queries[i%len(queries)]
+			best := 1000.0
+			for _, p := range points {
+				if d := DistNM(q[0], q[1], p[0], p[1]); d < best {
+					best = d
+				}
+			}
+		}
+	})
+}